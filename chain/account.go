@@ -8,7 +8,7 @@ import (
 )
 
 func (c *chain) IsContractAccount(address types.Address) (bool, error) {
-	if ok := types.IsBuiltinContractAddrInUse(address); ok {
+	if ok := ledger.IsBuiltinContractAddrInUse(address, c.latestSnapshotHeight()); ok {
 		return ok, nil
 	}
 
@@ -0,0 +1,292 @@
+package chain_archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+const (
+	Version = 1
+
+	manifestName = "manifest.json"
+	ledgerPrefix = "ledger/"
+)
+
+type FileInfo struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// Manifest describes the ledger at a snapshot block stored in an archive. It is the first entry
+// of the archive, the checksums of the ledger files are sha256 of the file content.
+type Manifest struct {
+	Version        int        `json:"version"`
+	SnapshotHeight uint64     `json:"snapshotHeight"`
+	SnapshotHash   types.Hash `json:"snapshotHash"`
+	GenesisHash    types.Hash `json:"genesisHash"`
+	CreateTime     int64      `json:"createTime"`
+	Files          []FileInfo `json:"files"`
+}
+
+// CopyDir copies the regular files under src to dst, the directories named in skipDirs are not copied
+func CopyDir(src, dst string, skipDirs ...string) error {
+	return filepath.Walk(src, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, filePath)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		if info.IsDir() {
+			for _, skipDir := range skipDirs {
+				if relPath == skipDir {
+					return filepath.SkipDir
+				}
+			}
+			return os.MkdirAll(target, 0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(filePath, target)
+	})
+}
+
+// Pack writes the files under ledgerDir and the manifest to w as a gzipped tar archive,
+// the file list of the manifest is filled in by Pack.
+func Pack(w io.Writer, ledgerDir string, manifest *Manifest) error {
+	files, err := listFiles(ledgerDir)
+	if err != nil {
+		return err
+	}
+	manifest.Version = Version
+	manifest.Files = files
+	return packWithManifest(w, ledgerDir, manifest)
+}
+
+func packWithManifest(w io.Writer, ledgerDir string, manifest *Manifest) error {
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     manifestName,
+		Mode:     0600,
+		Size:     int64(len(manifestBytes)),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestBytes); err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		if err := packFile(tw, ledgerDir, file); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// Unpack extracts the ledger files of the archive into ledgerDir, and verifies them with the
+// checksums of the manifest. ledgerDir should be empty or not exist.
+//
+// The checksums only detect a corrupted archive, the manifest is packed by the exporter and is not
+// trusted, the ledger should be verified with a trusted snapshot block hash after it's opened.
+func Unpack(r io.Reader, ledgerDir string) (*Manifest, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("read manifest failed, error is %s", err))
+	}
+	if header.Name != manifestName {
+		return nil, errors.New(fmt.Sprintf("the first entry is %s, expected %s", header.Name, manifestName))
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("decode manifest failed, error is %s", err))
+	}
+	if manifest.Version != Version {
+		return nil, errors.New(fmt.Sprintf("archive version is %d, expected %d", manifest.Version, Version))
+	}
+
+	fileMap := make(map[string]FileInfo, len(manifest.Files))
+	for _, file := range manifest.Files {
+		fileMap[file.Path] = file
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, errors.New(fmt.Sprintf("unexpected entry %s, type is %d", header.Name, header.Typeflag))
+		}
+		if !strings.HasPrefix(header.Name, ledgerPrefix) {
+			return nil, errors.New(fmt.Sprintf("unexpected entry %s", header.Name))
+		}
+
+		relPath := strings.TrimPrefix(header.Name, ledgerPrefix)
+		file, ok := fileMap[relPath]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("file %s is not in manifest", relPath))
+		}
+		delete(fileMap, relPath)
+
+		target := filepath.Join(ledgerDir, filepath.FromSlash(relPath))
+		if !strings.HasPrefix(target, filepath.Clean(ledgerDir)+string(os.PathSeparator)) {
+			return nil, errors.New(fmt.Sprintf("invalid file path %s", relPath))
+		}
+		if err := unpackFile(tr, target, file); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fileMap) > 0 {
+		missing := make([]string, 0, len(fileMap))
+		for relPath := range fileMap {
+			missing = append(missing, relPath)
+		}
+		sort.Strings(missing)
+		return nil, errors.New(fmt.Sprintf("%d files are missing, such as %s", len(missing), missing[0]))
+	}
+	return manifest, nil
+}
+
+func listFiles(dir string) ([]FileInfo, error) {
+	var files []FileInfo
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		checksum, err := fileChecksum(filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{
+			Path:     filepath.ToSlash(relPath),
+			Size:     info.Size(),
+			Checksum: checksum,
+		})
+		return nil
+	})
+	return files, err
+}
+
+func packFile(tw *tar.Writer, dir string, file FileInfo) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     ledgerPrefix + file.Path,
+		Mode:     0600,
+		Size:     file.Size,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	// the size is limited in case that the file is changed after listFiles
+	_, err = io.CopyN(tw, f, file.Size)
+	return err
+}
+
+func unpackFile(r io.Reader, target string, file FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return err
+	}
+	if size != file.Size {
+		return errors.New(fmt.Sprintf("size of %s is %d, expected %d", file.Path, size, file.Size))
+	}
+	if checksum := hex.EncodeToString(h.Sum(nil)); checksum != file.Checksum {
+		return errors.New(fmt.Sprintf("checksum of %s is %s, expected %s", file.Path, checksum, file.Checksum))
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fileChecksum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,115 @@
+package chain_archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func writeTestLedger(t *testing.T, dir string) map[string][]byte {
+	files := map[string][]byte{
+		"index/000001.ldb": []byte("index"),
+		"state/CURRENT":    []byte("MANIFEST-000001"),
+		"blocks/f_1":       bytes.Repeat([]byte{1, 2, 3}, 1024),
+		"sync_cache/f_1":   []byte("sync cache"),
+	}
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filePath, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestPackAndUnpack(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "chain_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	files := writeTestLedger(t, srcDir)
+
+	copyDir := filepath.Join(tmpDir, "copy")
+	if err := CopyDir(srcDir, copyDir, "sync_cache"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(copyDir, "sync_cache")); !os.IsNotExist(err) {
+		t.Fatal("sync_cache should be skipped")
+	}
+	delete(files, "sync_cache/f_1")
+
+	hash := types.DataHash([]byte("snapshot"))
+	buf := &bytes.Buffer{}
+	if err := Pack(buf, copyDir, &Manifest{SnapshotHeight: 100, SnapshotHash: hash}); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir := filepath.Join(tmpDir, "dst")
+	manifest, err := Unpack(bytes.NewReader(buf.Bytes()), dstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.SnapshotHeight != 100 || manifest.SnapshotHash != hash {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if len(manifest.Files) != len(files) {
+		t.Fatalf("manifest has %d files, expected %d", len(manifest.Files), len(files))
+	}
+	for name, content := range files {
+		got, err := ioutil.ReadFile(filepath.Join(dstDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("content of %s is different", name)
+		}
+	}
+}
+
+func TestUnpackChecksumMismatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "chain_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	writeTestLedger(t, srcDir)
+
+	manifest := &Manifest{SnapshotHeight: 100}
+	if err := Pack(ioutil.Discard, srcDir, manifest); err != nil {
+		t.Fatal(err)
+	}
+	// change a file after the checksums are computed
+	files := manifest.Files
+	if err := ioutil.WriteFile(filepath.Join(srcDir, "index", "000001.ldb"), []byte("INDEX"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Pack(buf, srcDir, &Manifest{SnapshotHeight: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// replace the manifest with the stale one
+	tampered := &bytes.Buffer{}
+	if err := packWithManifest(tampered, srcDir, &Manifest{Version: Version, SnapshotHeight: 100, Files: files}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Unpack(bytes.NewReader(buf.Bytes()), filepath.Join(tmpDir, "ok")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unpack(bytes.NewReader(tampered.Bytes()), filepath.Join(tmpDir, "tampered")); err == nil {
+		t.Fatal("checksum mismatch should fail")
+	}
+}
@@ -93,6 +93,29 @@ func (c *chain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
 	return abi.GetStakeBeneficialAmount(sd, addr)
 }
 
+// get the stake amount of the beneficiary at the snapshot height
+func (c *chain) GetStakeBeneficialAmountAtSnapshot(addr types.Address, snapshotHeight uint64) (*big.Int, error) {
+	if err := c.checkSnapshotHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+	sb, err := c.GetSnapshotHeaderByHeight(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		return nil, errors.New(fmt.Sprintf("snapshot block %d is not exist", snapshotHeight))
+	}
+
+	sd, err := c.stateDB.NewStorageDatabase(sb.Hash, types.AddressQuota)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.NewStorageDatabase failed, snapshotHeight is %d. Error: %s", snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetStakeBeneficialAmountAtSnapshot")
+		return nil, cErr
+	}
+
+	return abi.GetStakeBeneficialAmount(sd, addr)
+}
+
 // total
 func (c *chain) GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error) {
 
@@ -218,6 +218,9 @@ type Chain interface {
 	// get confirmed snapshot Balance, if history is too old, failed
 	GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error)
 
+	// get Balance map at the snapshot height
+	GetBalanceMapAtSnapshot(addr types.Address, snapshotHeight uint64) (map[types.TokenTypeId]*big.Int, error)
+
 	// get contract code
 	GetContractCode(contractAddr types.Address) ([]byte, error)
 
@@ -235,6 +238,10 @@ type Chain interface {
 
 	GetStorageIterator(address types.Address, prefix []byte) (interfaces.StorageIterator, error)
 
+	GetStorageIteratorAtSnapshot(address types.Address, prefix []byte, snapshotHeight uint64) (interfaces.StorageIterator, error)
+
+	GetValueAtSnapshot(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error)
+
 	GetValue(address types.Address, key []byte) ([]byte, error)
 
 	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
@@ -253,6 +260,8 @@ type Chain interface {
 
 	GetStakeBeneficialAmount(addr types.Address) (*big.Int, error)
 
+	GetStakeBeneficialAmountAtSnapshot(addr types.Address, snapshotHeight uint64) (*big.Int, error)
+
 	// total
 	GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error)
 
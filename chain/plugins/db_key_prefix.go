@@ -6,6 +6,12 @@ const (
 	OnRoadInfoKeyPrefix = byte(1)
 
 	DiffTokenHash = byte(2)
+
+	VmLogAddressKeyPrefix = byte(3)
+
+	VmLogTopicKeyPrefix = byte(4)
+
+	VmLogBlockKeyPrefix = byte(5)
 )
 
 func CreateOnRoadInfoKey(addr *types.Address, tId *types.TokenTypeId) []byte {
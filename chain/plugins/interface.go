@@ -21,6 +21,8 @@ type Chain interface {
 
 	GetAllUnconfirmedBlocks() []*ledger.AccountBlock
 
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+
 	LoadAllOnRoad() (map[types.Address][]types.Hash, error)
 }
 
@@ -45,6 +45,7 @@ func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
 	plugins := map[string]Plugin{
 		"filterToken": newFilterToken(store, chain),
 		"onRoadInfo":  newOnRoadInfo(store, chain),
+		"vmLogIndex":  newVmLogIndex(store, chain),
 	}
 
 	return &Plugins{
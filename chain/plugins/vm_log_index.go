@@ -0,0 +1,426 @@
+package chain_plugins
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	// only topic0..topic3 are indexed
+	maxIndexedTopics = 4
+
+	vmLogLocationSize = 8 + types.AddressSize + 8 + 4
+	vmLogValueSize    = types.HashSize * 2
+)
+
+var vLog = log15.New("plugin", "vm_log_index")
+
+// VmLogLocation is the position of a vm log in the ledger, ordered by snapshot height first.
+type VmLogLocation struct {
+	SnapshotHeight uint64
+	Address        types.Address
+	AccountHeight  uint64
+	LogIndex       uint32
+}
+
+func (l *VmLogLocation) Bytes() []byte {
+	buf := make([]byte, 0, vmLogLocationSize)
+	buf = append(buf, chain_utils.Uint64ToBytes(l.SnapshotHeight)...)
+	buf = append(buf, l.Address.Bytes()...)
+	buf = append(buf, chain_utils.Uint64ToBytes(l.AccountHeight)...)
+
+	logIndex := make([]byte, 4)
+	binary.BigEndian.PutUint32(logIndex, l.LogIndex)
+	return append(buf, logIndex...)
+}
+
+func (l *VmLogLocation) SetBytes(buf []byte) error {
+	if len(buf) != vmLogLocationSize {
+		return errors.New(fmt.Sprintf("invalid vm log location size %d", len(buf)))
+	}
+	addr, err := types.BytesToAddress(buf[8 : 8+types.AddressSize])
+	if err != nil {
+		return err
+	}
+	l.SnapshotHeight = chain_utils.BytesToUint64(buf[:8])
+	l.Address = addr
+	l.AccountHeight = chain_utils.BytesToUint64(buf[8+types.AddressSize : 16+types.AddressSize])
+	l.LogIndex = binary.BigEndian.Uint32(buf[16+types.AddressSize:])
+	return nil
+}
+
+// String returns the hex cursor of the location, which can be passed back to continue a query.
+func (l *VmLogLocation) String() string {
+	return hex.EncodeToString(l.Bytes())
+}
+
+func ParseVmLogLocation(cursor string) (*VmLogLocation, error) {
+	buf, err := hex.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	l := &VmLogLocation{}
+	if err := l.SetBytes(buf); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+type AccountHeightRange struct {
+	FromHeight uint64
+	ToHeight   uint64 // 0 means no upper bound
+}
+
+// VmLogFilter selects indexed vm logs. At least one address or one topic is required,
+// an empty topic slot matches any topic. ToSnapshotHeight 0 means the latest snapshot block.
+type VmLogFilter struct {
+	Addresses map[types.Address]AccountHeightRange
+	Topics    [][]types.Hash
+
+	FromSnapshotHeight uint64
+	ToSnapshotHeight   uint64
+}
+
+type IndexedVmLog struct {
+	Location         VmLogLocation
+	AccountBlockHash types.Hash
+	Log              *ledger.VmLog
+}
+
+// VmLogIndex indexes the vm logs of snapshot-confirmed account blocks by address and by topic0..topic3.
+// Only the logs saved by the state db (see config.Chain.VmLogAll and VmLogWhiteList) can be indexed.
+type VmLogIndex struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newVmLogIndex(store *chain_db.Store, chain Chain) Plugin {
+	return &VmLogIndex{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (vi *VmLogIndex) SetStore(store *chain_db.Store) {
+	vi.store = store
+}
+
+func (vi *VmLogIndex) InsertAccountBlock(*leveldb.Batch, *ledger.AccountBlock) error {
+	// unconfirmed blocks have no snapshot height, index them when they are snapshotted
+	return nil
+}
+
+func (vi *VmLogIndex) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	for _, block := range confirmedBlocks {
+		if block.LogHash == nil {
+			continue
+		}
+
+		logList, err := vi.chain.GetVmLogList(block.LogHash)
+		if err != nil {
+			return errors.New(fmt.Sprintf("vi.chain.GetVmLogList failed, logHash is %s. Error: %s", block.LogHash, err))
+		}
+		if len(logList) <= 0 {
+			continue
+		}
+
+		value := make([]byte, 0, vmLogValueSize)
+		value = append(value, block.Hash.Bytes()...)
+		value = append(value, block.LogHash.Bytes()...)
+
+		for index, vmLog := range logList {
+			location := &VmLogLocation{
+				SnapshotHeight: snapshotBlock.Height,
+				Address:        block.AccountAddress,
+				AccountHeight:  block.Height,
+				LogIndex:       uint32(index),
+			}
+			for _, key := range createVmLogKeys(location, vmLog) {
+				batch.Put(key, value)
+			}
+		}
+		batch.Put(createVmLogBlockKey(block.Hash), chain_utils.Uint64ToBytes(snapshotBlock.Height))
+	}
+	return nil
+}
+
+func (vi *VmLogIndex) DeleteAccountBlocks(batch *leveldb.Batch, blocks []*ledger.AccountBlock) error {
+	vi.deleteBlocks(batch, blocks)
+	return nil
+}
+
+func (vi *VmLogIndex) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	for _, chunk := range chunks {
+		vi.deleteBlocks(batch, chunk.AccountBlocks)
+	}
+	return nil
+}
+
+// RemoveNewUnconfirmed removes the index of the blocks which are unconfirmed again after their snapshot block was deleted.
+func (vi *VmLogIndex) RemoveNewUnconfirmed(rollbackBatch *leveldb.Batch, allUnconfirmedBlocks []*ledger.AccountBlock) error {
+	vi.deleteBlocks(rollbackBatch, allUnconfirmedBlocks)
+	return nil
+}
+
+// GetLogs returns at most count logs matched by the filter in ledger order, starting after the given location.
+func (vi *VmLogIndex) GetLogs(filter *VmLogFilter, after *VmLogLocation, count uint64) ([]*IndexedVmLog, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	latestSnapshotBlock := vi.chain.GetLatestSnapshotBlock()
+	if latestSnapshotBlock == nil {
+		return nil, errors.New("GetLatestSnapshotBlock fail")
+	}
+
+	toHeight := filter.ToSnapshotHeight
+	if toHeight == 0 || toHeight > latestSnapshotBlock.Height {
+		toHeight = latestSnapshotBlock.Height
+	}
+	if filter.FromSnapshotHeight > toHeight {
+		return nil, nil
+	}
+
+	prefixList := scanPrefixList(filter)
+	if len(prefixList) <= 0 {
+		return nil, errors.New("the filter requires at least one address or topic")
+	}
+
+	logListCache := make(map[types.Hash]ledger.VmLogList)
+
+	result := make([]*IndexedVmLog, 0, count)
+	for _, prefix := range prefixList {
+		logs, err := vi.scan(prefix, filter, toHeight, after, count, logListCache)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, logs...)
+	}
+
+	// every prefix is scanned in order, merge them
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Location.Bytes(), result[j].Location.Bytes()) < 0
+	})
+	if uint64(len(result)) > count {
+		result = result[:count]
+	}
+	return result, nil
+}
+
+func (vi *VmLogIndex) scan(prefix []byte, filter *VmLogFilter, toHeight uint64, after *VmLogLocation, count uint64,
+	logListCache map[types.Hash]ledger.VmLogList) ([]*IndexedVmLog, error) {
+
+	startKey := append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(filter.FromSnapshotHeight)...)
+	var afterKey []byte
+	if after != nil {
+		afterKey = append(append([]byte{}, prefix...), after.Bytes()...)
+		if bytes.Compare(afterKey, startKey) > 0 {
+			startKey = afterKey
+		}
+	}
+	limitKey := append(append([]byte{}, prefix...), chain_utils.Uint64ToBytes(toHeight+1)...)
+
+	iter := vi.store.NewIterator(&util.Range{Start: startKey, Limit: limitKey})
+	defer iter.Release()
+
+	logs := make([]*IndexedVmLog, 0)
+	for uint64(len(logs)) < count && iter.Next() {
+		key := iter.Key()
+		if afterKey != nil && bytes.Equal(key, afterKey) {
+			continue
+		}
+
+		var location VmLogLocation
+		if err := location.SetBytes(key[len(prefix):]); err != nil {
+			return nil, err
+		}
+
+		if len(filter.Addresses) > 0 {
+			hr, ok := filter.Addresses[location.Address]
+			if !ok || location.AccountHeight < hr.FromHeight ||
+				(hr.ToHeight > 0 && location.AccountHeight > hr.ToHeight) {
+				continue
+			}
+		}
+
+		value := iter.Value()
+		if len(value) != vmLogValueSize {
+			return nil, errors.New(fmt.Sprintf("invalid vm log index value size %d", len(value)))
+		}
+		blockHash, err := types.BytesToHash(value[:types.HashSize])
+		if err != nil {
+			return nil, err
+		}
+		logHash, err := types.BytesToHash(value[types.HashSize:])
+		if err != nil {
+			return nil, err
+		}
+
+		logList, ok := logListCache[logHash]
+		if !ok {
+			if logList, err = vi.chain.GetVmLogList(&logHash); err != nil {
+				return nil, err
+			}
+			logListCache[logHash] = logList
+		}
+		if int(location.LogIndex) >= len(logList) {
+			continue
+		}
+
+		vmLog := logList[location.LogIndex]
+		if !matchTopics(filter.Topics, vmLog) {
+			continue
+		}
+
+		logs = append(logs, &IndexedVmLog{
+			Location:         location,
+			AccountBlockHash: blockHash,
+			Log:              vmLog,
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (vi *VmLogIndex) deleteBlocks(batch *leveldb.Batch, blocks []*ledger.AccountBlock) {
+	for _, block := range blocks {
+		if block.LogHash == nil {
+			continue
+		}
+		blockKey := createVmLogBlockKey(block.Hash)
+		value, err := vi.store.Get(blockKey)
+		if err != nil {
+			vLog.Error(fmt.Sprintf("vi.store.Get failed, blockHash is %s. Error: %s", block.Hash, err), "method", "deleteBlocks")
+			continue
+		}
+		if len(value) <= 0 {
+			// not indexed
+			continue
+		}
+
+		logList, err := vi.chain.GetVmLogList(block.LogHash)
+		if err != nil {
+			vLog.Error(fmt.Sprintf("vi.chain.GetVmLogList failed, logHash is %s. Error: %s", block.LogHash, err), "method", "deleteBlocks")
+			continue
+		}
+
+		snapshotHeight := chain_utils.BytesToUint64(value)
+		for index, vmLog := range logList {
+			location := &VmLogLocation{
+				SnapshotHeight: snapshotHeight,
+				Address:        block.AccountAddress,
+				AccountHeight:  block.Height,
+				LogIndex:       uint32(index),
+			}
+			for _, key := range createVmLogKeys(location, vmLog) {
+				batch.Delete(key)
+			}
+		}
+		batch.Delete(blockKey)
+	}
+}
+
+func scanPrefixList(filter *VmLogFilter) [][]byte {
+	if len(filter.Addresses) > 0 {
+		prefixList := make([][]byte, 0, len(filter.Addresses))
+		for addr := range filter.Addresses {
+			prefixList = append(prefixList, createVmLogAddressPrefix(addr))
+		}
+		return prefixList
+	}
+
+	// scan the most selective topic slot
+	position := -1
+	for i, topicRange := range filter.Topics {
+		if i >= maxIndexedTopics {
+			break
+		}
+		if len(topicRange) > 0 && (position < 0 || len(topicRange) < len(filter.Topics[position])) {
+			position = i
+		}
+	}
+	if position < 0 {
+		return nil
+	}
+
+	topicSet := make(map[types.Hash]struct{}, len(filter.Topics[position]))
+	prefixList := make([][]byte, 0, len(filter.Topics[position]))
+	for _, topic := range filter.Topics[position] {
+		if _, ok := topicSet[topic]; ok {
+			continue
+		}
+		topicSet[topic] = struct{}{}
+		prefixList = append(prefixList, createVmLogTopicPrefix(byte(position), topic))
+	}
+	return prefixList
+}
+
+func matchTopics(topics [][]types.Hash, vmLog *ledger.VmLog) bool {
+	if len(vmLog.Topics) < len(topics) {
+		return false
+	}
+	for i, topicRange := range topics {
+		if len(topicRange) == 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range topicRange {
+			if topic == vmLog.Topics[i] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func createVmLogKeys(location *VmLogLocation, vmLog *ledger.VmLog) [][]byte {
+	keys := make([][]byte, 0, 1+maxIndexedTopics)
+	keys = append(keys, append(createVmLogAddressPrefix(location.Address), location.Bytes()...))
+
+	for i, topic := range vmLog.Topics {
+		if i >= maxIndexedTopics {
+			break
+		}
+		keys = append(keys, append(createVmLogTopicPrefix(byte(i), topic), location.Bytes()...))
+	}
+	return keys
+}
+
+func createVmLogAddressPrefix(addr types.Address) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+vmLogLocationSize)
+	key = append(key, VmLogAddressKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	return key
+}
+
+func createVmLogTopicPrefix(position byte, topic types.Hash) []byte {
+	key := make([]byte, 0, 2+types.HashSize+vmLogLocationSize)
+	key = append(key, VmLogTopicKeyPrefix, position)
+	key = append(key, topic.Bytes()...)
+	return key
+}
+
+func createVmLogBlockKey(blockHash types.Hash) []byte {
+	key := make([]byte, 0, 1+types.HashSize)
+	key = append(key, VmLogBlockKeyPrefix)
+	key = append(key, blockHash.Bytes()...)
+	return key
+}
@@ -0,0 +1,176 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/flusher"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockLogChain struct {
+	latest  *ledger.SnapshotBlock
+	logList map[types.Hash]ledger.VmLogList
+}
+
+func (c *mockLogChain) Flusher() *chain_flusher.Flusher { return nil }
+func (c *mockLogChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.latest
+}
+func (c *mockLogChain) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	return nil, nil
+}
+func (c *mockLogChain) GetSubLedgerAfterHeight(height uint64) ([]*ledger.SnapshotChunk, error) {
+	return nil, nil
+}
+func (c *mockLogChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	return nil, nil
+}
+func (c *mockLogChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+func (c *mockLogChain) IsAccountBlockExisted(hash types.Hash) (bool, error) { return false, nil }
+func (c *mockLogChain) IsGenesisAccountBlock(hash types.Hash) bool          { return false }
+func (c *mockLogChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock     { return nil }
+func (c *mockLogChain) LoadAllOnRoad() (map[types.Address][]types.Hash, error) {
+	return nil, nil
+}
+func (c *mockLogChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return c.logList[*logListHash], nil
+}
+
+func newTestVmLogIndex(t *testing.T) (*VmLogIndex, *mockLogChain, func()) {
+	dir, err := ioutil.TempDir("", "vm_log_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &mockLogChain{
+		latest:  &ledger.SnapshotBlock{Height: 1},
+		logList: make(map[types.Hash]ledger.VmLogList),
+	}
+	return newVmLogIndex(store, c).(*VmLogIndex), c, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestLogBlock(c *mockLogChain, addr types.Address, height uint64, topics ...[]types.Hash) *ledger.AccountBlock {
+	logList := make(ledger.VmLogList, len(topics))
+	for i, t := range topics {
+		logList[i] = &ledger.VmLog{Topics: t}
+	}
+	logHash := types.DataHash(append(addr.Bytes(), byte(height)))
+	blockHash := types.DataHash(append(addr.Bytes(), byte(height), 1))
+	c.logList[logHash] = logList
+	return &ledger.AccountBlock{
+		AccountAddress: addr,
+		Height:         height,
+		Hash:           blockHash,
+		LogHash:        &logHash,
+	}
+}
+
+func insertTestSnapshot(t *testing.T, vi *VmLogIndex, c *mockLogChain, height uint64, blocks ...*ledger.AccountBlock) {
+	sb := &ledger.SnapshotBlock{Height: height}
+	batch := vi.store.NewBatch()
+	if err := vi.InsertSnapshotBlock(batch, sb, blocks); err != nil {
+		t.Fatal(err)
+	}
+	vi.store.WriteDirectly(batch)
+	c.latest = sb
+}
+
+func TestVmLogLocation(t *testing.T) {
+	l := &VmLogLocation{SnapshotHeight: 10, Address: types.AddressQuota, AccountHeight: 3, LogIndex: 2}
+	parsed, err := ParseVmLogLocation(l.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed != *l {
+		t.Fatalf("expected %+v, got %+v", l, parsed)
+	}
+	if _, err := ParseVmLogLocation("00ff"); err == nil {
+		t.Fatal("expected error for invalid cursor")
+	}
+}
+
+func TestVmLogIndex_GetLogs(t *testing.T) {
+	vi, c, release := newTestVmLogIndex(t)
+	defer release()
+
+	topicA := types.DataHash([]byte("a"))
+	topicB := types.DataHash([]byte("b"))
+	topicC := types.DataHash([]byte("c"))
+
+	b1 := newTestLogBlock(c, types.AddressQuota, 1, []types.Hash{topicA, topicB}, []types.Hash{topicB})
+	b2 := newTestLogBlock(c, types.AddressGovernance, 1, []types.Hash{topicA, topicC})
+	b3 := newTestLogBlock(c, types.AddressQuota, 2, []types.Hash{topicA})
+
+	insertTestSnapshot(t, vi, c, 2, b1, b2)
+	insertTestSnapshot(t, vi, c, 3, b3)
+
+	// topic query across all contracts
+	logs, err := vi.GetLogs(&VmLogFilter{Topics: [][]types.Hash{{topicA}}}, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+	if logs[2].AccountBlockHash != b3.Hash || logs[2].Location.SnapshotHeight != 3 {
+		t.Fatalf("unexpected last log %+v", logs[2])
+	}
+
+	// pagination
+	page, err := vi.GetLogs(&VmLogFilter{Topics: [][]types.Hash{{topicA}}}, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := vi.GetLogs(&VmLogFilter{Topics: [][]types.Hash{{topicA}}}, &page[1].Location, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || len(next) != 1 || next[0].AccountBlockHash != b3.Hash {
+		t.Fatalf("unexpected pages %d %d", len(page), len(next))
+	}
+
+	// address with topic and snapshot range
+	logs, err = vi.GetLogs(&VmLogFilter{
+		Addresses:          map[types.Address]AccountHeightRange{types.AddressQuota: {}},
+		Topics:             [][]types.Hash{{topicA}, {topicB}},
+		FromSnapshotHeight: 2,
+		ToSnapshotHeight:   2,
+	}, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].AccountBlockHash != b1.Hash || logs[0].Location.LogIndex != 0 {
+		t.Fatalf("unexpected logs %+v", logs)
+	}
+
+	// rollback
+	batch := vi.store.NewBatch()
+	if err := vi.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{{SnapshotBlock: &ledger.SnapshotBlock{Height: 3}, AccountBlocks: []*ledger.AccountBlock{b3}}}); err != nil {
+		t.Fatal(err)
+	}
+	vi.store.WriteDirectly(batch)
+
+	logs, err = vi.GetLogs(&VmLogFilter{Topics: [][]types.Hash{{topicA}}}, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs after rollback, got %d", len(logs))
+	}
+
+	if _, err := vi.GetLogs(&VmLogFilter{}, nil, 10); err == nil {
+		t.Fatal("expected error for empty filter")
+	}
+}
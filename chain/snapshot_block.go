@@ -42,6 +42,14 @@ func (c *chain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
 	return c.cache.GetLatestSnapshotBlock()
 }
 
+// latestSnapshotHeight is the height of the latest snapshot block, it's 0 before the genesis is inserted
+func (c *chain) latestSnapshotHeight() uint64 {
+	if sb := c.GetLatestSnapshotBlock(); sb != nil {
+		return sb.Height
+	}
+	return 0
+}
+
 func (c *chain) GetSnapshotHeightByHash(hash types.Hash) (uint64, error) {
 	// cache
 	if header := c.cache.GetSnapshotHeaderByHash(hash); header != nil {
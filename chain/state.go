@@ -41,6 +41,21 @@ func (c *chain) GetConfirmedBalanceList(addrList []types.Address, tokenId types.
 	return balanceMap, nil
 }
 
+// get snapshot Balance map, read from the versioned balance history
+func (c *chain) GetBalanceMapAtSnapshot(addr types.Address, snapshotHeight uint64) (map[types.TokenTypeId]*big.Int, error) {
+	if err := c.checkSnapshotHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	result, err := c.stateDB.GetSnapshotBalanceMap(snapshotHeight, addr)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotBalanceMap failed, Addr is %s, snapshotHeight is %d. Error: %s,", addr, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetBalanceMapAtSnapshot")
+		return nil, cErr
+	}
+	return result, nil
+}
+
 // get contract code
 func (c *chain) GetContractCode(contractAddress types.Address) ([]byte, error) {
 	code, err := c.stateDB.GetCode(contractAddress)
@@ -53,7 +68,7 @@ func (c *chain) GetContractCode(contractAddress types.Address) ([]byte, error) {
 }
 
 func (c *chain) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
-	if meta := ledger.GetBuiltinContractMeta(contractAddress); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, c.latestSnapshotHeight()); meta != nil {
 		return meta, nil
 	}
 	meta, err := c.stateDB.GetContractMeta(contractAddress)
@@ -66,7 +81,7 @@ func (c *chain) GetContractMeta(contractAddress types.Address) (*ledger.Contract
 }
 
 func (c *chain) GetContractMetaInSnapshot(contractAddress types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
-	if meta := ledger.GetBuiltinContractMeta(contractAddress); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, snapshotHeight); meta != nil {
 		return meta, nil
 	}
 
@@ -102,7 +117,7 @@ func (c *chain) GetContractList(gid types.Gid) ([]types.Address, error) {
 		return nil, cErr
 	}
 	if util.IsDelegateGid(gid) {
-		addrList = append(addrList, types.BuiltinContracts...)
+		addrList = append(addrList, ledger.BuiltinContracts(c.latestSnapshotHeight())...)
 	}
 	return addrList, nil
 }
@@ -146,6 +161,36 @@ func (c *chain) GetStorageIterator(address types.Address, prefix []byte) (interf
 	return ss, nil
 }
 
+func (c *chain) GetStorageIteratorAtSnapshot(address types.Address, prefix []byte, snapshotHeight uint64) (interfaces.StorageIterator, error) {
+	if err := c.checkSnapshotHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	return c.stateDB.NewSnapshotStorageIteratorByHeight(snapshotHeight, address, prefix)
+}
+
+func (c *chain) GetValueAtSnapshot(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error) {
+	if err := c.checkSnapshotHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	value, err := c.stateDB.GetSnapshotValue(snapshotHeight, address, key)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotValue failed, address is %s, key is %v, snapshotHeight is %d. Error: %s", address, key, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetValueAtSnapshot")
+		return nil, cErr
+	}
+	return value, nil
+}
+
+func (c *chain) checkSnapshotHeight(snapshotHeight uint64) error {
+	latestSnapshotBlock := c.GetLatestSnapshotBlock()
+	if snapshotHeight <= 0 || snapshotHeight > latestSnapshotBlock.Height {
+		return errors.New(fmt.Sprintf("snapshot height %d is out of range, latest snapshot height is %d", snapshotHeight, latestSnapshotBlock.Height))
+	}
+	return nil
+}
+
 func (c *chain) GetValue(address types.Address, key []byte) ([]byte, error) {
 	value, err := c.stateDB.GetStorageValue(&address, key)
 	if err != nil {
@@ -115,6 +115,7 @@ type StateDBInterface interface {
 	GetCallDepth(sendBlockHash *types.Hash) (uint16, error)
 	GetSnapshotBalanceList(balanceMap map[types.Address]*big.Int, snapshotBlockHash types.Hash, addrList []types.Address, tokenId types.TokenTypeId) error
 	GetSnapshotValue(snapshotBlockHeight uint64, addr types.Address, key []byte) ([]byte, error)
+	GetSnapshotBalanceMap(snapshotBlockHeight uint64, addr types.Address) (map[types.TokenTypeId]*big.Int, error)
 	SetCacheLevelForConsensus(level uint32)
 	Store() *chain_db.Store
 	RedoStore() *chain_db.Store
@@ -1160,6 +1160,21 @@ func (mr *MockStateDBInterfaceMockRecorder) GetSnapshotValue(snapshotBlockHeight
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotValue", reflect.TypeOf((*MockStateDBInterface)(nil).GetSnapshotValue), snapshotBlockHeight, addr, key)
 }
 
+// GetSnapshotBalanceMap mocks base method
+func (m *MockStateDBInterface) GetSnapshotBalanceMap(snapshotBlockHeight uint64, addr types.Address) (map[types.TokenTypeId]*big.Int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotBalanceMap", snapshotBlockHeight, addr)
+	ret0, _ := ret[0].(map[types.TokenTypeId]*big.Int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotBalanceMap indicates an expected call of GetSnapshotBalanceMap
+func (mr *MockStateDBInterfaceMockRecorder) GetSnapshotBalanceMap(snapshotBlockHeight, addr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotBalanceMap", reflect.TypeOf((*MockStateDBInterface)(nil).GetSnapshotBalanceMap), snapshotBlockHeight, addr)
+}
+
 // SetCacheLevelForConsensus mocks base method
 func (m *MockStateDBInterface) SetCacheLevelForConsensus(level uint32) {
 	m.ctrl.T.Helper()
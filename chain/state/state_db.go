@@ -331,6 +331,33 @@ func (sDB *StateDB) GetSnapshotValue(snapshotBlockHeight uint64, addr types.Addr
 	return nil, nil
 }
 
+// GetSnapshotBalanceMap returns all token balances of the address at the snapshot height, read from the history balance index
+func (sDB *StateDB) GetSnapshotBalanceMap(snapshotBlockHeight uint64, addr types.Address) (map[types.TokenTypeId]*big.Int, error) {
+	balanceMap := make(map[types.TokenTypeId]*big.Int)
+
+	iter := sDB.store.NewIterator(util.BytesPrefix(append([]byte{chain_utils.BalanceHistoryKeyPrefix}, addr.Bytes()...)))
+	defer iter.Release()
+
+	// keys are sorted by token id and then snapshot height
+	for iter.Next() {
+		key := iter.Key()
+		if binary.BigEndian.Uint64(key[len(key)-8:]) > snapshotBlockHeight {
+			continue
+		}
+
+		tokenTypeId, err := types.BytesToTokenTypeId(key[1+types.AddressSize : 1+types.AddressSize+types.TokenTypeIdSize])
+		if err != nil {
+			return nil, err
+		}
+		balanceMap[tokenTypeId] = big.NewInt(0).SetBytes(iter.Value())
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+
+	return balanceMap, nil
+}
+
 func (sDB *StateDB) SetCacheLevelForConsensus(level uint32) {
 	atomic.StoreUint32(&sDB.consensusCacheLevel, level)
 }
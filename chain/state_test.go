@@ -40,6 +40,10 @@ func testState(t *testing.T, chainInstance *chain, accounts map[types.Address]*A
 		GetConfirmedBalanceList(chainInstance, accounts, snapshotBlocks)
 	})
 
+	t.Run("GetBalanceMapAtSnapshot", func(t *testing.T) {
+		GetBalanceMapAtSnapshot(chainInstance, accounts, snapshotBlocks)
+	})
+
 	t.Run("GetContractMeta", func(t *testing.T) {
 		GetContractMeta(chainInstance, accounts)
 	})
@@ -73,6 +77,8 @@ func testStateNoTesting(chainInstance *chain, accounts map[types.Address]*Accoun
 
 	GetConfirmedBalanceList(chainInstance, accounts, snapshotBlocks)
 
+	GetBalanceMapAtSnapshot(chainInstance, accounts, snapshotBlocks)
+
 	GetContractMeta(chainInstance, accounts)
 
 	GetContractCode(chainInstance, accounts)
@@ -172,6 +178,44 @@ func GetConfirmedBalanceList(chainInstance *chain, accounts map[types.Address]*A
 	}
 }
 
+func GetBalanceMapAtSnapshot(chainInstance *chain, accounts map[types.Address]*Account, snapshotBlocks []*ledger.SnapshotBlock) {
+	for index, snapshotBlock := range snapshotBlocks {
+		if snapshotBlock.Height <= 1 {
+			continue
+		}
+		for _, account := range accounts {
+			var highBlock *ledger.AccountBlock
+
+			for i := index; i >= 0 && highBlock == nil; i-- {
+				for hash := range account.ConfirmedBlockMap[snapshotBlocks[i].Hash] {
+					block := account.BlocksMap[hash]
+					if highBlock == nil || block.Height > highBlock.Height {
+						highBlock = block
+					}
+				}
+			}
+
+			balance := big.NewInt(0)
+			if highBlock != nil {
+				balance = account.BalanceMap[highBlock.Hash]
+			}
+
+			balanceMap, err := chainInstance.GetBalanceMapAtSnapshot(account.Addr, snapshotBlock.Height)
+			if err != nil {
+				panic(err)
+			}
+
+			queryBalance := balanceMap[ledger.ViteTokenId]
+			if queryBalance == nil {
+				queryBalance = big.NewInt(0)
+			}
+			if queryBalance.Cmp(balance) != 0 {
+				panic(fmt.Sprintf("snapshotBlock %d, addr: %s, highBlock: %+v, queryBalance: %d, Balance: %d", snapshotBlock.Height, account.Addr, highBlock, queryBalance, balance))
+			}
+		}
+	}
+}
+
 func GetContractCode(chainInstance *chain, accounts map[types.Address]*Account) {
 	for _, account := range accounts {
 		code, err := chainInstance.GetContractCode(account.Addr)
@@ -43,6 +43,7 @@ type ResponseTxParams struct {
 
 type Client interface {
 	DexClient
+	MultisigClient
 	BuildNormalRequestBlock(params RequestTxParams, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
 	BuildRequestCreateContractBlock(params RequestCreateContractParams, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
 	BuildResponseBlock(params ResponseTxParams, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
@@ -50,6 +51,8 @@ type Client interface {
 	GetBalanceAll(addr types.Address) (*api.RpcAccountInfo, *api.RpcAccountInfo, error)
 	SignData(wallet *entropystore.Manager, block *api.AccountBlock) error
 	SignDataWithPriKey(key *derivation.Key, block *api.AccountBlock) error
+	PrepareOfflineTx(params RequestTxParams) (*OfflineTx, error)
+	BroadcastOfflineTx(tx *OfflineTx) error
 }
 
 func NewClient(rpc RpcClient) (Client, error) {
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+type MultisigClient interface {
+	BuildRequestCreateMultisigBlock(param *abi.ParamCreateMultisig, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
+	BuildRequestMultisigDepositBlock(multisigId uint64, tokenId types.TokenTypeId, amount *big.Int, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
+	BuildRequestMultisigProposeBlock(param *abi.ParamMultisigPropose, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
+	BuildRequestMultisigApproveBlock(param *abi.ParamMultisigProposal, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
+	BuildRequestMultisigExecuteBlock(param *abi.ParamMultisigProposal, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error)
+}
+
+func (c *client) BuildRequestCreateMultisigBlock(param *abi.ParamCreateMultisig, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	data, err := abi.ABIMultisig.PackMethod(abi.MethodNameCreateMultisig, param.Owners, param.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMultisigRequestBlock(data, ledger.ViteTokenId, big.NewInt(0), selfAddr, prev)
+}
+
+func (c *client) BuildRequestMultisigDepositBlock(multisigId uint64, tokenId types.TokenTypeId, amount *big.Int, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	data, err := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigDeposit, multisigId)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMultisigRequestBlock(data, tokenId, amount, selfAddr, prev)
+}
+
+func (c *client) BuildRequestMultisigProposeBlock(param *abi.ParamMultisigPropose, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	proposalData := param.Data
+	if proposalData == nil {
+		proposalData = []byte{}
+	}
+	data, err := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigPropose, param.Id, param.To, param.TokenId, param.Amount, proposalData)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMultisigRequestBlock(data, ledger.ViteTokenId, big.NewInt(0), selfAddr, prev)
+}
+
+func (c *client) BuildRequestMultisigApproveBlock(param *abi.ParamMultisigProposal, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	data, err := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigApprove, param.Id, param.ProposalId)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMultisigRequestBlock(data, ledger.ViteTokenId, big.NewInt(0), selfAddr, prev)
+}
+
+func (c *client) BuildRequestMultisigExecuteBlock(param *abi.ParamMultisigProposal, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	data, err := abi.ABIMultisig.PackMethod(abi.MethodNameMultisigExecute, param.Id, param.ProposalId)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMultisigRequestBlock(data, ledger.ViteTokenId, big.NewInt(0), selfAddr, prev)
+}
+
+func (c *client) buildMultisigRequestBlock(data []byte, tokenId types.TokenTypeId, amount *big.Int, selfAddr types.Address, prev *ledger.HashHeight) (block *api.AccountBlock, err error) {
+	params := &RequestTxParams{}
+	params.SelfAddr = selfAddr
+	params.Data = data
+	params.ToAddr = types.AddressMultisig
+	params.Amount = amount
+	params.TokenId = tokenId
+	return c.BuildNormalRequestBlock(*params, prev)
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/pow"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+const offlineTxVersion = 1
+
+var errorInvalidPayload = errors.New("invalid offline tx payload")
+
+// OfflineTx is a send block prepared on an online machine with the prev hash, height and pow, signed on an
+// offline machine and broadcast by an online machine again. It's passed between the machines as a compact
+// payload, which fits in a QR code.
+type OfflineTx struct {
+	// SnapshotHeight is the latest snapshot height when the block is prepared, at which the difficulty is calculated
+	SnapshotHeight uint64
+	Block          *ledger.AccountBlock
+}
+
+// Encode returns the payload of the tx: the version, the snapshot height in uvarint and the block in protobuf,
+// encoded in url-safe base64.
+func (tx *OfflineTx) Encode() (string, error) {
+	data, err := tx.Block.Serialize()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(data))
+	buf[0] = offlineTxVersion
+	n := binary.PutUvarint(buf[1:], tx.SnapshotHeight)
+	buf = append(buf[:1+n], data...)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeOfflineTx decodes the payload and checks the hash, and the signature if it's signed.
+func DecodeOfflineTx(payload string) (*OfflineTx, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(buf) < 2 {
+		return nil, errorInvalidPayload
+	}
+	if buf[0] != offlineTxVersion {
+		return nil, errors.New(fmt.Sprintf("unsupported offline tx version %d", buf[0]))
+	}
+	snapshotHeight, n := binary.Uvarint(buf[1:])
+	if n <= 0 {
+		return nil, errorInvalidPayload
+	}
+	block := &ledger.AccountBlock{}
+	if err := block.Deserialize(buf[1+n:]); err != nil {
+		return nil, errorInvalidPayload
+	}
+	if !block.IsSendBlock() {
+		return nil, errors.New("offline tx must be a send block")
+	}
+	if block.Hash != block.ComputeHash() {
+		return nil, errors.New("hash of the offline tx is not the hash of the content")
+	}
+	tx := &OfflineTx{SnapshotHeight: snapshotHeight, Block: block}
+	if tx.Signed() {
+		if types.PubkeyToAddress(block.PublicKey) != block.AccountAddress || !block.VerifySignature() {
+			return nil, errors.New("invalid signature of the offline tx")
+		}
+	}
+	return tx, nil
+}
+
+func (tx *OfflineTx) Signed() bool {
+	return len(tx.Block.Signature) > 0
+}
+
+// Sign signs the block with the key of its account.
+func (tx *OfflineTx) Sign(key *derivation.Key) error {
+	if key == nil {
+		return errorNilKey
+	}
+	addr, err := key.Address()
+	if err != nil {
+		return err
+	}
+	if *addr != tx.Block.AccountAddress {
+		return errors.New(fmt.Sprintf("key of %s can't sign the block of %s", addr, tx.Block.AccountAddress))
+	}
+	signature, pub, err := key.SignData(tx.Block.Hash.Bytes())
+	if err != nil {
+		return err
+	}
+	tx.Block.Signature = signature
+	tx.Block.PublicKey = pub
+	return nil
+}
+
+// RpcBlock returns the block to be sent by ledger_sendRawTransaction.
+func (tx *OfflineTx) RpcBlock() *api.AccountBlock {
+	b := tx.Block
+	amount := b.Amount.String()
+	block := &api.AccountBlock{
+		BlockType:      b.BlockType,
+		Height:         strconv.FormatUint(b.Height, 10),
+		Hash:           b.Hash,
+		PrevHash:       b.PrevHash,
+		AccountAddress: b.AccountAddress,
+		PublicKey:      b.PublicKey,
+		ToAddress:      b.ToAddress,
+		TokenId:        b.TokenId,
+		Amount:         &amount,
+		Data:           b.Data,
+		Nonce:          b.Nonce,
+		Signature:      b.Signature,
+	}
+	if b.Fee != nil {
+		fee := b.Fee.String()
+		block.Fee = &fee
+	}
+	if b.Difficulty != nil {
+		difficulty := b.Difficulty.String()
+		block.Difficulty = &difficulty
+	}
+	return block
+}
+
+// PrepareOfflineTx builds the unsigned send block with the prev hash and height of the account, and calculates
+// the pow if the quota of the account is not enough.
+func (c *client) PrepareOfflineTx(params RequestTxParams) (*OfflineTx, error) {
+	snapshotHeight, err := strconv.ParseUint(c.rpc.GetSnapshotChainHeight(), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := c.getPrev(params.SelfAddr)
+	if err != nil {
+		return nil, err
+	}
+	rpcBlock, err := c.BuildNormalRequestBlock(params, prev)
+	if err != nil {
+		return nil, err
+	}
+	block, err := rpcBlock.RpcToLedgerBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.rpc.CalcPoWDifficulty(api.CalcPoWDifficultyParam{
+		SelfAddr:      params.SelfAddr,
+		PrevHash:      prev.Hash,
+		BlockType:     block.BlockType,
+		ToAddr:        &params.ToAddr,
+		Data:          params.Data,
+		UseStakeQuota: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Difficulty != "" {
+		difficulty, ok := new(big.Int).SetString(result.Difficulty, 10)
+		if !ok {
+			return nil, api.ErrStrToBigInt
+		}
+		nonce, err := pow.GetPowNonce(difficulty, types.DataListHash(block.AccountAddress.Bytes(), block.PrevHash.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		block.Difficulty = difficulty
+		block.Nonce = nonce
+	}
+	block.Hash = block.ComputeHash()
+	return &OfflineTx{SnapshotHeight: snapshotHeight, Block: block}, nil
+}
+
+// BroadcastOfflineTx sends the signed block by ledger_sendRawTransaction.
+func (c *client) BroadcastOfflineTx(tx *OfflineTx) error {
+	if !tx.Signed() {
+		return errors.New("offline tx is not signed")
+	}
+	return c.rpc.SendRawTransaction(tx.RpcBlock())
+}
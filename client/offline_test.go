@@ -0,0 +1,81 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+func TestOfflineTx(t *testing.T) {
+	seed := make([]byte, 64)
+	key, err := derivation.DeriveWithIndex(0, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := key.Address()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		Height:         3,
+		PrevHash:       types.DataHash([]byte("prev")),
+		AccountAddress: *addr,
+		ToAddress:      types.AddressQuota,
+		Amount:         big.NewInt(1e18),
+		TokenId:        ledger.ViteTokenId,
+		Fee:            big.NewInt(0),
+		Data:           []byte{1, 2, 3, 4},
+		Difficulty:     big.NewInt(67108863),
+		Nonce:          []byte{0, 0, 0, 0, 0, 0, 0, 1},
+	}
+	block.Hash = block.ComputeHash()
+	tx := &OfflineTx{SnapshotHeight: 1000, Block: block}
+
+	payload, err := tx.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared, err := DecodeOfflineTx(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared.Signed() || prepared.SnapshotHeight != 1000 || prepared.Block.Hash != block.Hash || prepared.Block.Difficulty.Cmp(block.Difficulty) != 0 {
+		t.Fatalf("unexpected prepared tx")
+	}
+
+	other, _ := derivation.DeriveWithIndex(1, seed)
+	if err := prepared.Sign(other); err == nil {
+		t.Fatalf("block should not be signed by the key of another account")
+	}
+	if err := prepared.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	payload, err = prepared.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("payload of %d bytes", len(payload))
+	signed, err := DecodeOfflineTx(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.Signed() {
+		t.Fatalf("tx should be signed")
+	}
+	lb, err := signed.RpcBlock().RpcToLedgerBlock()
+	if err != nil || lb.ComputeHash() != block.Hash {
+		t.Fatalf("rpc block is not the same as the signed block, %v", err)
+	}
+
+	signed.Block.Amount = big.NewInt(2e18)
+	if payload, err = signed.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeOfflineTx(payload); err == nil {
+		t.Fatalf("tampered tx should be rejected")
+	}
+}
@@ -12,6 +12,7 @@ type RpcClient interface {
 	rpc2.ContractApi
 	rpc2.DexTradeApi
 	rpc2.RandomApi
+	rpc2.MultisigApi
 
 	GetClient() *rpc.Client
 }
@@ -29,6 +30,7 @@ func NewRpcClient(rawurl string) (RpcClient, error) {
 		ContractApi: rpc2.NewContractApi(c),
 		DexTradeApi: rpc2.NewDexTradeApi(c),
 		RandomApi:   rpc2.NewRandomApi(c),
+		MultisigApi: rpc2.NewMultisigApi(c),
 		cc:          c,
 	}
 	return r, nil
@@ -41,6 +43,7 @@ type rpcClient struct {
 	rpc2.ContractApi
 	rpc2.DexTradeApi
 	rpc2.RandomApi
+	rpc2.MultisigApi
 
 	cc *rpc.Client
 }
@@ -31,6 +31,7 @@ type LedgerApi interface {
 	GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock
 	GetConfirmedBalances(snapshotHash types.Hash, addrList []types.Address, tokenIds []types.TokenTypeId) (api.GetBalancesRes, error)
 	GetHourSBPStats(startIdx uint64, endIdx uint64) ([]map[string]interface{}, error)
+	SendRawTransaction(block *api.AccountBlock) error
 }
 
 type ledgerApi struct {
@@ -149,3 +150,8 @@ func (li ledgerApi) GetHourSBPStats(startIdx uint64, endIdx uint64) (result []ma
 	err = li.cc.Call(&result, "sbpstats_getHourSBPStats", startIdx, endIdx)
 	return
 }
+
+func (li ledgerApi) SendRawTransaction(block *api.AccountBlock) (err error) {
+	err = li.cc.Call(nil, "ledger_sendRawTransaction", block)
+	return
+}
@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// MultisigApi ...
+type MultisigApi interface {
+	GetMultisigInfo(multisigId string) (*api.MultisigInfo, error)
+	GetMultisigListByOwner(owner types.Address) ([]*api.MultisigInfo, error)
+	GetPendingProposals(multisigId string) ([]*api.MultisigProposal, error)
+}
+
+type multisigApi struct {
+	cc *rpc.Client
+}
+
+func NewMultisigApi(cc *rpc.Client) MultisigApi {
+	return &multisigApi{cc: cc}
+}
+
+func (mi multisigApi) GetMultisigInfo(multisigId string) (result *api.MultisigInfo, err error) {
+	err = mi.cc.Call(&result, "multisig_getMultisigInfo", multisigId)
+	return
+}
+
+func (mi multisigApi) GetMultisigListByOwner(owner types.Address) (result []*api.MultisigInfo, err error) {
+	err = mi.cc.Call(&result, "multisig_getMultisigListByOwner", owner)
+	return
+}
+
+func (mi multisigApi) GetPendingProposals(multisigId string) (result []*api.MultisigProposal, err error) {
+	err = mi.cc.Call(&result, "multisig_getPendingProposals", multisigId)
+	return
+}
@@ -0,0 +1,72 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dnsCommand = cli.Command{
+		Name:     "dns",
+		Usage:    "Crawl the network and make the node list published to DNS",
+		Category: "DNS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(dnsCrawlAction),
+				Name:      "crawl",
+				Usage:     "dns crawl --dnsnodes=nodes.json --dnscrawltime=10m",
+				ArgsUsage: "--dnsnodes=nodes.json --dnscrawltime=10m",
+				Flags:     append([]cli.Flag{utils.DNSNodesFlag, utils.DNSCrawlTimeFlag}, configFlags...),
+				Description: `
+Crawl the network through discovery, from the BootNodes, BootSeeds and BootTrees of the config.
+The nodes found are written to the nodes file, the node itself is not started.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(dnsSignAction),
+				Name:      "sign",
+				Usage:     "dns sign --dnsnodes=nodes.json --dnsdomain=nodes.example.org --dnskey=dns.key --dnstree=tree.json",
+				ArgsUsage: "--dnsnodes=nodes.json --dnsdomain=nodes.example.org --dnskey=dns.key --dnstree=tree.json",
+				Flags: append([]cli.Flag{utils.DNSNodesFlag, utils.DNSDomainFlag, utils.DNSKeyFlag, utils.DNSSeqFlag,
+					utils.DNSLinksFlag, utils.DNSTreeFlag}, configFlags...),
+				Description: `
+Make the node list of the nodes file and sign it with the key, the TXT records are written to the tree file
+as {"<domain name>": "<TXT record>"}. After the records are published to DNS, nodes with the printed URL
+enrtree://<public key>@<domain> in BootTrees will boot from the node list.
+The sequence number should be increased every time the node list is updated.
+`,
+			},
+		},
+	}
+)
+
+func dnsCrawlAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewDNSCrawlNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
+
+func dnsSignAction(ctx *cli.Context) error {
+	if err := nodemanager.NewDNSSignNodeManager(ctx).Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
@@ -70,6 +70,13 @@ var (
 		utils.WSPortFlag,
 	}
 
+	//GraphQL
+	graphqlFlags = []cli.Flag{
+		utils.GraphQLEnabledFlag,
+		utils.GraphQLListenAddrFlag,
+		utils.GraphQLPortFlag,
+	}
+
 	//Console
 	consoleFlags = []cli.Flag{
 		utils.JSPathFlag,
@@ -82,6 +89,7 @@ var (
 		utils.MinerFlag,
 		utils.CoinBaseFlag,
 		utils.MinerIntervalFlag,
+		utils.RemoteSignerFlag,
 	}
 
 	//Log
@@ -99,6 +107,7 @@ var (
 	netFlags = []cli.Flag{
 		utils.SingleFlag,
 		utils.FilePortFlag,
+		utils.NATFlag,
 	}
 
 	//Stat
@@ -128,6 +137,35 @@ var (
 	exportFlags = []cli.Flag{
 		utils.ExportSbHeightFlags,
 	}
+
+	// Replay
+	replayFlags = []cli.Flag{
+		utils.ReplayFromHeightFlag,
+		utils.ReplayToHeightFlag,
+	}
+
+	// Snapshot
+	snapshotFlags = []cli.Flag{
+		utils.SnapshotHeightFlag,
+		utils.SnapshotArchiveFlag,
+		utils.SnapshotHashFlag,
+	}
+
+	// DNS
+	dnsFlags = []cli.Flag{
+		utils.DNSNodesFlag,
+		utils.DNSCrawlTimeFlag,
+		utils.DNSDomainFlag,
+		utils.DNSKeyFlag,
+		utils.DNSSeqFlag,
+		utils.DNSLinksFlag,
+		utils.DNSTreeFlag,
+	}
+
+	// Signer
+	signerFlags = []cli.Flag{
+		utils.SignerPolicyFlag,
+	}
 )
 
 func init() {
@@ -156,13 +194,18 @@ func init() {
 		exportCommand,
 		pluginDataCommand,
 		checkChainCommand,
+		replayCommand,
+		snapshotCommand,
+		signerCommand,
+		txCommand,
+		dnsCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	//Import: Please add the New Flags here
 	app.Flags = utils.MergeFlags(configFlags, generalFlags, p2pFlags,
-		ipcFlags, httpFlags, wsFlags, consoleFlags, producerFlags, logFlags,
-		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags)
+		ipcFlags, httpFlags, wsFlags, graphqlFlags, consoleFlags, producerFlags, logFlags,
+		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags, replayFlags, snapshotFlags, signerFlags, dnsFlags)
 
 	app.Before = beforeAction
 	app.Action = action
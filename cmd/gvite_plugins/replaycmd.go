@@ -0,0 +1,40 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+)
+
+var (
+	replayCommand = cli.Command{
+		Action:   utils.MigrateFlags(replayAction),
+		Name:     "replay",
+		Usage:    "replay --from=5000000 --to=5001000",
+		Flags:    append(replayFlags, configFlags...),
+		Category: "CHECK CHAIN COMMANDS",
+		Description: `
+Re-execute the contract receive blocks confirmed in the snapshot height range through the vm,
+and report the first block or state which is different from the ledger.
+`,
+	}
+)
+
+func replayAction(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	nodeManager, err := nodemanager.NewReplayNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,51 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	signerCommand = cli.Command{
+		Action:    utils.MigrateFlags(signerAction),
+		Name:      "signer",
+		Usage:     "signer --remotesigner=signer.ipc --signerpolicy=policy.json",
+		ArgsUsage: "--remotesigner=signer.ipc --signerpolicy=policy.json",
+		Flags:     append([]cli.Flag{utils.RemoteSignerFlag, utils.SignerPolicyFlag}, configFlags...),
+		Category:  "SIGNER COMMANDS",
+		Description: `
+Run the reference signer with the entropy store of the config, the producer and the onroad workers of a
+node started with the same --remotesigner request signatures from it. The policy file is like:
+
+{
+  "Addresses": ["vite_..."],
+  "MaxAmounts": {"tti_5649544520544f4b454e6e40": "1000000000000000000000"},
+  "Contracts": [{"Address": "vite_0000000000000000000000000000000000000004d28108e76b", "Methods": []}]
+}
+
+Only the listed addresses are signed with. The amount of a send block must not exceed the max amount
+of its token, and a send block may only call the listed contracts, with one of the hex method selectors
+if Methods is not empty.
+`,
+	}
+)
+
+func signerAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewSignerNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
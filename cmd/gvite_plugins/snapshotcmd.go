@@ -0,0 +1,74 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Export or import the ledger at a snapshot block",
+		Category: "SNAPSHOT COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(snapshotExportAction),
+				Name:      "export",
+				Usage:     "snapshot export --height=5000000 --archive=ledger_5000000.tar.gz",
+				ArgsUsage: "--height=5000000 --archive=ledger_5000000.tar.gz",
+				Flags:     append(snapshotFlags, configFlags...),
+				Description: `
+Export the ledger at the snapshot block to a checksummed archive, the node must be stopped.
+The ledger is copied and rolled back to the snapshot block, the data directory is not changed.
+The sha256 of the archive is written to <archive>.sha256.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(snapshotImportAction),
+				Name:      "import",
+				Usage:     "snapshot import --archive=ledger_5000000.tar.gz --hash=<snapshot block hash>",
+				ArgsUsage: "--archive=ledger_5000000.tar.gz --hash=<snapshot block hash>",
+				Flags:     append(snapshotFlags, configFlags...),
+				Description: `
+Import the ledger from an archive into an empty data directory. The files are verified with the
+checksums of the archive, and the snapshot block of the archive is verified with the trusted hash.
+`,
+			},
+		},
+	}
+)
+
+func snapshotExportAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewSnapshotExportNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
+
+func snapshotImportAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewSnapshotImportNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,209 @@
+package gvite_plugins
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/vitelabs/go-vite/client"
+	"github.com/vitelabs/go-vite/cmd/console"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	txCommand = cli.Command{
+		Name:     "tx",
+		Usage:    "Prepare a transaction online, sign it offline and broadcast it online",
+		Category: "TRANSACTION COMMANDS",
+		Description: `
+The transaction is passed between the machines as a compact payload which fits in a QR code.
+The payload is printed on the last line of the output.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(txPrepareAction),
+				Name:      "prepare",
+				Usage:     "tx prepare --address=vite_... --toaddress=vite_... --amount=1000000000000000000",
+				ArgsUsage: "--address=vite_... --toaddress=vite_... --amount=1000000000000000000",
+				Flags: []cli.Flag{utils.TxEndpointFlag, utils.TxAddressFlag, utils.TxToAddressFlag,
+					utils.TxAmountFlag, utils.TxTokenIdFlag, utils.TxDataFlag},
+				Description: `
+Build the unsigned send block with the prev hash, height and pow of the address on an online machine.
+The pow is calculated locally if the quota of the address is not enough.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(txSignAction),
+				Name:      "sign",
+				Usage:     "tx sign --entropystore=<entropy store file> [--yes] <payload>",
+				ArgsUsage: "--entropystore=<entropy store file> [--yes] <payload>",
+				Flags:     []cli.Flag{utils.TxEntropyStoreFlag, utils.TxYesFlag},
+				Description: `
+Sign the prepared payload with the entropy store on an offline machine. The transaction is printed
+and confirmed before signing unless --yes is set, then the password is prompted.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(txBroadcastAction),
+				Name:      "broadcast",
+				Usage:     "tx broadcast <payload>",
+				ArgsUsage: "<payload>",
+				Flags:     []cli.Flag{utils.TxEndpointFlag},
+				Description: `
+Send the signed payload by ledger_sendRawTransaction of an online node.
+`,
+			},
+		},
+	}
+)
+
+func txPrepareAction(ctx *cli.Context) error {
+	params := client.RequestTxParams{TokenId: ledger.ViteTokenId}
+	var err error
+	if params.SelfAddr, err = types.HexToAddress(ctx.String(utils.TxAddressFlag.Name)); err != nil {
+		return errors.New(fmt.Sprintf("invalid address, %v", err))
+	}
+	if params.ToAddr, err = types.HexToAddress(ctx.String(utils.TxToAddressFlag.Name)); err != nil {
+		return errors.New(fmt.Sprintf("invalid to address, %v", err))
+	}
+	amount, ok := new(big.Int).SetString(ctx.String(utils.TxAmountFlag.Name), 10)
+	if !ok || amount.Sign() < 0 {
+		return errors.New("invalid amount")
+	}
+	params.Amount = amount
+	if tokenId := ctx.String(utils.TxTokenIdFlag.Name); tokenId != "" {
+		if params.TokenId, err = types.HexToTokenTypeId(tokenId); err != nil {
+			return errors.New(fmt.Sprintf("invalid token id, %v", err))
+		}
+	}
+	if data := ctx.String(utils.TxDataFlag.Name); data != "" {
+		if params.Data, err = hex.DecodeString(strings.TrimPrefix(data, "0x")); err != nil {
+			return errors.New(fmt.Sprintf("invalid data, %v", err))
+		}
+	}
+
+	c, err := newTxClient(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := c.PrepareOfflineTx(params)
+	if err != nil {
+		return err
+	}
+	return printOfflineTx(tx)
+}
+
+func txSignAction(ctx *cli.Context) error {
+	tx, err := client.DecodeOfflineTx(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if tx.Signed() {
+		return errors.New("the payload is signed already")
+	}
+
+	// the payload is opaque, check what is signed
+	printTxSummary(tx)
+	if !ctx.Bool(utils.TxYesFlag.Name) {
+		confirmed, err := console.Stdin.PromptConfirm("Sign the transaction?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return errors.New("the transaction is not confirmed")
+		}
+	}
+
+	file, err := filepath.Abs(ctx.String(utils.TxEntropyStoreFlag.Name))
+	if err != nil {
+		return err
+	}
+	ok, primaryAddr, err := entropystore.IsMayValidEntropystoreFile(file)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("not valid entropy store file")
+	}
+	manager := entropystore.NewManager(file, *primaryAddr, entropystore.DefaultMaxIndex)
+	password, err := console.Stdin.PromptPassword("Password: ")
+	if err != nil {
+		return err
+	}
+	if err := manager.Unlock(password); err != nil {
+		return err
+	}
+	defer manager.Lock()
+
+	key, _, err := manager.FindAddr(tx.Block.AccountAddress)
+	if err != nil {
+		return err
+	}
+	if err := tx.Sign(key); err != nil {
+		return err
+	}
+	return printOfflineTx(tx)
+}
+
+func txBroadcastAction(ctx *cli.Context) error {
+	tx, err := client.DecodeOfflineTx(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	c, err := newTxClient(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.BroadcastOfflineTx(tx); err != nil {
+		return err
+	}
+	fmt.Println(tx.Block.Hash)
+	return nil
+}
+
+func newTxClient(ctx *cli.Context) (client.Client, error) {
+	rpc, err := client.NewRpcClient(ctx.String(utils.TxEndpointFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	return client.NewClient(rpc)
+}
+
+func printTxSummary(tx *client.OfflineTx) {
+	block := tx.Block
+	data := "-"
+	if len(block.Data) > 0 {
+		data = "0x" + hex.EncodeToString(block.Data)
+	}
+	fmt.Printf("From:      %s\n", block.AccountAddress)
+	fmt.Printf("To:        %s\n", block.ToAddress)
+	fmt.Printf("Token:     %s\n", block.TokenId)
+	fmt.Printf("Amount:    %s\n", bigIntString(block.Amount))
+	fmt.Printf("Fee:       %s\n", bigIntString(block.Fee))
+	fmt.Printf("Data:      %s\n", data)
+	fmt.Printf("Height:    %d\n", block.Height)
+	fmt.Printf("PrevHash:  %s\n", block.PrevHash)
+}
+
+func bigIntString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+func printOfflineTx(tx *client.OfflineTx) error {
+	payload, err := tx.Encode()
+	if err != nil {
+		return err
+	}
+	fmt.Println(payload)
+	return nil
+}
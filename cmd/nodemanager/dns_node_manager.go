@@ -0,0 +1,205 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/discovery"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// DNSCrawlNodeManager crawls the network through discovery with the boot nodes of the node config,
+// the nodes found are written to a JSON file, the node is not created.
+type DNSCrawlNodeManager struct {
+	ctx *cli.Context
+	cfg *node.Config
+}
+
+func NewDNSCrawlNodeManager(ctx *cli.Context, maker NodeMaker) (*DNSCrawlNodeManager, error) {
+	cfg, err := maker.MakeNodeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DNSCrawlNodeManager{
+		ctx: ctx,
+		cfg: cfg,
+	}, nil
+}
+
+func (nodeManager *DNSCrawlNodeManager) Start() error {
+	nodesFile := nodeManager.ctx.GlobalString(utils.DNSNodesFlag.Name)
+	if nodesFile == "" {
+		return errors.New("the nodes file is not set")
+	}
+	crawlTime := nodeManager.ctx.GlobalDuration(utils.DNSCrawlTimeFlag.Name)
+
+	// crawl with a temporary identity, so the crawler can run beside the node
+	_, peerKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	id, err := vnode.Bytes2NodeID(peerKey.PubByte())
+	if err != nil {
+		return err
+	}
+
+	cfg := nodeManager.cfg
+	self := &vnode.Node{
+		ID:  id,
+		Net: cfg.NetID,
+	}
+	d := discovery.New(peerKey, self, cfg.BootNodes, cfg.BootSeeds, cfg.BootTrees, cfg.ListenInterface+":0", nil)
+
+	// nodes may be removed from the table later, so record every node checked
+	var mu sync.Mutex
+	var found = make(map[vnode.NodeID]*vnode.Node)
+	d.SubscribeNode(func(n *vnode.Node) {
+		mu.Lock()
+		found[n.ID] = n
+		mu.Unlock()
+	})
+
+	if err = d.Start(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Crawling network %d for %s\n", cfg.NetID, crawlTime)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-c:
+	case <-time.After(crawlTime):
+	}
+	signal.Stop(c)
+
+	_ = d.Stop()
+
+	mu.Lock()
+	var nodes = make([]string, 0, len(found))
+	for _, n := range found {
+		if n.Net == cfg.NetID {
+			nodes = append(nodes, n.String())
+		}
+	}
+	mu.Unlock()
+	sort.Strings(nodes)
+
+	data, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(nodesFile, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d nodes are written to %s\n", len(nodes), nodesFile)
+	return nil
+}
+
+// DNSSignNodeManager makes the node list from the crawled nodes, then signs it and writes the TXT records
+// to a JSON file, which can be published to DNS.
+type DNSSignNodeManager struct {
+	ctx *cli.Context
+}
+
+func NewDNSSignNodeManager(ctx *cli.Context) *DNSSignNodeManager {
+	return &DNSSignNodeManager{
+		ctx: ctx,
+	}
+}
+
+func (nodeManager *DNSSignNodeManager) Start() error {
+	nodesFile := nodeManager.ctx.GlobalString(utils.DNSNodesFlag.Name)
+	domain := nodeManager.ctx.GlobalString(utils.DNSDomainFlag.Name)
+	keyFile := nodeManager.ctx.GlobalString(utils.DNSKeyFlag.Name)
+	treeFile := nodeManager.ctx.GlobalString(utils.DNSTreeFlag.Name)
+	if nodesFile == "" || domain == "" || keyFile == "" || treeFile == "" {
+		return errors.New("the nodes file, domain, key file and tree file must be set")
+	}
+
+	data, err := ioutil.ReadFile(nodesFile)
+	if err != nil {
+		return err
+	}
+	var urls []string
+	if err = json.Unmarshal(data, &urls); err != nil {
+		return errors.New(fmt.Sprintf("parse nodes file error, %v", err))
+	}
+	var nodes = make([]*vnode.Node, len(urls))
+	for i, url := range urls {
+		if nodes[i], err = vnode.ParseNode(url); err != nil {
+			return errors.New(fmt.Sprintf("parse node %s error, %v", url, err))
+		}
+	}
+
+	var links []string
+	if str := nodeManager.ctx.GlobalString(utils.DNSLinksFlag.Name); str != "" {
+		links = strings.Split(str, ",")
+	}
+
+	seq := nodeManager.ctx.GlobalUint(utils.DNSSeqFlag.Name)
+	if seq == 0 {
+		seq = uint(time.Now().Unix())
+	}
+
+	key, err := loadOrCreateDNSKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	tree, err := discovery.MakeTree(seq, nodes, links)
+	if err != nil {
+		return err
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(tree.ToTXT(domain), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(treeFile, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d nodes and %d links are signed with seq %d, the TXT records are written to %s\n", len(nodes), len(links), seq, treeFile)
+	fmt.Printf("The URL of the node list is %s\n", url)
+	return nil
+}
+
+func loadOrCreateDNSKey(keyFile string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err == nil {
+		return ed25519.HexToPrivateKey(strings.TrimSpace(string(data)))
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(keyFile, []byte(key.Hex()), 0600); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("A new key is generated to %s\n", keyFile)
+	return key, nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/vitelabs/go-vite/cmd/console"
 	"github.com/vitelabs/go-vite/cmd/utils"
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/log15"
@@ -27,6 +28,12 @@ func (maker FullNodeMaker) MakeNode(ctx *cli.Context) (*node.Node, error) {
 		return nil, err
 	}
 	log.Info(fmt.Sprintf("NodeConfig info: %v", nodeConfig))
+	// the pin of the pkcs11 token isn't kept in the config file
+	if nodeConfig.Pkcs11Module != "" && nodeConfig.Pkcs11PinFile == "" {
+		if nodeConfig.Pkcs11Pin, err = console.Stdin.PromptPassword("PKCS#11 token pin: "); err != nil {
+			return nil, err
+		}
+	}
 	// 2: New Node
 	node, err := node.New(nodeConfig)
 	log.Info(fmt.Sprintf("Node info: %v", node))
@@ -92,6 +99,10 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.MaxPendingPeers = ctx.GlobalInt(utils.MaxPendingPeersFlag.Name)
 	}
 
+	if nat := ctx.GlobalString(utils.NATFlag.Name); len(nat) > 0 {
+		cfg.NAT = nat
+	}
+
 	if nodeKeyHex := ctx.GlobalString(utils.NodeKeyHexFlag.Name); len(nodeKeyHex) > 0 {
 		cfg.SetPrivateKey(nodeKeyHex)
 	}
@@ -131,6 +142,19 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.WSPort = ctx.GlobalInt(utils.WSPortFlag.Name)
 	}
 
+	//GraphQL Config
+	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
+		cfg.GraphQLEnabled = ctx.GlobalBool(utils.GraphQLEnabledFlag.Name)
+	}
+
+	if graphqlListenAddr := ctx.GlobalString(utils.GraphQLListenAddrFlag.Name); len(graphqlListenAddr) > 0 {
+		cfg.GraphQLHost = graphqlListenAddr
+	}
+
+	if ctx.GlobalIsSet(utils.GraphQLPortFlag.Name) {
+		cfg.GraphQLPort = ctx.GlobalInt(utils.GraphQLPortFlag.Name)
+	}
+
 	//Producer Config
 	if coinBase := ctx.GlobalString(utils.CoinBaseFlag.Name); len(coinBase) > 0 {
 		cfg.CoinBase = coinBase
@@ -144,6 +168,14 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.MinerInterval = ctx.GlobalInt(utils.MinerIntervalFlag.Name)
 	}
 
+	if remoteSigner := ctx.GlobalString(utils.RemoteSignerFlag.Name); len(remoteSigner) > 0 {
+		cfg.RemoteSigner = remoteSigner
+	}
+
+	if signerPolicy := ctx.GlobalString(utils.SignerPolicyFlag.Name); len(signerPolicy) > 0 {
+		cfg.SignerPolicyFile = signerPolicy
+	}
+
 	//Log Level Config
 	if logLevel := ctx.GlobalString(utils.LogLvlFlag.Name); len(logLevel) > 0 {
 		cfg.LogLevel = logLevel
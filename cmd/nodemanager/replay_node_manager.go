@@ -0,0 +1,199 @@
+package nodemanager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/vm_db"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const replayRoundSize = uint64(100)
+
+type ReplayNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+	log  log15.Logger
+}
+
+func NewReplayNodeManager(ctx *cli.Context, maker NodeMaker) (*ReplayNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// single mode
+	node.Config().Single = true
+	node.ViteConfig().Net.Single = true
+
+	// no miner
+	node.Config().MinerEnabled = false
+	node.ViteConfig().Producer.Producer = false
+
+	// no ledger gc
+	ledgerGc := false
+	node.Config().LedgerGc = &ledgerGc
+	node.ViteConfig().Chain.LedgerGc = ledgerGc
+
+	return &ReplayNodeManager{
+		ctx:  ctx,
+		node: node,
+		log:  log15.New("module", "replayCMD"),
+	}, nil
+}
+
+func (nodeManager *ReplayNodeManager) getHeightRange(latestHeight uint64) (uint64, uint64) {
+	// snapshot blocks after genesis
+	from := uint64(2)
+	if nodeManager.ctx.GlobalIsSet(utils.ReplayFromHeightFlag.Name) {
+		from = nodeManager.ctx.GlobalUint64(utils.ReplayFromHeightFlag.Name)
+	}
+	if from < 2 {
+		from = 2
+	}
+	to := latestHeight
+	if nodeManager.ctx.GlobalIsSet(utils.ReplayToHeightFlag.Name) {
+		to = nodeManager.ctx.GlobalUint64(utils.ReplayToHeightFlag.Name)
+	}
+	if to > latestHeight {
+		to = latestHeight
+	}
+	return from, to
+}
+
+func (nodeManager *ReplayNodeManager) Start() error {
+	if err := StartNode(nodeManager.node); err != nil {
+		return err
+	}
+
+	v := nodeManager.node.Vite()
+	c := v.Chain()
+
+	from, to := nodeManager.getHeightRange(c.GetLatestSnapshotBlock().Height)
+	if from > to {
+		return errors.New(fmt.Sprintf("invalid snapshot height range, from %d to %d", from, to))
+	}
+	fmt.Printf("Start replaying snapshot height %d to %d\n", from, to)
+
+	blockCount := 0
+	for h := from; h <= to; h += replayRoundSize {
+		end := h + replayRoundSize - 1
+		if end > to {
+			end = to
+		}
+
+		chunks, err := c.GetSubLedger(h-1, end)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			if chunk.SnapshotBlock == nil || chunk.SnapshotBlock.Height < h {
+				continue
+			}
+			count, err := replaySnapshotChunk(c, v.Consensus(), chunk)
+			if err != nil {
+				return errors.New(fmt.Sprintf("replay failed at snapshot height %d: %s", chunk.SnapshotBlock.Height, err))
+			}
+			blockCount += count
+		}
+
+		nodeManager.log.Info(fmt.Sprintf("replayed snapshot height %d - %d, %d blocks", h, end, blockCount), "method", "Start")
+		fmt.Printf("Replayed snapshot height %d - %d, %d blocks\n", h, end, blockCount)
+	}
+
+	fmt.Println("Replay success.")
+	return nil
+}
+
+func (nodeManager *ReplayNodeManager) Stop() error {
+	StopNode(nodeManager.node)
+	return nil
+}
+
+func (nodeManager *ReplayNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
+
+// replaySnapshotChunk re-executes the contract receive blocks of the chunk on top of the state at
+// the previous snapshot block, and compares the results with the ledger. All state read by the vm,
+// include quota, stake amount, contract meta and confirmed times, is served at that height.
+func replaySnapshotChunk(c vm_db.HistoryChain, cs generator.Consensus, chunk *ledger.SnapshotChunk) (int, error) {
+	snapshotHeight := chunk.SnapshotBlock.Height
+	prevSb, err := c.GetSnapshotBlockByHeight(snapshotHeight - 1)
+	if err != nil {
+		return 0, err
+	}
+	if prevSb == nil {
+		return 0, errors.New(fmt.Sprintf("snapshot block %d is not exist", snapshotHeight-1))
+	}
+
+	snapshotChain, err := vm_db.NewSnapshotChain(c, prevSb.Height)
+	if err != nil {
+		return 0, err
+	}
+	addrMap := make(map[types.Address]struct{})
+	count := 0
+	for _, block := range chunk.AccountBlocks {
+		if !block.IsReceiveBlock() || !types.IsContractAddr(block.AccountAddress) {
+			continue
+		}
+		// the latest block of the contract is read at the previous snapshot height, then the applied one
+		addrMap[block.AccountAddress] = struct{}{}
+
+		result, err := generator.ReplayBlockOnSnapshot(snapshotChain, cs, prevSb, block, nil)
+		if err != nil {
+			return count, errors.New(fmt.Sprintf("block %s replay error: %s", block.Hash, err))
+		}
+		if result.VMBlock == nil {
+			return count, errors.New(fmt.Sprintf("block %s replay failed, isRetry is %v, err is %v", block.Hash, result.IsRetry, result.Err))
+		}
+		if err := compareReplayedBlock(block, result.VMBlock.AccountBlock); err != nil {
+			return count, errors.New(fmt.Sprintf("block %s is different: %s", block.Hash, err))
+		}
+		snapshotChain.Apply(result.VMBlock)
+		count++
+	}
+
+	for addr := range addrMap {
+		if err := snapshotChain.VerifyState(addr, snapshotHeight); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func compareReplayedBlock(block *ledger.AccountBlock, replayed *ledger.AccountBlock) error {
+	if block.Hash == replayed.Hash {
+		return nil
+	}
+
+	if (block.LogHash == nil) != (replayed.LogHash == nil) ||
+		(block.LogHash != nil && *block.LogHash != *replayed.LogHash) {
+		return errors.New(fmt.Sprintf("LogHash is %v, expected %v", replayed.LogHash, block.LogHash))
+	}
+	if len(block.SendBlockList) != len(replayed.SendBlockList) {
+		return errors.New(fmt.Sprintf("SendBlockList len is %d, expected %d", len(replayed.SendBlockList), len(block.SendBlockList)))
+	}
+	for i, sendBlock := range block.SendBlockList {
+		if sendBlock.Hash != replayed.SendBlockList[i].Hash {
+			return errors.New(fmt.Sprintf("SendBlockList[%d] hash is %s, expected %s", i, replayed.SendBlockList[i].Hash, sendBlock.Hash))
+		}
+	}
+	if !bytes.Equal(block.Data, replayed.Data) {
+		return errors.New(fmt.Sprintf("Data is %x, expected %x", replayed.Data, block.Data))
+	}
+	if block.BlockType != replayed.BlockType {
+		return errors.New(fmt.Sprintf("BlockType is %d, expected %d", replayed.BlockType, block.BlockType))
+	}
+	if block.Quota != replayed.Quota || block.QuotaUsed != replayed.QuotaUsed {
+		return errors.New(fmt.Sprintf("Quota is %d/%d, expected %d/%d", replayed.Quota, replayed.QuotaUsed, block.Quota, block.QuotaUsed))
+	}
+	return errors.New(fmt.Sprintf("Hash is %s, expected %s", replayed.Hash, block.Hash))
+}
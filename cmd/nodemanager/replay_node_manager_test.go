@@ -0,0 +1,228 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/header"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/quota"
+)
+
+const replayGenesisJson = `{
+  "GenesisAccountAddress": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+  "GovernanceInfo": {
+    "ConsensusGroupInfoMap": {
+      "00000000000000000001": {
+        "NodeCount": 1, "Interval": 1, "PerCount": 3, "RandCount": 2, "RandRank": 100, "Repeat": 1, "CheckLevel": 0,
+        "CountingTokenId": "tti_5649544520544f4b454e6e40", "RegisterConditionId": 1,
+        "RegisterConditionParam": {"StakeAmount": 100000000000000000000000, "StakeHeight": 1, "StakeToken": "tti_5649544520544f4b454e6e40"},
+        "VoteConditionId": 1, "VoteConditionParam": {},
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a", "StakeAmount": 0, "ExpirationHeight": 1
+      }
+    }
+  },
+  "AssetInfo": {
+    "TokenInfoMap": {
+      "tti_5649544520544f4b454e6e40": {
+        "TokenName": "Vite Token", "TokenSymbol": "VITE", "TotalSupply": 1000000000000000000000000000, "Decimals": 18,
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+        "MaxSupply": 115792089237316195423570985008687907853269984665640564039457584007913129639935,
+        "IsOwnerBurnOnly": false, "IsReIssuable": true
+      }
+    }
+  },
+  "QuotaInfo": {
+    "StakeInfoMap": {
+      "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a": [
+        {"Amount": 10000000000000000000000000, "ExpirationHeight": 259200, "Beneficiary": "%s"}
+      ]
+    },
+    "StakeBeneficialMap": {
+      "%s": 10000000000000000000000000
+    }
+  },
+  "AccountBalanceMap": {
+    "%s": {
+      "tti_5649544520544f4b454e6e40": 100000000000000000000000000
+    }
+  }
+}`
+
+// makeReplayChain generates a small chain through the vm, the quota contract receives
+// two stakes in different snapshot blocks:
+//
+//	snapshot 2: stake 1
+//	snapshot 3: quota contract receives stake 1
+//	snapshot 4: stake 2
+//	snapshot 5: quota contract receives stake 2
+func makeReplayChain(t *testing.T, dir string) (chain.Chain, *config.Genesis) {
+	fork.SetForkPoints(replayForkPoints())
+	quota.InitQuotaConfig(false, true)
+	vm.InitVMConfig(false, false, false, false, "")
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := types.PubkeyToAddress(pub)
+	signFunc := func(addr types.Address, data []byte) ([]byte, []byte, error) {
+		return ed25519.Sign(key, data), pub, nil
+	}
+
+	genesisConfig := &config.Genesis{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(replayGenesisJson, addr, addr, addr)), genesisConfig); err != nil {
+		t.Fatal(err)
+	}
+	c := chain.NewChain(dir, &config.Chain{}, genesisConfig)
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := func(addr types.Address, generate func(gen header.Generator) (*header.GenResult, error)) *ledger.AccountBlock {
+		latestSb := c.GetLatestSnapshotBlock()
+		var prevHash types.Hash
+		if prevBlock, err := c.GetLatestAccountBlock(addr); err != nil {
+			t.Fatal(err)
+		} else if prevBlock != nil {
+			prevHash = prevBlock.Hash
+		}
+		gen, err := generator.NewGenerator(c, &test_tools.MockConsensus{}, addr, &latestSb.Hash, &prevHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := generate(gen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.VMBlock == nil {
+			t.Fatalf("generate block failed: %v", result.Err)
+		}
+		if err := c.InsertAccountBlock(result.VMBlock); err != nil {
+			t.Fatal(err)
+		}
+		return result.VMBlock.AccountBlock
+	}
+	stake := func() *ledger.AccountBlock {
+		data, err := abi.ABIQuota.PackMethod(abi.MethodNameStake, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return insert(addr, func(gen header.Generator) (*header.GenResult, error) {
+			return gen.GenerateWithMessage(&header.IncomingMessage{
+				BlockType:      ledger.BlockTypeSendCall,
+				AccountAddress: addr,
+				ToAddress:      &types.AddressQuota,
+				TokenId:        &ledger.ViteTokenId,
+				Amount:         new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)),
+				Data:           data,
+			}, &addr, signFunc)
+		})
+	}
+	receive := func(sendBlock *ledger.AccountBlock) *ledger.AccountBlock {
+		return insert(types.AddressQuota, func(gen header.Generator) (*header.GenResult, error) {
+			return gen.GenerateWithOnRoad(sendBlock, &addr, signFunc, nil)
+		})
+	}
+	snapshot := func() {
+		latestSb := c.GetLatestSnapshotBlock()
+		now := latestSb.Timestamp.Add(time.Second)
+		sb := &ledger.SnapshotBlock{
+			PrevHash:        latestSb.Hash,
+			Height:          latestSb.Height + 1,
+			Timestamp:       &now,
+			SnapshotContent: c.GetContentNeedSnapshot(),
+		}
+		sb.Hash = sb.ComputeHash()
+		if _, err := c.InsertSnapshotBlock(sb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send1 := stake()
+	snapshot()
+	receive(send1)
+	snapshot()
+	send2 := stake()
+	snapshot()
+	receive(send2)
+	snapshot()
+
+	return c, genesisConfig
+}
+
+// no fork is active in the chain
+func replayForkPoints() *config.ForkPoints {
+	point := &config.ForkPoint{Height: 10000000, Version: 1}
+	return &config.ForkPoints{
+		SeedFork: point, DexFork: point, DexFeeFork: point, StemFork: point,
+		LeafFork: point, EarthFork: point, DexMiningFork: point, DexRobotFork: point, MultisigFork: point,
+	}
+}
+
+func getReplayChunk(t *testing.T, c chain.Chain, snapshotHeight uint64) *ledger.SnapshotChunk {
+	chunks, err := c.GetSubLedger(snapshotHeight-1, snapshotHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil && chunk.SnapshotBlock.Height == snapshotHeight {
+			return chunk
+		}
+	}
+	t.Fatalf("chunk of snapshot block %d is not found", snapshotHeight)
+	return nil
+}
+
+func TestReplaySnapshotChunk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, _ := makeReplayChain(t, dir)
+	defer c.Stop()
+
+	// only the contract receives are replayed, the second one reads the stake of the first one at height 4
+	for h := uint64(2); h <= 5; h++ {
+		count, err := replaySnapshotChunk(c, &test_tools.MockConsensus{}, getReplayChunk(t, c, h))
+		if err != nil {
+			t.Fatalf("replay snapshot height %d failed: %v", h, err)
+		}
+		if expected := int(h % 2); count != expected {
+			t.Fatalf("should replay %d blocks at snapshot height %d, but get %d", expected, h, count)
+		}
+	}
+
+	// a tampered block is reported
+	chunk := getReplayChunk(t, c, 5)
+	for i, block := range chunk.AccountBlocks {
+		if block.AccountAddress == types.AddressQuota {
+			tampered := block.Copy()
+			tampered.Hash = types.Hash{1}
+			tampered.Data = append(tampered.Data, 1)
+			chunk.AccountBlocks[i] = tampered
+		}
+	}
+	if _, err := replaySnapshotChunk(c, &test_tools.MockConsensus{}, chunk); err == nil || !strings.Contains(err.Error(), "is different") {
+		t.Fatalf("the tampered block should be different: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package nodemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/wallet/signer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// SignerNodeManager runs the reference signer with the entropy store of the node config, the node itself
+// is not started.
+type SignerNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+func NewSignerNodeManager(ctx *cli.Context, maker NodeMaker) (*SignerNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignerNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *SignerNodeManager) Start() error {
+	cfg := nodeManager.node.Config()
+	if cfg.RemoteSigner == "" {
+		return errors.New("the unix socket of the signer is not set")
+	}
+	if cfg.SignerPolicyFile == "" {
+		return errors.New("the policy file of the signer is not set")
+	}
+	if cfg.EntropyStorePath == "" {
+		return errors.New("the entropy store is not set")
+	}
+
+	policyCfg, err := signer.LoadPolicyConfig(cfg.SignerPolicyFile)
+	if err != nil {
+		return err
+	}
+	policy, err := signer.NewPolicy(policyCfg)
+	if err != nil {
+		return err
+	}
+
+	walletManager := nodeManager.node.WalletManager()
+	if err := walletManager.Start(); err != nil {
+		return err
+	}
+	defer walletManager.Stop()
+	if err := walletManager.AddEntropyStore(cfg.EntropyStorePath); err != nil {
+		return errors.New(fmt.Sprintf("add entropy store error, %v", err))
+	}
+	entropyStoreManager, err := walletManager.GetEntropyStoreManager(cfg.EntropyStorePath)
+	if err != nil {
+		return err
+	}
+	if err := entropyStoreManager.Unlock(cfg.EntropyStorePassword); err != nil {
+		return errors.New(fmt.Sprintf("unlock entropy store error, %v", err))
+	}
+
+	s := signer.NewSigner(entropyStoreManager, policy)
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(c)
+		<-c
+		fmt.Println("Preparing signer shutdown...")
+		s.Close()
+	}()
+
+	fmt.Printf("Signer is listening on %s\n", cfg.RemoteSigner)
+	return s.ListenAndServe(cfg.RemoteSigner)
+}
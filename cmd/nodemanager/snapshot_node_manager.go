@@ -0,0 +1,377 @@
+package nodemanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/archive"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	ledgerDirName    = "ledger"
+	syncCacheDirName = "sync_cache"
+)
+
+type SnapshotExportNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+func NewSnapshotExportNodeManager(ctx *cli.Context, maker NodeMaker) (*SnapshotExportNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapshotExportNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *SnapshotExportNodeManager) Start() error {
+	viteConfig := nodeManager.node.ViteConfig()
+	ledgerDir := path.Join(viteConfig.DataDir, ledgerDirName)
+	if _, err := os.Stat(ledgerDir); err != nil {
+		return errors.New(fmt.Sprintf("ledger is not exist, error is %s", err))
+	}
+
+	// the ledger is copied and rolled back, the data directory is not changed
+	tmpDataDir := path.Join(viteConfig.DataDir, fmt.Sprintf("snapshot_export_%d", time.Now().Unix()))
+	defer os.RemoveAll(tmpDataDir)
+
+	fmt.Printf("Copying ledger to %s\n", tmpDataDir)
+	if err := chain_archive.CopyDir(ledgerDir, path.Join(tmpDataDir, ledgerDirName), syncCacheDirName); err != nil {
+		return err
+	}
+
+	c, err := openChain(viteConfig, tmpDataDir)
+	if err != nil {
+		return err
+	}
+	manifest, err := nodeManager.rollback(c)
+	if stopErr := closeChain(c); err == nil {
+		err = stopErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(path.Join(tmpDataDir, ledgerDirName, syncCacheDirName)); err != nil {
+		return err
+	}
+
+	archivePath := nodeManager.ctx.GlobalString(utils.SnapshotArchiveFlag.Name)
+	if archivePath == "" {
+		archivePath = fmt.Sprintf("ledger_%d.tar.gz", manifest.SnapshotHeight)
+	}
+	fmt.Printf("Writing snapshot block %d %s to %s\n", manifest.SnapshotHeight, manifest.SnapshotHash, archivePath)
+
+	checksum, err := writeArchive(archivePath, path.Join(tmpDataDir, ledgerDirName), manifest)
+	if err != nil {
+		return err
+	}
+	checksumLine := fmt.Sprintf("%s  %s\n", checksum, filepath.Base(archivePath))
+	if err := ioutil.WriteFile(archivePath+".sha256", []byte(checksumLine), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Export success.\nSnapshot block height: %d\nSnapshot block hash: %s\nArchive sha256: %s\n",
+		manifest.SnapshotHeight, manifest.SnapshotHash, checksum)
+	return nil
+}
+
+// rollback deletes the snapshot blocks after the export height, and returns the manifest of the ledger
+func (nodeManager *SnapshotExportNodeManager) rollback(c chain.Chain) (*chain_archive.Manifest, error) {
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	height := latestHeight
+	if nodeManager.ctx.GlobalIsSet(utils.SnapshotHeightFlag.Name) {
+		height = nodeManager.ctx.GlobalUint64(utils.SnapshotHeightFlag.Name)
+	}
+	if height < 1 || height > latestHeight {
+		return nil, errors.New(fmt.Sprintf("snapshot height is %d, latest snapshot height is %d", height, latestHeight))
+	}
+
+	if height < latestHeight {
+		fmt.Printf("Rolling back from snapshot height %d to %d\n", latestHeight, height)
+		if _, err := c.DeleteSnapshotBlocksToHeight(height + 1); err != nil {
+			return nil, err
+		}
+	}
+
+	sb := c.GetLatestSnapshotBlock()
+	if sb.Height != height {
+		return nil, errors.New(fmt.Sprintf("latest snapshot height is %d after rollback, expected %d", sb.Height, height))
+	}
+	return &chain_archive.Manifest{
+		SnapshotHeight: sb.Height,
+		SnapshotHash:   sb.Hash,
+		GenesisHash:    c.GetGenesisSnapshotBlock().Hash,
+		CreateTime:     time.Now().Unix(),
+	}, nil
+}
+
+func (nodeManager *SnapshotExportNodeManager) Stop() error {
+	return nil
+}
+
+func (nodeManager *SnapshotExportNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
+
+type SnapshotImportNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+func NewSnapshotImportNodeManager(ctx *cli.Context, maker NodeMaker) (*SnapshotImportNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapshotImportNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *SnapshotImportNodeManager) Start() error {
+	archivePath := nodeManager.ctx.GlobalString(utils.SnapshotArchiveFlag.Name)
+	if archivePath == "" {
+		return errors.New("archive is not set")
+	}
+	trustedHash, err := types.HexToHash(nodeManager.ctx.GlobalString(utils.SnapshotHashFlag.Name))
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid trusted snapshot hash, error is %s", err))
+	}
+
+	viteConfig := nodeManager.node.ViteConfig()
+	ledgerDir := path.Join(viteConfig.DataDir, ledgerDirName)
+	if files, err := ioutil.ReadDir(ledgerDir); err == nil && len(files) > 0 {
+		return errors.New(fmt.Sprintf("ledger %s is not empty, remove it before importing", ledgerDir))
+	}
+
+	tmpLedgerDir := path.Join(viteConfig.DataDir, fmt.Sprintf("snapshot_import_%d", time.Now().Unix()))
+	defer os.RemoveAll(tmpLedgerDir)
+
+	fmt.Printf("Unpacking %s to %s\n", archivePath, tmpLedgerDir)
+	manifest, err := readArchive(archivePath, tmpLedgerDir)
+	if err != nil {
+		return err
+	}
+	if manifest.SnapshotHash != trustedHash {
+		return errors.New(fmt.Sprintf("snapshot block hash of archive is %s, expected %s", manifest.SnapshotHash, trustedHash))
+	}
+
+	if err := os.RemoveAll(ledgerDir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpLedgerDir, ledgerDir); err != nil {
+		return err
+	}
+
+	if err := verifyImportedLedger(viteConfig, manifest, trustedHash); err != nil {
+		os.RemoveAll(ledgerDir)
+		return err
+	}
+
+	fmt.Printf("Import success.\nSnapshot block height: %d\nSnapshot block hash: %s\n", manifest.SnapshotHeight, manifest.SnapshotHash)
+	return nil
+}
+
+func (nodeManager *SnapshotImportNodeManager) Stop() error {
+	return nil
+}
+
+func (nodeManager *SnapshotImportNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
+
+// verifyImportedLedger opens the imported ledger, and checks that the latest snapshot block is the one in manifest
+// and the ledger is committed to by the trusted hash
+func verifyImportedLedger(viteConfig *config.Config, manifest *chain_archive.Manifest, trustedHash types.Hash) error {
+	c, err := openChain(viteConfig, viteConfig.DataDir)
+	if err != nil {
+		return err
+	}
+
+	sb := c.GetLatestSnapshotBlock()
+	if sb.Height != manifest.SnapshotHeight || sb.Hash != manifest.SnapshotHash {
+		err = errors.New(fmt.Sprintf("latest snapshot block of ledger is %d %s, expected %d %s",
+			sb.Height, sb.Hash, manifest.SnapshotHeight, manifest.SnapshotHash))
+	} else if genesis := c.GetGenesisSnapshotBlock(); genesis.Hash != manifest.GenesisHash {
+		err = errors.New(fmt.Sprintf("genesis snapshot block of ledger is %s, expected %s", genesis.Hash, manifest.GenesisHash))
+	} else {
+		fmt.Printf("Verifying %d snapshot blocks\n", sb.Height)
+		err = verifyLedger(c, trustedHash)
+	}
+
+	if stopErr := closeChain(c); err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+type ledgerChain interface {
+	GetGenesisSnapshotBlock() *ledger.SnapshotBlock
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error)
+}
+
+// verifyLedger recomputes the hash of every snapshot block from the genesis one to the latest one,
+// which must be the trusted hash, and the hash of every account block committed to by the snapshot content.
+// The blocks are linked by PrevHash, so the ledger can't be changed without changing the trusted hash.
+// The state is not committed to by the hashes, it's trusted as the archive checksums.
+func verifyLedger(c ledgerChain, trustedHash types.Hash) error {
+	latestSb := c.GetLatestSnapshotBlock()
+	if latestSb == nil {
+		return errors.New("latest snapshot block is not exist")
+	}
+
+	var prevHash types.Hash
+	// the latest account block committed to of every account
+	committed := make(map[types.Address]*ledger.HashHeight)
+	for h := uint64(1); h <= latestSb.Height; h++ {
+		sb, err := c.GetSnapshotBlockByHeight(h)
+		if err != nil {
+			return err
+		}
+		if sb == nil {
+			return errors.New(fmt.Sprintf("snapshot block %d is not exist", h))
+		}
+		if hash := sb.ComputeHash(); hash != sb.Hash {
+			return errors.New(fmt.Sprintf("hash of snapshot block %d is %s, computed %s", h, sb.Hash, hash))
+		}
+		if h == 1 {
+			if genesis := c.GetGenesisSnapshotBlock(); sb.Hash != genesis.Hash {
+				return errors.New(fmt.Sprintf("genesis snapshot block is %s, expected %s", sb.Hash, genesis.Hash))
+			}
+		} else if sb.PrevHash != prevHash {
+			return errors.New(fmt.Sprintf("prev hash of snapshot block %d is %s, expected %s", h, sb.PrevHash, prevHash))
+		}
+
+		for addr, hashHeight := range sb.SnapshotContent {
+			if err := verifyAccountBlocks(c, addr, committed[addr], hashHeight); err != nil {
+				return errors.New(fmt.Sprintf("snapshot block %d: %s", h, err))
+			}
+			committed[addr] = hashHeight
+		}
+		prevHash = sb.Hash
+	}
+
+	if prevHash != trustedHash || latestSb.Hash != trustedHash {
+		return errors.New(fmt.Sprintf("latest snapshot block is %s, expected the trusted hash %s", prevHash, trustedHash))
+	}
+	return nil
+}
+
+// verifyAccountBlocks checks the account blocks after the committed one up to hashHeight
+func verifyAccountBlocks(c ledgerChain, addr types.Address, committed *ledger.HashHeight, hashHeight *ledger.HashHeight) error {
+	var prevHash types.Hash
+	startHeight := uint64(1)
+	if committed != nil {
+		prevHash = committed.Hash
+		startHeight = committed.Height + 1
+	}
+	if hashHeight.Height < startHeight {
+		return errors.New(fmt.Sprintf("account %s is committed to height %d, lower than %d", addr, hashHeight.Height, startHeight-1))
+	}
+
+	for height := startHeight; height <= hashHeight.Height; height++ {
+		block, err := c.GetAccountBlockByHeight(addr, height)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return errors.New(fmt.Sprintf("account block %s %d is not exist", addr, height))
+		}
+		if hash := block.ComputeHash(); hash != block.Hash {
+			return errors.New(fmt.Sprintf("hash of account block %s %d is %s, computed %s", addr, height, block.Hash, hash))
+		}
+		for _, sendBlock := range block.SendBlockList {
+			if hash := sendBlock.ComputeHash(); hash != sendBlock.Hash {
+				return errors.New(fmt.Sprintf("hash of send block %s in %s is computed %s", sendBlock.Hash, block.Hash, hash))
+			}
+		}
+		if block.PrevHash != prevHash {
+			return errors.New(fmt.Sprintf("prev hash of account block %s %d is %s, expected %s", addr, height, block.PrevHash, prevHash))
+		}
+		prevHash = block.Hash
+	}
+
+	if prevHash != hashHeight.Hash {
+		return errors.New(fmt.Sprintf("account block %s %d is %s, expected %s", addr, hashHeight.Height, prevHash, hashHeight.Hash))
+	}
+	return nil
+}
+
+func openChain(viteConfig *config.Config, dataDir string) (chain.Chain, error) {
+	// set fork points
+	fork.SetForkPoints(viteConfig.ForkPoints)
+
+	c := chain.NewChain(dataDir, viteConfig.Chain, viteConfig.Genesis)
+	if err := c.Init(); err != nil {
+		return nil, err
+	}
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func closeChain(c chain.Chain) error {
+	if err := c.Stop(); err != nil {
+		return err
+	}
+	return c.Destroy()
+}
+
+// writeArchive packs the ledger to archivePath, and returns the sha256 of the archive
+func writeArchive(archivePath string, ledgerDir string, manifest *chain_archive.Manifest) (string, error) {
+	tmpPath := archivePath + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpPath)
+
+	h := sha256.New()
+	if err := chain_archive.Pack(io.MultiWriter(f, h), ledgerDir, manifest); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readArchive(archivePath string, ledgerDir string) (*chain_archive.Manifest, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return chain_archive.Unpack(f, ledgerDir)
+}
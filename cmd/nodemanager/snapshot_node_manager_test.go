@@ -0,0 +1,100 @@
+package nodemanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/archive"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// exportTestLedger generates a ledger, forge is called to change the ledger before exported,
+// and returns the archive, the trusted hash and the config to import it
+func exportTestLedger(t *testing.T, dir string, forge func(c ledgerChainWriter) types.Hash) (string, types.Hash, *config.Config) {
+	c, genesisConfig := makeReplayChain(t, path.Join(dir, "src"))
+	trustedHash := c.GetLatestSnapshotBlock().Hash
+	if forge != nil {
+		trustedHash = forge(c)
+	}
+	sb := c.GetLatestSnapshotBlock()
+	manifest := &chain_archive.Manifest{
+		SnapshotHeight: sb.Height,
+		SnapshotHash:   sb.Hash,
+		GenesisHash:    c.GetGenesisSnapshotBlock().Hash,
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	genesisConfig.ForkPoints = replayForkPoints()
+
+	archivePath := path.Join(dir, "ledger.tar.gz")
+	if _, err := writeArchive(archivePath, path.Join(dir, "src", ledgerDirName), manifest); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath, trustedHash, &config.Config{
+		DataDir: path.Join(dir, "dst"),
+		Chain:   &config.Chain{},
+		Genesis: genesisConfig,
+	}
+}
+
+type ledgerChainWriter interface {
+	ledgerChain
+	InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) ([]*ledger.AccountBlock, error)
+}
+
+func importTestLedger(t *testing.T, archivePath string, trustedHash types.Hash, viteConfig *config.Config) error {
+	if err := os.RemoveAll(viteConfig.DataDir); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := readArchive(archivePath, path.Join(viteConfig.DataDir, ledgerDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifyImportedLedger(viteConfig, manifest, trustedHash)
+}
+
+func TestVerifyImportedLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot_import_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archivePath, trustedHash, viteConfig := exportTestLedger(t, path.Join(dir, "honest"), nil)
+	if err := importTestLedger(t, archivePath, trustedHash, viteConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	// the hash is not the trusted one
+	if err := importTestLedger(t, archivePath, types.DataHash([]byte("other")), viteConfig); err == nil {
+		t.Fatal("the ledger of another hash should be rejected")
+	}
+
+	// the stored hash of the head is the trusted one, the content is not
+	archivePath, trustedHash, viteConfig = exportTestLedger(t, path.Join(dir, "forged"), func(c ledgerChainWriter) types.Hash {
+		latestSb := c.GetLatestSnapshotBlock()
+		now := latestSb.Timestamp.Add(time.Second)
+		forged := &ledger.SnapshotBlock{
+			PrevHash:        latestSb.Hash,
+			Height:          latestSb.Height + 1,
+			Timestamp:       &now,
+			SnapshotContent: ledger.SnapshotContent{},
+		}
+		forged.Hash = types.DataHash([]byte("trusted"))
+		if _, err := c.InsertSnapshotBlock(forged); err != nil {
+			t.Fatal(err)
+		}
+		return forged.Hash
+	})
+	if err := importTestLedger(t, archivePath, trustedHash, viteConfig); err == nil || !strings.Contains(err.Error(), "computed") {
+		t.Fatalf("the forged ledger should be rejected: %v", err)
+	}
+}
@@ -111,6 +111,20 @@ var (
 		Usage: "WS-RPC server listening port",
 	}
 
+	//GraphQL Settings
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphqladdr",
+		Usage: "GraphQL server listening interface",
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphqlport",
+		Usage: "GraphQL server listening port",
+	}
+
 	//Console Settings
 	JSPathFlag = cli.StringFlag{
 		Name:  "jspath",
@@ -142,6 +156,16 @@ var (
 		Usage: "Miner Interval(unit: second)",
 	}
 
+	RemoteSignerFlag = cli.StringFlag{
+		Name:  "remotesigner",
+		Usage: "The unix socket of the remote signer, the producer signs the blocks through it",
+	}
+
+	SignerPolicyFlag = cli.StringFlag{
+		Name:  "signerpolicy",
+		Usage: "The policy file of the signer",
+	}
+
 	//Log Lvl
 	LogLvlFlag = cli.StringFlag{
 		Name:  "loglevel",
@@ -190,6 +214,97 @@ var (
 		Usage: "The snapshot block height",
 	}
 
+	// Replay snapshot height range
+	ReplayFromHeightFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The first snapshot block height to replay",
+	}
+	ReplayToHeightFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The last snapshot block height to replay, defaults to the latest",
+	}
+
+	// Snapshot archive
+	SnapshotHeightFlag = cli.Uint64Flag{
+		Name:  "height",
+		Usage: "The snapshot block height to export, defaults to the latest",
+	}
+	SnapshotArchiveFlag = cli.StringFlag{
+		Name:  "archive",
+		Usage: "The path of the snapshot archive",
+	}
+	SnapshotHashFlag = cli.StringFlag{
+		Name:  "hash",
+		Usage: "The trusted snapshot block hash of the archive",
+	}
+
+	// DNS node list
+	DNSNodesFlag = cli.StringFlag{
+		Name:  "dnsnodes",
+		Usage: "The JSON file of the crawled nodes",
+	}
+	DNSCrawlTimeFlag = cli.DurationFlag{
+		Name:  "dnscrawltime",
+		Usage: "How long to crawl the network",
+		Value: 10 * time.Minute,
+	}
+	DNSDomainFlag = cli.StringFlag{
+		Name:  "dnsdomain",
+		Usage: "The domain the node list is published to",
+	}
+	DNSKeyFlag = cli.StringFlag{
+		Name:  "dnskey",
+		Usage: "The file of the hex private key to sign the node list, a new key is generated if the file does not exist",
+	}
+	DNSSeqFlag = cli.UintFlag{
+		Name:  "dnsseq",
+		Usage: "The sequence number of the node list, defaults to the current unix time",
+	}
+	DNSLinksFlag = cli.StringFlag{
+		Name:  "dnslinks",
+		Usage: "The comma separated URLs of other node lists linked by the node list",
+	}
+	DNSTreeFlag = cli.StringFlag{
+		Name:  "dnstree",
+		Usage: "The JSON file of the TXT records of the signed node list",
+	}
+
+	// Offline transaction
+	TxEndpointFlag = cli.StringFlag{
+		Name:  "endpoint",
+		Usage: "The http rpc endpoint of an online node",
+		Value: "http://127.0.0.1:48132",
+	}
+	TxAddressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "The address sending the transaction",
+	}
+	TxToAddressFlag = cli.StringFlag{
+		Name:  "toaddress",
+		Usage: "The address receiving the transaction",
+	}
+	TxAmountFlag = cli.StringFlag{
+		Name:  "amount",
+		Usage: "The amount in the smallest unit of the token",
+		Value: "0",
+	}
+	TxTokenIdFlag = cli.StringFlag{
+		Name:  "tokenid",
+		Usage: "The token id, defaults to VITE",
+	}
+	TxDataFlag = cli.StringFlag{
+		Name:  "data",
+		Usage: "The data of the transaction in hex",
+	}
+	TxEntropyStoreFlag = cli.StringFlag{
+		Name:  "entropystore",
+		Usage: "The entropy store file of the offline wallet",
+	}
+	TxYesFlag = cli.BoolFlag{
+		Name:  "yes",
+		Usage: "Sign the transaction without the confirmation",
+	}
+
 	//Net
 	SingleFlag = cli.BoolFlag{
 		Name:  "single",
@@ -201,6 +316,11 @@ var (
 		Usage: "File transfer listening port",
 	}
 
+	NATFlag = cli.StringFlag{
+		Name:  "nat",
+		Usage: "NAT port mapping mechanism (none|any|upnp|pmp|pmp:<IP>)",
+	}
+
 	//Stat
 	PProfEnabledFlag = cli.BoolFlag{
 		Name:  "pprof",
@@ -44,6 +44,10 @@ func SetForkPoints(points *config.ForkPoints) {
 
 		for k := 0; k < t.NumField(); k++ {
 			forkPoint := v.Field(k).Interface().(*config.ForkPoint)
+			// the optional fork is not scheduled
+			if forkPoint == nil {
+				continue
+			}
 
 			forkName := t.Field(k).Name
 			forkPointItem := &ForkPointItem{
@@ -65,6 +69,11 @@ func SetActiveChecker(ac ActiveChecker) {
 	activeChecker = ac
 }
 
+// optionalForkPoints are the forks not scheduled on mainnet, they are disabled if not set
+var optionalForkPoints = map[string]bool{
+	"MultisigFork": true,
+}
+
 func CheckForkPoints(points config.ForkPoints) error {
 	t := reflect.TypeOf(points)
 	v := reflect.ValueOf(points)
@@ -72,6 +81,9 @@ func CheckForkPoints(points config.ForkPoints) error {
 	for k := 0; k < t.NumField(); k++ {
 		forkPoint := v.Field(k).Interface().(*config.ForkPoint)
 
+		if forkPoint == nil && optionalForkPoints[t.Field(k).Name] {
+			continue
+		}
 		if forkPoint == nil {
 			return errors.New(fmt.Sprintf("The fork point %s can't be nil. the `ForkPoints` config in genesis.json is not correct, "+
 				"you can remove the `ForkPoints` key in genesis.json then use the default config of `ForkPoints`", t.Field(k).Name))
@@ -189,6 +201,21 @@ func IsDexRobotFork(snapshotHeight uint64) bool {
 	return snapshotHeight >= dexRobotForkPoint.Height && IsForkActive(*dexRobotForkPoint)
 }
 
+/*
+IsMultisigFork checks whether current snapshot block height is over multisig hard fork.
+The multisig hard fork is not scheduled on mainnet yet, it's disabled if MultisigFork is not set.
+Features:
+  1. Built-in multisig contract. M-of-N owners propose, approve and execute
+     transfers of a shared balance.
+*/
+func IsMultisigFork(snapshotHeight uint64) bool {
+	multisigForkPoint, ok := forkPointMap["MultisigFork"]
+	if !ok {
+		return false
+	}
+	return snapshotHeight >= multisigForkPoint.Height && IsForkActive(*multisigForkPoint)
+}
+
 func GetLeafForkPoint() *ForkPointItem {
 	leafForkPoint, ok := forkPointMap["LeafFork"]
 	if !ok {
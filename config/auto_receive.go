@@ -0,0 +1,26 @@
+package config
+
+type AutoReceive struct {
+	Enabled bool `json:"Enabled"`
+
+	// EntropyStore is the entropy store which the addresses are derived from, it should be unlocked when the node starts
+	EntropyStore string `json:"EntropyStore"`
+
+	// IndexRanges are the derivation index ranges of the addresses, e.g. "0-999" or "1500"
+	IndexRanges []string `json:"IndexRanges"`
+
+	// Addresses are the addresses kept by any unlocked keystore of the wallet
+	Addresses []string `json:"Addresses"`
+
+	// MinAmount is the min amount of a send block to be received, the sends of less amount are left onroad
+	MinAmount string `json:"MinAmount"`
+
+	// TokenWhitelist are the token ids to be received, all tokens are received if it is empty
+	TokenWhitelist []string `json:"TokenWhitelist"`
+
+	// Concurrency is the max count of addresses receiving at the same time
+	Concurrency int `json:"Concurrency"`
+
+	// PoW enables to calculate the PoW for a receive block when the stake quota of the address is not enough
+	PoW bool `json:"PoW"`
+}
@@ -10,13 +10,15 @@ import (
 )
 
 type Config struct {
-	*Producer   `json:"Producer"`
-	*Chain      `json:"Chain"`
-	*Vm         `json:"Vm"`
-	*Subscribe  `json:"Subscribe"`
-	*Net        `json:"Net"`
-	*biz.Reward `json:"Reward"`
-	*Genesis    `json:"Genesis"`
+	*Producer    `json:"Producer"`
+	*Chain       `json:"Chain"`
+	*Vm          `json:"Vm"`
+	*Subscribe   `json:"Subscribe"`
+	*EventSink   `json:"EventSink"`
+	*AutoReceive `json:"AutoReceive"`
+	*Net         `json:"Net"`
+	*biz.Reward  `json:"Reward"`
+	*Genesis     `json:"Genesis"`
 
 	// global keys
 	DataDir string `json:"DataDir"`
@@ -0,0 +1,14 @@
+package config
+
+type EventSink struct {
+	Enabled bool `json:"Enabled"`
+
+	// FilePath is the path of the append-only journal, the journal is disabled if it is empty
+	FilePath string `json:"FilePath"`
+
+	// WebhookURL is the url which the events are posted to, the webhook is disabled if it is empty
+	WebhookURL string `json:"WebhookURL"`
+
+	// BatchSize is the max count of events delivered at once
+	BatchSize int `json:"BatchSize"`
+}
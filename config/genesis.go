@@ -88,6 +88,7 @@ type ForkPoints struct {
 	EarthFork     *ForkPoint
 	DexMiningFork *ForkPoint
 	DexRobotFork  *ForkPoint
+	MultisigFork  *ForkPoint // not scheduled on mainnet, the multisig contract is disabled if not set
 }
 
 type GenesisVmLog struct {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 )
 
@@ -53,6 +54,10 @@ type Net struct {
 
 	FilePublicAddress string
 
+	// NAT is the mechanism to map Port and FilePort on the NAT gateway: "none", "any", "upnp", "pmp" or "pmp:<gateway IP>",
+	// the external addresses will be advertised if PublicAddress or FilePublicAddress is not set
+	NAT string
+
 	// DataDir is the directory to storing p2p data, if is null-string, will use memory as database
 	DataDir string
 
@@ -69,6 +74,10 @@ type Net struct {
 	// BootSeeds are the address where can query BootNodes, is a more flexible option than BootNodes
 	BootSeeds []string
 
+	// BootTrees are the URLs of node lists published to DNS, like enrtree://<public key>@nodes.example.org,
+	// the lists are verified by the public key, then nodes in lists are used as BootNodes
+	BootTrees []string
+
 	// StaticNodes will be connect directly
 	StaticNodes []string
 
@@ -93,7 +102,11 @@ type Net struct {
 	BlackBlockHashList []string
 	WhiteBlockList     []string
 
-	MineKey ed25519.PrivateKey
+	// MineKey is the key of the producer coinbase if it can be exported from the keystore, otherwise
+	// MineSigner signs with it. MineAddress is the coinbase, it's nil if the node is not a producer.
+	MineKey     ed25519.PrivateKey
+	MineAddress *types.Address                                           `json:"-"`
+	MineSigner  func(data []byte) (signedData, pubkey []byte, err error) `json:"-"`
 }
 
 func getPeerKey(filename string) (privateKey ed25519.PrivateKey, err error) {
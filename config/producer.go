@@ -4,4 +4,7 @@ type Producer struct {
 	Producer         bool   `json:"Producer"`
 	Coinbase         string `json:"Coinbase"`
 	EntropyStorePath string `json:"EntropyStorePath"`
+
+	// RemoteSigner is the unix socket of the signer process, the blocks are signed by it instead of the wallet if it's set
+	RemoteSigner string `json:"RemoteSigner"`
 }
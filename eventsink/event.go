@@ -0,0 +1,114 @@
+package eventsink
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type EventType string
+
+const (
+	InsertAccountBlock  EventType = "InsertAccountBlock"
+	InsertSnapshotBlock EventType = "InsertSnapshotBlock"
+
+	// the revert events are emitted when the blocks are deleted by a rollback, the blocks are
+	// reverted from the latest to the earliest
+	RevertAccountBlock  EventType = "RevertAccountBlock"
+	RevertSnapshotBlock EventType = "RevertSnapshotBlock"
+)
+
+// Event is an entry of the event feed, the offset increases by 1 for each event. The events are
+// delivered at least once in the order of offset, so a consumer can skip the offsets it has seen.
+type Event struct {
+	Offset uint64    `json:"offset"`
+	Type   EventType `json:"type"`
+
+	AccountBlock  *AccountBlock  `json:"accountBlock,omitempty"`
+	SnapshotBlock *SnapshotBlock `json:"snapshotBlock,omitempty"`
+}
+
+type AccountBlock struct {
+	BlockType     byte              `json:"blockType"`
+	Hash          types.Hash        `json:"hash"`
+	PrevHash      types.Hash        `json:"prevHash"`
+	Height        uint64            `json:"height"`
+	Address       types.Address     `json:"address"`
+	ToAddress     types.Address     `json:"toAddress"`
+	FromBlockHash types.Hash        `json:"fromBlockHash"`
+	TokenId       types.TokenTypeId `json:"tokenId"`
+	Amount        string            `json:"amount"`
+	Fee           string            `json:"fee"`
+	Data          []byte            `json:"data"`
+	LogHash       *types.Hash       `json:"logHash"`
+	Logs          []*ledger.VmLog   `json:"logs"`
+	SendBlockList []types.Hash      `json:"sendBlockList"`
+}
+
+type SnapshotBlock struct {
+	Hash      types.Hash    `json:"hash"`
+	PrevHash  types.Hash    `json:"prevHash"`
+	Height    uint64        `json:"height"`
+	Producer  types.Address `json:"producer"`
+	Timestamp int64         `json:"timestamp"`
+
+	// AccountBlocks are the account blocks confirmed by the snapshot block
+	AccountBlocks []*ConfirmedBlock `json:"accountBlocks"`
+}
+
+type ConfirmedBlock struct {
+	Address types.Address `json:"address"`
+	Hash    types.Hash    `json:"hash"`
+	Height  uint64        `json:"height"`
+}
+
+func bigIntToString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+func newAccountBlock(block *ledger.AccountBlock, logs ledger.VmLogList) *AccountBlock {
+	ab := &AccountBlock{
+		BlockType:     block.BlockType,
+		Hash:          block.Hash,
+		PrevHash:      block.PrevHash,
+		Height:        block.Height,
+		Address:       block.AccountAddress,
+		ToAddress:     block.ToAddress,
+		FromBlockHash: block.FromBlockHash,
+		TokenId:       block.TokenId,
+		Amount:        bigIntToString(block.Amount),
+		Fee:           bigIntToString(block.Fee),
+		Data:          block.Data,
+		LogHash:       block.LogHash,
+		Logs:          logs,
+	}
+	for _, sendBlock := range block.SendBlockList {
+		ab.SendBlockList = append(ab.SendBlockList, sendBlock.Hash)
+	}
+	return ab
+}
+
+func newSnapshotBlock(chunk *ledger.SnapshotChunk) *SnapshotBlock {
+	sb := chunk.SnapshotBlock
+	result := &SnapshotBlock{
+		Hash:     sb.Hash,
+		PrevHash: sb.PrevHash,
+		Height:   sb.Height,
+		Producer: sb.Producer(),
+	}
+	if sb.Timestamp != nil {
+		result.Timestamp = sb.Timestamp.Unix()
+	}
+	for _, block := range chunk.AccountBlocks {
+		result.AccountBlocks = append(result.AccountBlocks, &ConfirmedBlock{
+			Address: block.AccountAddress,
+			Hash:    block.Hash,
+			Height:  block.Height,
+		})
+	}
+	return result
+}
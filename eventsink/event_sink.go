@@ -0,0 +1,320 @@
+package eventsink
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	defaultBatchSize = 100
+
+	retryMinInterval = time.Second
+	retryMaxInterval = time.Minute
+	pollInterval     = 5 * time.Second
+	pruneInterval    = time.Minute
+)
+
+// EventSink registers to the chain as an EventListener and streams the inserted and deleted blocks
+// to the targets. The events are persisted in the outbox before the chain callback returns, and each
+// target delivers them in order from its own cursor, so a target resumes from where it stopped.
+type EventSink struct {
+	chain     chain.Chain
+	outbox    *outbox
+	targets   []Target
+	batchSize int
+
+	// the revert events captured in the prepare callbacks, they are appended after the blocks are deleted
+	preDeleteEvents []*Event
+
+	notifyList []chan struct{}
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+
+	log log15.Logger
+}
+
+func NewEventSink(c chain.Chain, cfg *config.EventSink, dataDir string) (*EventSink, error) {
+	var targets []Target
+	if cfg.FilePath != "" {
+		target, err := NewFileTarget(cfg.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	if cfg.WebhookURL != "" {
+		targets = append(targets, NewWebhookTarget(cfg.WebhookURL))
+	}
+	if len(targets) <= 0 {
+		return nil, errors.New("event sink has no target, FilePath or WebhookURL should be set")
+	}
+
+	return newEventSink(c, targets, cfg.BatchSize, filepath.Join(dataDir, "eventsink"))
+}
+
+func newEventSink(c chain.Chain, targets []Target, batchSize int, outboxDir string) (*EventSink, error) {
+	o, err := newOutbox(outboxDir)
+	if err != nil {
+		for _, target := range targets {
+			target.Close()
+		}
+		return nil, err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &EventSink{
+		chain:     c,
+		outbox:    o,
+		targets:   targets,
+		batchSize: batchSize,
+		log:       log15.New("module", "eventsink"),
+	}, nil
+}
+
+func (s *EventSink) Start() {
+	s.stopCh = make(chan struct{})
+	s.notifyList = make([]chan struct{}, len(s.targets))
+	for i, target := range s.targets {
+		s.notifyList[i] = make(chan struct{}, 1)
+
+		s.wg.Add(1)
+		go s.dispatch(target, s.notifyList[i])
+	}
+
+	s.wg.Add(1)
+	go s.pruneLoop()
+
+	s.chain.Register(s)
+}
+
+func (s *EventSink) Stop() {
+	// stop the retrying emit first, it holds the listener lock of the chain
+	close(s.stopCh)
+	s.chain.UnRegister(s)
+	s.wg.Wait()
+
+	for _, target := range s.targets {
+		if err := target.Close(); err != nil {
+			s.log.Error("close target failed, error is "+err.Error(), "method", "Stop", "target", target.Name())
+		}
+	}
+	if err := s.outbox.close(); err != nil {
+		s.log.Error("close outbox failed, error is "+err.Error(), "method", "Stop")
+	}
+}
+
+// emit appends the events to the outbox. The chain doesn't handle the errors of the callbacks after
+// the blocks are written, so the append is retried with backoff and the chain is blocked until the events
+// are persisted, or the sink is stopped, then the error is returned and the events are lost.
+func (s *EventSink) emit(events []*Event, method string) error {
+	if len(events) <= 0 {
+		return nil
+	}
+
+	retryInterval := retryMinInterval
+	for {
+		err := s.outbox.append(events)
+		if err == nil {
+			break
+		}
+		s.log.Error("append events failed, error is "+err.Error(), "method", method)
+
+		select {
+		case <-s.stopCh:
+			return err
+		case <-time.After(retryInterval):
+		}
+		if retryInterval *= 2; retryInterval > retryMaxInterval {
+			retryInterval = retryMaxInterval
+		}
+	}
+
+	for _, notify := range s.notifyList {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// dispatch delivers the events to the target in batches, the cursor is moved only after
+// the target accepts a batch, the batch is retried with backoff if the target fails
+func (s *EventSink) dispatch(target Target, notify chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	retryInterval := retryMinInterval
+	for {
+		wait, err := s.deliver(target)
+		if err != nil {
+			s.log.Error("deliver events failed, error is "+err.Error(), "method", "dispatch", "target", target.Name())
+
+			select {
+			case <-s.stopCh:
+				return
+			case <-time.After(retryInterval):
+			}
+			if retryInterval *= 2; retryInterval > retryMaxInterval {
+				retryInterval = retryMaxInterval
+			}
+			continue
+		}
+		retryInterval = retryMinInterval
+
+		if !wait {
+			select {
+			case <-s.stopCh:
+				return
+			default:
+			}
+			continue
+		}
+
+		select {
+		case <-s.stopCh:
+			return
+		case <-notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver sends a batch of events to the target, it returns true if there is no event to deliver
+func (s *EventSink) deliver(target Target) (bool, error) {
+	cursor, err := s.outbox.cursor(target.Name())
+	if err != nil {
+		return false, err
+	}
+	events, err := s.outbox.read(cursor, s.batchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(events) <= 0 {
+		return true, nil
+	}
+
+	if err := target.Send(events); err != nil {
+		return false, err
+	}
+	return false, s.outbox.setCursor(target.Name(), events[len(events)-1].Offset)
+}
+
+func (s *EventSink) pruneLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	names := make([]string, len(s.targets))
+	for i, target := range s.targets {
+		names[i] = target.Name()
+	}
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			if err := s.outbox.prune(names); err != nil {
+				s.log.Error("prune outbox failed, error is "+err.Error(), "method", "pruneLoop")
+			}
+		}
+	}
+}
+
+func (s *EventSink) getLogList(block *ledger.AccountBlock) ledger.VmLogList {
+	if block.LogHash == nil {
+		return nil
+	}
+	logList, err := s.chain.GetVmLogList(block.LogHash)
+	if err != nil {
+		s.log.Error("get log list failed, error is "+err.Error(), "method", "getLogList", "addr", block.AccountAddress, "hash", block.Hash, "height", block.Height)
+	}
+	return logList
+}
+
+func (s *EventSink) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (s *EventSink) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	events := make([]*Event, 0, len(blocks))
+	for _, b := range blocks {
+		events = append(events, &Event{
+			Type:         InsertAccountBlock,
+			AccountBlock: newAccountBlock(b.AccountBlock, b.VmDb.GetLogList()),
+		})
+	}
+	return s.emit(events, "InsertAccountBlocks")
+}
+
+func (s *EventSink) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (s *EventSink) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	events := make([]*Event, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		events = append(events, &Event{
+			Type:          InsertSnapshotBlock,
+			SnapshotBlock: newSnapshotBlock(chunk),
+		})
+	}
+	return s.emit(events, "InsertSnapshotBlocks")
+}
+
+func (s *EventSink) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		s.preDeleteEvents = append(s.preDeleteEvents, &Event{
+			Type:         RevertAccountBlock,
+			AccountBlock: newAccountBlock(blocks[i], s.getLogList(blocks[i])),
+		})
+	}
+	return nil
+}
+
+func (s *EventSink) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	events := s.preDeleteEvents
+	s.preDeleteEvents = nil
+	return s.emit(events, "DeleteAccountBlocks")
+}
+
+func (s *EventSink) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	for i := len(chunks) - 1; i >= 0; i-- {
+		chunk := chunks[i]
+		if chunk.SnapshotBlock != nil {
+			s.preDeleteEvents = append(s.preDeleteEvents, &Event{
+				Type:          RevertSnapshotBlock,
+				SnapshotBlock: newSnapshotBlock(chunk),
+			})
+		}
+		for j := len(chunk.AccountBlocks) - 1; j >= 0; j-- {
+			block := chunk.AccountBlocks[j]
+			s.preDeleteEvents = append(s.preDeleteEvents, &Event{
+				Type:         RevertAccountBlock,
+				AccountBlock: newAccountBlock(block, s.getLogList(block)),
+			})
+		}
+	}
+	return nil
+}
+
+func (s *EventSink) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	events := s.preDeleteEvents
+	s.preDeleteEvents = nil
+	return s.emit(events, "DeleteSnapshotBlocks")
+}
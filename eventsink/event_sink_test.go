@@ -0,0 +1,312 @@
+package eventsink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockChain struct {
+	chain.Chain
+}
+
+func (c *mockChain) Register(listener chain.EventListener)   {}
+func (c *mockChain) UnRegister(listener chain.EventListener) {}
+func (c *mockChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return nil, nil
+}
+
+type memoryTarget struct {
+	mu     sync.Mutex
+	events []*Event
+	fail   bool
+}
+
+func (t *memoryTarget) Name() string {
+	return "memory"
+}
+
+func (t *memoryTarget) Send(events []*Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fail {
+		return errors.New("target is down")
+	}
+	t.events = append(t.events, events...)
+	return nil
+}
+
+func (t *memoryTarget) Close() error {
+	return nil
+}
+
+func (t *memoryTarget) received() []*Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Event{}, t.events...)
+}
+
+func (t *memoryTarget) setFail(fail bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fail = fail
+}
+
+func newChunk(height uint64, accountBlockCount int) *ledger.SnapshotChunk {
+	now := time.Unix(1560000000+int64(height), 0)
+	chunk := &ledger.SnapshotChunk{
+		SnapshotBlock: &ledger.SnapshotBlock{
+			Hash:      types.DataHash(chain_utils.Uint64ToBytes(height)),
+			Height:    height,
+			Timestamp: &now,
+		},
+	}
+	for i := 0; i < accountBlockCount; i++ {
+		chunk.AccountBlocks = append(chunk.AccountBlocks, &ledger.AccountBlock{
+			Hash:   types.DataHash(append(chain_utils.Uint64ToBytes(height), byte(i))),
+			Height: uint64(i + 1),
+		})
+	}
+	return chunk
+}
+
+func waitEvents(t *testing.T, target *memoryTarget, count int) []*Event {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if events := target.received(); len(events) >= count {
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("receive %d events, expected %d", len(target.received()), count)
+	return nil
+}
+
+func TestEventSinkDeliverAndRevert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventsink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := &memoryTarget{}
+	s, err := newEventSink(&mockChain{}, []Target{target}, 2, filepath.Join(dir, "outbox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	chunks := []*ledger.SnapshotChunk{newChunk(1, 1), newChunk(2, 2)}
+	s.InsertSnapshotBlocks(chunks)
+	s.PrepareDeleteSnapshotBlocks(chunks)
+	s.DeleteSnapshotBlocks(chunks)
+
+	events := waitEvents(t, target, 7)
+	expected := []struct {
+		eventType EventType
+		hash      types.Hash
+	}{
+		{InsertSnapshotBlock, chunks[0].SnapshotBlock.Hash},
+		{InsertSnapshotBlock, chunks[1].SnapshotBlock.Hash},
+		{RevertSnapshotBlock, chunks[1].SnapshotBlock.Hash},
+		{RevertAccountBlock, chunks[1].AccountBlocks[1].Hash},
+		{RevertAccountBlock, chunks[1].AccountBlocks[0].Hash},
+		{RevertSnapshotBlock, chunks[0].SnapshotBlock.Hash},
+		{RevertAccountBlock, chunks[0].AccountBlocks[0].Hash},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("receive %d events, expected %d", len(events), len(expected))
+	}
+	for i, event := range events {
+		if event.Offset != uint64(i+1) || event.Type != expected[i].eventType {
+			t.Fatalf("event %d is %d %s, expected %d %s", i, event.Offset, event.Type, i+1, expected[i].eventType)
+		}
+		hash := types.Hash{}
+		if event.SnapshotBlock != nil {
+			hash = event.SnapshotBlock.Hash
+		} else if event.AccountBlock != nil {
+			hash = event.AccountBlock.Hash
+		}
+		if hash != expected[i].hash {
+			t.Fatalf("event %d hash is %s, expected %s", i, hash, expected[i].hash)
+		}
+	}
+	if len(events[0].SnapshotBlock.AccountBlocks) != 1 {
+		t.Fatalf("snapshot block should confirm 1 account block")
+	}
+}
+
+func TestEventSinkResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventsink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	outboxDir := filepath.Join(dir, "outbox")
+
+	// the target is down, the events stay in the outbox
+	target := &memoryTarget{fail: true}
+	s, err := newEventSink(&mockChain{}, []Target{target}, 10, outboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.InsertSnapshotBlocks([]*ledger.SnapshotChunk{newChunk(1, 0), newChunk(2, 0)})
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	if len(target.received()) != 0 {
+		t.Fatalf("the failed target should receive nothing")
+	}
+
+	// restart, the events are delivered and the offset continues
+	target.setFail(false)
+	s, err = newEventSink(&mockChain{}, []Target{target}, 10, outboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.InsertSnapshotBlocks([]*ledger.SnapshotChunk{newChunk(3, 0)})
+	events := waitEvents(t, target, 3)
+	s.Stop()
+
+	for i, event := range events {
+		if event.Offset != uint64(i+1) || event.SnapshotBlock.Height != uint64(i+1) {
+			t.Fatalf("event %d is offset %d height %d", i, event.Offset, event.SnapshotBlock.Height)
+		}
+	}
+
+	// restart again, the delivered events are not sent again
+	s, err = newEventSink(&mockChain{}, []Target{target}, 10, outboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.InsertSnapshotBlocks([]*ledger.SnapshotChunk{newChunk(4, 0)})
+	events = waitEvents(t, target, 4)
+	s.Stop()
+	if len(events) != 4 || events[3].Offset != 4 {
+		t.Fatalf("receive %d events after restart", len(events))
+	}
+}
+
+func TestEventSinkRetryAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventsink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	outboxDir := filepath.Join(dir, "outbox")
+
+	// not started, the outbox is only used by emit
+	s, err := newEventSink(&mockChain{}, []Target{&memoryTarget{}}, 10, outboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.stopCh = make(chan struct{})
+
+	// the append fails until the outbox is reopened
+	s.outbox.close()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.InsertSnapshotBlocks([]*ledger.SnapshotChunk{newChunk(1, 0)})
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("the callback should be blocked until the events are appended: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	db, err := leveldb.OpenFile(outboxDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.outbox.mu.Lock()
+	s.outbox.db = db
+	s.outbox.mu.Unlock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the events should be appended after the outbox is reopened")
+	}
+	if events, err := s.outbox.read(0, 10); err != nil || len(events) != 1 || events[0].SnapshotBlock.Height != 1 {
+		t.Fatalf("the outbox should have the event: %v", err)
+	}
+
+	// the error is returned when the sink is stopped
+	s.outbox.close()
+	go func() {
+		done <- s.InsertSnapshotBlocks([]*ledger.SnapshotChunk{newChunk(2, 0)})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(s.stopCh)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("the append error should be returned after stopped")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the callback should return after stopped")
+	}
+}
+
+func TestFileTargetSkipsWrittenEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventsink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal", "events.log")
+
+	target, err := NewFileTarget(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Send([]*Event{{Offset: 1, Type: InsertSnapshotBlock}, {Offset: 2, Type: InsertSnapshotBlock}}); err != nil {
+		t.Fatal(err)
+	}
+	target.Close()
+
+	// the batch is retried after a restart, only the new event is written
+	target, err = NewFileTarget(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Send([]*Event{{Offset: 2, Type: InsertSnapshotBlock}, {Offset: 3, Type: RevertSnapshotBlock}}); err != nil {
+		t.Fatal(err)
+	}
+	target.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var offsets []uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := &Event{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, event.Offset)
+	}
+	if len(offsets) != 3 || offsets[0] != 1 || offsets[1] != 2 || offsets[2] != 3 {
+		t.Fatalf("unexpected offsets in journal %v", offsets)
+	}
+}
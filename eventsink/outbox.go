@@ -0,0 +1,163 @@
+package eventsink
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+)
+
+const (
+	eventKeyPrefix  = byte(1)
+	cursorKeyPrefix = byte(2)
+)
+
+// outbox is the durable queue of the events. The events are appended in the chain event callbacks,
+// and removed when all of the targets have delivered them.
+type outbox struct {
+	db *leveldb.DB
+
+	mu         sync.Mutex
+	nextOffset uint64
+}
+
+func newOutbox(dir string) (*outbox, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	o := &outbox{db: db, nextOffset: 1}
+
+	// find the offset of the last event
+	iter := db.NewIterator(util.BytesPrefix([]byte{eventKeyPrefix}), nil)
+	defer iter.Release()
+	if iter.Last() {
+		o.nextOffset = chain_utils.BytesToUint64(iter.Key()[1:]) + 1
+	}
+	if err := iter.Error(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// the events may be removed, the cursors are not less than the last offset
+	cursorIter := db.NewIterator(util.BytesPrefix([]byte{cursorKeyPrefix}), nil)
+	defer cursorIter.Release()
+	for cursorIter.Next() {
+		if cursor := chain_utils.BytesToUint64(cursorIter.Value()); cursor >= o.nextOffset {
+			o.nextOffset = cursor + 1
+		}
+	}
+	if err := cursorIter.Error(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *outbox) close() error {
+	return o.db.Close()
+}
+
+func eventKey(offset uint64) []byte {
+	return append([]byte{eventKeyPrefix}, chain_utils.Uint64ToBytes(offset)...)
+}
+
+func cursorKey(name string) []byte {
+	return append([]byte{cursorKeyPrefix}, []byte(name)...)
+}
+
+// append assigns the offsets to the events and writes them in a batch
+func (o *outbox) append(events []*Event) error {
+	if len(events) <= 0 {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	offset := o.nextOffset
+	for _, event := range events {
+		event.Offset = offset
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		batch.Put(eventKey(offset), value)
+		offset++
+	}
+	if err := o.db.Write(batch, nil); err != nil {
+		return err
+	}
+	o.nextOffset = offset
+	return nil
+}
+
+// read returns at most count events after the offset
+func (o *outbox) read(after uint64, count int) ([]*Event, error) {
+	iter := o.db.NewIterator(&util.Range{Start: eventKey(after + 1), Limit: eventKey(after + 1 + uint64(count))}, nil)
+	defer iter.Release()
+
+	var events []*Event
+	for iter.Next() {
+		event := &Event{}
+		if err := json.Unmarshal(iter.Value(), event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, iter.Error()
+}
+
+// cursor returns the offset of the last event delivered by the target
+func (o *outbox) cursor(name string) (uint64, error) {
+	value, err := o.db.Get(cursorKey(name), nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return chain_utils.BytesToUint64(value), nil
+}
+
+func (o *outbox) setCursor(name string, offset uint64) error {
+	return o.db.Put(cursorKey(name), chain_utils.Uint64ToBytes(offset), nil)
+}
+
+// prune removes the events which are delivered by all of the targets
+func (o *outbox) prune(names []string) error {
+	if len(names) <= 0 {
+		return nil
+	}
+	minCursor := uint64(0)
+	for i, name := range names {
+		cursor, err := o.cursor(name)
+		if err != nil {
+			return err
+		}
+		if i == 0 || cursor < minCursor {
+			minCursor = cursor
+		}
+	}
+	if minCursor == 0 {
+		return nil
+	}
+
+	iter := o.db.NewIterator(&util.Range{Start: eventKey(0), Limit: eventKey(minCursor + 1)}, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if batch.Len() <= 0 {
+		return nil
+	}
+	return o.db.Write(batch, nil)
+}
@@ -0,0 +1,150 @@
+package eventsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Target is an external destination of the events. Send is retried with the same events until it
+// succeeds, so a target should be idempotent or leave the deduplication to the consumer by offset.
+type Target interface {
+	// Name is the unique name of the target, the delivered offset is saved by name
+	Name() string
+
+	Send(events []*Event) error
+
+	Close() error
+}
+
+// the tail of the journal which is read to find the last offset, it should be larger than an event
+const journalTailSize = 4 * 1024 * 1024
+
+// fileTarget appends the events to a journal file, one JSON event per line. The events which are
+// already in the journal are skipped, so the journal has no duplicate events after a restart.
+type fileTarget struct {
+	path       string
+	file       *os.File
+	lastOffset uint64
+}
+
+func NewFileTarget(path string) (Target, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	lastOffset, err := readJournalLastOffset(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileTarget{path: path, file: file, lastOffset: lastOffset}, nil
+}
+
+func readJournalLastOffset(file *os.File) (uint64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	start := info.Size() - journalTailSize
+	if start < 0 {
+		start = 0
+	}
+	tail := make([]byte, info.Size()-start)
+	if _, err := file.ReadAt(tail, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	lines := bytes.Split(bytes.TrimRight(tail, "\n"), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		event := &Event{}
+		if err := json.Unmarshal(lines[i], event); err == nil {
+			return event.Offset, nil
+		}
+	}
+	return 0, nil
+}
+
+func (t *fileTarget) Name() string {
+	return "file"
+}
+
+func (t *fileTarget) Send(events []*Event) error {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	lastOffset := t.lastOffset
+	for _, event := range events {
+		if event.Offset <= lastOffset {
+			continue
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		lastOffset = event.Offset
+	}
+	if buf.Len() <= 0 {
+		return nil
+	}
+
+	if _, err := t.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+	t.lastOffset = lastOffset
+	return nil
+}
+
+func (t *fileTarget) Close() error {
+	return t.file.Close()
+}
+
+// webhookTarget posts the events to an url as a JSON array, any 2xx status means the events are accepted
+type webhookTarget struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookTarget(url string) Target {
+	return &webhookTarget{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (t *webhookTarget) Name() string {
+	return "webhook"
+}
+
+func (t *webhookTarget) Send(events []*Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New(fmt.Sprintf("webhook %s returns status %d", t.url, resp.StatusCode))
+	}
+	return nil
+}
+
+func (t *webhookTarget) Close() error {
+	return nil
+}
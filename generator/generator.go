@@ -43,6 +43,14 @@ type generator struct {
 // the third "addr" needs to be filled with the address of the account chain to be blocked,
 // and the last needs to be filled with the previous/latest block's hash on the account chain.
 func NewGenerator(chain vm_db.Chain, consensus Consensus, addr types.Address, latestSnapshotBlockHash, prevBlockHash *types.Hash) (header.Generator, error) {
+	gen, err := newGenerator(chain, consensus, addr, latestSnapshotBlockHash, prevBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+func newGenerator(chain vm_db.Chain, consensus Consensus, addr types.Address, latestSnapshotBlockHash, prevBlockHash *types.Hash) (*generator, error) {
 	gen := &generator{
 		log: log15.New("module", "Generator"),
 	}
@@ -129,12 +137,12 @@ func (gen *generator) generateBlock(block *ledger.AccountBlock, fromBlock *ledge
 
 		limitSb, err := gen.chain.GetSnapshotBlockByContractMeta(block.AccountAddress, fromBlock.Hash)
 		if err != nil {
-			return nil, fmt.Errorf("GetSnapshotBlockByContractMeta failed", "err", err)
+			return nil, fmt.Errorf("GetSnapshotBlockByContractMeta failed, err: %v", err)
 		}
 		if fork.IsSeedFork(latestSb.Height) {
 			limitSeedSb, err := gen.chain.GetSeedConfirmedSnapshotBlock(block.AccountAddress, fromBlock.Hash)
 			if err != nil {
-				return nil, fmt.Errorf("GetSeedConfirmedSnapshotBlock failed", "err", err)
+				return nil, fmt.Errorf("GetSeedConfirmedSnapshotBlock failed, err: %v", err)
 			}
 			if limitSb == nil {
 				if limitSeedSb != nil {
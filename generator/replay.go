@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/header"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// ReplayAccountBlock re-executes an inserted account block and returns the execution result,
+// nothing is written to the chain.
+//
+// A confirmed block is executed on top of the state at the snapshot block before the one which
+// confirms it, an unconfirmed block is executed on top of the latest snapshot block. The blocks
+// of the same account between that snapshot block and the block are re-executed before it.
+// The tracer is only attached to the execution of the block itself, and may be nil.
+func ReplayAccountBlock(chain vm_db.HistoryChain, consensus Consensus, block *ledger.AccountBlock, tracer vm.Tracer) (*header.GenResult, error) {
+	var snapshotHeight uint64
+	confirmSb, err := chain.GetConfirmSnapshotHeaderByAbHash(block.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if confirmSb != nil {
+		snapshotHeight = confirmSb.Height - 1
+	} else {
+		snapshotHeight = chain.GetLatestSnapshotBlock().Height
+	}
+	if snapshotHeight < 1 {
+		return nil, errors.New(fmt.Sprintf("block %s is confirmed by genesis snapshot block, can't be replayed", block.Hash))
+	}
+	sb, err := chain.GetSnapshotBlockByHeight(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		return nil, errors.New(fmt.Sprintf("snapshot block %d is not exist", snapshotHeight))
+	}
+
+	// the blocks of the account which are not confirmed at the snapshot height
+	var prevBlocks []*ledger.AccountBlock
+	var latestBlock *ledger.AccountBlock
+	prevHash := block.PrevHash
+	for !prevHash.IsZero() {
+		prevBlock, err := chain.GetAccountBlockByHash(prevHash)
+		if err != nil {
+			return nil, err
+		}
+		if prevBlock == nil {
+			return nil, errors.New(fmt.Sprintf("prev block %s is not exist", prevHash))
+		}
+		prevConfirmSb, err := chain.GetConfirmSnapshotHeaderByAbHash(prevHash)
+		if err != nil {
+			return nil, err
+		}
+		if prevConfirmSb != nil && prevConfirmSb.Height <= snapshotHeight {
+			latestBlock = prevBlock
+			break
+		}
+		prevBlocks = append(prevBlocks, prevBlock)
+		prevHash = prevBlock.PrevHash
+	}
+
+	snapshotChain, err := vm_db.NewSnapshotChain(chain, snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if latestBlock != nil {
+		snapshotChain.SetLatestAccountBlock(latestBlock)
+	}
+	for i := len(prevBlocks) - 1; i >= 0; i-- {
+		result, err := ReplayBlockOnSnapshot(snapshotChain, consensus, sb, prevBlocks[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		if result.VMBlock == nil {
+			return nil, errors.New(fmt.Sprintf("replay prev block %s failed, err: %v", prevBlocks[i].Hash, result.Err))
+		}
+		snapshotChain.Apply(result.VMBlock)
+	}
+	return ReplayBlockOnSnapshot(snapshotChain, consensus, sb, block, tracer)
+}
+
+// ReplayBlockOnSnapshot re-executes an inserted account block on top of the snapshot block and the
+// state served by the chain, usually a vm_db.SnapshotChain. The tracer may be nil.
+func ReplayBlockOnSnapshot(chain vm_db.Chain, consensus Consensus, sb *ledger.SnapshotBlock, block *ledger.AccountBlock, tracer vm.Tracer) (*header.GenResult, error) {
+	var fromBlock *ledger.AccountBlock
+	if block.IsReceiveBlock() {
+		var err error
+		fromBlock, err = chain.GetAccountBlockByHash(block.FromBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if fromBlock == nil {
+			return nil, errors.New(fmt.Sprintf("send block %s is not exist", block.FromBlockHash))
+		}
+	}
+
+	prevHash := block.PrevHash
+	gen, err := newGenerator(chain, consensus, block.AccountAddress, &sb.Hash, &prevHash)
+	if err != nil {
+		return nil, err
+	}
+	if tracer != nil {
+		gen.vm.SetTracer(tracer)
+	}
+	return gen.GenerateWithBlock(block, fromBlock)
+}
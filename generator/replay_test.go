@@ -0,0 +1,348 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	chain_ "github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/header"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/quota"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const replayGenesisJson = `{
+  "GenesisAccountAddress": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+  "GovernanceInfo": {
+    "ConsensusGroupInfoMap": {
+      "00000000000000000001": {
+        "NodeCount": 1, "Interval": 1, "PerCount": 3, "RandCount": 2, "RandRank": 100, "Repeat": 1, "CheckLevel": 0,
+        "CountingTokenId": "tti_5649544520544f4b454e6e40", "RegisterConditionId": 1,
+        "RegisterConditionParam": {"StakeAmount": 100000000000000000000000, "StakeHeight": 1, "StakeToken": "tti_5649544520544f4b454e6e40"},
+        "VoteConditionId": 1, "VoteConditionParam": {},
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a", "StakeAmount": 0, "ExpirationHeight": 1
+      }
+    }
+  },
+  "AssetInfo": {
+    "TokenInfoMap": {
+      "tti_5649544520544f4b454e6e40": {
+        "TokenName": "Vite Token", "TokenSymbol": "VITE", "TotalSupply": 1000000000000000000000000000, "Decimals": 18,
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+        "MaxSupply": 115792089237316195423570985008687907853269984665640564039457584007913129639935,
+        "IsOwnerBurnOnly": false, "IsReIssuable": true
+      }
+    }
+  },
+  "QuotaInfo": {
+    "StakeInfoMap": {
+      "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a": [
+        {"Amount": 10000000000000000000000000, "ExpirationHeight": 259200, "Beneficiary": "%s"},
+        {"Amount": 10000000000000000000000000, "ExpirationHeight": 259200, "Beneficiary": "%s"}
+      ]
+    },
+    "StakeBeneficialMap": {
+      "%s": 10000000000000000000000000,
+      "%s": 10000000000000000000000000
+    }
+  },
+  "AccountBalanceMap": {
+    "%s": {
+      "tti_5649544520544f4b454e6e40": 100000000000000000000000000
+    }
+  }
+}`
+
+type replayAccount struct {
+	addr types.Address
+	key  ed25519.PrivateKey
+}
+
+func newReplayAccount(t *testing.T) *replayAccount {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &replayAccount{
+		addr: types.PubkeyToAddress(pub),
+		key:  key,
+	}
+}
+
+func (acc *replayAccount) sign(addr types.Address, data []byte) ([]byte, []byte, error) {
+	return ed25519.Sign(acc.key, data), acc.key.PubByte(), nil
+}
+
+// replayFixture is a chain with blocks generated by the vm, so they can be replayed
+type replayFixture struct {
+	t     *testing.T
+	dir   string
+	chain chain_.Chain
+}
+
+func newReplayFixture(t *testing.T, a, b *replayAccount) *replayFixture {
+	// no fork is active in the fixture
+	point := &config.ForkPoint{Height: 10000000, Version: 1}
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork: point, DexFork: point, DexFeeFork: point, StemFork: point,
+		LeafFork: point, EarthFork: point, DexMiningFork: point, DexRobotFork: point, MultisigFork: point,
+	})
+	// quota is calculated by the stake amount and the quota used, as the vm does on the chain
+	quota.InitQuotaConfig(false, true)
+	vm.InitVMConfig(false, false, false, false, "")
+
+	dir, err := ioutil.TempDir("", "replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesisConfig := &config.Genesis{}
+	genesisJson := fmt.Sprintf(replayGenesisJson, a.addr, b.addr, a.addr, b.addr, a.addr)
+	if err := json.Unmarshal([]byte(genesisJson), genesisConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	c := chain_.NewChain(dir, &config.Chain{}, genesisConfig)
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &replayFixture{
+		t:     t,
+		dir:   dir,
+		chain: c,
+	}
+}
+
+func (f *replayFixture) close() {
+	f.chain.Stop()
+	os.RemoveAll(f.dir)
+}
+
+func (f *replayFixture) generate(acc *replayAccount, msg *header.IncomingMessage) *ledger.AccountBlock {
+	latestSb := f.chain.GetLatestSnapshotBlock()
+	var prevHash types.Hash
+	prevBlock, err := f.chain.GetLatestAccountBlock(acc.addr)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if prevBlock != nil {
+		prevHash = prevBlock.Hash
+	}
+
+	gen, err := NewGenerator(f.chain, &test_tools.MockConsensus{}, acc.addr, &latestSb.Hash, &prevHash)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	msg.AccountAddress = acc.addr
+	result, err := gen.GenerateWithMessage(msg, &acc.addr, acc.sign)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if result.VMBlock == nil {
+		f.t.Fatalf("generate block failed: %v", result.Err)
+	}
+	if err := f.chain.InsertAccountBlock(result.VMBlock); err != nil {
+		f.t.Fatal(err)
+	}
+	return result.VMBlock.AccountBlock
+}
+
+func (f *replayFixture) send(from, to *replayAccount, amount int64) *ledger.AccountBlock {
+	return f.generate(from, &header.IncomingMessage{
+		BlockType: ledger.BlockTypeSendCall,
+		ToAddress: &to.addr,
+		TokenId:   &ledger.ViteTokenId,
+		Amount:    big.NewInt(amount),
+	})
+}
+
+func (f *replayFixture) receive(to *replayAccount, sendBlock *ledger.AccountBlock) *ledger.AccountBlock {
+	return f.generate(to, &header.IncomingMessage{
+		BlockType:     ledger.BlockTypeReceive,
+		FromBlockHash: &sendBlock.Hash,
+	})
+}
+
+// snapshot confirms all unconfirmed blocks
+func (f *replayFixture) snapshot() *ledger.SnapshotBlock {
+	latestSb := f.chain.GetLatestSnapshotBlock()
+	now := latestSb.Timestamp.Add(time.Second)
+	sb := &ledger.SnapshotBlock{
+		PrevHash:        latestSb.Hash,
+		Height:          latestSb.Height + 1,
+		Timestamp:       &now,
+		SnapshotContent: f.chain.GetContentNeedSnapshot(),
+	}
+	sb.Hash = sb.ComputeHash()
+	if _, err := f.chain.InsertSnapshotBlock(sb); err != nil {
+		f.t.Fatal(err)
+	}
+	return sb
+}
+
+func (f *replayFixture) balanceAt(addr types.Address, snapshotHeight uint64) *big.Int {
+	balanceMap, err := f.chain.GetBalanceMapAtSnapshot(addr, snapshotHeight)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if balance, ok := balanceMap[ledger.ViteTokenId]; ok {
+		return balance
+	}
+	return big.NewInt(0)
+}
+
+// history of the fixture:
+//
+//	snapshot 2: a sends 10 to b
+//	snapshot 3: b receives 10, a sends 20 to b
+//	snapshot 4: b receives 20
+//	unconfirmed: a sends 30 to b, b receives 30
+type replayHistory struct {
+	a, b                *replayAccount
+	send1, send2, send3 *ledger.AccountBlock
+	recv1, recv2, recv3 *ledger.AccountBlock
+}
+
+func makeReplayHistory(t *testing.T) (*replayFixture, *replayHistory) {
+	h := &replayHistory{
+		a: newReplayAccount(t),
+		b: newReplayAccount(t),
+	}
+	f := newReplayFixture(t, h.a, h.b)
+
+	h.send1 = f.send(h.a, h.b, 10)
+	f.snapshot()
+	h.recv1 = f.receive(h.b, h.send1)
+	h.send2 = f.send(h.a, h.b, 20)
+	f.snapshot()
+	h.recv2 = f.receive(h.b, h.send2)
+	f.snapshot()
+	h.send3 = f.send(h.a, h.b, 30)
+	h.recv3 = f.receive(h.b, h.send3)
+
+	return f, h
+}
+
+func TestSnapshotChain(t *testing.T) {
+	f, h := makeReplayHistory(t)
+	defer f.close()
+
+	sc, err := vm_db.NewSnapshotChain(f.chain, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// balance
+	if balance, err := sc.GetBalance(h.b.addr, ledger.ViteTokenId); err != nil || balance.Sign() != 0 {
+		t.Fatalf("balance of b should be 0, but get %v, %v", balance, err)
+	}
+	expected := new(big.Int).Sub(f.balanceAt(h.a.addr, 1), big.NewInt(10))
+	if balance, err := sc.GetBalance(h.a.addr, ledger.ViteTokenId); err != nil || balance.Cmp(expected) != 0 {
+		t.Fatalf("balance of a should be %s, but get %v, %v", expected, balance, err)
+	}
+
+	// latest account blocks
+	if block, err := sc.GetLatestAccountBlock(h.a.addr); err != nil || block == nil || block.Hash != h.send1.Hash {
+		t.Fatalf("latest block of a should be %s, but get %v, %v", h.send1.Hash, block, err)
+	}
+	if block, err := sc.GetLatestAccountBlock(h.b.addr); err != nil || block != nil {
+		t.Fatalf("latest block of b should be nil, but get %v, %v", block, err)
+	}
+
+	// confirmed times and snapshot blocks
+	if times, err := sc.GetConfirmedTimes(h.send1.Hash); err != nil || times != 1 {
+		t.Fatalf("confirmed times of send1 should be 1, but get %d, %v", times, err)
+	}
+	if times, err := sc.GetConfirmedTimes(h.send2.Hash); err != nil || times != 0 {
+		t.Fatalf("confirmed times of send2 should be 0, but get %d, %v", times, err)
+	}
+	if sb, err := sc.GetConfirmSnapshotHeaderByAbHash(h.recv1.Hash); err != nil || sb != nil {
+		t.Fatalf("recv1 should not be confirmed, but get %v, %v", sb, err)
+	}
+	if sb, err := sc.GetSnapshotBlockByHeight(3); err != nil || sb != nil {
+		t.Fatalf("snapshot block 3 should be nil, but get %v, %v", sb, err)
+	}
+
+	// quota
+	usedList := sc.GetQuotaUsedList(h.a.addr)
+	if len(usedList) != 2 || usedList[0].BlockCount != 1 || usedList[0].QuotaUsedTotal != h.send1.QuotaUsed || usedList[1].BlockCount != 0 {
+		t.Fatalf("wrong quota used list of a: %+v", usedList)
+	}
+	if usedList = sc.GetQuotaUsedList(h.b.addr); usedList[0].BlockCount != 0 {
+		t.Fatalf("wrong quota used list of b: %+v", usedList)
+	}
+	if globalQuota := sc.GetGlobalQuota(); globalQuota.BlockCount != 1 {
+		t.Fatalf("wrong global quota: %+v", globalQuota)
+	}
+	if amount, err := sc.GetStakeBeneficialAmount(h.a.addr); err != nil || amount.Cmp(new(big.Int).Mul(big.NewInt(1e7), big.NewInt(1e18))) != 0 {
+		t.Fatalf("wrong stake amount of a: %v, %v", amount, err)
+	}
+
+	// contract
+	if ok, err := sc.IsContractAccount(h.a.addr); err != nil || ok {
+		t.Fatalf("a is not contract: %v, %v", ok, err)
+	}
+	if ok, err := sc.IsContractAccount(types.AddressQuota); err != nil || !ok {
+		t.Fatalf("quota is contract: %v, %v", ok, err)
+	}
+}
+
+func TestReplayAccountBlock(t *testing.T) {
+	f, h := makeReplayHistory(t)
+	defer f.close()
+
+	cases := []struct {
+		block          *ledger.AccountBlock
+		snapshotHeight uint64 // state after the block
+	}{
+		{h.send1, 2},
+		{h.send2, 3},
+		{h.recv1, 3},
+		{h.recv2, 4},
+	}
+	for _, c := range cases {
+		result, err := ReplayAccountBlock(f.chain, &test_tools.MockConsensus{}, c.block, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.VMBlock == nil {
+			t.Fatalf("replay block %s failed: %v", c.block.Hash, result.Err)
+		}
+		replayed := result.VMBlock.AccountBlock
+		if replayed.Hash != c.block.Hash || replayed.Quota != c.block.Quota || replayed.QuotaUsed != c.block.QuotaUsed {
+			t.Fatalf("replayed block is different: %+v, expected %+v", replayed, c.block)
+		}
+
+		balance := result.VMBlock.VmDb.GetUnsavedBalanceMap()[ledger.ViteTokenId]
+		if expected := f.balanceAt(c.block.AccountAddress, c.snapshotHeight); balance == nil || balance.Cmp(expected) != 0 {
+			t.Fatalf("balance of %s after replay is %v, expected %s", c.block.AccountAddress, balance, expected)
+		}
+	}
+
+	// unconfirmed blocks are replayed on the latest snapshot block, after the previous unconfirmed blocks
+	result, err := ReplayAccountBlock(f.chain, &test_tools.MockConsensus{}, h.recv3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.VMBlock == nil || result.VMBlock.AccountBlock.Hash != h.recv3.Hash {
+		t.Fatalf("replay recv3 failed: %v", result.Err)
+	}
+	if balance := result.VMBlock.VmDb.GetUnsavedBalanceMap()[ledger.ViteTokenId]; balance == nil || balance.Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("balance of b after recv3 is %v, expected 60", balance)
+	}
+}
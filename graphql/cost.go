@@ -0,0 +1,576 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxQueryCost is the limit of the estimated count of resolved fields of a query, so that the nested lists
+	// can't fan out to millions of chain reads, e.g. the hashes of 100 account blocks of each of 100 snapshot blocks cost 10101
+	maxQueryCost = 100000
+
+	// maxFragmentDepth is the limit of the nested fragment spreads when the cost is estimated
+	maxFragmentDepth = 32
+)
+
+// listFields are the list fields of the schema and the size of the list if the count is omitted
+var listFields = map[string]uint64{
+	"snapshotBlocks": maxCount,
+	"accountBlocks":  maxCount,
+	"balances":       maxCount,
+	"blocks":         maxCount,
+	"vmLogs":         maxCount,
+	"sendBlockList":  maxCount,
+}
+
+var errInvalidQuery = errors.New("invalid query")
+
+// selection is a field, a fragment spread or an inline fragment of a selection set
+type selection struct {
+	name     string // the field name or the name of the spread fragment
+	spread   bool
+	count    *value // the count argument of a list field
+	children []*selection
+}
+
+// value is an int literal or a variable
+type value struct {
+	variable string
+	number   int64
+}
+
+type operation struct {
+	name      string
+	defaults  map[string]int64
+	selection []*selection
+}
+
+// queryCost estimates the cost of the operation to be executed by the count of resolved fields, the fields
+// under a list are multiplied by the size of the list, which is the count argument or the max count.
+// It returns an error if the query can't be parsed.
+func queryCost(query, operationName string, variables map[string]interface{}) (uint64, error) {
+	p := &costParser{lexer: lexer{src: query}}
+	ops, fragments, err := p.parseDocument()
+	if err != nil {
+		return 0, err
+	}
+	var cost uint64
+	for _, op := range ops {
+		if operationName != "" && op.name != operationName {
+			continue
+		}
+		e := &costEstimator{fragments: fragments, variables: variables, defaults: op.defaults}
+		c, err := e.selectionCost(op.selection, 0)
+		if err != nil {
+			return 0, err
+		}
+		if c > cost {
+			cost = c
+		}
+	}
+	return cost, nil
+}
+
+type costEstimator struct {
+	fragments map[string][]*selection
+	variables map[string]interface{}
+	defaults  map[string]int64
+}
+
+func (e *costEstimator) selectionCost(set []*selection, depth int) (uint64, error) {
+	var cost uint64
+	for _, s := range set {
+		var c uint64
+		if s.spread {
+			fragment, ok := e.fragments[s.name]
+			if !ok || depth >= maxFragmentDepth {
+				return 0, errInvalidQuery
+			}
+			var err error
+			if c, err = e.selectionCost(fragment, depth+1); err != nil {
+				return 0, err
+			}
+		} else {
+			children, err := e.selectionCost(s.children, depth)
+			if err != nil {
+				return 0, err
+			}
+			if s.name != "" {
+				c = 1
+				if size, ok := listFields[s.name]; ok {
+					children = saturatingMul(children, e.listSize(s.count, size))
+				}
+			}
+			c = saturatingAdd(c, children)
+		}
+		cost = saturatingAdd(cost, c)
+	}
+	return cost, nil
+}
+
+// listSize returns the count in [1, maxCount], or the size if the count is omitted
+func (e *costEstimator) listSize(count *value, size uint64) uint64 {
+	if count == nil {
+		return size
+	}
+	n := count.number
+	if count.variable != "" {
+		if v, ok := e.variables[count.variable].(float64); ok {
+			n = int64(v)
+		} else if d, ok := e.defaults[count.variable]; ok {
+			n = d
+		} else {
+			return size
+		}
+	}
+	if n < 1 {
+		return 1
+	}
+	if n > maxCount {
+		return maxCount
+	}
+	return uint64(n)
+}
+
+func saturatingAdd(a, b uint64) uint64 {
+	if a+b < a || a+b > maxQueryCost {
+		return maxQueryCost + 1
+	}
+	return a + b
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > (maxQueryCost+1)/b {
+		return maxQueryCost + 1
+	}
+	return a * b
+}
+
+// costParser parses the operations and fragments of a query, it keeps only the fields, the count arguments
+// and the variable defaults, the rest is skipped
+type costParser struct {
+	lexer
+}
+
+func (p *costParser) parseDocument() ([]*operation, map[string][]*selection, error) {
+	var ops []*operation
+	fragments := make(map[string][]*selection)
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case tok == "":
+			return ops, fragments, nil
+		case tok == "{":
+			set, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, nil, err
+			}
+			ops = append(ops, &operation{selection: set})
+		case tok == "query" || tok == "mutation" || tok == "subscription":
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, nil, err
+			}
+			ops = append(ops, op)
+		case tok == "fragment":
+			name, err := p.next()
+			if err != nil {
+				return nil, nil, err
+			}
+			// on TypeCondition
+			if _, err := p.next(); err != nil {
+				return nil, nil, err
+			}
+			if _, err := p.next(); err != nil {
+				return nil, nil, err
+			}
+			set, err := p.parseDirectivesAndSelectionSet()
+			if err != nil {
+				return nil, nil, err
+			}
+			fragments[name] = set
+		default:
+			return nil, nil, errInvalidQuery
+		}
+	}
+}
+
+func (p *costParser) parseOperation() (*operation, error) {
+	op := &operation{defaults: make(map[string]int64)}
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if isName(tok) {
+		op.name, _ = p.next()
+		if tok, err = p.peek(); err != nil {
+			return nil, err
+		}
+	}
+	if tok == "(" {
+		p.next()
+		if err := p.parseVariableDefinitions(op.defaults); err != nil {
+			return nil, err
+		}
+	}
+	if op.selection, err = p.parseDirectivesAndSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// parseVariableDefinitions parses ($name: Type = default ...) after the (, it keeps the int defaults
+func (p *costParser) parseVariableDefinitions(defaults map[string]int64) error {
+	var variable string
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case ")":
+			return nil
+		case "$":
+			if variable, err = p.next(); err != nil {
+				return err
+			}
+		case "=":
+			v, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if v != nil && v.variable == "" {
+				defaults[variable] = v.number
+			}
+		case "@":
+			if err := p.skipDirective(); err != nil {
+				return err
+			}
+		case "":
+			return errInvalidQuery
+		}
+	}
+}
+
+func (p *costParser) parseDirectivesAndSelectionSet() ([]*selection, error) {
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case "{":
+			return p.parseSelectionSet()
+		case "@":
+			if err := p.skipDirective(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errInvalidQuery
+		}
+	}
+}
+
+// parseSelectionSet parses the selections after the {
+func (p *costParser) parseSelectionSet() ([]*selection, error) {
+	var set []*selection
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok == "}":
+			return set, nil
+		case tok == "...":
+			s, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, s)
+		case isName(tok):
+			s, err := p.parseField(tok)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, s)
+		default:
+			return nil, errInvalidQuery
+		}
+	}
+}
+
+// parseFragment parses a fragment spread or an inline fragment after the ...
+func (p *costParser) parseFragment() (*selection, error) {
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if isName(tok) && tok != "on" {
+		p.next()
+		if err := p.skipDirectives(); err != nil {
+			return nil, err
+		}
+		return &selection{name: tok, spread: true}, nil
+	}
+	if tok == "on" {
+		p.next()
+		if _, err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	children, err := p.parseDirectivesAndSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &selection{children: children}, nil
+}
+
+func (p *costParser) parseField(name string) (*selection, error) {
+	s := &selection{name: name}
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok == ":" {
+		p.next()
+		if s.name, err = p.next(); err != nil {
+			return nil, err
+		}
+		if tok, err = p.peek(); err != nil {
+			return nil, err
+		}
+	}
+	if tok == "(" {
+		p.next()
+		if s.count, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.skipDirectives(); err != nil {
+		return nil, err
+	}
+	if tok, err = p.peek(); err != nil {
+		return nil, err
+	}
+	if tok == "{" {
+		p.next()
+		if s.children, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// parseArguments parses the arguments after the ( and returns the count argument
+func (p *costParser) parseArguments() (*value, error) {
+	var count *value
+	for {
+		name, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if name == ")" {
+			return count, nil
+		}
+		if tok, err := p.next(); err != nil || tok != ":" {
+			return nil, errInvalidQuery
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if name == "count" {
+			count = v
+		}
+	}
+}
+
+// parseValue parses a value, it returns nil if the value is not an int or a variable
+func (p *costParser) parseValue() (*value, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tok == "$":
+		name, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		return &value{variable: name}, nil
+	case tok == "[":
+		return nil, p.skipUntil("]")
+	case tok == "{":
+		return nil, p.skipUntil("}")
+	case tok == "" || tok == ")" || tok == "]" || tok == "}":
+		return nil, errInvalidQuery
+	}
+	if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+		return &value{number: n}, nil
+	}
+	return nil, nil
+}
+
+func (p *costParser) skipDirectives() error {
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return err
+		}
+		if tok != "@" {
+			return nil
+		}
+		p.next()
+		if err := p.skipDirective(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipDirective skips the name and the arguments of a directive after the @
+func (p *costParser) skipDirective() error {
+	if _, err := p.next(); err != nil {
+		return err
+	}
+	tok, err := p.peek()
+	if err != nil {
+		return err
+	}
+	if tok == "(" {
+		p.next()
+		return p.skipUntil(")")
+	}
+	return nil
+}
+
+// skipUntil skips the tokens until the closing token of the same nesting level
+func (p *costParser) skipUntil(closing string) error {
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case closing:
+			return nil
+		case "":
+			return errInvalidQuery
+		case "(":
+			err = p.skipUntil(")")
+		case "[":
+			err = p.skipUntil("]")
+		case "{":
+			err = p.skipUntil("}")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func isName(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	c := tok[0]
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// lexer splits a query into tokens, the strings are returned with their quotes and an empty token is the end
+type lexer struct {
+	src    string
+	pos    int
+	peeked *string
+}
+
+func (l *lexer) peek() (string, error) {
+	if l.peeked == nil {
+		tok, err := l.scan()
+		if err != nil {
+			return "", err
+		}
+		l.peeked = &tok
+	}
+	return *l.peeked, nil
+}
+
+func (l *lexer) next() (string, error) {
+	if l.peeked != nil {
+		tok := *l.peeked
+		l.peeked = nil
+		return tok, nil
+	}
+	return l.scan()
+}
+
+func (l *lexer) scan() (string, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+		} else if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		} else if strings.HasPrefix(l.src[l.pos:], "\ufeff") {
+			l.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+	if l.pos >= len(l.src) {
+		return "", nil
+	}
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		l.pos += 3
+		for ; !strings.HasPrefix(l.src[l.pos:], `"""`); l.pos++ {
+			if l.pos >= len(l.src) {
+				return "", errInvalidQuery
+			}
+			if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+				l.pos += 3
+			}
+		}
+		l.pos += 3
+	case c == '"':
+		l.pos++
+		for ; l.pos < len(l.src) && l.src[l.pos] != '"'; l.pos++ {
+			if l.src[l.pos] == '\\' {
+				l.pos++
+			}
+		}
+		if l.pos >= len(l.src) {
+			return "", errInvalidQuery
+		}
+		l.pos++
+	case isName(string(c)):
+		for l.pos < len(l.src) && (isName(l.src[l.pos:l.pos+1]) || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+			l.pos++
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		l.pos++
+		for l.pos < len(l.src) && strings.IndexByte("0123456789.eE+-", l.src[l.pos]) >= 0 {
+			l.pos++
+		}
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		l.pos++
+	default:
+		return "", errors.New(fmt.Sprintf("unexpected character %q", c))
+	}
+	return l.src[start:l.pos], nil
+}
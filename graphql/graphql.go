@@ -0,0 +1,551 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const maxCount = 100
+
+// Long is a 64 bit unsigned integer, it is a number in the response, and can be a number or
+// a decimal string in the request.
+type Long uint64
+
+func (l Long) ImplementsGraphQLType(name string) bool {
+	return name == "Long"
+}
+
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		value, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			return err
+		}
+		*l = Long(value)
+	case int32:
+		if input < 0 {
+			return errors.New(fmt.Sprintf("negative Long %d", input))
+		}
+		*l = Long(input)
+	case float64:
+		if input < 0 || input != float64(uint64(input)) {
+			return errors.New(fmt.Sprintf("invalid Long %v", input))
+		}
+		*l = Long(input)
+	default:
+		return errors.New(fmt.Sprintf("unexpected type %T for Long", input))
+	}
+	return nil
+}
+
+func checkCount(count int32) (uint64, error) {
+	if count <= 0 || count > maxCount {
+		return 0, errors.New(fmt.Sprintf("count is %d, should be in [1, %d]", count, maxCount))
+	}
+	return uint64(count), nil
+}
+
+// pageRange returns the range of the list of length to be returned, the count is checked as checkCount
+func pageRange(length int, offset int32, count int32) (int, int, error) {
+	if _, err := checkCount(count); err != nil {
+		return 0, 0, err
+	}
+	if offset < 0 {
+		return 0, 0, errors.New(fmt.Sprintf("offset is %d, should not be negative", offset))
+	}
+	start := int(offset)
+	if start > length {
+		start = length
+	}
+	end := start + int(count)
+	if end > length {
+		end = length
+	}
+	return start, end, nil
+}
+
+func bigIntToString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+// Resolver is the root resolver of the schema
+type Resolver struct {
+	chain chain.Chain
+}
+
+func (r *Resolver) SnapshotBlock(args struct {
+	Height *Long
+	Hash   *string
+}) (*SnapshotBlock, error) {
+	var sb *ledger.SnapshotBlock
+	var err error
+	switch {
+	case args.Height != nil:
+		sb, err = r.chain.GetSnapshotHeaderByHeight(uint64(*args.Height))
+	case args.Hash != nil:
+		hash, hashErr := types.HexToHash(*args.Hash)
+		if hashErr != nil {
+			return nil, hashErr
+		}
+		sb, err = r.chain.GetSnapshotHeaderByHash(hash)
+	default:
+		sb = r.chain.GetLatestSnapshotBlock()
+	}
+	if err != nil || sb == nil {
+		return nil, err
+	}
+	return &SnapshotBlock{chain: r.chain, block: sb}, nil
+}
+
+func (r *Resolver) SnapshotBlocks(args struct {
+	Height Long
+	Count  int32
+}) ([]*SnapshotBlock, error) {
+	count, err := checkCount(args.Count)
+	if err != nil {
+		return nil, err
+	}
+	sbList, err := r.chain.GetSnapshotHeadersByHeight(uint64(args.Height), true, count)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SnapshotBlock, 0, len(sbList))
+	for _, sb := range sbList {
+		result = append(result, &SnapshotBlock{chain: r.chain, block: sb})
+	}
+	return result, nil
+}
+
+func (r *Resolver) AccountBlock(args struct{ Hash string }) (*AccountBlock, error) {
+	hash, err := types.HexToHash(args.Hash)
+	if err != nil {
+		return nil, err
+	}
+	block, err := r.chain.GetCompleteBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountBlock(r.chain, block), nil
+}
+
+func (r *Resolver) Account(args struct{ Address string }) (*Account, error) {
+	addr, err := types.HexToAddress(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{chain: r.chain, address: addr}, nil
+}
+
+func (r *Resolver) TokenInfo(args struct{ TokenId string }) (*TokenInfo, error) {
+	tokenId, err := types.HexToTokenTypeId(args.TokenId)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenInfo(r.chain, tokenId)
+}
+
+type SnapshotBlock struct {
+	chain chain.Chain
+	block *ledger.SnapshotBlock
+}
+
+func (s *SnapshotBlock) Hash() string {
+	return s.block.Hash.String()
+}
+
+func (s *SnapshotBlock) PrevHash() string {
+	return s.block.PrevHash.String()
+}
+
+func (s *SnapshotBlock) Height() Long {
+	return Long(s.block.Height)
+}
+
+func (s *SnapshotBlock) Producer() *Account {
+	return &Account{chain: s.chain, address: s.block.Producer()}
+}
+
+func (s *SnapshotBlock) Timestamp() Long {
+	return Long(s.block.Timestamp.Unix())
+}
+
+func (s *SnapshotBlock) Seed() Long {
+	return Long(s.block.Seed)
+}
+
+func (s *SnapshotBlock) SeedHash() *string {
+	if s.block.SeedHash == nil {
+		return nil
+	}
+	seedHash := s.block.SeedHash.String()
+	return &seedHash
+}
+
+func (s *SnapshotBlock) AccountBlocks(args struct {
+	Offset int32
+	Count  int32
+}) ([]*AccountBlock, error) {
+	if _, _, err := pageRange(0, args.Offset, args.Count); err != nil {
+		return nil, err
+	}
+	if s.block.Height <= 1 {
+		return nil, nil
+	}
+	chunks, err := s.chain.GetSubLedger(s.block.Height-1, s.block.Height)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*ledger.AccountBlock
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil && chunk.SnapshotBlock.Hash == s.block.Hash {
+			blocks = chunk.AccountBlocks
+		}
+	}
+	start, end, _ := pageRange(len(blocks), args.Offset, args.Count)
+	result := make([]*AccountBlock, 0, end-start)
+	for _, block := range blocks[start:end] {
+		result = append(result, &AccountBlock{chain: s.chain, block: block})
+	}
+	return result, nil
+}
+
+type AccountBlock struct {
+	chain chain.Chain
+	block *ledger.AccountBlock
+}
+
+func newAccountBlock(c chain.Chain, block *ledger.AccountBlock) *AccountBlock {
+	if block == nil {
+		return nil
+	}
+	return &AccountBlock{chain: c, block: block}
+}
+
+func (a *AccountBlock) Hash() string {
+	return a.block.Hash.String()
+}
+
+func (a *AccountBlock) PrevHash() string {
+	return a.block.PrevHash.String()
+}
+
+func (a *AccountBlock) Height() Long {
+	return Long(a.block.Height)
+}
+
+func (a *AccountBlock) BlockType() int32 {
+	return int32(a.block.BlockType)
+}
+
+func (a *AccountBlock) IsSend() bool {
+	return a.block.IsSendBlock()
+}
+
+func (a *AccountBlock) Account() *Account {
+	return &Account{chain: a.chain, address: a.block.AccountAddress}
+}
+
+func (a *AccountBlock) ToAddress() string {
+	return a.block.ToAddress.String()
+}
+
+func (a *AccountBlock) Amount() string {
+	return bigIntToString(a.block.Amount)
+}
+
+func (a *AccountBlock) Token() (*TokenInfo, error) {
+	if !a.block.IsSendBlock() {
+		return nil, nil
+	}
+	return newTokenInfo(a.chain, a.block.TokenId)
+}
+
+func (a *AccountBlock) Fee() string {
+	return bigIntToString(a.block.Fee)
+}
+
+func (a *AccountBlock) Data() string {
+	return base64.StdEncoding.EncodeToString(a.block.Data)
+}
+
+func (a *AccountBlock) Quota() Long {
+	return Long(a.block.Quota)
+}
+
+func (a *AccountBlock) QuotaUsed() Long {
+	return Long(a.block.QuotaUsed)
+}
+
+func (a *AccountBlock) Difficulty() *string {
+	if a.block.Difficulty == nil {
+		return nil
+	}
+	difficulty := a.block.Difficulty.String()
+	return &difficulty
+}
+
+func (a *AccountBlock) Nonce() *string {
+	if len(a.block.Nonce) == 0 {
+		return nil
+	}
+	nonce := base64.StdEncoding.EncodeToString(a.block.Nonce)
+	return &nonce
+}
+
+func (a *AccountBlock) LogHash() *string {
+	if a.block.LogHash == nil {
+		return nil
+	}
+	logHash := a.block.LogHash.String()
+	return &logHash
+}
+
+func (a *AccountBlock) VmLogs() ([]*VmLog, error) {
+	if a.block.LogHash == nil {
+		return nil, nil
+	}
+	logList, err := a.chain.GetVmLogList(a.block.LogHash)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*VmLog, 0, len(logList))
+	for _, log := range logList {
+		result = append(result, &VmLog{log: log})
+	}
+	return result, nil
+}
+
+func (a *AccountBlock) SendBlock() (*AccountBlock, error) {
+	if !a.block.IsReceiveBlock() || a.block.FromBlockHash.IsZero() {
+		return nil, nil
+	}
+	block, err := a.chain.GetCompleteBlockByHash(a.block.FromBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountBlock(a.chain, block), nil
+}
+
+func (a *AccountBlock) ReceiveBlock() (*AccountBlock, error) {
+	if !a.block.IsSendBlock() {
+		return nil, nil
+	}
+	block, err := a.chain.GetReceiveAbBySendAb(a.block.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountBlock(a.chain, block), nil
+}
+
+func (a *AccountBlock) SendBlockList() []*AccountBlock {
+	result := make([]*AccountBlock, 0, len(a.block.SendBlockList))
+	for _, sendBlock := range a.block.SendBlockList {
+		result = append(result, &AccountBlock{chain: a.chain, block: sendBlock})
+	}
+	return result
+}
+
+func (a *AccountBlock) ConfirmedSnapshotBlock() (*SnapshotBlock, error) {
+	sb, err := a.chain.GetConfirmSnapshotHeaderByAbHash(a.block.Hash)
+	if err != nil || sb == nil {
+		return nil, err
+	}
+	return &SnapshotBlock{chain: a.chain, block: sb}, nil
+}
+
+func (a *AccountBlock) Confirmations() (Long, error) {
+	confirmedTimes, err := a.chain.GetConfirmedTimes(a.block.Hash)
+	return Long(confirmedTimes), err
+}
+
+type VmLog struct {
+	log *ledger.VmLog
+}
+
+func (v *VmLog) Topics() []string {
+	topics := make([]string, 0, len(v.log.Topics))
+	for _, topic := range v.log.Topics {
+		topics = append(topics, topic.String())
+	}
+	return topics
+}
+
+func (v *VmLog) Data() string {
+	return base64.StdEncoding.EncodeToString(v.log.Data)
+}
+
+type TokenInfo struct {
+	chain   chain.Chain
+	tokenId types.TokenTypeId
+	info    *types.TokenInfo
+}
+
+func newTokenInfo(c chain.Chain, tokenId types.TokenTypeId) (*TokenInfo, error) {
+	info, err := c.GetTokenInfoById(tokenId)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return &TokenInfo{chain: c, tokenId: tokenId, info: info}, nil
+}
+
+func (t *TokenInfo) TokenId() string {
+	return t.tokenId.String()
+}
+
+func (t *TokenInfo) Name() string {
+	return t.info.TokenName
+}
+
+func (t *TokenInfo) Symbol() string {
+	return t.info.TokenSymbol
+}
+
+func (t *TokenInfo) TotalSupply() string {
+	return bigIntToString(t.info.TotalSupply)
+}
+
+func (t *TokenInfo) Decimals() int32 {
+	return int32(t.info.Decimals)
+}
+
+func (t *TokenInfo) Owner() *Account {
+	return &Account{chain: t.chain, address: t.info.Owner}
+}
+
+func (t *TokenInfo) MaxSupply() string {
+	return bigIntToString(t.info.MaxSupply)
+}
+
+func (t *TokenInfo) IsReIssuable() bool {
+	return t.info.IsReIssuable
+}
+
+func (t *TokenInfo) OwnerBurnOnly() bool {
+	return t.info.OwnerBurnOnly
+}
+
+func (t *TokenInfo) Index() int32 {
+	return int32(t.info.Index)
+}
+
+type Balance struct {
+	chain   chain.Chain
+	tokenId types.TokenTypeId
+	amount  *big.Int
+}
+
+func (b *Balance) Token() (*TokenInfo, error) {
+	token, err := newTokenInfo(b.chain, b.tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, errors.New(fmt.Sprintf("token %s is not exist", b.tokenId))
+	}
+	return token, nil
+}
+
+func (b *Balance) Amount() string {
+	return bigIntToString(b.amount)
+}
+
+type Account struct {
+	chain   chain.Chain
+	address types.Address
+}
+
+func (a *Account) Address() string {
+	return a.address.String()
+}
+
+func (a *Account) IsContract() (bool, error) {
+	return a.chain.IsContractAccount(a.address)
+}
+
+func (a *Account) Height() (Long, error) {
+	height, err := a.chain.GetLatestAccountHeight(a.address)
+	return Long(height), err
+}
+
+func (a *Account) LatestBlock() (*AccountBlock, error) {
+	block, err := a.chain.GetLatestAccountBlock(a.address)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountBlock(a.chain, block), nil
+}
+
+func (a *Account) Balance(args struct{ TokenId string }) (string, error) {
+	tokenId, err := types.HexToTokenTypeId(args.TokenId)
+	if err != nil {
+		return "", err
+	}
+	balance, err := a.chain.GetBalance(a.address, tokenId)
+	if err != nil {
+		return "", err
+	}
+	return bigIntToString(balance), nil
+}
+
+func (a *Account) Balances(args struct {
+	Offset int32
+	Count  int32
+}) ([]*Balance, error) {
+	if _, _, err := pageRange(0, args.Offset, args.Count); err != nil {
+		return nil, err
+	}
+	balanceMap, err := a.chain.GetBalanceMap(a.address)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Balance, 0, len(balanceMap))
+	for tokenId, amount := range balanceMap {
+		result = append(result, &Balance{chain: a.chain, tokenId: tokenId, amount: amount})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].tokenId.String() < result[j].tokenId.String()
+	})
+	start, end, _ := pageRange(len(result), args.Offset, args.Count)
+	return result[start:end], nil
+}
+
+func (a *Account) Blocks(args struct {
+	Height *Long
+	Count  int32
+}) ([]*AccountBlock, error) {
+	count, err := checkCount(args.Count)
+	if err != nil {
+		return nil, err
+	}
+	var height uint64
+	if args.Height != nil {
+		height = uint64(*args.Height)
+	} else if height, err = a.chain.GetLatestAccountHeight(a.address); err != nil {
+		return nil, err
+	}
+	if height == 0 {
+		return nil, nil
+	}
+
+	blocks, err := a.chain.GetAccountBlocksByHeight(a.address, height, count)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*AccountBlock, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, &AccountBlock{chain: a.chain, block: block})
+	}
+	return result, nil
+}
@@ -0,0 +1,231 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockChain struct {
+	chain.Chain
+
+	sb      *ledger.SnapshotBlock
+	address types.Address
+	balance *big.Int
+}
+
+func (c *mockChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height != c.sb.Height {
+		return nil, nil
+	}
+	return c.sb, nil
+}
+
+func (c *mockChain) GetBalanceMap(addr types.Address) (map[types.TokenTypeId]*big.Int, error) {
+	if addr != c.address {
+		return nil, nil
+	}
+	return map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: c.balance}, nil
+}
+
+func (c *mockChain) GetTokenInfoById(tokenId types.TokenTypeId) (*types.TokenInfo, error) {
+	if tokenId != ledger.ViteTokenId {
+		return nil, nil
+	}
+	return &types.TokenInfo{TokenName: "Vite Token", TokenSymbol: "VITE", Decimals: 18, Owner: c.address}, nil
+}
+
+func newMockChain(t *testing.T) *mockChain {
+	address, _, err := types.CreateAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1560000000, 0)
+	return &mockChain{
+		sb: &ledger.SnapshotBlock{
+			Hash:      types.DataHash([]byte("sb")),
+			Height:    10,
+			Timestamp: &now,
+		},
+		address: address,
+		balance: big.NewInt(1000),
+	}
+}
+
+func TestHandler(t *testing.T) {
+	c := newMockChain(t)
+	h, err := NewHandler(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `query ($address: String!) {
+		snapshotBlock(height: 10) { hash height timestamp }
+		missing: snapshotBlock(height: 11) { hash }
+		account(address: $address) {
+			balances { amount token { symbol owner { address } } }
+		}
+	}`
+	body, _ := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"address": c.address.String()},
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status is %d, body is %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			SnapshotBlock *struct {
+				Hash      string
+				Height    uint64
+				Timestamp int64
+			}
+			Missing *struct{ Hash string }
+			Account struct {
+				Balances []struct {
+					Amount string
+					Token  struct {
+						Symbol string
+						Owner  struct{ Address string }
+					}
+				}
+			}
+		}
+		Errors []interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors %v", response.Errors)
+	}
+
+	sb := response.Data.SnapshotBlock
+	if sb == nil || sb.Hash != c.sb.Hash.String() || sb.Height != 10 || sb.Timestamp != c.sb.Timestamp.Unix() {
+		t.Fatalf("unexpected snapshot block %+v", sb)
+	}
+	if response.Data.Missing != nil {
+		t.Fatalf("snapshot block 11 should be null")
+	}
+	balances := response.Data.Account.Balances
+	if len(balances) != 1 || balances[0].Amount != "1000" || balances[0].Token.Symbol != "VITE" ||
+		balances[0].Token.Owner.Address != c.address.String() {
+		t.Fatalf("unexpected balances %+v", balances)
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	c := newMockChain(t)
+	h, err := NewHandler(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := func(query string) (data map[string]interface{}, errors []interface{}) {
+		body, _ := json.Marshal(map[string]interface{}{"query": query})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+		var response struct {
+			Data   map[string]interface{}
+			Errors []interface{}
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Data, response.Errors
+	}
+
+	address := c.address.String()
+	data, errors := exec(`{ account(address: "` + address + `") { balances(offset: 1) { amount } } }`)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+	if balances := data["account"].(map[string]interface{})["balances"].([]interface{}); len(balances) != 0 {
+		t.Fatalf("balances after offset 1 should be empty, but get %v", balances)
+	}
+
+	if _, errors = exec(`{ account(address: "` + address + `") { balances(count: 101) { amount } } }`); len(errors) == 0 {
+		t.Fatal("count larger than 100 should be refused")
+	}
+
+	// the depth is 9
+	deep := `{ account(address: "` + address + `") { latestBlock { account { latestBlock { account { latestBlock { account { latestBlock { account { address } } } } } } } } } }`
+	if _, errors = exec(deep); len(errors) == 0 || !strings.Contains(fmt.Sprint(errors), "depth") {
+		t.Fatalf("query deeper than 8 should be refused: %v", errors)
+	}
+}
+
+func TestLongUnmarshal(t *testing.T) {
+	cases := []struct {
+		input    interface{}
+		expected Long
+		err      bool
+	}{
+		{int32(10), 10, false},
+		{"18446744073709551615", 18446744073709551615, false},
+		{float64(4294967296), 4294967296, false},
+		{int32(-1), 0, true},
+		{float64(1.5), 0, true},
+		{"abc", 0, true},
+		{true, 0, true},
+	}
+	for _, c := range cases {
+		var l Long
+		err := l.UnmarshalGraphQL(c.input)
+		if (err != nil) != c.err {
+			t.Fatalf("input %v, err is %v", c.input, err)
+		}
+		if err == nil && l != c.expected {
+			t.Fatalf("input %v, result is %d, expected %d", c.input, l, c.expected)
+		}
+	}
+}
+
+func TestQueryCost(t *testing.T) {
+	cases := []struct {
+		query     string
+		variables map[string]interface{}
+		cost      uint64
+	}{
+		{`{ snapshotBlock { hash height } }`, nil, 3},
+		{`{ snapshotBlocks(height: 1, count: 10) { hash } }`, nil, 11},
+		{`{ b: snapshotBlocks(height: 1, count: 10) { accountBlocks(count: 2) { hash } } }`, nil, 31},
+		// the count is omitted, the list is estimated by the max count
+		{`{ snapshotBlocks(height: 1, count: 100) { accountBlocks { hash } } }`, nil, 10101},
+		{`query ($c: Int = 5) { snapshotBlocks(height: 1, count: $c) { hash } }`, nil, 6},
+		{`query ($c: Int = 5) { snapshotBlocks(height: 1, count: $c) { hash } }`, map[string]interface{}{"c": float64(7)}, 8},
+		{`query { snapshotBlocks(height: 1, count: 10) { ...f } } fragment f on SnapshotBlock { hash ... on SnapshotBlock { height } }`, nil, 21},
+		{`{ snapshotBlocks(height: 1, count: 100) { accountBlocks { vmLogs { data } } } }`, nil, maxQueryCost + 1},
+	}
+	for i, c := range cases {
+		cost, err := queryCost(c.query, "", c.variables)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if cost != c.cost {
+			t.Fatalf("case %d: cost should be %d, but get %d", i, c.cost, cost)
+		}
+	}
+
+	h, err := NewHandler(newMockChain(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]interface{}{"query": cases[len(cases)-1].query})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	if !strings.Contains(w.Body.String(), "exceeds the limit") {
+		t.Fatalf("query over the cost limit should be refused: %s", w.Body.String())
+	}
+}
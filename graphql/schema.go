@@ -0,0 +1,101 @@
+package graphql
+
+// schema is the GraphQL schema of the ledger. Hashes, addresses and token ids are strings in the
+// same format as the JSON-RPC api, big integers are decimal strings and bytes are base64 strings.
+const schema string = `
+    # Long is a 64 bit unsigned integer.
+    scalar Long
+
+    schema {
+        query: Query
+    }
+
+    type Query {
+        # SnapshotBlock returns the snapshot block by height or hash, or the latest snapshot block if both are omitted.
+        snapshotBlock(height: Long, hash: String): SnapshotBlock
+        # SnapshotBlocks returns at most 100 snapshot blocks from the height upwards.
+        snapshotBlocks(height: Long!, count: Int!): [SnapshotBlock!]!
+        # AccountBlock returns the account block by hash.
+        accountBlock(hash: String!): AccountBlock
+        # Account returns the account by address.
+        account(address: String!): Account!
+        # TokenInfo returns the token by token id.
+        tokenInfo(tokenId: String!): TokenInfo
+    }
+
+    type SnapshotBlock {
+        hash: String!
+        prevHash: String!
+        height: Long!
+        producer: Account!
+        timestamp: Long!
+        seed: Long!
+        seedHash: String
+        # AccountBlocks returns at most 100 account blocks confirmed by the snapshot block from the offset.
+        accountBlocks(offset: Int = 0, count: Int = 100): [AccountBlock!]!
+    }
+
+    type AccountBlock {
+        hash: String!
+        prevHash: String!
+        height: Long!
+        blockType: Int!
+        isSend: Boolean!
+        account: Account!
+        toAddress: String!
+        amount: String!
+        token: TokenInfo
+        fee: String!
+        data: String!
+        quota: Long!
+        quotaUsed: Long!
+        difficulty: String
+        nonce: String
+        logHash: String
+        vmLogs: [VmLog!]!
+        # SendBlock is the send block of a receive block.
+        sendBlock: AccountBlock
+        # ReceiveBlock is the receive block of a send block, null if it is not received.
+        receiveBlock: AccountBlock
+        # SendBlockList are the send blocks generated by a contract receive block.
+        sendBlockList: [AccountBlock!]!
+        # ConfirmedSnapshotBlock is the first snapshot block which confirms the block.
+        confirmedSnapshotBlock: SnapshotBlock
+        confirmations: Long!
+    }
+
+    type VmLog {
+        topics: [String!]!
+        data: String!
+    }
+
+    type TokenInfo {
+        tokenId: String!
+        name: String!
+        symbol: String!
+        totalSupply: String!
+        decimals: Int!
+        owner: Account!
+        maxSupply: String!
+        isReIssuable: Boolean!
+        ownerBurnOnly: Boolean!
+        index: Int!
+    }
+
+    type Balance {
+        token: TokenInfo!
+        amount: String!
+    }
+
+    type Account {
+        address: String!
+        isContract: Boolean!
+        height: Long!
+        latestBlock: AccountBlock
+        balance(tokenId: String!): String!
+        # Balances returns at most 100 balances ordered by token id from the offset.
+        balances(offset: Int = 0, count: Int = 100): [Balance!]!
+        # Blocks returns at most 100 account blocks from the height downwards, the latest block if height is omitted.
+        blocks(height: Long, count: Int!): [AccountBlock!]!
+    }
+`
@@ -0,0 +1,94 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+var log = log15.New("module", "graphql")
+
+const (
+	// maxRequestContentLength is the limit of the request body, same as the JSON-RPC over HTTP
+	maxRequestContentLength = 1024 * 512
+	// maxQueryDepth is the limit of the field nesting, a block with its account and blocks of the account is 4
+	maxQueryDepth = 8
+	// maxParallelism is the limit of resolvers run in parallel for a request
+	maxParallelism = 8
+)
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type handler struct {
+	schema *graphql.Schema
+}
+
+// NewHandler returns a http handler which serves GraphQL queries resolved against the chain.
+// A query is a POST request with a JSON body of query, operationName and variables, or
+// a GET request with the query in the query parameter. The queries whose estimated cost
+// exceeds maxQueryCost are refused before execution.
+func NewHandler(c chain.Chain) (http.Handler, error) {
+	s, err := graphql.ParseSchema(schema, &Resolver{chain: c}, graphql.MaxDepth(maxQueryDepth), graphql.MaxParallelism(maxParallelism))
+	if err != nil {
+		return nil, err
+	}
+	return &handler{schema: s}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if r.ContentLength > maxRequestContentLength {
+			http.Error(w, "content length too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		body := http.MaxBytesReader(w, r.Body, maxRequestContentLength)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var response *graphql.Response
+	if cost, err := queryCost(req.Query, req.OperationName, req.Variables); err != nil {
+		// report the syntax errors of the schema parser, the cost parser only understands the valid queries
+		errs := h.schema.Validate(req.Query)
+		if len(errs) == 0 {
+			errs = []*errors.QueryError{errors.Errorf("%v", err)}
+		}
+		response = &graphql.Response{Errors: errs}
+	} else if cost > maxQueryCost {
+		response = &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("query cost %d exceeds the limit %d", cost, maxQueryCost)}}
+	} else {
+		response = h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		log.Error("marshal response failed, error is "+err.Error(), "method", "ServeHTTP")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
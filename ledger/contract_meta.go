@@ -1,6 +1,9 @@
 package ledger
 
-import "github.com/vitelabs/go-vite/common/types"
+import (
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+)
 
 type ContractMeta struct {
 	Gid types.Gid // belong to the consensus group id
@@ -55,10 +58,35 @@ func (cm *ContractMeta) Deserialize(buf []byte) error {
 	return nil
 }
 
-func GetBuiltinContractMeta(addr types.Address) *ContractMeta {
+// IsBuiltinContractAddrInUse checks whether addr is a built-in contract in use at the snapshot height,
+// the multisig contract is in use after the multisig fork
+func IsBuiltinContractAddrInUse(addr types.Address, sbHeight uint64) bool {
+	if addr == types.AddressMultisig {
+		return fork.IsMultisigFork(sbHeight)
+	}
+	return types.IsBuiltinContractAddrInUse(addr)
+}
+
+// IsBuiltinContractAddrInUseWithoutQuota checks whether addr is a built-in contract without quota limit at the snapshot height
+func IsBuiltinContractAddrInUseWithoutQuota(addr types.Address, sbHeight uint64) bool {
+	if addr == types.AddressMultisig {
+		return fork.IsMultisigFork(sbHeight)
+	}
+	return types.IsBuiltinContractAddrInUseWithoutQuota(addr)
+}
+
+// BuiltinContracts returns the built-in contracts in use at the snapshot height
+func BuiltinContracts(sbHeight uint64) []types.Address {
+	if fork.IsMultisigFork(sbHeight) {
+		return append(append([]types.Address{}, types.BuiltinContracts...), types.AddressMultisig)
+	}
+	return types.BuiltinContracts
+}
+
+func GetBuiltinContractMeta(addr types.Address, sbHeight uint64) *ContractMeta {
 	if types.IsBuiltinContractAddrInUseWithSendConfirm(addr) {
 		return &ContractMeta{types.DELEGATE_GID, 1, types.Hash{}, getBuiltinContractQuotaRatio(addr), 0}
-	} else if types.IsBuiltinContractAddrInUse(addr) {
+	} else if IsBuiltinContractAddrInUse(addr, sbHeight) {
 		return &ContractMeta{types.DELEGATE_GID, 0, types.Hash{}, getBuiltinContractQuotaRatio(addr), 0}
 	}
 	return nil
@@ -0,0 +1,118 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// snapshot blocks requested from the full peers at a time
+const headerBatchSize = 100
+
+// Backend is the full peers which serve the light nodes
+type Backend interface {
+	// GetSnapshotBlocksByHeight returns no more than count continuous snapshot blocks from the height, lower to higher
+	GetSnapshotBlocksByHeight(height uint64, count uint64) ([]*ledger.SnapshotBlock, error)
+	// GetAccountProof returns the proof of the account block in the height
+	GetAccountProof(addr types.Address, height uint64) (*AccountProof, error)
+}
+
+// Client is the light client, it syncs snapshot headers only and requests account blocks with proofs on demand
+type Client struct {
+	headers *HeaderChain
+	backend Backend
+	log     log15.Logger
+}
+
+func NewClient(headers *HeaderChain, backend Backend) *Client {
+	return &Client{
+		headers: headers,
+		backend: backend,
+		log:     log15.New("module", "light"),
+	}
+}
+
+// Headers returns the verified snapshot header chain
+func (c *Client) Headers() *HeaderChain {
+	return c.headers
+}
+
+// Sync requests and verifies the snapshot blocks after the local latest one, until the backend has no more
+func (c *Client) Sync() error {
+	for {
+		latest := c.headers.Latest()
+		blocks, err := c.backend.GetSnapshotBlocksByHeight(latest.Height+1, headerBatchSize)
+		if err != nil {
+			c.log.Warn(fmt.Sprintf("failed to get snapshot blocks from %d: %v", latest.Height+1, err), "method", "Sync")
+			return err
+		}
+		if len(blocks) == 0 {
+			return nil
+		}
+
+		if _, err = c.headers.Insert(blocks); err != nil {
+			c.log.Error(err.Error(), "method", "Sync")
+			return err
+		}
+	}
+}
+
+// GetAccountBlockByHeight returns the account block proved against the synced snapshot headers
+func (c *Client) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error) {
+	proof, err := c.backend.GetAccountProof(addr, height)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil || len(proof.Blocks) == 0 {
+		return nil, errProofEmpty
+	}
+
+	header := c.headers.GetHeaderByHeight(proof.SnapshotHeight)
+	if header == nil {
+		return nil, errHeaderMissing
+	}
+
+	block, err := proof.Verify(header)
+	if err != nil {
+		return nil, err
+	}
+	if block.AccountAddress != addr || block.Height != height {
+		return nil, errors.New("account proof is not the requested block")
+	}
+
+	return block, nil
+}
+
+// chainBackend serves the light nodes with the local full chain
+type chainBackend struct {
+	chain backendChain
+}
+
+type backendChain interface {
+	proofChain
+	GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error)
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+}
+
+// NewChainBackend returns the Backend of the full node, chain.Chain satisfies the param
+func NewChainBackend(chain backendChain) Backend {
+	return &chainBackend{chain: chain}
+}
+
+func (b *chainBackend) GetSnapshotBlocksByHeight(height uint64, count uint64) ([]*ledger.SnapshotBlock, error) {
+	latest := b.chain.GetLatestSnapshotBlock()
+	if latest == nil || height > latest.Height || count == 0 {
+		return nil, nil
+	}
+	if count > headerBatchSize {
+		count = headerBatchSize
+	}
+	return b.chain.GetSnapshotBlocksByHeight(height, true, count)
+}
+
+func (b *chainBackend) GetAccountProof(addr types.Address, height uint64) (*AccountProof, error) {
+	return BuildAccountProof(b.chain, addr, height)
+}
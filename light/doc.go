@@ -0,0 +1,16 @@
+/*
+Package light is a library to verify the ledger with the snapshot headers only, it is not a light node mode.
+
+The snapshot blocks are synced from a trusted checkpoint and verified by the hash, the signature and
+the schedule of the snapshot consensus group by ScheduleVerifier. The election of the producers needs
+the votes in the state, so the producers are trusted by the Checkpoint instead, the producers of a
+later checkpoint are trusted since its height, to follow the registrations of the group.
+
+The account blocks are proved against the snapshot content of the verified headers by AccountProof.
+
+The package doesn't provide, and nothing in the node uses it yet:
+  - the Edge node mode, which still runs the full chain, pool and verifier
+  - the p2p messages to serve the Backend from the full peers, NewChainBackend serves it in process only
+  - the balance proofs, the snapshot blocks have no commitment to the state to prove the balances against
+*/
+package light
@@ -0,0 +1,160 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	errHeaderNotContinuous = errors.New("snapshot header is not continuous with the local chain")
+	errHeaderInvalidHash   = errors.New("snapshot header hash is invalid")
+	errHeaderInvalidSig    = errors.New("snapshot header signature is invalid")
+	errHeaderInvalidTime   = errors.New("snapshot header timestamp is not later than the previous one")
+	errHeaderInvalidSBP    = errors.New("snapshot header is not produced by the consensus group")
+	errHeaderMissing       = errors.New("snapshot header is missing")
+)
+
+// ProducerVerifier checks whether a snapshot block is produced by the snapshot consensus group,
+// consensus.Verifier satisfies it on the nodes which have the full state.
+type ProducerVerifier interface {
+	VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error)
+}
+
+// producerSet is the trusted producers of a checkpoint, the light client cannot compute the election result without the state.
+// It accepts the given addresses at any time, so it should be wrapped by a ScheduleVerifier.
+type producerSet map[types.Address]struct{}
+
+// NewProducerSet returns a ProducerVerifier accepts the given producers
+func NewProducerSet(producers []types.Address) ProducerVerifier {
+	set := make(producerSet, len(producers))
+	for _, addr := range producers {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// GenesisProducers returns the block producing addresses registered in the snapshot consensus group of genesis,
+// they are the trusted producers of the genesis only, the later registrations are trusted by the checkpoints
+func GenesisProducers(genesis *config.Genesis) (producers []types.Address) {
+	if genesis == nil || genesis.GovernanceInfo == nil {
+		return nil
+	}
+
+	for _, info := range genesis.GovernanceInfo.RegistrationInfoMap[types.SNAPSHOT_GID.String()] {
+		if info.BlockProducingAddress != nil {
+			producers = append(producers, *info.BlockProducingAddress)
+		}
+		producers = append(producers, info.HistoryAddressList...)
+	}
+	return
+}
+
+func (s producerSet) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	_, ok := s[block.Producer()]
+	return ok, nil
+}
+
+// HeaderChain keeps the verified snapshot blocks from a trusted checkpoint, without any account block or state.
+// The snapshot content is kept, because the hash of snapshot block commits to it,
+// and it is the commitment the account block proofs are verified against.
+type HeaderChain struct {
+	mu       sync.RWMutex
+	headers  []*ledger.SnapshotBlock // headers[i].Height == checkpoint.Height + i
+	hashes   map[types.Hash]uint64
+	producer ProducerVerifier
+}
+
+// NewHeaderChain returns a HeaderChain starts from the trusted checkpoint, usually the genesis snapshot block
+func NewHeaderChain(checkpoint *ledger.SnapshotBlock, producer ProducerVerifier) *HeaderChain {
+	return &HeaderChain{
+		headers:  []*ledger.SnapshotBlock{checkpoint},
+		hashes:   map[types.Hash]uint64{checkpoint.Hash: checkpoint.Height},
+		producer: producer,
+	}
+}
+
+// Latest returns the highest verified snapshot block
+func (hc *HeaderChain) Latest() *ledger.SnapshotBlock {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	return hc.headers[len(hc.headers)-1]
+}
+
+// GetHeaderByHeight returns nil if the height is not synced or lower than the checkpoint
+func (hc *HeaderChain) GetHeaderByHeight(height uint64) *ledger.SnapshotBlock {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	return hc.getHeaderByHeight(height)
+}
+
+func (hc *HeaderChain) getHeaderByHeight(height uint64) *ledger.SnapshotBlock {
+	first := hc.headers[0].Height
+	if height < first || height-first >= uint64(len(hc.headers)) {
+		return nil
+	}
+	return hc.headers[height-first]
+}
+
+// GetHeaderByHash returns nil if the block is not synced
+func (hc *HeaderChain) GetHeaderByHash(hash types.Hash) *ledger.SnapshotBlock {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	if height, ok := hc.hashes[hash]; ok {
+		return hc.getHeaderByHeight(height)
+	}
+	return nil
+}
+
+// Insert verifies the continuous snapshot blocks and appends them to the chain,
+// the blocks before the first invalid one are inserted.
+// Reorganization is not supported, so the blocks should be lower than the irreversible height of the full peers.
+func (hc *HeaderChain) Insert(blocks []*ledger.SnapshotBlock) (inserted int, err error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	for _, block := range blocks {
+		prev := hc.headers[len(hc.headers)-1]
+		if err = hc.verify(prev, block); err != nil {
+			return inserted, fmt.Errorf("failed to verify snapshot block %s/%d: %v", block.Hash, block.Height, err)
+		}
+
+		hc.headers = append(hc.headers, block)
+		hc.hashes[block.Hash] = block.Height
+		inserted++
+	}
+
+	return
+}
+
+func (hc *HeaderChain) verify(prev, block *ledger.SnapshotBlock) error {
+	if block.PrevHash != prev.Hash || block.Height != prev.Height+1 {
+		return errHeaderNotContinuous
+	}
+	if block.Timestamp == nil || !block.Timestamp.After(*prev.Timestamp) {
+		return errHeaderInvalidTime
+	}
+	if block.ComputeHash() != block.Hash {
+		return errHeaderInvalidHash
+	}
+	if len(block.Signature) == 0 || !block.VerifySignature() {
+		return errHeaderInvalidSig
+	}
+
+	ok, err := hc.producer.VerifySnapshotProducer(block)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errHeaderInvalidSBP
+	}
+
+	return nil
+}
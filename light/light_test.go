@@ -0,0 +1,338 @@
+package light
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockChain struct {
+	snapshots []*ledger.SnapshotBlock // snapshots[i].Height == i + 1
+	accounts  map[types.Address][]*ledger.AccountBlock
+	confirmed map[types.Hash]uint64
+}
+
+func (c *mockChain) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error) {
+	blocks := c.accounts[addr]
+	if height == 0 || height > uint64(len(blocks)) {
+		return nil, nil
+	}
+	return blocks[height-1], nil
+}
+
+func (c *mockChain) GetConfirmSnapshotBlockByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	if height, ok := c.confirmed[abHash]; ok {
+		return c.snapshots[height-1], nil
+	}
+	return nil, nil
+}
+
+func (c *mockChain) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	end := height - 1 + count
+	if end > uint64(len(c.snapshots)) {
+		end = uint64(len(c.snapshots))
+	}
+	return c.snapshots[height-1 : end], nil
+}
+
+func (c *mockChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.snapshots[len(c.snapshots)-1]
+}
+
+// newMockChain returns a chain of which every snapshot block snapshots 2 blocks of the account
+func newMockChain(t *testing.T, sbpKey, accountKey ed25519.PrivateKey, snapshots int) *mockChain {
+	point := &config.ForkPoint{Height: 100000, Version: 1}
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork: point, DexFork: point, DexFeeFork: point, StemFork: point,
+		LeafFork: point, EarthFork: point, DexMiningFork: point, DexRobotFork: point, MultisigFork: point,
+	})
+
+	addr := types.PubkeyToAddress(accountKey.PubByte())
+	c := &mockChain{
+		accounts:  make(map[types.Address][]*ledger.AccountBlock),
+		confirmed: make(map[types.Hash]uint64),
+	}
+
+	now := time.Unix(1000, 0)
+	genesis := &ledger.SnapshotBlock{Height: 1, Timestamp: &now}
+	genesis.Hash = genesis.ComputeHash()
+	c.snapshots = append(c.snapshots, genesis)
+
+	var prevAb *ledger.AccountBlock
+	for i := 1; i < snapshots; i++ {
+		for j := 0; j < 2; j++ {
+			ab := &ledger.AccountBlock{
+				BlockType:      ledger.BlockTypeSendCall,
+				Height:         1,
+				AccountAddress: addr,
+				ToAddress:      addr,
+				Amount:         big.NewInt(int64(i)),
+				Fee:            big.NewInt(0),
+				PublicKey:      accountKey.PubByte(),
+			}
+			if prevAb != nil {
+				ab.PrevHash, ab.Height = prevAb.Hash, prevAb.Height+1
+			}
+			ab.Hash = ab.ComputeHash()
+			ab.Signature = ed25519.Sign(accountKey, ab.Hash.Bytes())
+			c.accounts[addr] = append(c.accounts[addr], ab)
+			c.confirmed[ab.Hash] = uint64(i + 1)
+			prevAb = ab
+		}
+
+		prev := c.snapshots[len(c.snapshots)-1]
+		timestamp := prev.Timestamp.Add(time.Second)
+		sb := &ledger.SnapshotBlock{
+			PrevHash:  prev.Hash,
+			Height:    prev.Height + 1,
+			Timestamp: &timestamp,
+			PublicKey: sbpKey.PubByte(),
+			SnapshotContent: ledger.SnapshotContent{
+				addr: {Hash: prevAb.Hash, Height: prevAb.Height},
+			},
+		}
+		sb.Hash = sb.ComputeHash()
+		sb.Signature = ed25519.Sign(sbpKey, sb.Hash.Bytes())
+		c.snapshots = append(c.snapshots, sb)
+	}
+
+	return c
+}
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestClient(t *testing.T) {
+	sbpKey, accountKey := generateKey(t), generateKey(t)
+	chain := newMockChain(t, sbpKey, accountKey, 250)
+	addr := types.PubkeyToAddress(accountKey.PubByte())
+
+	producers := NewProducerSet([]types.Address{types.PubkeyToAddress(sbpKey.PubByte())})
+	client := NewClient(NewHeaderChain(chain.snapshots[0], producers), NewChainBackend(chain))
+	if err := client.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if latest := client.Headers().Latest(); latest.Hash != chain.GetLatestSnapshotBlock().Hash {
+		t.Fatalf("sync stopped at %d", latest.Height)
+	}
+
+	for _, height := range []uint64{1, 2, 100, 498} {
+		block, err := client.GetAccountBlockByHeight(addr, height)
+		if err != nil {
+			t.Fatalf("failed to get account block %d: %v", height, err)
+		}
+		if block.Hash != chain.accounts[addr][height-1].Hash {
+			t.Fatalf("wrong account block %d", height)
+		}
+	}
+	if _, err := client.GetAccountBlockByHeight(addr, 499); err == nil {
+		t.Fatal("not existed block should not be returned")
+	}
+}
+
+func TestHeaderChain_Insert(t *testing.T) {
+	sbpKey, accountKey := generateKey(t), generateKey(t)
+	chain := newMockChain(t, sbpKey, accountKey, 5)
+
+	hc := NewHeaderChain(chain.snapshots[0], NewProducerSet(nil))
+	if n, err := hc.Insert(chain.snapshots[1:]); err == nil || n != 0 {
+		t.Fatal("block of unknown producer should be refused")
+	}
+
+	hc = NewHeaderChain(chain.snapshots[0], NewProducerSet([]types.Address{types.PubkeyToAddress(sbpKey.PubByte())}))
+	if _, err := hc.Insert(chain.snapshots[2:]); err == nil {
+		t.Fatal("discontinuous block should be refused")
+	}
+
+	// tamper the snapshot content
+	tampered := *chain.snapshots[1]
+	tampered.SnapshotContent = ledger.SnapshotContent{}
+	if _, err := hc.Insert([]*ledger.SnapshotBlock{&tampered}); err == nil {
+		t.Fatal("block with a wrong hash should be refused")
+	}
+
+	if n, err := hc.Insert(chain.snapshots[1:]); err != nil || n != 4 {
+		t.Fatalf("failed to insert blocks: %d %v", n, err)
+	}
+	if hc.GetHeaderByHash(chain.snapshots[3].Hash) != chain.snapshots[3] || hc.GetHeaderByHeight(6) != nil {
+		t.Fatal("wrong headers")
+	}
+}
+
+func TestAccountProof_Verify(t *testing.T) {
+	sbpKey, accountKey := generateKey(t), generateKey(t)
+	chain := newMockChain(t, sbpKey, accountKey, 5)
+	addr := types.PubkeyToAddress(accountKey.PubByte())
+
+	proof, err := BuildAccountProof(chain, addr, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.Blocks) != 2 || proof.SnapshotHeight != 3 {
+		t.Fatalf("wrong proof of %d blocks to snapshot %d", len(proof.Blocks), proof.SnapshotHeight)
+	}
+	header := chain.snapshots[2]
+	if _, err = proof.Verify(header); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = proof.Verify(chain.snapshots[3]); err == nil {
+		t.Fatal("proof should not be verified against other snapshot")
+	}
+
+	// the proved block is replaced
+	forged := *proof.Blocks[0]
+	forged.Amount = big.NewInt(1000)
+	forged.Hash = forged.ComputeHash()
+	forged.Signature = ed25519.Sign(accountKey, forged.Hash.Bytes())
+	forgedProof := &AccountProof{
+		SnapshotHeight: proof.SnapshotHeight,
+		SnapshotHash:   proof.SnapshotHash,
+		Blocks:         []*ledger.AccountBlock{&forged, proof.Blocks[1]},
+	}
+	if _, err = forgedProof.Verify(header); err != errProofNotContinuous {
+		t.Fatalf("forged block should be refused, got %v", err)
+	}
+
+	// the snapshotted block is missing
+	forgedProof.Blocks = proof.Blocks[:1]
+	if _, err = forgedProof.Verify(header); err != errProofInvalidSnapshot {
+		t.Fatalf("incomplete proof should be refused, got %v", err)
+	}
+}
+
+// nextSnapshot returns a snapshot block after prev signed by key, produced at t seconds after the genesis
+func nextSnapshot(prev *ledger.SnapshotBlock, key ed25519.PrivateKey, t time.Duration) *ledger.SnapshotBlock {
+	timestamp := time.Unix(1000, 0).Add(t)
+	sb := &ledger.SnapshotBlock{
+		PrevHash:  prev.Hash,
+		Height:    prev.Height + 1,
+		Timestamp: &timestamp,
+		PublicKey: key.PubByte(),
+	}
+	sb.Hash = sb.ComputeHash()
+	sb.Signature = ed25519.Sign(key, sb.Hash.Bytes())
+	return sb
+}
+
+func TestScheduleVerifier(t *testing.T) {
+	sbpKey1, sbpKey2, otherKey := generateKey(t), generateKey(t), generateKey(t)
+	genesis := newMockChain(t, sbpKey1, otherKey, 1).snapshots[0]
+	group := types.ConsensusGroupInfo{Gid: types.SNAPSHOT_GID, NodeCount: 2, Interval: 1, PerCount: 2, Repeat: 1}
+	newHeaderChain := func() *HeaderChain {
+		producers := NewProducerSet([]types.Address{
+			types.PubkeyToAddress(sbpKey1.PubByte()),
+			types.PubkeyToAddress(sbpKey2.PubByte()),
+		})
+		return NewHeaderChain(genesis, NewScheduleVerifier(*genesis.Timestamp, group, producers))
+	}
+
+	// a round is 4 seconds, the first 2 seconds are of position 0 and the others are of position 1
+	hc := newHeaderChain()
+	prev := genesis
+	for _, slot := range []struct {
+		key ed25519.PrivateKey
+		t   time.Duration
+	}{
+		{sbpKey1, time.Second}, {sbpKey2, 2 * time.Second}, {sbpKey2, 3 * time.Second},
+		{sbpKey2, 4 * time.Second}, {sbpKey1, 6 * time.Second}, {sbpKey1, 7 * time.Second},
+	} {
+		block := nextSnapshot(prev, slot.key, slot.t)
+		if _, err := hc.Insert([]*ledger.SnapshotBlock{block}); err != nil {
+			t.Fatalf("failed to insert the block at %v: %v", slot.t, err)
+		}
+		prev = block
+	}
+
+	// the producer of position 0 takes position 1 in the same round
+	hc = newHeaderChain()
+	if n, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(genesis, sbpKey1, time.Second)}); err != nil || n != 1 {
+		t.Fatalf("failed to insert blocks: %d %v", n, err)
+	}
+	if _, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(hc.Latest(), sbpKey1, 2*time.Second)}); err == nil {
+		t.Fatal("the producer of another position should be refused")
+	}
+
+	// another producer takes position 0 of the next round
+	if n, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(hc.Latest(), sbpKey1, 4*time.Second)}); err != nil || n != 1 {
+		t.Fatalf("failed to insert blocks: %d %v", n, err)
+	}
+	if _, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(hc.Latest(), sbpKey2, 5*time.Second)}); err == nil {
+		t.Fatal("the position of another producer should be refused")
+	}
+
+	for _, block := range []*ledger.SnapshotBlock{
+		nextSnapshot(genesis, sbpKey1, 1500*time.Millisecond),
+		nextSnapshot(genesis, sbpKey1, -time.Second),
+		nextSnapshot(genesis, otherKey, time.Second),
+	} {
+		if _, err := newHeaderChain().Insert([]*ledger.SnapshotBlock{block}); err == nil {
+			t.Fatalf("the block at %v by %s should be refused", block.Timestamp, block.Producer())
+		}
+	}
+}
+
+func TestScheduleVerifier_AddCheckpoint(t *testing.T) {
+	sbpKey1, sbpKey2, sbpKey3 := generateKey(t), generateKey(t), generateKey(t)
+	genesis := newMockChain(t, sbpKey1, sbpKey3, 1).snapshots[0]
+	group := types.ConsensusGroupInfo{Gid: types.SNAPSHOT_GID, NodeCount: 2, Interval: 1, PerCount: 2, Repeat: 1}
+	addr := func(key ed25519.PrivateKey) types.Address {
+		return types.PubkeyToAddress(key.PubByte())
+	}
+
+	// the producer 1 is replaced by the producer 3 since height 4
+	block2 := nextSnapshot(genesis, sbpKey1, time.Second)
+	block3 := nextSnapshot(block2, sbpKey2, 2*time.Second)
+	block4 := nextSnapshot(block3, sbpKey3, 4*time.Second)
+	checkpoint := Checkpoint{Height: 4, Hash: block4.Hash, Producers: []types.Address{addr(sbpKey2), addr(sbpKey3)}}
+	newHeaderChain := func(checkpoints ...Checkpoint) *HeaderChain {
+		sv := NewScheduleVerifier(*genesis.Timestamp, group, NewProducerSet([]types.Address{addr(sbpKey1), addr(sbpKey2)}))
+		for _, cp := range checkpoints {
+			if err := sv.AddCheckpoint(cp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return NewHeaderChain(genesis, sv)
+	}
+
+	if n, err := newHeaderChain().Insert([]*ledger.SnapshotBlock{block2, block3, block4}); err == nil || n != 2 {
+		t.Fatalf("the new producer should be refused without a checkpoint: %d %v", n, err)
+	}
+
+	forged := checkpoint
+	forged.Hash = types.Hash{1}
+	if n, err := newHeaderChain(forged).Insert([]*ledger.SnapshotBlock{block2, block3, block4}); err == nil || n != 2 {
+		t.Fatalf("the block of another hash at the checkpoint should be refused: %d %v", n, err)
+	}
+
+	hc := newHeaderChain(checkpoint)
+	if n, err := hc.Insert([]*ledger.SnapshotBlock{block2, block3, block4}); err != nil || n != 3 {
+		t.Fatalf("failed to insert blocks: %d %v", n, err)
+	}
+	if _, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(block4, sbpKey1, 6*time.Second)}); err == nil {
+		t.Fatal("the replaced producer should be refused since the checkpoint")
+	}
+	if _, err := hc.Insert([]*ledger.SnapshotBlock{nextSnapshot(block4, sbpKey2, 6*time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	sv := NewScheduleVerifier(*genesis.Timestamp, group, nil)
+	if err := sv.AddCheckpoint(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.AddCheckpoint(Checkpoint{Height: 3}); err != errCheckpointOrder {
+		t.Fatalf("the lower checkpoint should be refused, got %v", err)
+	}
+}
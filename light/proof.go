@@ -0,0 +1,128 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// the account blocks between the proved block and the snapshotted one
+const maxProofLength = 1000
+
+var (
+	errProofEmpty           = errors.New("account proof is empty")
+	errProofTooLong         = errors.New("account proof is too long")
+	errProofNotSnapshotted  = errors.New("account block is not snapshotted")
+	errProofNotContinuous   = errors.New("account blocks of proof are not continuous")
+	errProofInvalidHash     = errors.New("account block hash is invalid")
+	errProofInvalidSig      = errors.New("account block signature is invalid")
+	errProofInvalidSnapshot = errors.New("account proof does not match the snapshot content")
+)
+
+/*
+ * AccountProof proves an account block is confirmed by a snapshot block.
+ * The snapshot content records the latest hash and height of the accounts snapshotted, and it is committed by the
+ * hash of snapshot block. So the account blocks from the proved one to the snapshotted one, which are chained by
+ * PrevHash, prove the first block against the verified snapshot header.
+ *
+ * The state (balances, storage) is not committed by snapshot blocks, so it can not be proved.
+ */
+type AccountProof struct {
+	SnapshotHeight uint64
+	SnapshotHash   types.Hash
+	// Blocks[0] is the proved block, the last one is the block recorded in the snapshot content
+	Blocks []*ledger.AccountBlock
+}
+
+// proofChain is the chain of the full nodes which build proofs
+type proofChain interface {
+	GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error)
+	GetConfirmSnapshotBlockByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error)
+}
+
+// BuildAccountProof returns the proof of the account block in the height, the block must be snapshotted
+func BuildAccountProof(chain proofChain, addr types.Address, height uint64) (*AccountProof, error) {
+	block, err := chain.GetAccountBlockByHeight(addr, height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("account block %s/%d is not existed", addr, height)
+	}
+
+	snapshot, err := chain.GetConfirmSnapshotBlockByAbHash(block.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, errProofNotSnapshotted
+	}
+
+	head, ok := snapshot.SnapshotContent[addr]
+	if !ok || head.Height < height {
+		return nil, errProofInvalidSnapshot
+	}
+	if head.Height-height >= maxProofLength {
+		return nil, errProofTooLong
+	}
+
+	proof := &AccountProof{
+		SnapshotHeight: snapshot.Height,
+		SnapshotHash:   snapshot.Hash,
+		Blocks:         make([]*ledger.AccountBlock, 0, head.Height-height+1),
+	}
+	proof.Blocks = append(proof.Blocks, block)
+	for h := height + 1; h <= head.Height; h++ {
+		if block, err = chain.GetAccountBlockByHeight(addr, h); err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("account block %s/%d is not existed", addr, h)
+		}
+		proof.Blocks = append(proof.Blocks, block)
+	}
+
+	return proof, nil
+}
+
+// Verify checks the proof against the snapshot header, which should be verified by HeaderChain
+func (p *AccountProof) Verify(header *ledger.SnapshotBlock) (*ledger.AccountBlock, error) {
+	if len(p.Blocks) == 0 {
+		return nil, errProofEmpty
+	}
+	if len(p.Blocks) > maxProofLength {
+		return nil, errProofTooLong
+	}
+	if header.Hash != p.SnapshotHash || header.Height != p.SnapshotHeight {
+		return nil, errProofInvalidSnapshot
+	}
+
+	first := p.Blocks[0]
+	for i, block := range p.Blocks {
+		if block.AccountAddress != first.AccountAddress {
+			return nil, errProofNotContinuous
+		}
+		if i > 0 {
+			prev := p.Blocks[i-1]
+			if block.PrevHash != prev.Hash || block.Height != prev.Height+1 {
+				return nil, errProofNotContinuous
+			}
+		}
+		if block.ComputeHash() != block.Hash {
+			return nil, errProofInvalidHash
+		}
+		if len(block.Signature) == 0 || !block.VerifySignature() {
+			return nil, errProofInvalidSig
+		}
+	}
+
+	last := p.Blocks[len(p.Blocks)-1]
+	head, ok := header.SnapshotContent[first.AccountAddress]
+	if !ok || head.Hash != last.Hash || head.Height != last.Height {
+		return nil, errProofInvalidSnapshot
+	}
+
+	return first, nil
+}
@@ -0,0 +1,159 @@
+package light
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	errCheckpointOrder  = errors.New("checkpoints must be added in order of height")
+	errHeaderCheckpoint = errors.New("snapshot header is not the trusted checkpoint")
+)
+
+// Checkpoint is a trusted snapshot block and the producers of the snapshot consensus group since it.
+// The producers registered in the group change over time, the light nodes follow the changes by
+// the checkpoints published with the releases, like the genesis.
+type Checkpoint struct {
+	Height    uint64
+	Hash      types.Hash
+	Producers []types.Address
+}
+
+// ScheduleVerifier checks the snapshot blocks against the schedule of the snapshot consensus group.
+// The producers of a round are elected by the votes in the state, which the light nodes don't have,
+// so it checks the parts of the schedule derived from the group info and the headers:
+//   - the block is produced at the start time of a slot
+//   - a position of the round is taken by one producer, and a producer takes one position of the round
+//   - the producer is accepted by the trusted producers of the latest checkpoint not higher than the block
+//
+// The blocks must be verified in order of height.
+type ScheduleVerifier struct {
+	info      *core.GroupInfo
+	producers ProducerVerifier // the trusted producers before the first checkpoint
+
+	mu          sync.Mutex
+	checkpoints []Checkpoint
+	trusted     []ProducerVerifier // the producers of checkpoints
+	checkpoint  int                // the index of checkpoint in use, -1 is producers
+
+	round     uint64
+	positions map[uint64]types.Address // the producers of the positions in the round
+	members   map[types.Address]uint64 // the positions of the producers in the round
+}
+
+// NewScheduleVerifier returns a ScheduleVerifier of the group, the genesis time is the timestamp of the genesis snapshot block,
+// producers are trusted before the first checkpoint, nil accepts no producer.
+func NewScheduleVerifier(genesisTime time.Time, group types.ConsensusGroupInfo, producers ProducerVerifier) *ScheduleVerifier {
+	if producers == nil {
+		producers = NewProducerSet(nil)
+	}
+	return &ScheduleVerifier{
+		info:       core.NewGroupInfo(genesisTime, group),
+		producers:  producers,
+		checkpoint: -1,
+	}
+}
+
+// SnapshotGroupInfo returns the snapshot consensus group of genesis, it's nil if genesis doesn't have it
+func SnapshotGroupInfo(genesis *config.Genesis) *types.ConsensusGroupInfo {
+	if genesis == nil || genesis.GovernanceInfo == nil {
+		return nil
+	}
+
+	info, ok := genesis.GovernanceInfo.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()]
+	if !ok || info == nil {
+		return nil
+	}
+	return &types.ConsensusGroupInfo{
+		Gid:       types.SNAPSHOT_GID,
+		NodeCount: info.NodeCount,
+		Interval:  info.Interval,
+		PerCount:  info.PerCount,
+		RandCount: info.RandCount,
+		RandRank:  info.RandRank,
+		Repeat:    info.Repeat,
+	}
+}
+
+// AddCheckpoint trusts the producers of checkpoint for the blocks since the checkpoint,
+// the checkpoints must be added in order of height
+func (s *ScheduleVerifier) AddCheckpoint(checkpoint Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.checkpoints); n > 0 && checkpoint.Height <= s.checkpoints[n-1].Height {
+		return errCheckpointOrder
+	}
+
+	s.checkpoints = append(s.checkpoints, checkpoint)
+	s.trusted = append(s.trusted, NewProducerSet(checkpoint.Producers))
+	return nil
+}
+
+func (s *ScheduleVerifier) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	round, position, ok := s.slot(*block.Timestamp)
+	if !ok {
+		return false, nil
+	}
+
+	checkpoint, producers := -1, s.producers
+	for i := len(s.checkpoints) - 1; i >= 0; i-- {
+		if block.Height >= s.checkpoints[i].Height {
+			if block.Height == s.checkpoints[i].Height && block.Hash != s.checkpoints[i].Hash {
+				return false, errHeaderCheckpoint
+			}
+			checkpoint, producers = i, s.trusted[i]
+			break
+		}
+	}
+
+	if ok, err := producers.VerifySnapshotProducer(block); err != nil || !ok {
+		return false, err
+	}
+
+	// the positions are taken by the new producers since the checkpoint
+	if s.positions == nil || round != s.round || checkpoint != s.checkpoint {
+		s.round = round
+		s.checkpoint = checkpoint
+		s.positions = make(map[uint64]types.Address)
+		s.members = make(map[types.Address]uint64)
+	}
+
+	producer := block.Producer()
+	if addr, ok := s.positions[position]; ok && addr != producer {
+		return false, nil
+	}
+	if pos, ok := s.members[producer]; ok && pos != position {
+		return false, nil
+	}
+	s.positions[position] = producer
+	s.members[producer] = position
+	return true, nil
+}
+
+// slot returns the round and the position of the producer in the round, it's false if t is not the start time of a slot
+func (s *ScheduleVerifier) slot(t time.Time) (round uint64, position uint64, ok bool) {
+	if t.Before(s.info.GenesisTime) || s.info.Interval <= 0 || s.info.PerCount <= 0 || s.info.NodeCount == 0 {
+		return 0, 0, false
+	}
+
+	round = s.info.Time2Index(t)
+	sTime, _ := s.info.Index2Time(round)
+	interval := time.Duration(s.info.Interval) * time.Second
+	offset := t.Sub(sTime)
+	if offset%interval != 0 {
+		return 0, 0, false
+	}
+
+	position = uint64(offset/interval) / uint64(s.info.PerCount) % uint64(s.info.NodeCount)
+	return round, position, true
+}
@@ -454,6 +454,8 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		block := nb.Block
 
+		b.received(msg.Sender, block.Hash)
+
 		if block.Height+100 < b.chain.GetLatestSnapshotBlock().Height {
 			b.log.Warn(fmt.Sprintf("receive new snapshotblock %s/%d from %s: too old", block.Hash, block.Height, msg.Sender))
 			return
@@ -476,8 +478,10 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		if err = b.verifier.VerifyNetSnapshotBlock(block); err != nil {
 			b.log.Error(fmt.Sprintf("verify new snapshotblock %s/%d from %s error: %v", hash, block.Height, msg.Sender, err))
+			b.peers.score(msg.Sender, scoreInvalidBlock)
 			return err
 		}
+		b.peers.score(msg.Sender, scoreNewBlock)
 
 		if nb.TTL > 0 {
 			nb.TTL--
@@ -507,6 +511,8 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		b.log.Info(fmt.Sprintf("receive new accountblock %s from %s", block.Hash, msg.Sender))
 
+		b.received(msg.Sender, block.Hash)
+
 		// check if block has exist first
 		if exist := b.filter.Test(block.Hash[:]); exist {
 			return nil
@@ -541,8 +547,10 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		if err = b.verifier.VerifyNetAccountBlock(block); err != nil {
 			b.log.Error(fmt.Sprintf("verify new accountblock %s from %s error: %v", hash, msg.Sender, err))
+			b.peers.score(msg.Sender, scoreInvalidBlock)
 			return err
 		}
+		b.peers.score(msg.Sender, scoreNewBlock)
 
 		if nb.TTL > 0 {
 			nb.TTL--
@@ -560,6 +568,19 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 	return nil
 }
 
+// received records the block sent by the peer, the peer is penalized if it has sent the block before.
+// The blocks we sent to the peer are not counted, the peer may relay the block to us at the same time.
+func (b *broadcaster) received(sender *Peer, hash types.Hash) {
+	if sender == nil {
+		return
+	}
+
+	sender.knownBlocks.Add(hash[:])
+	if sender.receivedBlocks.TestAndAdd(hash[:]) {
+		b.peers.score(sender, scoreDuplicateBlock)
+	}
+}
+
 const records1h = 3600
 const records12h = 12 * records1h
 const records24h = 24 * records1h
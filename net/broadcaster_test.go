@@ -2,11 +2,14 @@ package net
 
 import (
 	"fmt"
+	"math/big"
 	"math/rand"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/vitelabs/go-vite/common/bloom"
+	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/net/vnode"
 	"github.com/vitelabs/go-vite/tools/circle"
@@ -441,3 +444,78 @@ func BenchmarkRingStatic(b *testing.B) {
 		}
 	})
 }
+
+type mockBroadcastVerifier struct{}
+
+func (mockBroadcastVerifier) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
+	return nil
+}
+
+func (mockBroadcastVerifier) VerifyNetAccountBlock(block *ledger.AccountBlock) error {
+	return nil
+}
+
+type mockBroadcastChain struct{}
+
+func (mockBroadcastChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{Height: 1}
+}
+
+func (mockBroadcastChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return 0, nil
+}
+
+func TestBroadcaster_crossing(t *testing.T) {
+	newPeer := func() *Peer {
+		return &Peer{
+			Id:             vnode.RandomNodeID(),
+			errChan:        make(chan error, 1),
+			knownBlocks:    bloom.New(filterCap, rt),
+			receivedBlocks: bloom.New(filterCap, rt),
+		}
+	}
+	first, relay := newPeer(), newPeer()
+
+	ps := newPeerSet()
+	b := newBroadcaster(ps, mockBroadcastVerifier{}, nil, newMemBlockStore(10), nil, mockBroadcastChain{})
+
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		Height:         1,
+		AccountAddress: types.AddressQuota,
+		Amount:         big.NewInt(1),
+		Fee:            big.NewInt(0),
+	}
+	block.Hash = block.ComputeHash()
+	payload, err := (&NewAccountBlock{Block: block}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handle := func(sender *Peer) {
+		if err := b.handle(Msg{Code: CodeNewAccountBlock, Payload: payload, Sender: sender}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handle(first)
+	if score := ps.scores.get(first.Id); score != scoreNewBlock {
+		t.Fatalf("the first sender should get %d, got %d", scoreNewBlock, score)
+	}
+
+	// the block is forwarded to the relay peer, while the relay peer sends it to us
+	relay.knownBlocks.Add(block.Hash[:])
+	handle(relay)
+	if score := ps.scores.get(relay.Id); score != 0 {
+		t.Fatalf("the crossing block should not be penalized, got %d", score)
+	}
+
+	// the relay peer sends the block again
+	handle(relay)
+	if score := ps.scores.get(relay.Id); score != scoreDuplicateBlock {
+		t.Fatalf("the second copy should get %d, got %d", scoreDuplicateBlock, score)
+	}
+	handle(first)
+	if score := ps.scores.get(first.Id); score != scoreNewBlock+scoreDuplicateBlock {
+		t.Fatalf("the second copy should get %d, got %d", scoreDuplicateBlock, score-scoreNewBlock)
+	}
+}
@@ -45,6 +45,7 @@ var (
 	nodeActivePrefix = []byte("node:active:") // activeAt
 	nodeCheckPrefix  = []byte("node:check:")  // checkAt
 	nodeMarkPrefix   = []byte("node:mark:")   // mark
+	nodeScorePrefix  = []byte("node:score:")  // reputation score
 
 	nodeBlockIPPrefix = []byte("node:block:ip:") // block expiration
 	nodeBlockIDPrefix = []byte("node:block:id:") // block expiration
@@ -168,6 +169,38 @@ func (db *DB) StoreMark(id vnode.NodeID, v int64) {
 	_ = db.DB.Put(key, value, nil)
 }
 
+func (db *DB) StoreScore(id vnode.NodeID, score int64) {
+	key := append(nodeScorePrefix, id.Bytes()...)
+	db.StoreInt64(key, score)
+}
+
+func (db *DB) RemoveScore(id vnode.NodeID) {
+	key := append(nodeScorePrefix, id.Bytes()...)
+	_ = db.Delete(key, nil)
+}
+
+// ReadScores return all the reputation scores stored
+func (db *DB) ReadScores() map[vnode.NodeID]int64 {
+	itr := db.NewIterator(util.BytesPrefix(nodeScorePrefix), nil)
+	defer itr.Release()
+
+	scores := make(map[vnode.NodeID]int64)
+	prefixLen := len(nodeScorePrefix)
+
+	for itr.Next() {
+		key := itr.Key()
+		id, err := vnode.Bytes2NodeID(key[prefixLen:])
+		if err != nil {
+			_ = db.Delete(key, nil)
+			continue
+		}
+
+		scores[id] = decodeVarint(itr.Value())
+	}
+
+	return scores
+}
+
 func (db *DB) BlockIP(ip net.IP, expiration int64) {
 	key := append(nodeBlockIPPrefix, ip...)
 	db.StoreInt64(key, expiration)
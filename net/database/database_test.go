@@ -58,3 +58,24 @@ func TestNodeDB_Store(t *testing.T) {
 		t.Error("diff net")
 	}
 }
+
+func TestDB_Score(t *testing.T) {
+	mdb, err := New("", 1, id)
+	if err != nil {
+		panic(err)
+	}
+
+	id1, id2 := vnode.RandomNodeID(), vnode.RandomNodeID()
+	mdb.StoreScore(id1, -100)
+	mdb.StoreScore(id2, 20)
+
+	scores := mdb.ReadScores()
+	if len(scores) != 2 || scores[id1] != -100 || scores[id2] != 20 {
+		t.Errorf("wrong scores: %v", scores)
+	}
+
+	mdb.RemoveScore(id1)
+	if scores = mdb.ReadScores(); len(scores) != 1 || scores[id2] != 20 {
+		t.Errorf("wrong scores: %v", scores)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -104,6 +105,7 @@ type Discovery struct {
 
 	bootNodes []string
 	bootSeeds []string
+	bootTrees []string
 
 	booters []booter
 
@@ -160,6 +162,11 @@ func (d *Discovery) GetNodes(count int) (nodes []*vnode.Node) {
 	return
 }
 
+// SetEndPoint changes the endpoint advertised to other nodes, e.g. the external address mapped on the NAT gateway
+func (d *Discovery) SetEndPoint(ep vnode.EndPoint) {
+	d.socket.setEndPoint(ep)
+}
+
 func (d *Discovery) SetFinder(f Finder) {
 	if d.finder != nil {
 		d.finder.UnSub(d.table)
@@ -181,11 +188,12 @@ func (d *Discovery) Delete(id vnode.NodeID, reason error) {
 }
 
 // New create a Discovery implementation
-func New(peerKey ed25519.PrivateKey, node *vnode.Node, bootNodes, bootSeeds []string, listenAddress string, db NodeDB) *Discovery {
+func New(peerKey ed25519.PrivateKey, node *vnode.Node, bootNodes, bootSeeds, bootTrees []string, listenAddress string, db NodeDB) *Discovery {
 	d := &Discovery{
 		node:       node,
 		bootNodes:  bootNodes,
 		bootSeeds:  bootSeeds,
+		bootTrees:  bootTrees,
 		booters:    nil,
 		table:      nil,
 		finder:     nil,
@@ -224,6 +232,14 @@ func (d *Discovery) Start() (err error) {
 	if len(d.bootSeeds) > 0 {
 		d.booters = append(d.booters, newNetBooter(d.node, d.bootSeeds))
 	}
+	if len(d.bootTrees) > 0 {
+		var bt booter
+		bt, err = newDNSBooter(d.node, d.bootTrees, net.DefaultResolver)
+		if err != nil {
+			return err
+		}
+		d.booters = append(d.booters, bt)
+	}
 	if len(d.bootNodes) > 0 {
 		var bt booter
 		bt, err = newCfgBooter(d.bootNodes, d.node)
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+const dnsTimeout = 5 * time.Second
+const maxTreeEntries = 10000 // stop syncing the huge tree
+const maxLinkedTrees = 16    // the trees can be synced in one time, include the linked trees
+
+var errNoRoot = errors.New("no DNS root entry")
+var errNoEntry = errors.New("no DNS entry matches the hash")
+var errTooManyEntries = errors.New("too many DNS entries")
+
+// Resolver can lookup DNS TXT records, eg. net.DefaultResolver
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// dnsClient retrieves and verifies the trees from DNS
+type dnsClient struct {
+	resolver Resolver
+
+	mu      sync.Mutex
+	entries map[string]entry // entries are immutable, can be cached by hash
+}
+
+func newDNSClient(resolver Resolver) *dnsClient {
+	return &dnsClient{
+		resolver: resolver,
+		entries:  make(map[string]entry),
+	}
+}
+
+// syncTree retrieves the whole tree of url, the root and every entry are verified
+func (c *dnsClient) syncTree(url string) (t *Tree, err error) {
+	link, err := parseLink(url)
+	if err != nil {
+		return
+	}
+
+	root, err := c.resolveRoot(link)
+	if err != nil {
+		return
+	}
+
+	t = &Tree{
+		root:    root,
+		entries: make(map[string]entry),
+	}
+
+	if err = c.syncSubtree(link.domain, root.eroot, false, t); err != nil {
+		return nil, err
+	}
+	if err = c.syncSubtree(link.domain, root.lroot, true, t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (c *dnsClient) syncSubtree(domain, hash string, isLink bool, t *Tree) error {
+	var queue = []string{hash}
+
+	for len(queue) > 0 {
+		hash, queue = queue[0], queue[1:]
+		if _, ok := t.entries[hash]; ok {
+			continue
+		}
+		if len(t.entries) >= maxTreeEntries {
+			return errTooManyEntries
+		}
+
+		e, err := c.resolveEntry(domain, hash)
+		if err != nil {
+			return err
+		}
+		t.entries[hash] = e
+
+		switch e := e.(type) {
+		case *branchEntry:
+			queue = append(queue, e.children...)
+		case *nodeEntry:
+			if isLink {
+				return fmt.Errorf("node %s in link subtree of %s", hash, domain)
+			}
+		case *linkEntry:
+			if !isLink {
+				return fmt.Errorf("link %s in node subtree of %s", hash, domain)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *dnsClient) resolveRoot(link *linkEntry) (*rootEntry, error) {
+	txts, err := c.lookupTXT(link.domain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, rootPrefix) {
+			continue
+		}
+
+		root, err := parseRoot(txt)
+		if err != nil {
+			return nil, err
+		}
+		if !root.verify(link.pubKey) {
+			return nil, errInvalidRootSig
+		}
+
+		return root, nil
+	}
+
+	return nil, errNoRoot
+}
+
+func (c *dnsClient) resolveEntry(domain, hash string) (entry, error) {
+	c.mu.Lock()
+	e, ok := c.entries[hash]
+	c.mu.Unlock()
+	if ok {
+		return e, nil
+	}
+
+	txts, err := c.lookupTXT(hash + "." + domain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txt := range txts {
+		// verify the record by its name
+		if subdomain(rawEntry(txt)) != hash {
+			continue
+		}
+
+		e, err = parseEntry(txt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS entry %s.%s: %v", hash, domain, err)
+		}
+
+		c.mu.Lock()
+		c.entries[hash] = e
+		c.mu.Unlock()
+
+		return e, nil
+	}
+
+	return nil, errNoEntry
+}
+
+func (c *dnsClient) lookupTXT(name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+
+	return c.resolver.LookupTXT(ctx, name)
+}
+
+// rawEntry is the record as it is, to calculate the hash
+type rawEntry string
+
+func (e rawEntry) String() string {
+	return string(e)
+}
+
+// dnsBooter supply random bootNodes from the trees published to DNS
+type dnsBooter struct {
+	self   *vnode.Node
+	client *dnsClient
+	urls   []string
+	log    log15.Logger
+}
+
+func newDNSBooter(self *vnode.Node, urls []string, resolver Resolver) (booter, error) {
+	for _, url := range urls {
+		if _, err := parseLink(url); err != nil {
+			return nil, fmt.Errorf("failed to parse bootTree: %s", url)
+		}
+	}
+
+	return &dnsBooter{
+		self:   self,
+		client: newDNSClient(resolver),
+		urls:   urls,
+		log:    discvLog.New("module", "dnsBooter"),
+	}, nil
+}
+
+func (d *dnsBooter) getBootNodes(count int) (nodes []*Node) {
+	var queue = append([]string(nil), d.urls...)
+	var synced = make(map[string]struct{})
+
+	var url string
+	for len(queue) > 0 && len(synced) < maxLinkedTrees {
+		url, queue = queue[0], queue[1:]
+		if _, ok := synced[url]; ok {
+			continue
+		}
+		synced[url] = struct{}{}
+
+		t, err := d.client.syncTree(url)
+		if err != nil {
+			d.log.Error(fmt.Sprintf("failed to sync tree %s: %v", url, err))
+			continue
+		}
+
+		for _, n := range t.Nodes() {
+			if n.Net != 0 && n.Net != d.self.Net {
+				continue
+			}
+
+			node := *n
+			node.Net = d.self.Net
+			nodes = append(nodes, &Node{
+				Node: node,
+			})
+		}
+
+		queue = append(queue, t.Links()...)
+	}
+
+	d.log.Info(fmt.Sprintf("load %d nodes from %d trees", len(nodes), len(synced)))
+
+	rand.Shuffle(len(nodes), func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	})
+	if len(nodes) > count {
+		nodes = nodes[:count]
+	}
+
+	return
+}
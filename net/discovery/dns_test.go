@@ -0,0 +1,302 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// stubDNS is a DNS server only answers TXT queries
+type stubDNS struct {
+	conn    *net.UDPConn
+	mu      sync.Mutex
+	records map[string]string // lower case domain name: TXT record
+}
+
+func newStubDNS(t *testing.T) *stubDNS {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &stubDNS{
+		conn:    conn,
+		records: make(map[string]string),
+	}
+	go s.serve()
+
+	return s
+}
+
+func (s *stubDNS) add(records map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, txt := range records {
+		s.records[strings.ToLower(name)] = txt
+	}
+}
+
+// resolver sends all queries to the stub server
+func (s *stubDNS) resolver() Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", s.conn.LocalAddr().String())
+		},
+	}
+}
+
+func (s *stubDNS) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if n < 12 || binary.BigEndian.Uint16(buf[4:]) != 1 {
+			continue
+		}
+
+		// question
+		var labels []string
+		i := 12
+		for i < n && buf[i] != 0 {
+			l := int(buf[i])
+			if i+1+l > n {
+				break
+			}
+			labels = append(labels, string(buf[i+1:i+1+l]))
+			i += 1 + l
+		}
+		i += 5
+		if i > n {
+			continue
+		}
+		qtype := binary.BigEndian.Uint16(buf[i-4:])
+
+		s.mu.Lock()
+		txt, ok := s.records[strings.ToLower(strings.Join(labels, "."))]
+		s.mu.Unlock()
+
+		res := make([]byte, 12, 512)
+		copy(res, buf[:2])
+		res[2] = 0x84 | buf[2]&1 // response, authoritative, recursion desired
+		res[3] = 0x80            // recursion available
+		binary.BigEndian.PutUint16(res[4:], 1)
+		res = append(res, buf[12:i]...)
+
+		if !ok {
+			res[3] |= 3 // name error
+		} else if qtype == 16 {
+			binary.BigEndian.PutUint16(res[6:], 1)
+
+			var rdata []byte
+			for len(txt) > 0 {
+				l := len(txt)
+				if l > 255 {
+					l = 255
+				}
+				rdata = append(rdata, byte(l))
+				rdata = append(rdata, txt[:l]...)
+				txt = txt[l:]
+			}
+
+			// pointer to question name, type TXT, class IN, ttl 60
+			res = append(res, 0xc0, 12, 0, 16, 0, 1, 0, 0, 0, 60, byte(len(rdata)>>8), byte(len(rdata)))
+			res = append(res, rdata...)
+		}
+
+		_, _ = s.conn.WriteToUDP(res, addr)
+	}
+}
+
+func mockTreeNodes(count, netId int) (nodes []*vnode.Node) {
+	for i := 0; i < count; i++ {
+		n := vnode.MockNode(false, true)
+		n.Net = netId
+		nodes = append(nodes, n)
+	}
+
+	return
+}
+
+func mockTreeKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func mockSignedTree(t *testing.T, key ed25519.PrivateKey, nodes []*vnode.Node, links []string, domain string) (*Tree, string) {
+	tree, err := MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree, url
+}
+
+func TestMakeTree(t *testing.T) {
+	nodes := mockTreeNodes(100, 3)
+	_, linked := mockSignedTree(t, mockTreeKey(t), nil, nil, "linked.vite.org")
+
+	tree, url := mockSignedTree(t, mockTreeKey(t), nodes, []string{linked}, "nodes.vite.org")
+	if !strings.HasPrefix(url, linkPrefix) || !strings.HasSuffix(url, "@nodes.vite.org") {
+		t.Fatalf("wrong url %s", url)
+	}
+
+	if len(tree.Nodes()) != len(nodes) {
+		t.Fatalf("should be %d nodes, but get %d", len(nodes), len(tree.Nodes()))
+	}
+	if links := tree.Links(); len(links) != 1 || links[0] != linked {
+		t.Fatalf("wrong links %v", links)
+	}
+
+	records := tree.ToTXT("nodes.vite.org")
+	if len(records) != len(tree.entries)+1 {
+		t.Fatalf("wrong records count %d", len(records))
+	}
+
+	for name, txt := range records {
+		if len(txt) > 255 {
+			t.Errorf("record %s is too long: %d", name, len(txt))
+		}
+		if name == "nodes.vite.org" {
+			root, err := parseRoot(txt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			link, _ := parseLink(url)
+			if !root.verify(link.pubKey) {
+				t.Fatal("failed to verify root")
+			}
+			continue
+		}
+
+		if _, err := parseEntry(txt); err != nil {
+			t.Fatalf("failed to parse %s: %v", txt, err)
+		}
+		if hash := subdomain(rawEntry(txt)); name != hash+".nodes.vite.org" {
+			t.Fatalf("wrong name %s of %s", name, hash)
+		}
+	}
+}
+
+func TestDNSClient_syncTree(t *testing.T) {
+	s := newStubDNS(t)
+	defer s.conn.Close()
+
+	nodes := mockTreeNodes(50, 3)
+	tree, url := mockSignedTree(t, mockTreeKey(t), nodes, nil, "nodes.vite.org")
+	s.add(tree.ToTXT("nodes.vite.org"))
+
+	c := newDNSClient(s.resolver())
+	synced, err := c.syncTree(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced.Seq() != 1 || len(synced.Nodes()) != len(nodes) {
+		t.Fatalf("wrong tree: seq %d, %d nodes", synced.Seq(), len(synced.Nodes()))
+	}
+
+	var ids = make(map[vnode.NodeID]*vnode.Node)
+	for _, n := range synced.Nodes() {
+		ids[n.ID] = n
+	}
+	for _, n := range nodes {
+		if m, ok := ids[n.ID]; !ok || !m.EndPoint.Equal(&n.EndPoint) || m.Net != n.Net {
+			t.Fatalf("wrong node %v", m)
+		}
+	}
+
+	// public key mismatch
+	_, other := mockSignedTree(t, mockTreeKey(t), nil, nil, "nodes.vite.org")
+	if _, err = newDNSClient(s.resolver()).syncTree(other); err != errInvalidRootSig {
+		t.Fatalf("should be invalid signature: %v", err)
+	}
+
+	// tampered node
+	for name, txt := range tree.ToTXT("nodes.vite.org") {
+		if strings.HasPrefix(txt, nodePrefix) {
+			s.add(map[string]string{
+				name: nodePrefix + mockTreeNodes(1, 3)[0].String(),
+			})
+			break
+		}
+	}
+	if _, err = newDNSClient(s.resolver()).syncTree(url); err != errNoEntry {
+		t.Fatalf("should be no entry: %v", err)
+	}
+}
+
+func TestDNSBooter(t *testing.T) {
+	s := newStubDNS(t)
+	defer s.conn.Close()
+
+	key1 := mockTreeKey(t)
+	_, url1 := mockSignedTree(t, key1, nil, nil, "a.vite.org")
+	tree2, url2 := mockSignedTree(t, mockTreeKey(t), mockTreeNodes(20, 1), []string{url1}, "b.vite.org")
+	tree3, url3 := mockSignedTree(t, mockTreeKey(t), mockTreeNodes(20, 0), []string{url1, url2}, "c.vite.org")
+	// the linked trees have loop
+	tree1, _ := mockSignedTree(t, key1, mockTreeNodes(20, 3), []string{url3}, "a.vite.org")
+	s.add(tree1.ToTXT("a.vite.org"))
+	s.add(tree2.ToTXT("b.vite.org"))
+	s.add(tree3.ToTXT("c.vite.org"))
+
+	if _, err := newDNSBooter(&vnode.Node{Net: 3}, []string{"nodes.vite.org"}, s.resolver()); err == nil {
+		t.Fatal("invalid url should be refused")
+	}
+
+	btr, err := newDNSBooter(&vnode.Node{Net: 3}, []string{url1}, s.resolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes of net 1 are skipped
+	nodes := btr.getBootNodes(100)
+	if len(nodes) != 40 {
+		t.Fatalf("should be 40 nodes, but get %d", len(nodes))
+	}
+	for _, n := range nodes {
+		if n.Net != 3 {
+			t.Fatalf("wrong net %d", n.Net)
+		}
+	}
+
+	if nodes = btr.getBootNodes(10); len(nodes) != 10 {
+		t.Fatalf("should be 10 nodes, but get %d", len(nodes))
+	}
+}
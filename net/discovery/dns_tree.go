@@ -0,0 +1,352 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// The node list is published as a merkle tree of DNS TXT records, like EIP-1459:
+//
+//	<domain>:        enrtree-root:v1 e=<node root> l=<link root> seq=<seq> sig=<signature>
+//	<hash>.<domain>: enrtree-branch:<hash>,<hash>,...
+//	<hash>.<domain>: vnode://<node id>@<host:port>/<net>
+//	<hash>.<domain>: enrtree://<public key>@<domain of another tree>
+//
+// hash is the base32 of the first 16 bytes of the record hash, so every record can be verified
+// by its name, and the root is signed by the publisher with the public key in the tree URL.
+// The node subtree contains only branches and nodes, the link subtree contains only branches and links.
+const (
+	rootPrefix   = "enrtree-root:v1"
+	branchPrefix = "enrtree-branch:"
+	linkPrefix   = "enrtree://"
+	nodePrefix   = "vnode://"
+)
+
+const (
+	hashAbbrevSize = 16
+	// records should be less than 255 bytes, fit in one TXT string. every child is 26 bytes hash and a comma
+	maxBranchChildren = (255 - len(branchPrefix)) / 27
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+var b64 = base64.RawURLEncoding
+
+var errUnknownEntry = errors.New("unknown DNS entry")
+var errInvalidRoot = errors.New("invalid DNS root entry")
+var errInvalidRootSig = errors.New("invalid signature of DNS root entry")
+var errInvalidLink = errors.New("invalid DNS tree URL")
+var errInvalidChild = errors.New("invalid child hash of DNS branch")
+
+type entry interface {
+	fmt.Stringer
+}
+
+type rootEntry struct {
+	eroot string
+	lroot string
+	seq   uint
+	sig   []byte
+}
+
+type branchEntry struct {
+	children []string
+}
+
+type nodeEntry struct {
+	node *vnode.Node
+}
+
+type linkEntry struct {
+	str    string
+	domain string
+	pubKey ed25519.PublicKey
+}
+
+// Tree is the node list can be published to DNS
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry // hash: entry
+}
+
+// MakeTree creates an unsigned tree of the nodes and the URLs of other trees
+func MakeTree(seq uint, nodes []*vnode.Node, links []string) (*Tree, error) {
+	nodes = append([]*vnode.Node(nil), nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].ID[:], nodes[j].ID[:]) < 0
+	})
+
+	var nodeEntries = make([]entry, len(nodes))
+	for i, n := range nodes {
+		// Ext is not published
+		nodeEntries[i] = &nodeEntry{
+			node: &vnode.Node{
+				ID:       n.ID,
+				EndPoint: n.EndPoint,
+				Net:      n.Net,
+			},
+		}
+	}
+
+	links = append([]string(nil), links...)
+	sort.Strings(links)
+
+	var linkEntries = make([]entry, len(links))
+	for i, url := range links {
+		l, err := parseLink(url)
+		if err != nil {
+			return nil, err
+		}
+		linkEntries[i] = l
+	}
+
+	t := &Tree{
+		entries: make(map[string]entry),
+	}
+	eroot := t.build(nodeEntries)
+	t.entries[subdomain(eroot)] = eroot
+	lroot := t.build(linkEntries)
+	t.entries[subdomain(lroot)] = lroot
+
+	t.root = &rootEntry{
+		eroot: subdomain(eroot),
+		lroot: subdomain(lroot),
+		seq:   seq,
+	}
+
+	return t, nil
+}
+
+// build the subtree, return the branch of the subtree root
+func (t *Tree) build(entries []entry) entry {
+	if len(entries) <= maxBranchChildren {
+		children := make([]string, len(entries))
+		for i, e := range entries {
+			children[i] = subdomain(e)
+			t.entries[children[i]] = e
+		}
+		return &branchEntry{children}
+	}
+
+	var subtrees []entry
+	for len(entries) > 0 {
+		n := maxBranchChildren
+		if n > len(entries) {
+			n = len(entries)
+		}
+		subtrees = append(subtrees, t.build(entries[:n]))
+		entries = entries[n:]
+	}
+
+	return t.build(subtrees)
+}
+
+// Sign the root of tree, return the URL of the tree can be set in BootTrees
+func (t *Tree) Sign(key ed25519.PrivateKey, domain string) (url string, err error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid private key")
+	}
+
+	t.root.sig = ed25519.Sign(key, []byte(t.root.signedContent()))
+
+	l := &linkEntry{
+		domain: domain,
+		pubKey: key.PubByte(),
+	}
+	l.str = l.String()
+
+	return l.str, nil
+}
+
+// Seq is the sequence number of the tree, should be increased when the tree is updated
+func (t *Tree) Seq() uint {
+	return t.root.seq
+}
+
+// Nodes in the tree
+func (t *Tree) Nodes() (nodes []*vnode.Node) {
+	for _, e := range t.entries {
+		if n, ok := e.(*nodeEntry); ok {
+			nodes = append(nodes, n.node)
+		}
+	}
+
+	return
+}
+
+// Links are the URLs of other trees in the tree
+func (t *Tree) Links() (links []string) {
+	for _, e := range t.entries {
+		if l, ok := e.(*linkEntry); ok {
+			links = append(links, l.str)
+		}
+	}
+
+	return
+}
+
+// ToTXT returns all records of the signed tree: domain name: TXT record
+func (t *Tree) ToTXT(domain string) map[string]string {
+	records := map[string]string{
+		domain: t.root.String(),
+	}
+	for hash, e := range t.entries {
+		records[hash+"."+domain] = e.String()
+	}
+
+	return records
+}
+
+// subdomain is the hash of entry
+func subdomain(e entry) string {
+	return b32.EncodeToString(crypto.Hash256([]byte(e.String()))[:hashAbbrevSize])
+}
+
+func (e *rootEntry) signedContent() string {
+	return fmt.Sprintf("%s e=%s l=%s seq=%d", rootPrefix, e.eroot, e.lroot, e.seq)
+}
+
+func (e *rootEntry) String() string {
+	return e.signedContent() + " sig=" + b64.EncodeToString(e.sig)
+}
+
+func (e *rootEntry) verify(pubKey ed25519.PublicKey) bool {
+	return len(e.sig) == ed25519.SignatureSize && ed25519.Verify(pubKey, []byte(e.signedContent()), e.sig)
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+func (e *nodeEntry) String() string {
+	return nodePrefix + e.node.String()
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + b32.EncodeToString(e.pubKey) + "@" + e.domain
+}
+
+func parseRoot(str string) (e *rootEntry, err error) {
+	fields := strings.Fields(str)
+	if len(fields) != 5 || fields[0] != rootPrefix {
+		return nil, errInvalidRoot
+	}
+
+	values := make(map[string]string, 4)
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, errInvalidRoot
+		}
+		values[kv[0]] = kv[1]
+	}
+
+	e = &rootEntry{
+		eroot: values["e"],
+		lroot: values["l"],
+	}
+	if !isHash(e.eroot) || !isHash(e.lroot) {
+		return nil, errInvalidRoot
+	}
+
+	seq, err := strconv.ParseUint(values["seq"], 10, 32)
+	if err != nil {
+		return nil, errInvalidRoot
+	}
+	e.seq = uint(seq)
+
+	if e.sig, err = b64.DecodeString(values["sig"]); err != nil {
+		return nil, errInvalidRoot
+	}
+
+	return e, nil
+}
+
+// parseEntry parses the records except root
+func parseEntry(str string) (entry, error) {
+	switch {
+	case strings.HasPrefix(str, branchPrefix):
+		var children []string
+		if str = str[len(branchPrefix):]; str != "" {
+			children = strings.Split(str, ",")
+		}
+		for i, child := range children {
+			child = strings.ToUpper(child)
+			if !isHash(child) {
+				return nil, errInvalidChild
+			}
+			children[i] = child
+		}
+		return &branchEntry{children}, nil
+	case strings.HasPrefix(str, nodePrefix):
+		n, err := vnode.ParseNode(str)
+		if err != nil {
+			return nil, err
+		}
+		return &nodeEntry{n}, nil
+	case strings.HasPrefix(str, linkPrefix):
+		return parseLink(str)
+	default:
+		return nil, errUnknownEntry
+	}
+}
+
+// parseLink parses the tree URL like enrtree://<public key>@<domain>
+func parseLink(url string) (*linkEntry, error) {
+	if !strings.HasPrefix(url, linkPrefix) {
+		return nil, errInvalidLink
+	}
+
+	str := url[len(linkPrefix):]
+	i := strings.IndexByte(str, '@')
+	if i < 0 || i == len(str)-1 {
+		return nil, errInvalidLink
+	}
+
+	pubKey, err := b32.DecodeString(strings.ToUpper(str[:i]))
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return nil, errInvalidLink
+	}
+
+	return &linkEntry{
+		str:    url,
+		domain: str[i+1:],
+		pubKey: pubKey,
+	}, nil
+}
+
+func isHash(str string) bool {
+	if len(str) != b32.EncodedLen(hashAbbrevSize) {
+		return false
+	}
+
+	_, err := b32.DecodeString(str)
+	return err == nil
+}
@@ -62,6 +62,8 @@ type receiver interface {
 type socket interface {
 	sender
 	receiver
+	// setEndPoint changes the endpoint of self in the ping and pong messages
+	setEndPoint(ep vnode.EndPoint)
 }
 
 // packet is a parsed message received from socket
@@ -72,6 +74,7 @@ type packet struct {
 }
 
 type agent struct {
+	mu            sync.RWMutex // guard the endpoint of node
 	node          *vnode.Node
 	listenAddress string
 	socket        *net.UDPConn
@@ -136,6 +139,21 @@ func (a *agent) stop() (err error) {
 	return errSocketIsNotRunning
 }
 
+func (a *agent) setEndPoint(ep vnode.EndPoint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.node.EndPoint = ep
+}
+
+func (a *agent) endPoint() *vnode.EndPoint {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	ep := a.node.EndPoint
+	return &ep
+}
+
 func (a *agent) ping(n *Node, callback func(*Node, error)) {
 	udp, err := n.udpAddr()
 	if err != nil {
@@ -148,7 +166,7 @@ func (a *agent) ping(n *Node, callback func(*Node, error)) {
 		c:  codePing,
 		id: a.node.ID,
 		body: &ping{
-			from: &a.node.EndPoint,
+			from: a.endPoint(),
 			to:   &n.EndPoint,
 			net:  a.node.Net,
 			ext:  a.node.Ext,
@@ -183,7 +201,7 @@ func (a *agent) pong(echo []byte, n *Node) (err error) {
 		c:  codePong,
 		id: a.node.ID,
 		body: &pong{
-			from: &a.node.EndPoint,
+			from: a.endPoint(),
 			to:   &n.EndPoint,
 			net:  a.node.Net,
 			ext:  a.node.Ext,
@@ -125,7 +125,10 @@ func (f *fetcher) clean(t int64) {
 			r.done(nil, Msg{}, errFetchTimeout)
 
 			// recycle
-			for _, ret := range r.targets {
+			for id, ret := range r.targets {
+				if ret.status == reqPending {
+					f.peers.score(f.peers.get(id), scoreFetchFailed)
+				}
 				f.peerFetchResultPool.Put(ret)
 			}
 			r.reset()
@@ -211,7 +214,10 @@ func (f *fetcher) done(id MsgId, peer *Peer, msg Msg, err error) {
 		r.done(peer, msg, err)
 
 		if err != nil {
+			f.peers.score(peer, scoreFetchFailed)
 			f.log.Warn(fmt.Sprintf("failed to fetch %s to %s: %v", r.hash, peer, err))
+		} else {
+			f.peers.score(peer, scoreFetchDone)
 		}
 	}
 }
@@ -262,6 +268,8 @@ func (f *fetcher) pickTargets(r *record, height uint64, peers *peerSet) peers {
 		ps = ps[:j]
 	}
 
+	// prefer the peers of good reputation
+	peers.scores.sortByScore(ps)
 	if len(ps) > 3 {
 		ps = ps[:3]
 	}
@@ -384,6 +392,7 @@ func (f *fetcher) handle(msg Msg) (err error) {
 
 		for _, block := range bs.Blocks {
 			if err = f.receiver.receiveSnapshotBlock(block, types.RemoteFetch); err != nil {
+				f.peers.score(msg.Sender, scoreInvalidBlock)
 				return err
 			}
 		}
@@ -403,6 +412,7 @@ func (f *fetcher) handle(msg Msg) (err error) {
 
 		for _, block := range bs.Blocks {
 			if err = f.receiver.receiveAccountBlock(block, types.RemoteFetch); err != nil {
+				f.peers.score(msg.Sender, scoreInvalidBlock)
 				return err
 			}
 		}
@@ -19,6 +19,7 @@
 package net
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -230,6 +231,10 @@ func (f *finder) dial(node *vnode.Node) {
 	if _, ok := f.dialing[node.ID]; ok {
 		return
 	}
+
+	if f.peers.scores.get(node.ID) < evictPeerScore {
+		return
+	}
 	f.dialing[node.ID] = struct{}{}
 	go f.doDial(node)
 }
@@ -294,6 +299,7 @@ func (f *finder) loop() {
 				total := f.total()
 				if total < f.minPeers {
 					nodes = f.resolver.GetNodes((f.minPeers - total) * 2)
+					f.sortNodes(nodes)
 					f.rw.Lock()
 					for _, node := range nodes {
 						f.dial(node)
@@ -307,12 +313,38 @@ func (f *finder) loop() {
 	}
 }
 
-func setNodeExt(mineKey ed25519.PrivateKey, node *vnode.Node) {
+// sortNodes sort nodes by reputation from high to low, so the good nodes will be dialed first
+func (f *finder) sortNodes(nodes []*vnode.Node) {
+	scores := make(map[peerId]int64, len(nodes))
+	for _, node := range nodes {
+		scores[node.ID] = f.peers.scores.get(node.ID)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return scores[nodes[i].ID] > scores[nodes[j].ID]
+	})
+}
+
+// mineSigner signs the data with the key of the producer coinbase, the key may be kept by a keystore
+type mineSigner func(data []byte) (signedData, pubkey []byte, err error)
+
+// keySigner signs with the exported key of the coinbase
+func keySigner(mineKey ed25519.PrivateKey) mineSigner {
+	return func(data []byte) (signedData, pubkey []byte, err error) {
+		return ed25519.Sign(mineKey, data), mineKey.PubByte(), nil
+	}
+}
+
+func setNodeExt(sign mineSigner, node *vnode.Node) error {
 	// minePUB + minePriv.Sign(node.ID)
+	signedData, pubkey, err := sign(node.ID.Bytes())
+	if err != nil {
+		return err
+	}
 	node.Ext = make([]byte, extLen)
-	copy(node.Ext[:32], mineKey.PubByte())
-	sign := ed25519.Sign(mineKey, node.ID.Bytes())
-	copy(node.Ext[32:], sign)
+	copy(node.Ext[:32], pubkey)
+	copy(node.Ext[32:], signedData)
+	return nil
 }
 
 func parseNodeExt(node *vnode.Node) (addr types.Address, ok bool) {
@@ -23,6 +23,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	_net "net"
+	"sync"
 	"time"
 
 	"github.com/vitelabs/go-vite/net/netool"
@@ -57,6 +58,9 @@ type HandshakeMsg struct {
 
 	FileAddress   []byte
 	PublicAddress []byte
+
+	// VersionToken authenticates the Version since versionEncrypted, the old versions don't have it
+	VersionToken []byte
 }
 
 func (b *HandshakeMsg) Serialize() (data []byte, err error) {
@@ -73,6 +77,7 @@ func (b *HandshakeMsg) Serialize() (data []byte, err error) {
 		Key:           b.Key,
 		Token:         b.Token,
 		PublicAddress: b.PublicAddress,
+		VersionToken:  b.VersionToken,
 	}
 
 	return proto.Marshal(pb)
@@ -106,6 +111,7 @@ func (b *HandshakeMsg) Deserialize(data []byte) (err error) {
 	}
 	b.FileAddress = pb.FileAddress
 	b.PublicAddress = pb.PublicAddress
+	b.VersionToken = pb.VersionToken
 
 	b.Key = pb.Key
 	b.Token = pb.Token
@@ -119,11 +125,12 @@ type handshaker struct {
 	name          string
 	id            vnode.NodeID
 	genesis       types.Hash
+	mu            sync.RWMutex // guard fileAddress and publicAddress, they are changed by the NAT mapping
 	fileAddress   []byte
 	publicAddress []byte
 
 	peerKey ed25519.PrivateKey
-	key     ed25519.PrivateKey
+	key     mineSigner
 
 	codecFactory CodecFactory
 
@@ -208,6 +215,15 @@ func (h *handshaker) getSecret(theirId peerId) (secret []byte, err error) {
 	return
 }
 
+// versionToken is the MAC of the version by the secret, the Token of the old versions doesn't
+// cover the Version, so it can be rewritten to downgrade the connection to plaintext
+func versionToken(secret []byte, timestamp, version int64) []byte {
+	t := make([]byte, 16)
+	binary.BigEndian.PutUint64(t[:8], uint64(timestamp))
+	binary.BigEndian.PutUint64(t[8:], uint64(version))
+	return crypto.Hash256(secret, t, []byte("version"))
+}
+
 func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err error) {
 	t := make([]byte, 8)
 	binary.BigEndian.PutUint64(t, uint64(their.Timestamp))
@@ -225,11 +241,38 @@ func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err er
 		}
 	}
 
+	// a version supporting encryption must be authenticated, and a rewritten version mismatches the token
+	if their.Version >= versionEncrypted || len(their.VersionToken) != 0 {
+		if false == bytes.Equal(versionToken(secret, their.Timestamp, their.Version), their.VersionToken) {
+			err = PeerInvalidToken
+			return
+		}
+	}
+
 	return
 }
 
+func (h *handshaker) setPublicAddress(data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.publicAddress = data
+}
+
+func (h *handshaker) setFileAddress(data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.fileAddress = data
+}
+
 func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 	latestBlock := h.chain.GetLatestSnapshotBlock()
+
+	h.mu.RLock()
+	fileAddress, publicAddress := h.fileAddress, h.publicAddress
+	h.mu.RUnlock()
+
 	our = &HandshakeMsg{
 		Version:       int64(h.version),
 		NetID:         int64(h.netId),
@@ -241,8 +284,8 @@ func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 		Genesis:       h.genesis,
 		Key:           nil,
 		Token:         nil,
-		FileAddress:   h.fileAddress,
-		PublicAddress: h.publicAddress,
+		FileAddress:   fileAddress,
+		PublicAddress: publicAddress,
 	}
 
 	t := make([]byte, 8)
@@ -251,8 +294,16 @@ func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 
 	our.Token = xor(hash, secret)
 	if h.key != nil {
-		our.Key = h.key.PubByte()
-		our.Token = ed25519.Sign(h.key, our.Token)
+		if token, key, err := h.key(our.Token); err != nil {
+			netLog.Warn(fmt.Sprintf("failed to sign the handshake token by the mine key: %v", err))
+		} else {
+			our.Key = key
+			our.Token = token
+		}
+	}
+
+	if our.Version >= versionEncrypted {
+		our.VersionToken = versionToken(secret, our.Timestamp, our.Version)
 	}
 
 	return
@@ -320,6 +371,11 @@ func (h *handshaker) ReceiveHandshake(conn _net.Conn) (c Codec, their *Handshake
 
 	our := h.makeHandshake(secret)
 	err = h.sendHandshake(c, our, msgId)
+	if err != nil {
+		return
+	}
+
+	c, err = h.secure(conn, c, their, our, secret, false)
 	return
 }
 
@@ -358,6 +414,11 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 		return
 	}
 
+	c, err = h.secure(conn, c, our, their, secret, true)
+	if err != nil {
+		return
+	}
+
 	superior, err = h.onHandshaker(c, PeerFlagOutbound, their)
 	if err != nil {
 		return
@@ -379,3 +440,27 @@ func (h *handshaker) doHandshake(c Codec, flag PeerFlag, their *HandshakeMsg) (e
 
 	return
 }
+
+// secure exchanges the keys and returns the codec of the encrypted connection if the peer supports,
+// or the plaintext codec to interoperate with the old peers. The versions of both sides are authenticated
+// by verifyHandshake, so the connection is never plaintext if both of them support encryption,
+// and the version tokens are mixed into the keys to bind the keys to the handshake.
+func (h *handshaker) secure(conn _net.Conn, c Codec, initiatorMsg, receiverMsg *HandshakeMsg, secret []byte, initiator bool) (Codec, error) {
+	if !encrypted(initiatorMsg.Version, receiverMsg.Version) {
+		return c, nil
+	}
+
+	transcript := crypto.Hash256(initiatorMsg.VersionToken, receiverMsg.VersionToken)
+	keys, err := exchangeKeys(c, secret, transcript, initiator)
+	if err != nil {
+		netLog.Warn(fmt.Sprintf("failed to exchange keys with %s: %v", c.Address(), err))
+		return c, PeerNetworkError
+	}
+
+	sconn, err := newSecureConn(conn, keys)
+	if err != nil {
+		return c, err
+	}
+
+	return h.codecFactory.CreateCodec(sconn), nil
+}
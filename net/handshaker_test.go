@@ -49,6 +49,7 @@ func TestProtoHandshakeMsg_Serialize(t *testing.T) {
 		Token:         []byte{5, 6, 7},
 		FileAddress:   []byte{1, 2},
 		PublicAddress: []byte{3, 4},
+		VersionToken:  []byte{8, 9},
 	}
 
 	data, err := msg.Serialize()
@@ -98,6 +99,9 @@ func TestProtoHandshakeMsg_Serialize(t *testing.T) {
 	if false == bytes.Equal(msg.Token, msg2.Token) {
 		t.Errorf("different token: %v %v", msg.Token, msg2.Token)
 	}
+	if false == bytes.Equal(msg.VersionToken, msg2.VersionToken) {
+		t.Errorf("different version token: %v %v", msg.VersionToken, msg2.VersionToken)
+	}
 }
 
 func TestExtractFileAddress(t *testing.T) {
@@ -208,7 +212,7 @@ func TestHandshake(t *testing.T) {
 			fileAddress:   nil,
 			publicAddress: nil,
 			peerKey:       priv1,
-			key:           priv2,
+			key:           keySigner(priv2),
 			codecFactory:  codecFac,
 			chain:         nil,
 			blackList: netool.NewBlackList(func(t int64, count int) bool {
@@ -264,7 +268,7 @@ func TestHandshake(t *testing.T) {
 			fileAddress:   fileAddress,
 			publicAddress: publicAddress,
 			peerKey:       priv3,
-			key:           priv4,
+			key:           keySigner(priv4),
 			codecFactory:  codecFac,
 			chain:         nil,
 			blackList: netool.NewBlackList(func(t int64, count int) bool {
@@ -314,7 +318,7 @@ check:
 
 	hkr := &handshaker{
 		peerKey: priv1,
-		key:     priv3,
+		key:     keySigner(priv3),
 	}
 	hkr.setChain(mockChain{
 		height: 111,
@@ -361,7 +365,7 @@ func TestHandshaker_verifyHandshake(t *testing.T) {
 
 	hkr := &handshaker{
 		peerKey: priv1,
-		key:     priv3,
+		key:     keySigner(priv3),
 	}
 	hkr.setChain(mockChain{
 		height: 111,
@@ -398,6 +402,7 @@ func TestHandshaker_verifyHandshake(t *testing.T) {
 			our.Key = mineKey.PubByte()
 			our.Token = ed25519.Sign(mineKey, our.Token)
 		}
+		our.VersionToken = versionToken(secret, our.Timestamp, our.Version)
 
 		return
 	}
@@ -421,4 +426,100 @@ func TestHandshaker_verifyHandshake(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
+
+	// the version is rewritten to downgrade the connection to plaintext
+	our.Version = versionEncrypted - 1
+	if err = hkr.verifyHandshake(our, secret); err != PeerInvalidToken {
+		t.Fatalf("the rewritten version should be refused: %v", err)
+	}
+	// the version token is removed too
+	our.VersionToken = nil
+	our.Version = versionEncrypted
+	if err = hkr.verifyHandshake(our, secret); err != PeerInvalidToken {
+		t.Fatalf("the version without token should be refused: %v", err)
+	}
+}
+
+// downgradeProxy forwards the messages between the two connections, the Version of the
+// handshake from the initiator is rewritten to the version before encryption
+func downgradeProxy(initiator, receiver _net.Conn) {
+	ci := NewTransport(initiator, 100, 5*time.Second, 5*time.Second)
+	cr := NewTransport(receiver, 100, 5*time.Second, 5*time.Second)
+	go func() {
+		for {
+			msg, err := cr.ReadMsg()
+			if err != nil || ci.WriteMsg(msg) != nil {
+				_ = initiator.Close()
+				return
+			}
+		}
+	}()
+	for {
+		msg, err := ci.ReadMsg()
+		if err != nil {
+			_ = receiver.Close()
+			return
+		}
+		if msg.Code == CodeHandshake {
+			hk := new(HandshakeMsg)
+			if err = hk.Deserialize(msg.Payload); err == nil {
+				hk.Version = versionEncrypted - 1
+				msg.Payload, _ = hk.Serialize()
+			}
+		}
+		if err = cr.WriteMsg(msg); err != nil {
+			_ = initiator.Close()
+			return
+		}
+	}
+}
+
+func TestHandshake_Downgrade(t *testing.T) {
+	newHandshaker := func(name string) (*handshaker, vnode.NodeID) {
+		_, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := vnode.Bytes2NodeID(priv.PubByte())
+		hk := &handshaker{
+			version: version,
+			netId:   7,
+			name:    name,
+			id:      id,
+			peerKey: priv,
+			codecFactory: &transportFactory{
+				minCompressLength: 100,
+				readTimeout:       5 * time.Second,
+				writeTimeout:      5 * time.Second,
+			},
+			blackList: netool.NewBlackList(func(t int64, count int) bool {
+				return false
+			}),
+			onHandshaker: func(c Codec, flag PeerFlag, their *HandshakeMsg) (superior bool, err error) {
+				return false, nil
+			},
+		}
+		hk.setChain(mockChain{height: 100})
+		return hk, id
+	}
+	hk1, _ := newHandshaker("node1")
+	hk2, id2 := newHandshaker("node2")
+
+	conn1, proxy1 := _net.Pipe()
+	proxy2, conn2 := _net.Pipe()
+	go downgradeProxy(proxy1, proxy2)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, _, err := hk2.ReceiveHandshake(conn2)
+		_ = conn2.Close()
+		errCh <- err
+	}()
+
+	if _, _, _, err := hk1.InitiateHandshake(conn1, id2); err == nil {
+		t.Fatal("the handshake should fail")
+	}
+	if err := <-errCh; err != PeerInvalidToken {
+		t.Fatalf("the downgraded handshake should be refused: %v", err)
+	}
 }
@@ -255,5 +255,7 @@ type Net interface {
 	Info() NodeInfo
 	Nodes() []*vnode.Node
 	PeerCount() int
+	// PeerScores return the reputation of peers, from high to low
+	PeerScores() []PeerScore
 	PeerKey() ed25519.PrivateKey
 }
@@ -34,6 +34,7 @@ const (
 	CodeHandshake   Code = 2
 	CodeControlFlow Code = 3
 	CodeHeartBeat   Code = 4
+	CodeKeyExchange Code = 5
 
 	CodeGetHashList       Code = 25
 	CodeHashList          Code = 26
@@ -53,7 +54,11 @@ const (
 	CodeTrace     Code = 128
 )
 
-const version = iota
+// the protocol version, the messages are encrypted after the handshake since versionEncrypted
+const (
+	versionEncrypted = 1
+	version          = versionEncrypted
+)
 
 type Code = byte
 type MsgId = uint32
@@ -105,6 +105,10 @@ func (n *mockNet) PeerCount() int {
 	return 0
 }
 
+func (n *mockNet) PeerScores() []PeerScore {
+	return nil
+}
+
 func mock(chain Chain) Net {
 	return &mockNet{
 		chain: chain,
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"fmt"
+	_net "net"
+	"sync"
+
+	"github.com/vitelabs/go-vite/net/nat"
+	"github.com/vitelabs/go-vite/net/netool"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// sharedAddressSpace is the carrier-grade NAT addresses of RFC 6598
+var sharedAddressSpace netool.Netlist
+
+func init() {
+	sharedAddressSpace.Add("100.64.0.0/10")
+}
+
+// mapPorts maps Port and FilePort on the NAT gateway, then advertise the external addresses
+// to discovery and handshake, unless the public addresses have been set in config.
+// NAT traversal is best effort, failures will not stop the network.
+func (n *net) mapPorts() {
+	if n.config.NAT == "" {
+		return
+	}
+
+	m, err := nat.Parse(n.config.NAT)
+	if err != nil {
+		n.log.Warn(fmt.Sprintf("failed to find NAT gateway by %s: %v", n.config.NAT, err))
+		return
+	}
+	if m == nil {
+		return
+	}
+
+	n.mapPortsBy(m)
+}
+
+func (n *net) mapPortsBy(m nat.Interface) {
+	ip, err := m.ExternalIP()
+	if err != nil {
+		n.log.Warn(fmt.Sprintf("failed to get external IP from %s: %v", m, err))
+		return
+	}
+	// the gateway is behind another NAT, the mapped ports cannot be reached from the internet
+	if !isPublicIP(ip) {
+		n.log.Warn(fmt.Sprintf("external IP %s of %s is not public, skip NAT traversal", ip, m))
+		return
+	}
+
+	if n.config.PublicAddress == "" {
+		n.mapNodePorts(m)
+	}
+
+	if n.config.FilePublicAddress == "" {
+		if mp := n.mapPort(m, nat.TCP, n.config.FilePort, "vite file"); mp != nil {
+			// the address before mapped, advertised when the external IP is not public any more
+			n.hkr.mu.RLock()
+			fileAddress := n.hkr.fileAddress
+			n.hkr.mu.RUnlock()
+
+			advertise := func(ip _net.IP, port int) {
+				data := fileAddress
+				if isPublicIP(ip) {
+					var err error
+					if data, err = natEndPoint(ip, port).Serialize(); err != nil {
+						return
+					}
+				} else {
+					n.log.Warn(fmt.Sprintf("external IP %s of %s is not public, advertise no file address", ip, m))
+				}
+				n.hkr.setFileAddress(data)
+			}
+			advertise(mp.ExternalIP(), mp.ExternalPort())
+			mp.Notify(advertise)
+		}
+	}
+}
+
+// mapNodePorts maps the port of discovery and p2p, they share the same port number,
+// so the endpoint is advertised only if the external ports of UDP and TCP are the same.
+// The endpoint is advertised again when the external IP or any of the ports is changed.
+func (n *net) mapNodePorts(m nat.Interface) {
+	udp := n.mapPort(m, nat.UDP, n.config.Port, "vite discovery")
+	tcp := n.mapPort(m, nat.TCP, n.config.Port, "vite p2p")
+	if udp == nil || tcp == nil {
+		return
+	}
+
+	// the addresses before mapped, advertised when no endpoint can be advertised
+	n.hkr.mu.RLock()
+	publicAddress := n.hkr.publicAddress
+	n.hkr.mu.RUnlock()
+
+	var mu sync.Mutex
+	advertise := func(_net.IP, int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var ep vnode.EndPoint
+		data := publicAddress
+		ip := tcp.ExternalIP()
+		if udpPort, tcpPort := udp.ExternalPort(), tcp.ExternalPort(); !isPublicIP(ip) {
+			n.log.Warn(fmt.Sprintf("external IP %s of %s is not public, advertise no endpoint", ip, m))
+		} else if udpPort != tcpPort {
+			n.log.Warn(fmt.Sprintf("external port of UDP %d and TCP %d are different, advertise no endpoint", udpPort, tcpPort))
+		} else {
+			var err error
+			ep = natEndPoint(ip, tcpPort)
+			if data, err = ep.Serialize(); err != nil {
+				return
+			}
+		}
+
+		n.setEndPoint(ep)
+		n.hkr.setPublicAddress(data)
+	}
+
+	advertise(nil, 0)
+	udp.Notify(advertise)
+	tcp.Notify(advertise)
+}
+
+func (n *net) setEndPoint(ep vnode.EndPoint) {
+	if n.discover != nil {
+		n.discover.SetEndPoint(ep)
+	} else {
+		n.node.EndPoint = ep
+	}
+}
+
+func (n *net) mapPort(m nat.Interface, protocol string, port int, name string) *nat.Mapping {
+	mp, err := nat.Map(m, protocol, port, port, name)
+	if err != nil {
+		n.log.Warn(fmt.Sprintf("failed to map %s port %d by %s: %v", protocol, port, m, err))
+		return nil
+	}
+
+	n.mappings = append(n.mappings, mp)
+	return mp
+}
+
+func (n *net) unmapPorts() {
+	for _, mp := range n.mappings {
+		mp.Stop()
+	}
+	n.mappings = nil
+}
+
+func isPublicIP(ip _net.IP) bool {
+	if ip.IsUnspecified() || netool.IsLAN(ip) || netool.IsSpecialNetwork(ip) {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && sharedAddressSpace.Contains(ip4) {
+		return false
+	}
+	return true
+}
+
+func natEndPoint(ip _net.IP, port int) vnode.EndPoint {
+	if ip4 := ip.To4(); ip4 != nil {
+		return vnode.EndPoint{Host: ip4, Port: port, Typ: vnode.HostIPv4}
+	}
+
+	return vnode.EndPoint{Host: ip, Port: port, Typ: vnode.HostIPv6}
+}
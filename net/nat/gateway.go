@@ -0,0 +1,97 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+const linuxRouteFile = "/proc/net/route"
+
+// defaultGateways returns the default gateways in route table on linux,
+// otherwise guess the gateways are the x.x.x.1 of the private networks this host in.
+func defaultGateways() (ips []net.IP) {
+	if f, err := os.Open(linuxRouteFile); err == nil {
+		ips = parseRouteTable(f)
+		_ = f.Close()
+	}
+
+	if len(ips) > 0 {
+		return
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil || !isPrivate(ip) {
+			continue
+		}
+		gateway := ip.Mask(ipNet.Mask)
+		gateway[3] |= 1
+		ips = append(ips, gateway)
+	}
+
+	return
+}
+
+// parseRouteTable returns the gateways of default routes in the format of /proc/net/route:
+//
+//	Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+//	eth0  00000000    0100A8C0 0003 0 0 0 00000000 0 0 0
+//
+// the addresses are hex of little-endian
+func parseRouteTable(r io.Reader) (ips []net.IP) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+
+		ip := net.IPv4(b[3], b[2], b[1], b[0])
+		if !ip.IsUnspecified() {
+			ips = append(ips, ip)
+		}
+	}
+
+	return
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip[0] == 10 ||
+		(ip[0] == 172 && ip[1]&0xf0 == 16) ||
+		(ip[0] == 192 && ip[1] == 168)
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package nat maps the listening ports on the NAT gateway through UPnP-IGD or NAT-PMP,
+// so the nodes behind NAT can be reached by other nodes.
+package nat
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	TCP = "TCP"
+	UDP = "UDP"
+)
+
+const (
+	// the lifetime requested for a mapping, the mapping will be renewed before expired
+	mapLifetime = 20 * time.Minute
+	mapRenewal  = mapLifetime / 2
+	// retry interval when failed to renew
+	mapRetry = time.Minute
+)
+
+var errNoGateway = errors.New("no NAT gateway found")
+var errUnknownNAT = errors.New("unknown NAT mechanism")
+
+var natLog = log15.New("module", "nat")
+
+// Interface is a NAT gateway can map ports
+type Interface interface {
+	// ExternalIP is the public IP of the gateway
+	ExternalIP() (net.IP, error)
+	// AddMapping maps the extPort of the gateway to the intPort of this host,
+	// return the external port mapped, which maybe different from extPort
+	AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) (mapped int, err error)
+	// DeleteMapping removes the mapping added before
+	DeleteMapping(protocol string, extPort, intPort int) error
+	String() string
+}
+
+// Parse the NAT mechanism in config:
+//
+//	"" or "none": no NAT traversal
+//	"any": discover the gateway by UPnP and NAT-PMP, use the first one responds
+//	"upnp": discover the gateway by UPnP
+//	"pmp": NAT-PMP to the default gateway
+//	"pmp:192.168.0.1": NAT-PMP to the specific gateway
+//
+// The gateways are discovered in this function, so it maybe block for seconds.
+func Parse(spec string) (Interface, error) {
+	mech, param := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		mech, param = spec[:i], spec[i+1:]
+	}
+
+	switch strings.ToLower(mech) {
+	case "", "none":
+		return nil, nil
+	case "any":
+		return discoverAny()
+	case "upnp":
+		return DiscoverUPnP(ssdpAddress)
+	case "pmp":
+		if param == "" {
+			return discoverPMP(defaultGateways())
+		}
+		ip := net.ParseIP(param)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid NAT-PMP gateway %s", param)
+		}
+		return discoverPMP([]net.IP{ip})
+	default:
+		return nil, errUnknownNAT
+	}
+}
+
+// discoverAny discovers UPnP and NAT-PMP gateways concurrently
+func discoverAny() (Interface, error) {
+	ch := make(chan Interface, 2)
+
+	go func() {
+		m, err := DiscoverUPnP(ssdpAddress)
+		if err != nil {
+			natLog.Info(fmt.Sprintf("failed to discover UPnP gateway: %v", err))
+		}
+		ch <- m
+	}()
+	go func() {
+		m, err := discoverPMP(defaultGateways())
+		if err != nil {
+			natLog.Info(fmt.Sprintf("failed to discover NAT-PMP gateway: %v", err))
+		}
+		ch <- m
+	}()
+
+	for i := 0; i < 2; i++ {
+		if m := <-ch; m != nil {
+			return m, nil
+		}
+	}
+
+	return nil, errNoGateway
+}
+
+// Mapping keeps a port mapping alive until stopped
+type Mapping struct {
+	nat      Interface
+	protocol string
+	extPort  int
+	intPort  int
+	name     string
+
+	mu     sync.Mutex
+	ip     net.IP
+	mapped int
+	notify func(ip net.IP, mapped int)
+
+	term chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Map adds the mapping, then renews it in background
+func Map(m Interface, protocol string, extPort, intPort int, name string) (*Mapping, error) {
+	mapped, err := m.AddMapping(protocol, extPort, intPort, name, mapLifetime)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := m.ExternalIP()
+	if err != nil {
+		m.DeleteMapping(protocol, mapped, intPort)
+		return nil, err
+	}
+
+	mp := &Mapping{
+		nat:      m,
+		protocol: protocol,
+		extPort:  extPort,
+		intPort:  intPort,
+		name:     name,
+		ip:       ip,
+		mapped:   mapped,
+		term:     make(chan struct{}),
+	}
+	natLog.Info(fmt.Sprintf("map %s %d to %d by %s", protocol, mapped, intPort, m))
+
+	mp.wg.Add(1)
+	go mp.loop()
+
+	return mp, nil
+}
+
+// ExternalPort is the port mapped on the gateway
+func (mp *Mapping) ExternalPort() int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.mapped
+}
+
+// ExternalIP is the external IP of the gateway when the mapping was added or renewed last time
+func (mp *Mapping) ExternalIP() net.IP {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.ip
+}
+
+// Notify sets fn to be called with the new external IP and port when any of them is changed at renewal
+func (mp *Mapping) Notify(fn func(ip net.IP, mapped int)) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.notify = fn
+}
+
+func (mp *Mapping) loop() {
+	defer mp.wg.Done()
+
+	timer := time.NewTimer(mapRenewal)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-mp.term:
+			return
+		case <-timer.C:
+			if err := mp.Renew(); err != nil {
+				natLog.Warn(fmt.Sprintf("failed to renew mapping %s %d: %v", mp.protocol, mp.intPort, err))
+				timer.Reset(mapRetry)
+				continue
+			}
+
+			timer.Reset(mapRenewal)
+		}
+	}
+}
+
+// Renew adds the mapping again and notifies if the external IP or port is changed, it's called
+// periodically in background until the mapping is stopped
+func (mp *Mapping) Renew() error {
+	mapped, err := mp.nat.AddMapping(mp.protocol, mp.ExternalPort(), mp.intPort, mp.name, mapLifetime)
+	if err != nil {
+		return err
+	}
+	// the gateway may get a new IP from the ISP while the mapping is kept
+	ip, err := mp.nat.ExternalIP()
+	if err != nil {
+		return err
+	}
+
+	mp.mu.Lock()
+	var notify func(ip net.IP, mapped int)
+	if mapped != mp.mapped || !ip.Equal(mp.ip) {
+		natLog.Warn(fmt.Sprintf("external address of %s %d changed from %s:%d to %s:%d", mp.protocol, mp.intPort, mp.ip, mp.mapped, ip, mapped))
+		mp.ip = ip
+		mp.mapped = mapped
+		notify = mp.notify
+	}
+	mp.mu.Unlock()
+
+	if notify != nil {
+		notify(ip, mapped)
+	}
+	return nil
+}
+
+// Stop renewing and delete the mapping
+func (mp *Mapping) Stop() {
+	select {
+	case <-mp.term:
+		return
+	default:
+		close(mp.term)
+	}
+
+	mp.wg.Wait()
+
+	if err := mp.nat.DeleteMapping(mp.protocol, mp.ExternalPort(), mp.intPort); err != nil {
+		natLog.Warn(fmt.Sprintf("failed to delete mapping %s %d: %v", mp.protocol, mp.intPort, err))
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"", "none", "NONE"} {
+		if m, err := Parse(spec); m != nil || err != nil {
+			t.Fatalf("%q should be no NAT: %v %v", spec, m, err)
+		}
+	}
+
+	if _, err := Parse("stun"); err != errUnknownNAT {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := Parse("pmp:x.y"); err == nil {
+		t.Fatal("invalid gateway should be refused")
+	}
+}
+
+func TestParseRouteTable(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+`
+	ips := parseRouteTable(strings.NewReader(table))
+	if len(ips) != 1 || !ips[0].Equal(net.IPv4(192, 168, 1, 1)) {
+		t.Fatalf("wrong gateways %v", ips)
+	}
+}
+
+func TestMap(t *testing.T) {
+	f := newFakePMP(t)
+	defer f.conn.Close()
+
+	mp, err := Map(NewPMP(f.addr()), UDP, 8483, 8483, "vite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp.ExternalPort() != 18483 {
+		t.Fatalf("wrong external port %d", mp.ExternalPort())
+	}
+
+	mp.Stop()
+	mp.Stop()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.mappings) != 0 {
+		t.Fatal("mapping should be deleted when stopped")
+	}
+}
+
+// fakeNAT maps the ports to the external ports in ports, or the internal port if not set
+type fakeNAT struct {
+	mu    sync.Mutex
+	ip    net.IP
+	ports map[int]int
+}
+
+func (f *fakeNAT) ExternalIP() (net.IP, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ip == nil {
+		return net.IPv4(1, 2, 3, 4), nil
+	}
+	return f.ip, nil
+}
+
+func (f *fakeNAT) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if port, ok := f.ports[intPort]; ok {
+		return port, nil
+	}
+	return intPort, nil
+}
+
+func (f *fakeNAT) DeleteMapping(protocol string, extPort, intPort int) error {
+	return nil
+}
+
+func (f *fakeNAT) String() string {
+	return "fake"
+}
+
+func TestMapping_Notify(t *testing.T) {
+	f := &fakeNAT{ports: make(map[int]int)}
+	mp, err := Map(f, TCP, 8483, 8483, "vite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mp.Stop()
+
+	var notified []int
+	mp.Notify(func(ip net.IP, mapped int) {
+		notified = append(notified, mapped)
+	})
+
+	// the port is not changed
+	if err = mp.Renew(); err != nil {
+		t.Fatal(err)
+	}
+
+	f.mu.Lock()
+	f.ports[8483] = 9000
+	f.mu.Unlock()
+	if err = mp.Renew(); err != nil {
+		t.Fatal(err)
+	}
+	if mp.ExternalPort() != 9000 || len(notified) != 1 || notified[0] != 9000 {
+		t.Fatalf("the changed port should be notified: %d %v", mp.ExternalPort(), notified)
+	}
+
+	// the gateway gets a new IP
+	f.mu.Lock()
+	f.ip = net.IPv4(5, 6, 7, 8)
+	f.mu.Unlock()
+	if err = mp.Renew(); err != nil {
+		t.Fatal(err)
+	}
+	if !mp.ExternalIP().Equal(net.IPv4(5, 6, 7, 8)) || len(notified) != 2 || notified[1] != 9000 {
+		t.Fatalf("the changed IP should be notified: %s %v", mp.ExternalIP(), notified)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NAT-PMP, RFC 6886
+const (
+	pmpPort    = 5351
+	pmpVersion = 0
+
+	pmpOpExternalAddress = 0
+	pmpOpMapUDP          = 1
+	pmpOpMapTCP          = 2
+	pmpOpResponse        = 128
+
+	// the first request timeout, doubled every retry
+	pmpTimeout = 250 * time.Millisecond
+	pmpRetry   = 4
+)
+
+var errPMPInvalidResponse = errors.New("invalid NAT-PMP response")
+
+type pmpResultCode uint16
+
+var pmpResults = map[pmpResultCode]string{
+	1: "unsupported version",
+	2: "not authorized",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+func (c pmpResultCode) Error() string {
+	if str, ok := pmpResults[c]; ok {
+		return "NAT-PMP: " + str
+	}
+	return "NAT-PMP: result code " + strconv.Itoa(int(c))
+}
+
+type pmp struct {
+	gateway *net.UDPAddr
+	mu      sync.Mutex // one request at a time
+}
+
+// NewPMP returns the NAT-PMP client of the gateway, gateway is `IP:Port`, Port is 5351 usually
+func NewPMP(gateway *net.UDPAddr) Interface {
+	return &pmp{
+		gateway: gateway,
+	}
+}
+
+// discoverPMP returns the first gateway responds the external address request
+func discoverPMP(gateways []net.IP) (Interface, error) {
+	ch := make(chan Interface, len(gateways))
+
+	for _, ip := range gateways {
+		go func(ip net.IP) {
+			m := NewPMP(&net.UDPAddr{IP: ip, Port: pmpPort})
+			if _, err := m.ExternalIP(); err != nil {
+				ch <- nil
+			} else {
+				ch <- m
+			}
+		}(ip)
+	}
+
+	for range gateways {
+		if m := <-ch; m != nil {
+			return m, nil
+		}
+	}
+
+	return nil, errNoGateway
+}
+
+func (p *pmp) String() string {
+	return "NAT-PMP(" + p.gateway.String() + ")"
+}
+
+func (p *pmp) ExternalIP() (net.IP, error) {
+	res, err := p.request([]byte{pmpVersion, pmpOpExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.IPv4(res[8], res[9], res[10], res[11]), nil
+}
+
+/*
+ * mapping request
+ *  +---------+--------+------------+---------------+---------------+------------+
+ *  | Version | OpCode |  Reserved  | Internal Port | External Port |  Lifetime  |
+ *  | 1 byte  | 1 byte |  2 bytes   |    2 bytes    |    2 bytes    |  4 bytes   |
+ *  +---------+--------+------------+---------------+---------------+------------+
+ * mapping response
+ *  +---------+--------+------------+---------------+---------------+---------------+------------+
+ *  | Version | OpCode |   Result   |     Epoch     | Internal Port | External Port |  Lifetime  |
+ *  | 1 byte  | 1 byte |  2 bytes   |    4 bytes    |    2 bytes    |    2 bytes    |  4 bytes   |
+ *  +---------+--------+------------+---------------+---------------+---------------+------------+
+ */
+func (p *pmp) mapping(protocol string, extPort, intPort int, lifetime time.Duration) (mapped int, err error) {
+	var op byte
+	switch protocol {
+	case TCP:
+		op = pmpOpMapTCP
+	case UDP:
+		op = pmpOpMapUDP
+	default:
+		return 0, fmt.Errorf("unknown protocol %s", protocol)
+	}
+
+	req := make([]byte, 12)
+	req[0], req[1] = pmpVersion, op
+	binary.BigEndian.PutUint16(req[4:], uint16(intPort))
+	binary.BigEndian.PutUint16(req[6:], uint16(extPort))
+	binary.BigEndian.PutUint32(req[8:], uint32(lifetime/time.Second))
+
+	res, err := p.request(req, 16)
+	if err != nil {
+		return 0, err
+	}
+
+	if int(binary.BigEndian.Uint16(res[8:])) != intPort {
+		return 0, errPMPInvalidResponse
+	}
+
+	return int(binary.BigEndian.Uint16(res[10:])), nil
+}
+
+func (p *pmp) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) (int, error) {
+	if lifetime <= 0 {
+		lifetime = mapLifetime
+	}
+	return p.mapping(protocol, extPort, intPort, lifetime)
+}
+
+// DeleteMapping requests the mapping of zero lifetime and zero external port
+func (p *pmp) DeleteMapping(protocol string, extPort, intPort int) error {
+	_, err := p.mapping(protocol, 0, intPort, 0)
+	return err
+}
+
+// request sends req to the gateway, retry with doubled timeout until got the response of size
+func (p *pmp) request(req []byte, size int) (res []byte, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, err := net.DialUDP("udp", nil, p.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 16)
+	timeout := pmpTimeout
+	for i := 0; i < pmpRetry; i++ {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(timeout)
+		timeout *= 2
+		for {
+			if err = conn.SetReadDeadline(deadline); err != nil {
+				return nil, err
+			}
+
+			var n int
+			n, err = conn.Read(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+
+			// ignore the responses of other requests
+			if n < size || buf[0] != pmpVersion || buf[1] != pmpOpResponse+req[1] {
+				continue
+			}
+
+			if code := pmpResultCode(binary.BigEndian.Uint16(buf[2:])); code != 0 {
+				return nil, code
+			}
+
+			return buf[:size], nil
+		}
+	}
+
+	return nil, err
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePMP is a NAT-PMP gateway maps the external port to internal port + 10000
+type fakePMP struct {
+	conn     *net.UDPConn
+	mu       sync.Mutex
+	mappings map[[2]int]uint32 // protocol op, internal port: lifetime
+	drop     int               // drop the first requests to test retry
+}
+
+func newFakePMP(t *testing.T) *fakePMP {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakePMP{
+		conn:     conn,
+		mappings: make(map[[2]int]uint32),
+	}
+	go f.serve()
+	return f
+}
+
+func (f *fakePMP) addr() *net.UDPAddr {
+	return f.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (f *fakePMP) serve() {
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := f.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		if f.drop > 0 {
+			f.drop--
+			f.mu.Unlock()
+			continue
+		}
+
+		var res []byte
+		switch {
+		case n == 2 && buf[1] == pmpOpExternalAddress:
+			res = make([]byte, 12)
+			copy(res[8:], []byte{203, 0, 113, 7})
+		case n == 12 && (buf[1] == pmpOpMapTCP || buf[1] == pmpOpMapUDP):
+			intPort := binary.BigEndian.Uint16(buf[4:])
+			lifetime := binary.BigEndian.Uint32(buf[8:])
+			key := [2]int{int(buf[1]), int(intPort)}
+			if lifetime == 0 {
+				delete(f.mappings, key)
+			} else {
+				f.mappings[key] = lifetime
+			}
+
+			res = make([]byte, 16)
+			binary.BigEndian.PutUint16(res[8:], intPort)
+			if lifetime > 0 {
+				binary.BigEndian.PutUint16(res[10:], intPort+10000)
+			}
+			binary.BigEndian.PutUint32(res[12:], lifetime)
+		default:
+			res = make([]byte, 8)
+			binary.BigEndian.PutUint16(res[2:], 5)
+		}
+		f.mu.Unlock()
+
+		res[0], res[1] = pmpVersion, pmpOpResponse+buf[1]
+		_, _ = f.conn.WriteToUDP(res, addr)
+	}
+}
+
+func TestPMP(t *testing.T) {
+	f := newFakePMP(t)
+	defer f.conn.Close()
+
+	f.mu.Lock()
+	f.drop = 1
+	f.mu.Unlock()
+
+	m := NewPMP(f.addr())
+	ip, err := m.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(203, 0, 113, 7)) {
+		t.Fatalf("wrong external IP %s", ip)
+	}
+
+	mapped, err := m.AddMapping(TCP, 8483, 8483, "test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapped != 18483 {
+		t.Fatalf("wrong external port %d", mapped)
+	}
+	if _, err = m.AddMapping(UDP, 8483, 8483, "test", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	f.mu.Lock()
+	if f.mappings[[2]int{pmpOpMapTCP, 8483}] != 3600 || f.mappings[[2]int{pmpOpMapUDP, 8483}] != uint32(mapLifetime/time.Second) {
+		t.Fatalf("wrong mappings %v", f.mappings)
+	}
+	f.mu.Unlock()
+
+	if err = m.DeleteMapping(TCP, mapped, 8483); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	if _, ok := f.mappings[[2]int{pmpOpMapTCP, 8483}]; ok {
+		t.Fatal("mapping should be deleted")
+	}
+	f.mu.Unlock()
+}
+
+func TestDiscoverPMP(t *testing.T) {
+	if _, err := discoverPMP(nil); err != errNoGateway {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UPnP-IGD
+const (
+	ssdpAddress         = "239.255.255.250:1900"
+	upnpDiscoverTimeout = 3 * time.Second
+	upnpRequestTimeout  = 5 * time.Second
+
+	// the error code of routers only support permanent mappings
+	upnpOnlyPermanentLeases = "725"
+)
+
+var upnpDevices = []string{
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+}
+
+var upnpServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+var errUPnPNoService = errors.New("no WAN connection service in the UPnP device")
+
+type upnp struct {
+	client  *http.Client
+	control string // the control url of service
+	service string // the service type
+	localIP net.IP // the IP of this host in the network of gateway
+}
+
+// DiscoverUPnP searches the Internet Gateway Device by SSDP, ssdp is the multicast address usually
+func DiscoverUPnP(ssdp string) (Interface, error) {
+	addr, err := net.ResolveUDPAddr("udp4", ssdp)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, device := range upnpDevices {
+		req := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpAddress + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 2\r\n" +
+			"ST: " + device + "\r\n\r\n"
+		if _, err = conn.WriteToUDP([]byte(req), addr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = conn.SetReadDeadline(time.Now().Add(upnpDiscoverTimeout)); err != nil {
+		return nil, err
+	}
+
+	tried := make(map[string]struct{})
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil, errNoGateway
+			}
+			return nil, err
+		}
+
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil || res.StatusCode != http.StatusOK {
+			continue
+		}
+
+		location := res.Header.Get("Location")
+		if _, ok := tried[location]; ok || location == "" {
+			continue
+		}
+		tried[location] = struct{}{}
+
+		u, err := newUPnP(location)
+		if err != nil {
+			natLog.Info(fmt.Sprintf("ignore UPnP device %s: %v", location, err))
+			continue
+		}
+
+		return u, nil
+	}
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+// findService searches the WAN connection service in the device tree
+func (d *upnpDevice) findService() *upnpService {
+	for _, typ := range upnpServices {
+		for i := range d.Services {
+			if d.Services[i].ServiceType == typ {
+				return &d.Services[i]
+			}
+		}
+	}
+
+	for i := range d.Devices {
+		if s := d.Devices[i].findService(); s != nil {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// newUPnP reads the device description from location
+func newUPnP(location string) (*upnp, error) {
+	client := &http.Client{Timeout: upnpRequestTimeout}
+
+	res, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device description status %s", res.Status)
+	}
+
+	var root upnpRoot
+	if err = xml.NewDecoder(res.Body).Decode(&root); err != nil {
+		return nil, err
+	}
+
+	service := root.Device.findService()
+	if service == nil {
+		return nil, errUPnPNoService
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return nil, err
+		}
+	}
+	control, err := base.Parse(service.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// the local address connects to the gateway
+	host := control.Host
+	if control.Port() == "" {
+		host = net.JoinHostPort(control.Hostname(), "80")
+	}
+	conn, err := net.Dial("udp4", host)
+	if err != nil {
+		return nil, err
+	}
+	localIP := conn.LocalAddr().(*net.UDPAddr).IP
+	_ = conn.Close()
+
+	return &upnp{
+		client:  client,
+		control: control.String(),
+		service: service.ServiceType,
+		localIP: localIP,
+	}, nil
+}
+
+func (u *upnp) String() string {
+	return "UPnP(" + u.control + ")"
+}
+
+func (u *upnp) ExternalIP() (net.IP, error) {
+	values, err := u.soap("GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(values["NewExternalIPAddress"])
+	if ip == nil {
+		return nil, fmt.Errorf("invalid external IP %q", values["NewExternalIPAddress"])
+	}
+	return ip, nil
+}
+
+func (u *upnp) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) (int, error) {
+	args := [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(extPort)},
+		{"NewProtocol", protocol},
+		{"NewInternalPort", strconv.Itoa(intPort)},
+		{"NewInternalClient", u.localIP.String()},
+		{"NewEnabled", "1"},
+		{"NewPortMappingDescription", name},
+		{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+	}
+
+	_, err := u.soap("AddPortMapping", args)
+	if e, ok := err.(*upnpError); ok && e.code == upnpOnlyPermanentLeases {
+		args[len(args)-1][1] = "0"
+		_, err = u.soap("AddPortMapping", args)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return extPort, nil
+}
+
+func (u *upnp) DeleteMapping(protocol string, extPort, intPort int) error {
+	_, err := u.soap("DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(extPort)},
+		{"NewProtocol", protocol},
+	})
+	return err
+}
+
+type upnpError struct {
+	action      string
+	code        string
+	description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("UPnP %s error %s: %s", e.action, e.code, e.description)
+}
+
+// soap invokes the action of service, return the values of response
+func (u *upnp) soap(action string, args [][2]string) (map[string]string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + u.service + `">`)
+	for _, arg := range args {
+		body.WriteString("<" + arg[0] + ">")
+		_ = xml.EscapeText(&body, []byte(arg[1]))
+		body.WriteString("</" + arg[0] + ">")
+	}
+	body.WriteString(`</u:` + action + `></s:Body></s:Envelope>`)
+
+	req, err := http.NewRequest(http.MethodPost, u.control, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+u.service+"#"+action+`"`)
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := soapValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		if code, ok := values["errorCode"]; ok {
+			return nil, &upnpError{action, code, values["errorDescription"]}
+		}
+		return nil, fmt.Errorf("UPnP %s status %s", action, res.Status)
+	}
+
+	return values, nil
+}
+
+// soapValues collects the text of leaf elements by local name
+func soapValues(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var name string
+	var text []byte
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name, text = t.Name.Local, nil
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.EndElement:
+			if name == t.Name.Local {
+				values[name] = strings.TrimSpace(string(text))
+			}
+			name = ""
+		}
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fakeDeviceDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/l3f</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+// fakeIGD is an Internet Gateway Device only supports permanent mappings
+type fakeIGD struct {
+	ssdp     *net.UDPConn
+	http     *httptest.Server
+	mu       sync.Mutex
+	mappings map[string]map[string]string // protocol/port: args
+}
+
+func newFakeIGD(t *testing.T) *fakeIGD {
+	f := &fakeIGD{
+		mappings: make(map[string]map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/desc.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeDeviceDescription))
+	})
+	mux.HandleFunc("/ctl/IPConn", f.control)
+	f.http = httptest.NewServer(mux)
+
+	var err error
+	f.ssdp, err = net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go f.serveSSDP()
+
+	return f
+}
+
+func (f *fakeIGD) close() {
+	_ = f.ssdp.Close()
+	f.http.Close()
+}
+
+func (f *fakeIGD) serveSSDP() {
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := f.ssdp.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		req := string(buf[:n])
+		if !strings.HasPrefix(req, "M-SEARCH") || !strings.Contains(req, upnpDevices[0]) {
+			continue
+		}
+
+		res := "HTTP/1.1 200 OK\r\n" +
+			"CACHE-CONTROL: max-age=120\r\n" +
+			"ST: " + upnpDevices[0] + "\r\n" +
+			"LOCATION: " + f.http.URL + "/desc.xml\r\n\r\n"
+		_, _ = f.ssdp.WriteToUDP([]byte(res), addr)
+	}
+}
+
+func (f *fakeIGD) control(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	args, err := soapValues(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	action := r.Header.Get("SOAPAction")
+	action = strings.Trim(action[strings.IndexByte(action, '#')+1:], `"`)
+
+	var res string
+	f.mu.Lock()
+	switch action {
+	case "GetExternalIPAddress":
+		res = "<NewExternalIPAddress>198.51.100.9</NewExternalIPAddress>"
+	case "AddPortMapping":
+		if args["NewLeaseDuration"] != "0" {
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+				`<detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>725</errorCode>`+
+				`<errorDescription>OnlyPermanentLeasesSupported</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+			return
+		}
+		f.mappings[args["NewProtocol"]+"/"+args["NewExternalPort"]] = args
+	case "DeletePortMapping":
+		delete(f.mappings, args["NewProtocol"]+"/"+args["NewExternalPort"])
+	}
+	f.mu.Unlock()
+
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+		`<u:%sResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">%s</u:%sResponse></s:Body></s:Envelope>`,
+		action, res, action)
+}
+
+func TestUPnP(t *testing.T) {
+	f := newFakeIGD(t)
+	defer f.close()
+
+	m, err := DiscoverUPnP(f.ssdp.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := m.(*upnp); !strings.HasSuffix(u.control, "/ctl/IPConn") || !u.localIP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("wrong service %s %s", u.control, u.localIP)
+	}
+
+	ip, err := m.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(198, 51, 100, 9)) {
+		t.Fatalf("wrong external IP %s", ip)
+	}
+
+	mapped, err := m.AddMapping(TCP, 8484, 8484, "vite", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapped != 8484 {
+		t.Fatalf("wrong external port %d", mapped)
+	}
+
+	f.mu.Lock()
+	args := f.mappings["TCP/8484"]
+	if args == nil || args["NewInternalPort"] != "8484" || args["NewInternalClient"] != "127.0.0.1" || args["NewPortMappingDescription"] != "vite" {
+		t.Fatalf("wrong mapping %v", args)
+	}
+	f.mu.Unlock()
+
+	if err = m.DeleteMapping(TCP, 8484, 8484); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	if len(f.mappings) != 0 {
+		t.Fatalf("mapping should be deleted")
+	}
+	f.mu.Unlock()
+}
+
+func TestSoapValues(t *testing.T) {
+	values, err := soapValues([]byte(`<a><b> 1 </b><c><d>x&amp;y</d></c></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["b"] != "1" || values["d"] != "x&y" {
+		t.Fatalf("wrong values %v", values)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"bytes"
+	_net "net"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// fakeGateway maps the ports to the external ports in ports, or the internal port + 10000 if not set
+type fakeGateway struct {
+	ip    _net.IP
+	ports map[string]int
+}
+
+func (f *fakeGateway) ExternalIP() (_net.IP, error) {
+	return f.ip, nil
+}
+
+func (f *fakeGateway) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) (int, error) {
+	if port, ok := f.ports[protocol]; ok {
+		return port, nil
+	}
+	return intPort + 10000, nil
+}
+
+func (f *fakeGateway) DeleteMapping(protocol string, extPort, intPort int) error {
+	return nil
+}
+
+func (f *fakeGateway) String() string {
+	return "fake"
+}
+
+func TestNet_mapPorts(t *testing.T) {
+	newNet := func() *net {
+		cfg := &config.Net{Port: 8483, FilePort: 8484}
+		publicAddress, _ := retrieveAddressBytesFromConfig("", cfg.Port)
+		fileAddress, _ := retrieveAddressBytesFromConfig("", cfg.FilePort)
+		return &net{
+			config: cfg,
+			node:   &vnode.Node{},
+			hkr: &handshaker{
+				publicAddress: publicAddress,
+				fileAddress:   fileAddress,
+			},
+			log: netLog,
+		}
+	}
+	serialize := func(ep vnode.EndPoint) []byte {
+		data, err := ep.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	ip := _net.IPv4(1, 2, 3, 4)
+
+	n := newNet()
+	n.mapPortsBy(&fakeGateway{ip: ip})
+	defer n.unmapPorts()
+	if ep := natEndPoint(ip, 18483); !n.node.EndPoint.Equal(&ep) || !bytes.Equal(n.hkr.publicAddress, serialize(ep)) {
+		t.Fatalf("wrong public address %s", n.node.EndPoint)
+	}
+	if !bytes.Equal(n.hkr.fileAddress, serialize(natEndPoint(ip, 18484))) {
+		t.Fatal("wrong file address")
+	}
+
+	// the external ports of discovery and p2p are different
+	n = newNet()
+	defaultAddress := n.hkr.publicAddress
+	n.mapPortsBy(&fakeGateway{ip: ip, ports: map[string]int{"UDP": 18483, "TCP": 28483}})
+	defer n.unmapPorts()
+	if n.node.EndPoint.Port != 0 || !bytes.Equal(n.hkr.publicAddress, defaultAddress) {
+		t.Fatalf("no endpoint should be advertised, got %s", n.node.EndPoint)
+	}
+
+	// the external IP is changed at renewal
+	n = newNet()
+	defaultAddress, defaultFileAddress := n.hkr.publicAddress, n.hkr.fileAddress
+	gateway := &fakeGateway{ip: ip}
+	n.mapPortsBy(gateway)
+	defer n.unmapPorts()
+	renew := func() {
+		for _, mp := range n.mappings {
+			if err := mp.Renew(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	newIP := _net.IPv4(5, 6, 7, 8)
+	gateway.ip = newIP
+	renew()
+	if ep := natEndPoint(newIP, 18483); !n.node.EndPoint.Equal(&ep) || !bytes.Equal(n.hkr.publicAddress, serialize(ep)) {
+		t.Fatalf("the new IP should be advertised, got %s", n.node.EndPoint)
+	}
+	if !bytes.Equal(n.hkr.fileAddress, serialize(natEndPoint(newIP, 18484))) {
+		t.Fatal("the file address of the new IP should be advertised")
+	}
+	gateway.ip = _net.IPv4(100, 64, 1, 1)
+	renew()
+	if n.node.EndPoint.Port != 0 || !bytes.Equal(n.hkr.publicAddress, defaultAddress) || !bytes.Equal(n.hkr.fileAddress, defaultFileAddress) {
+		t.Fatalf("no endpoint should be advertised for the IP not public, got %s", n.node.EndPoint)
+	}
+
+	// the gateway is behind another NAT
+	for _, ip := range []_net.IP{_net.IPv4(100, 64, 1, 1), _net.IPv4(192, 168, 1, 1), _net.IPv4zero} {
+		n = newNet()
+		n.mapPortsBy(&fakeGateway{ip: ip})
+		if len(n.mappings) != 0 || n.node.EndPoint.Port != 0 {
+			t.Fatalf("ports should not be mapped by the gateway of %s", ip)
+		}
+	}
+}
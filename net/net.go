@@ -25,6 +25,7 @@ import (
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net/database"
 	"github.com/vitelabs/go-vite/net/discovery"
+	"github.com/vitelabs/go-vite/net/nat"
 	"github.com/vitelabs/go-vite/net/vnode"
 )
 
@@ -52,6 +53,9 @@ type net struct {
 	hkr          *handshaker
 	receiveSlots chan struct{}
 
+	// port mappings on the NAT gateway
+	mappings []*nat.Mapping
+
 	confirmedHashHeightList []*ledger.HashHeight
 
 	syncServer *syncServer
@@ -220,6 +224,11 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		return
 	}
 
+	if n.peers.scores.get(msg.ID) < evictPeerScore {
+		err = PeerBadReputation
+		return
+	}
+
 	// no space
 	if n.peers.countWithoutSBP() >= n.config.MaxPeers {
 		err = PeerTooManyPeers
@@ -397,6 +406,19 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		Verifier:    verifier,
 	}
 
+	// the mine key proves to the peers that the node is the producer of the coinbase
+	var mineKey mineSigner
+	var mineAddress types.Address
+	if len(cfg.MineKey) != 0 {
+		mineKey = keySigner(cfg.MineKey)
+		mineAddress = types.PubkeyToAddress(cfg.MineKey.PubByte())
+	} else if cfg.MineSigner != nil {
+		mineKey = cfg.MineSigner
+	}
+	if cfg.MineAddress != nil {
+		mineAddress = *cfg.MineAddress
+	}
+
 	var id peerId
 	id, _ = vnode.Bytes2NodeID(peerKey.PubByte())
 	syncConnFac := &defaultSyncConnectionFactory{
@@ -404,7 +426,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		peers:   peers,
 		id:      id,
 		peerKey: peerKey,
-		mineKey: cfg.MineKey,
+		mineKey: mineKey,
 	}
 	downloader := newExecutor(50, 10, peers, syncConnFac)
 
@@ -468,7 +490,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		fileAddress:   fileAddress,
 		publicAddress: publicAddress,
 		peerKey:       peerKey,
-		key:           cfg.MineKey,
+		key:           mineKey,
 		codecFactory: &transportFactory{
 			minCompressLength: 100,
 			readTimeout:       readMsgTimeout,
@@ -483,17 +505,13 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 	if err != nil {
 		return nil, err
 	}
+	peers.scores = newPeerScores(n.db)
 
 	if cfg.Discover {
-		n.discover = discovery.New(peerKey, n.node, cfg.BootNodes, cfg.BootSeeds, cfg.ListenInterface+":"+strconv.Itoa(cfg.Port), n.db)
-	}
-
-	var addr types.Address
-	if len(n.config.MineKey) != 0 {
-		addr = types.PubkeyToAddress(n.config.MineKey.PubByte())
+		n.discover = discovery.New(peerKey, n.node, cfg.BootNodes, cfg.BootSeeds, cfg.BootTrees, cfg.ListenInterface+":"+strconv.Itoa(cfg.Port), n.db)
 	}
 
-	n.finder, err = newFinder(addr, n.peers, cfg.MinPeers, cfg.StaticNodes, n.db, n, consensus)
+	n.finder, err = newFinder(mineAddress, n.peers, cfg.MinPeers, cfg.StaticNodes, n.db, n, consensus)
 	if err != nil {
 		return nil, err
 	}
@@ -505,8 +523,10 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 
 	if n.discover != nil {
 		n.discover.SetFinder(n.finder)
-		if len(n.config.MineKey) != 0 {
-			setNodeExt(n.config.MineKey, n.node)
+		if mineKey != nil {
+			if err = setNodeExt(mineKey, n.node); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -591,8 +611,13 @@ func (n *net) beatLoop() {
 					weight = 100
 				}
 
+				weight += n.peers.scores.get(pe.Id)
+
 				n.db.StoreMark(pe.Id, weight)
 			}
+
+			n.peers.scores.decay()
+			n.peers.scores.flush()
 		}
 	}
 }
@@ -667,6 +692,8 @@ func (n *net) Start() (err error) {
 			return
 		}
 
+		n.mapPorts()
+
 		if n.discover != nil {
 			err = n.discover.Start()
 			if err != nil {
@@ -727,6 +754,10 @@ func (n *net) Stop() error {
 		n.finder.clean()
 
 		n.wg.Wait()
+
+		n.unmapPorts()
+
+		n.peers.scores.flush()
 		return nil
 	}
 
@@ -741,6 +772,10 @@ func (n *net) PeerKey() ed25519.PrivateKey {
 	return n.peerKey
 }
 
+func (n *net) PeerScores() []PeerScore {
+	return n.peers.scores.list()
+}
+
 func (n *net) PeerCount() int {
 	return n.peers.count()
 }
@@ -132,7 +132,8 @@ type Peer struct {
 	manager PeerManager
 	handler msgHandler
 
-	knownBlocks *bloom.Filter
+	knownBlocks    *bloom.Filter // the blocks sent by the peer or to the peer, will not be forwarded to the peer
+	receivedBlocks *bloom.Filter // the blocks sent by the peer
 
 	m  map[peerId]struct{}
 	m2 map[peerId]struct{} // MUST NOT write m2, only read, for cross peers
@@ -230,31 +231,32 @@ func newPeer(c Codec, their *HandshakeMsg, publicAddress, fileAddress string, su
 	c.SetWriteTimeout(writeMsgTimeout)
 
 	peer := &Peer{
-		codec:         c,
-		Id:            their.ID,
-		Name:          their.Name,
-		Height:        their.Height,
-		Head:          their.Head,
-		Version:       their.Version,
-		publicAddress: publicAddress,
-		fileAddress:   fileAddress,
-		CreateAt:      their.Timestamp,
-		Flag:          flag,
-		Superior:      superior,
-		reliable:      0,
-		running:       0,
-		writable:      1,
-		writing:       0,
-		readQueue:     make(chan Msg, 10),
-		writeQueue:    make(chan Msg, 1000),
-		errChan:       make(chan error, 4), // read write handle catch
-		wg:            sync.WaitGroup{},
-		manager:       manager,
-		handler:       handler,
-		knownBlocks:   bloom.New(filterCap, rt),
-		m:             make(map[peerId]struct{}),
-		m2:            nil,
-		once:          sync.Once{},
+		codec:          c,
+		Id:             their.ID,
+		Name:           their.Name,
+		Height:         their.Height,
+		Head:           their.Head,
+		Version:        their.Version,
+		publicAddress:  publicAddress,
+		fileAddress:    fileAddress,
+		CreateAt:       their.Timestamp,
+		Flag:           flag,
+		Superior:       superior,
+		reliable:       0,
+		running:        0,
+		writable:       1,
+		writing:        0,
+		readQueue:      make(chan Msg, 10),
+		writeQueue:     make(chan Msg, 1000),
+		errChan:        make(chan error, 4), // read write handle catch
+		wg:             sync.WaitGroup{},
+		manager:        manager,
+		handler:        handler,
+		knownBlocks:    bloom.New(filterCap, rt),
+		receivedBlocks: bloom.New(filterCap, rt),
+		m:              make(map[peerId]struct{}),
+		m2:             nil,
+		once:           sync.Once{},
 	}
 	peer.log = netLog.New("peer", peer.String())
 
@@ -494,6 +496,8 @@ type peerSet struct {
 	m   map[peerId]*Peer
 	prw sync.RWMutex
 
+	scores *peerScores
+
 	subs []chan<- peerEvent
 }
 
@@ -540,7 +544,20 @@ func (m *peerSet) pickDownloadPeers(height uint64) (m2 map[peerId]*Peer) {
 
 func newPeerSet() *peerSet {
 	return &peerSet{
-		m: make(map[peerId]*Peer),
+		m:      make(map[peerId]*Peer),
+		scores: newPeerScores(nil),
+	}
+}
+
+// score change the reputation of the peer, disconnect the peer if the score is too low, except producers
+func (m *peerSet) score(p *Peer, delta int64) {
+	if p == nil {
+		return
+	}
+
+	if score := m.scores.add(p.Id, delta); score < evictPeerScore && !p.Superior {
+		netLog.Warn(fmt.Sprintf("disconnect peer %s for bad reputation %d", p.Id, score))
+		p.catch(PeerBadReputation)
 	}
 }
 
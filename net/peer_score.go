@@ -0,0 +1,180 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"sort"
+	"sync"
+)
+
+// the score changes of peer behaviors
+const (
+	scoreNewBlock       = 1   // broadcast a block not received before
+	scoreDuplicateBlock = -2  // broadcast a block it has sent
+	scoreFetchDone      = 2   // response a fetch request
+	scoreFetchFailed    = -5  // fetch request failed or timeout
+	scoreSyncDone       = 5   // a chunk downloaded
+	scoreSyncSlow       = -5  // a chunk downloaded, but too slow
+	scoreSyncFailed     = -20 // failed to download or read a chunk
+	scoreInvalidBlock   = -50 // the block cannot pass verification
+)
+
+const (
+	maxPeerScore = 1000
+	minPeerScore = -1000
+	// peers lower than the score will be disconnected, and will not be accepted or dialed
+	evictPeerScore = -200
+	// score decays 1/scoreDecayRatio every decay, so bad peers can come back after hours
+	scoreDecayRatio = 64
+	// sync speed slower than it is slow, byte/s
+	slowSyncSpeed = 10 * 1024
+)
+
+type scoreStore interface {
+	StoreScore(id peerId, score int64)
+	RemoveScore(id peerId)
+	ReadScores() map[peerId]int64
+}
+
+type PeerScore struct {
+	Id    string `json:"id"`
+	Score int64  `json:"score"`
+}
+
+// peerScores is the reputation of peers, keep the score of disconnected peers too
+type peerScores struct {
+	mu     sync.Mutex
+	scores map[peerId]int64
+	dirty  map[peerId]struct{}
+	store  scoreStore
+}
+
+// newPeerScores load scores from store, store can be nil
+func newPeerScores(store scoreStore) *peerScores {
+	s := &peerScores{
+		scores: make(map[peerId]int64),
+		dirty:  make(map[peerId]struct{}),
+		store:  store,
+	}
+
+	if store != nil {
+		for id, score := range store.ReadScores() {
+			s.scores[id] = score
+		}
+	}
+
+	return s
+}
+
+func (s *peerScores) get(id peerId) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.scores[id]
+}
+
+// add delta to the score of peer, return the new score
+func (s *peerScores) add(id peerId, delta int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	score := s.scores[id] + delta
+	if score > maxPeerScore {
+		score = maxPeerScore
+	} else if score < minPeerScore {
+		score = minPeerScore
+	}
+
+	s.scores[id] = score
+	s.dirty[id] = struct{}{}
+
+	return score
+}
+
+// decay all scores towards zero, the zero scores will be removed
+func (s *peerScores) decay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, score := range s.scores {
+		d := score / scoreDecayRatio
+		if d == 0 {
+			if score > 0 {
+				d = 1
+			} else if score < 0 {
+				d = -1
+			}
+		}
+
+		s.scores[id] = score - d
+		s.dirty[id] = struct{}{}
+	}
+}
+
+// flush the changed scores to store
+func (s *peerScores) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.dirty {
+		score := s.scores[id]
+		if score == 0 {
+			delete(s.scores, id)
+		}
+
+		if s.store != nil {
+			if score == 0 {
+				s.store.RemoveScore(id)
+			} else {
+				s.store.StoreScore(id, score)
+			}
+		}
+	}
+
+	s.dirty = make(map[peerId]struct{})
+}
+
+// list return scores from high to low
+func (s *peerScores) list() []PeerScore {
+	s.mu.Lock()
+	list := make([]PeerScore, 0, len(s.scores))
+	for id, score := range s.scores {
+		list = append(list, PeerScore{
+			Id:    id.String(),
+			Score: score,
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Score > list[j].Score
+	})
+
+	return list
+}
+
+// sortByScore sort peers from high score to low
+func (s *peerScores) sortByScore(ps peers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sort.SliceStable(ps, func(i, j int) bool {
+		return s.scores[ps[i].Id] > s.scores[ps[j].Id]
+	})
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/net/database"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func TestPeerScores(t *testing.T) {
+	db, err := database.New("", 1, vnode.RandomNodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newPeerScores(db)
+	good, bad := vnode.RandomNodeID(), vnode.RandomNodeID()
+
+	for i := 0; i < 2*maxPeerScore; i++ {
+		s.add(good, scoreNewBlock)
+	}
+	if score := s.get(good); score != maxPeerScore {
+		t.Fatalf("score should be limited to %d, got %d", maxPeerScore, score)
+	}
+	s.add(bad, scoreInvalidBlock)
+
+	list := s.list()
+	if len(list) != 2 || list[0].Id != good.String() || list[1].Score != scoreInvalidBlock {
+		t.Fatalf("wrong scores: %v", list)
+	}
+
+	s.flush()
+	if scores := newPeerScores(db); scores.get(good) != maxPeerScore || scores.get(bad) != scoreInvalidBlock {
+		t.Fatal("scores should be persisted")
+	}
+
+	// bad peers come back at last
+	for s.get(bad) != 0 {
+		s.decay()
+	}
+	s.flush()
+	if _, ok := db.ReadScores()[bad]; ok {
+		t.Fatal("zero score should be removed")
+	}
+	if score := s.get(good); score <= 0 || score >= maxPeerScore {
+		t.Fatalf("score should decay, got %d", score)
+	}
+}
+
+func TestPeerSet_score(t *testing.T) {
+	m := newPeerSet()
+	p := &Peer{
+		Id:      vnode.RandomNodeID(),
+		errChan: make(chan error, 1),
+	}
+	sbp := &Peer{
+		Id:       vnode.RandomNodeID(),
+		Superior: true,
+		errChan:  make(chan error, 1),
+	}
+
+	for m.scores.get(p.Id) >= evictPeerScore {
+		m.score(p, scoreInvalidBlock)
+		m.score(sbp, scoreInvalidBlock)
+	}
+
+	select {
+	case err := <-p.errChan:
+		if err != PeerBadReputation {
+			t.Fatalf("unexpected error %v", err)
+		}
+	default:
+		t.Fatal("peer of bad reputation should be disconnected")
+	}
+
+	select {
+	case err := <-sbp.errChan:
+		t.Fatalf("producer should not be disconnected: %v", err)
+	default:
+	}
+
+	// nil peer is ignored
+	m.score(nil, scoreInvalidBlock)
+}
+
+func TestPeerScores_sortByScore(t *testing.T) {
+	s := newPeerScores(nil)
+	ps := peers{
+		{Id: vnode.RandomNodeID()},
+		{Id: vnode.RandomNodeID()},
+		{Id: vnode.RandomNodeID()},
+	}
+	s.add(ps[1].Id, scoreFetchDone)
+	s.add(ps[2].Id, scoreFetchFailed)
+	best, worst := ps[1], ps[2]
+
+	s.sortByScore(ps)
+	if ps[0] != best || ps[2] != worst {
+		t.Fatal("peers should be sorted by score")
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	_net "net"
+
+	"github.com/vitelabs/go-vite/crypto"
+	"golang.org/x/crypto/curve25519"
+)
+
+// the plaintext of a frame is no more than maxSecureFrameSize bytes
+const maxSecureFrameSize = 16 * 1024
+
+var errInvalidEphemeralKey = errors.New("invalid ephemeral key")
+var errSecureFrameTooLarge = errors.New("secure frame is too large")
+
+// encrypted reports whether the messages are encrypted between the two versions
+func encrypted(ourVersion, theirVersion int64) bool {
+	return ourVersion >= versionEncrypted && theirVersion >= versionEncrypted
+}
+
+type secureKeys struct {
+	egress  []byte
+	ingress []byte
+}
+
+/*
+ * key exchange
+ * After the handshake, the initiator sends its ephemeral x25519 public key in a CodeKeyExchange message,
+ * then the receiver replies with its own. The keys of the two directions are
+ *
+ *  Hash256(ephemeral secret, static secret, transcript, initiator ephemeral key, receiver ephemeral key, direction)
+ *
+ * the static secret is the x25519 secret of the node keys which authenticates the handshake,
+ * so the keys can only be derived by the two handshaked nodes. The transcript is the hash of
+ * the version tokens of the handshake, the keys mismatch if either of the handshakes is tampered.
+ */
+func exchangeKeys(c Codec, secret, transcript []byte, initiator bool) (keys *secureKeys, err error) {
+	var priv, pub [32]byte
+	if _, err = io.ReadFull(crand.Reader, priv[:]); err != nil {
+		return
+	}
+	curve25519.ScalarBaseMult(&pub, &priv)
+
+	var theirs []byte
+	if initiator {
+		if err = writeEphemeralKey(c, pub[:]); err != nil {
+			return
+		}
+		if theirs, err = readEphemeralKey(c); err != nil {
+			return
+		}
+	} else {
+		if theirs, err = readEphemeralKey(c); err != nil {
+			return
+		}
+		if err = writeEphemeralKey(c, pub[:]); err != nil {
+			return
+		}
+	}
+
+	ephemeral, err := crypto.X25519ComputeSecret(priv[:], theirs)
+	if err != nil {
+		return
+	}
+	// a low order point makes the secret zero
+	if bytes.Equal(ephemeral, make([]byte, len(ephemeral))) {
+		err = errInvalidEphemeralKey
+		return
+	}
+
+	initiatorKey, receiverKey := pub[:], theirs
+	if !initiator {
+		initiatorKey, receiverKey = theirs, pub[:]
+	}
+	toReceiver := crypto.Hash256(ephemeral, secret, transcript, initiatorKey, receiverKey, []byte("initiator"))
+	toInitiator := crypto.Hash256(ephemeral, secret, transcript, initiatorKey, receiverKey, []byte("receiver"))
+
+	if initiator {
+		return &secureKeys{egress: toReceiver, ingress: toInitiator}, nil
+	}
+	return &secureKeys{egress: toInitiator, ingress: toReceiver}, nil
+}
+
+func writeEphemeralKey(c Codec, key []byte) error {
+	return c.WriteMsg(Msg{
+		Code:    CodeKeyExchange,
+		Payload: key,
+	})
+}
+
+func readEphemeralKey(c Codec) (key []byte, err error) {
+	msg, err := c.ReadMsg()
+	if err != nil {
+		return
+	}
+
+	if msg.Code == CodeDisconnect {
+		if len(msg.Payload) > 0 {
+			err = PeerError(msg.Payload[0])
+		} else {
+			err = PeerUnknownReason
+		}
+		return
+	}
+
+	if msg.Code != CodeKeyExchange || len(msg.Payload) != 32 {
+		err = errInvalidEphemeralKey
+		return
+	}
+
+	return msg.Payload, nil
+}
+
+/*
+ * secure frame structure
+ *  +-----------------+---------------------------------------+
+ *  |     Length      |        AES-GCM sealed plaintext       |
+ *  |     4 bytes     |       Length bytes, 16 bytes tag      |
+ *  +-----------------+---------------------------------------+
+ * The nonce is the count of frames in the direction, the Length is the additional data.
+ */
+type secureConn struct {
+	_net.Conn
+	enc, dec           cipher.AEAD
+	encNonce, decNonce uint64
+	writeBuf           []byte
+	readHead           [4]byte
+	readBuf            []byte
+	plain              []byte // decrypted but not read yet
+}
+
+func newSecureConn(conn _net.Conn, keys *secureKeys) (*secureConn, error) {
+	enc, err := newGCM(keys.egress)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := newGCM(keys.ingress)
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{
+		Conn: conn,
+		enc:  enc,
+		dec:  dec,
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func makeNonce(aead cipher.AEAD, n uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], n)
+	return nonce
+}
+
+// Write is NOT thread-safe
+func (s *secureConn) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		size := len(p)
+		if size > maxSecureFrameSize {
+			size = maxSecureFrameSize
+		}
+
+		frameLen := 4 + size + s.enc.Overhead()
+		if cap(s.writeBuf) < frameLen {
+			s.writeBuf = make([]byte, frameLen)
+		}
+		frame := s.writeBuf[:frameLen]
+		binary.BigEndian.PutUint32(frame[:4], uint32(size+s.enc.Overhead()))
+		s.enc.Seal(frame[4:4], makeNonce(s.enc, s.encNonce), p[:size], frame[:4])
+		s.encNonce++
+
+		if _, err = s.Conn.Write(frame); err != nil {
+			return
+		}
+		n += size
+		p = p[size:]
+	}
+
+	return
+}
+
+// Read is NOT thread-safe
+func (s *secureConn) Read(p []byte) (n int, err error) {
+	if len(s.plain) == 0 {
+		if _, err = io.ReadFull(s.Conn, s.readHead[:]); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint32(s.readHead[:]))
+		if length > maxSecureFrameSize+s.dec.Overhead() {
+			return 0, errSecureFrameTooLarge
+		}
+
+		if cap(s.readBuf) < length {
+			s.readBuf = make([]byte, length)
+		}
+		sealed := s.readBuf[:length]
+		if _, err = io.ReadFull(s.Conn, sealed); err != nil {
+			return
+		}
+
+		s.plain, err = s.dec.Open(sealed[:0], makeNonce(s.dec, s.decNonce), sealed, s.readHead[:])
+		if err != nil {
+			return 0, fmt.Errorf("failed to open secure frame: %v", err)
+		}
+		s.decNonce++
+	}
+
+	n = copy(p, s.plain)
+	s.plain = s.plain[n:]
+	return
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	_net "net"
+	"testing"
+	"time"
+)
+
+// securePipe exchanges keys over a pipe and returns the codecs of the encrypted connections
+func securePipe(t *testing.T, secret1, secret2 []byte) (c1, c2 Codec, err1, err2 error) {
+	conn1, conn2 := _net.Pipe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var keys *secureKeys
+		keys, err2 = exchangeKeys(NewTransport(conn2, 100, time.Second, time.Second), secret2, nil, false)
+		if err2 != nil {
+			return
+		}
+		sconn, err := newSecureConn(conn2, keys)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		c2 = NewTransport(sconn, 100, time.Second, time.Second)
+	}()
+
+	keys, err1 := exchangeKeys(NewTransport(conn1, 100, time.Second, time.Second), secret1, nil, true)
+	<-done
+	if err1 != nil {
+		return
+	}
+	sconn, err := newSecureConn(conn1, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 = NewTransport(sconn, 100, time.Second, time.Second)
+	return
+}
+
+func TestSecureConn(t *testing.T) {
+	secret := make([]byte, 32)
+	_, _ = crand.Read(secret)
+
+	c1, c2, err1, err2 := securePipe(t, secret, secret)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("failed to exchange keys: %v %v", err1, err2)
+	}
+
+	// larger than a frame and incompressible
+	large := make([]byte, 3*maxSecureFrameSize+7)
+	_, _ = crand.Read(large)
+
+	for _, payload := range [][]byte{[]byte("hello"), large, nil} {
+		go func(payload []byte) {
+			if err := c1.WriteMsg(Msg{Code: CodeHeartBeat, Id: 7, Payload: payload}); err != nil {
+				t.Error(err)
+			}
+		}(payload)
+		msg, err := c2.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Code != CodeHeartBeat || msg.Id != 7 || !bytes.Equal(msg.Payload, payload) {
+			t.Fatalf("unexpected message %d %d, payload length %d", msg.Code, msg.Id, len(msg.Payload))
+		}
+	}
+
+	// the other direction
+	go func() {
+		_ = c2.WriteMsg(Msg{Code: CodeHeartBeat, Payload: []byte("world")})
+	}()
+	msg, err := c1.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Payload) != "world" {
+		t.Fatalf("unexpected payload %s", msg.Payload)
+	}
+}
+
+func TestSecureConn_WrongSecret(t *testing.T) {
+	secret1 := make([]byte, 32)
+	secret2 := make([]byte, 32)
+	_, _ = crand.Read(secret1)
+	_, _ = crand.Read(secret2)
+
+	c1, c2, err1, err2 := securePipe(t, secret1, secret2)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("failed to exchange keys: %v %v", err1, err2)
+	}
+
+	go func() {
+		_ = c1.WriteMsg(Msg{Code: CodeHeartBeat, Payload: []byte("hello")})
+	}()
+	if _, err := c2.ReadMsg(); err == nil {
+		t.Fatal("message of a different secret should not be opened")
+	}
+}
+
+func TestEncrypted(t *testing.T) {
+	if encrypted(versionEncrypted, versionEncrypted-1) || encrypted(versionEncrypted-1, versionEncrypted) {
+		t.Fatal("messages to the old version should be plaintext")
+	}
+	if !encrypted(versionEncrypted, versionEncrypted) {
+		t.Fatal("messages should be encrypted")
+	}
+}
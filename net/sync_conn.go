@@ -174,7 +174,7 @@ type defaultSyncConnectionFactory struct {
 	peers   *peerSet
 	id      peerId
 	peerKey ed25519.PrivateKey
-	mineKey ed25519.PrivateKey
+	mineKey mineSigner
 }
 
 func (d *defaultSyncConnectionFactory) makeSyncConn(conn net2.Conn) *syncConn {
@@ -184,6 +184,28 @@ func (d *defaultSyncConnectionFactory) makeSyncConn(conn net2.Conn) *syncConn {
 	}
 }
 
+// secure encrypts the connection if the peer supports, both the messages and the chunks are encrypted.
+// The version of the peer is authenticated by the handshake of the peer, so there is no transcript.
+func (f *syncConn) secure(theirVersion int64, secret []byte, initiator bool) error {
+	if !encrypted(version, theirVersion) {
+		return nil
+	}
+
+	keys, err := exchangeKeys(f.c, secret, nil, initiator)
+	if err != nil {
+		return err
+	}
+
+	sconn, err := newSecureConn(f.conn, keys)
+	if err != nil {
+		return err
+	}
+
+	f.conn = sconn
+	f.c = NewTransport(sconn, 100, 10*time.Second, 10*time.Second)
+	return nil
+}
+
 func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*syncConn, error) {
 	c := d.makeSyncConn(conn)
 
@@ -202,9 +224,13 @@ func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*sy
 	binary.BigEndian.PutUint64(t, uint64(hk.time))
 	hash := crypto.Hash256(t)
 	hk.token = xor(hash, secret)
-	if len(d.mineKey) != 0 {
-		hk.key = d.mineKey.PubByte()
-		hk.token = ed25519.Sign(d.mineKey, hk.token)
+	if d.mineKey != nil {
+		if token, key, err := d.mineKey(hk.token); err != nil {
+			netLog.Warn(fmt.Sprintf("failed to sign the sync handshake token by the mine key: %v", err))
+		} else {
+			hk.key = key
+			hk.token = token
+		}
 	}
 
 	data, err := hk.Serialize()
@@ -230,6 +256,11 @@ func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*sy
 		return nil, errHandshakeError
 	}
 
+	err = c.secure(peer.Version, secret, true)
+	if err != nil {
+		return nil, err
+	}
+
 	c.peer = peer
 	c.cacher = d.chain
 
@@ -301,6 +332,11 @@ func (d *defaultSyncConnectionFactory) receive(conn net2.Conn) (*syncConn, error
 		return nil, err
 	}
 
+	err = c.secure(p.Version, secret, false)
+	if err != nil {
+		return nil, err
+	}
+
 	c.peer = p
 	c.cacher = d.chain
 
@@ -2,12 +2,16 @@ package net
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	mrand "math/rand"
+	net2 "net"
 	"testing"
 	"time"
 
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/net/vnode"
 )
@@ -354,3 +358,70 @@ func TestSyncRequest_Serialize(t *testing.T) {
 		t.Errorf("different request")
 	}
 }
+
+func TestSyncConn_secure(t *testing.T) {
+	pub1, priv1, _ := ed25519.GenerateKey(nil)
+	pub2, priv2, _ := ed25519.GenerateKey(nil)
+	id1, _ := vnode.Bytes2NodeID(pub1)
+	id2, _ := vnode.Bytes2NodeID(pub2)
+
+	// the peers of the main connections
+	peers1, peers2 := newPeerSet(), newPeerSet()
+	peers1.m[id2] = &Peer{Id: id2, Version: version}
+	peers2.m[id1] = &Peer{Id: id1, Version: version}
+
+	initiator := &defaultSyncConnectionFactory{peers: peers1, id: id1, peerKey: priv1}
+	receiver := &defaultSyncConnectionFactory{peers: peers2, id: id2, peerKey: priv2}
+
+	// the empty message CodeSyncHandshakeOK blocks on a pipe, so use tcp
+	ln, err := net2.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	chunk := []byte("chunk of the ledger")
+
+	done := make(chan error, 1)
+	go func() {
+		conn2, err := ln.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn2.Close()
+		c, err := receiver.receive(conn2)
+		if err == nil {
+			if _, ok := c.conn.(*secureConn); !ok {
+				err = errors.New("receiver connection is not encrypted")
+			} else {
+				_, err = c.conn.Write(chunk)
+			}
+		}
+		done <- err
+	}()
+
+	conn1, err := net2.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn1.Close()
+
+	c, err := initiator.initiate(conn1, peers1.m[id2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.conn.(*secureConn); !ok {
+		t.Fatal("initiator connection is not encrypted")
+	}
+	buf := make([]byte, len(chunk))
+	if _, err = io.ReadFull(c.conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, chunk) {
+		t.Fatalf("unexpected chunk %s", buf)
+	}
+}
@@ -424,9 +424,16 @@ func (e *executor) doJob(c *syncConn, t *syncTask) error {
 			e.log.Warn(fmt.Sprintf("delete sync connection %s: %v", c.address(), err))
 		}
 
+		e.pool.peers.score(c.peer, scoreSyncFailed)
 		return err
 	}
 
+	if c.speed() < slowSyncSpeed {
+		e.pool.peers.score(c.peer, scoreSyncSlow)
+	} else {
+		e.pool.peers.score(c.peer, scoreSyncDone)
+	}
+
 	e.log.Info(fmt.Sprintf("download chunk %s from %s elapse %s", t, c.address(), time.Now().Sub(start)))
 
 	return nil
@@ -523,4 +530,5 @@ func (e *executor) notify(t *syncTask, err error) {
 
 func (e *executor) addBlackList(id peerId) {
 	e.pool.blockPeer(id, 60*time.Second)
+	e.pool.peers.score(e.pool.peers.get(id), scoreSyncFailed)
 }
@@ -243,8 +243,8 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		}
 
 		var wn int64
-		_ = conn.SetWriteDeadline(time.Now().Add(fileTimeout))
-		wn, err = io.Copy(conn, reader)
+		_ = sconn.conn.SetWriteDeadline(time.Now().Add(fileTimeout))
+		wn, err = io.Copy(sconn.conn, reader)
 		_ = reader.Close()
 
 		if wn != int64(reader.Size()) {
@@ -3,6 +3,7 @@ package node
 import (
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,7 +19,9 @@ import (
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/pkcs11store"
 )
 
 type Config struct {
@@ -45,6 +48,7 @@ type Config struct {
 	FilePort           int
 	PublicAddress      string
 	FilePublicAddress  string
+	NAT                string
 	Identity           string
 	NetID              int
 	PeerKey            string `json:"PrivateKey"`
@@ -55,6 +59,7 @@ type Config struct {
 	MaxPendingPeers    int
 	BootNodes          []string
 	BootSeeds          []string
+	BootTrees          []string
 	StaticNodes        []string
 	AccessControl      string
 	AccessAllowKeys    []string
@@ -69,12 +74,23 @@ type Config struct {
 	CoinBase             string `json:"CoinBase"`
 	MinerEnabled         bool   `json:"Miner"`
 	MinerInterval        int    `json:"MinerInterval"`
+	RemoteSigner         string `json:"RemoteSigner"`
+	SignerPolicyFile     string `json:"SignerPolicyFile"`
+
+	// the coinbase key is kept by the pkcs11 token instead of the entropy store if Pkcs11Module is set,
+	// the pin is read from Pkcs11PinFile, or prompted when gvite starts if the file is not set
+	Pkcs11Module     string `json:"Pkcs11Module"`
+	Pkcs11TokenLabel string `json:"Pkcs11TokenLabel"`
+	Pkcs11PinFile    string `json:"Pkcs11PinFile"`
+	Pkcs11Pin        string `json:"-"`
+	Pkcs11Mechanism  uint   `json:"Pkcs11Mechanism"`
 
 	//rpc
-	RPCEnabled  bool  `json:"RPCEnabled"`
-	IPCEnabled  bool  `json:"IPCEnabled"`
-	WSEnabled   bool  `json:"WSEnabled"`
-	TxDexEnable *bool `json:"TxDexEnable"`
+	RPCEnabled     bool  `json:"RPCEnabled"`
+	IPCEnabled     bool  `json:"IPCEnabled"`
+	WSEnabled      bool  `json:"WSEnabled"`
+	GraphQLEnabled bool  `json:"GraphQLEnabled"`
+	TxDexEnable    *bool `json:"TxDexEnable"`
 
 	IPCPath          string   `json:"IPCPath"`
 	HttpHost         string   `json:"HttpHost"`
@@ -82,6 +98,8 @@ type Config struct {
 	HttpVirtualHosts []string `json:"HttpVirtualHosts"`
 	WSHost           string   `json:"WSHost"`
 	WSPort           int      `json:"WSPort"`
+	GraphQLHost      string   `json:"GraphQLHost"`
+	GraphQLPort      int      `json:"GraphQLPort"`
 
 	HTTPCors            []string `json:"HTTPCors"`
 	WSOrigins           []string `json:"WSOrigins"`
@@ -91,6 +109,20 @@ type Config struct {
 	TestTokenHexPrivKey string   `json:"TestTokenHexPrivKey"`
 	TestTokenTti        string   `json:"TestTokenTti"`
 
+	// rpc limit, the zero values mean no limit
+	RPCLimitEnabled  bool               `json:"RPCLimitEnabled"`
+	RPCRateLimit     float64            `json:"RPCRateLimit"`
+	RPCRateBurst     int                `json:"RPCRateBurst"`
+	RPCMaxConcurrent int                `json:"RPCMaxConcurrent"`
+	RPCMaxBatchSize  int                `json:"RPCMaxBatchSize"`
+	RPCApiKeys       []string           `json:"RPCApiKeys"`
+	RPCMethodClasses []*rpc.MethodClass `json:"RPCMethodClasses"`
+
+	// rpc auth, the modules not exposed over http and websocket are available to the authenticated clients
+	RPCAuthEnabled     bool              `json:"RPCAuthEnabled"`
+	RPCAuthCredentials []*rpc.Credential `json:"RPCAuthCredentials"`
+	RPCAuthJWTSecret   string            `json:"RPCAuthJWTSecret"`
+
 	PowServerUrl string `json:"PowServerUrl"`
 
 	//Log level
@@ -106,6 +138,22 @@ type Config struct {
 	// subscribe
 	SubscribeEnabled bool `json:"SubscribeEnabled"`
 
+	// event sink
+	EventSinkEnabled   bool   `json:"EventSinkEnabled"`
+	EventSinkFile      string `json:"EventSinkFile"`
+	EventSinkWebhook   string `json:"EventSinkWebhook"`
+	EventSinkBatchSize int    `json:"EventSinkBatchSize"`
+
+	// auto receive, the addresses are derived from AutoReceiveEntropyStore or EntropyStorePath
+	AutoReceiveEnabled        bool     `json:"AutoReceiveEnabled"`
+	AutoReceiveEntropyStore   string   `json:"AutoReceiveEntropyStore"`
+	AutoReceiveIndexRanges    []string `json:"AutoReceiveIndexRanges"`
+	AutoReceiveAddresses      []string `json:"AutoReceiveAddresses"`
+	AutoReceiveMinAmount      string   `json:"AutoReceiveMinAmount"`
+	AutoReceiveTokenWhitelist []string `json:"AutoReceiveTokenWhitelist"`
+	AutoReceiveConcurrency    int      `json:"AutoReceiveConcurrency"`
+	AutoReceivePoW            bool     `json:"AutoReceivePoW"`
+
 	// dashboard
 	DashboardTargetURL string
 
@@ -128,15 +176,17 @@ func (c *Config) makeWalletConfig() *wallet.Config {
 
 func (c *Config) makeViteConfig() *config.Config {
 	return &config.Config{
-		Chain:     c.makeChainConfig(),
-		Producer:  c.makeMinerConfig(),
-		DataDir:   c.DataDir,
-		Net:       c.makeNetConfig(),
-		Vm:        c.makeVmConfig(),
-		Subscribe: c.makeSubscribeConfig(),
-		Reward:    c.makeRewardConfig(),
-		Genesis:   config_gen.MakeGenesisConfig(c.GenesisFile),
-		LogLevel:  c.LogLevel,
+		Chain:       c.makeChainConfig(),
+		Producer:    c.makeMinerConfig(),
+		DataDir:     c.DataDir,
+		Net:         c.makeNetConfig(),
+		Vm:          c.makeVmConfig(),
+		Subscribe:   c.makeSubscribeConfig(),
+		EventSink:   c.makeEventSinkConfig(),
+		AutoReceive: c.makeAutoReceiveConfig(),
+		Reward:      c.makeRewardConfig(),
+		Genesis:     config_gen.MakeGenesisConfig(c.GenesisFile),
+		LogLevel:    c.LogLevel,
 	}
 }
 
@@ -152,11 +202,13 @@ func (c *Config) makeNetConfig() *config.Net {
 		FilePort:           c.FilePort,
 		PublicAddress:      c.PublicAddress,
 		FilePublicAddress:  c.FilePublicAddress,
+		NAT:                c.NAT,
 		DataDir:            datadir,
 		PeerKey:            c.PeerKey,
 		Discover:           c.Discover,
 		BootNodes:          c.BootNodes,
 		BootSeeds:          c.BootSeeds,
+		BootTrees:          c.BootTrees,
 		StaticNodes:        c.StaticNodes,
 		MaxPeers:           c.MaxPeers,
 		MaxInboundRatio:    c.MaxInboundRatio,
@@ -194,6 +246,60 @@ func (c *Config) makeSubscribeConfig() *config.Subscribe {
 	}
 }
 
+func (c *Config) makeEventSinkConfig() *config.EventSink {
+	return &config.EventSink{
+		Enabled:    c.EventSinkEnabled,
+		FilePath:   c.EventSinkFile,
+		WebhookURL: c.EventSinkWebhook,
+		BatchSize:  c.EventSinkBatchSize,
+	}
+}
+
+func (c *Config) makeAutoReceiveConfig() *config.AutoReceive {
+	entropyStore := c.AutoReceiveEntropyStore
+	if entropyStore == "" {
+		entropyStore = c.EntropyStorePath
+	}
+	return &config.AutoReceive{
+		Enabled:        c.AutoReceiveEnabled,
+		EntropyStore:   entropyStore,
+		IndexRanges:    c.AutoReceiveIndexRanges,
+		Addresses:      c.AutoReceiveAddresses,
+		MinAmount:      c.AutoReceiveMinAmount,
+		TokenWhitelist: c.AutoReceiveTokenWhitelist,
+		Concurrency:    c.AutoReceiveConcurrency,
+		PoW:            c.AutoReceivePoW,
+	}
+}
+
+// makeLimiterConfig returns nil if the rpc limit is disabled
+func (c *Config) makeLimiterConfig() *rpc.LimiterConfig {
+	if !c.RPCLimitEnabled {
+		return nil
+	}
+	return &rpc.LimiterConfig{
+		Default: rpc.RateLimit{
+			Rate:          c.RPCRateLimit,
+			Burst:         c.RPCRateBurst,
+			MaxConcurrent: c.RPCMaxConcurrent,
+		},
+		Classes:      c.RPCMethodClasses,
+		MaxBatchSize: c.RPCMaxBatchSize,
+		ApiKeys:      c.RPCApiKeys,
+	}
+}
+
+// makeAuthConfig returns nil if the rpc auth is disabled
+func (c *Config) makeAuthConfig() *rpc.AuthConfig {
+	if !c.RPCAuthEnabled {
+		return nil
+	}
+	return &rpc.AuthConfig{
+		Credentials: c.RPCAuthCredentials,
+		JWTSecret:   c.RPCAuthJWTSecret,
+	}
+}
+
 func (c *Config) makeMetricsConfig() *metrics.Config {
 	mc := &metrics.Config{
 		IsEnable:         false,
@@ -220,10 +326,35 @@ func (c *Config) makeMetricsConfig() *metrics.Config {
 }
 
 func (c *Config) makeMinerConfig() *config.Producer {
+	entropyStorePath := c.EntropyStorePath
+	if c.Pkcs11Module != "" {
+		entropyStorePath = pkcs11store.Name(c.Pkcs11TokenLabel)
+	}
 	return &config.Producer{
 		Producer:         c.MinerEnabled,
 		Coinbase:         c.CoinBase,
-		EntropyStorePath: c.EntropyStorePath,
+		EntropyStorePath: entropyStorePath,
+		RemoteSigner:     c.RemoteSigner,
+	}
+}
+
+// pkcs11Pin returns the pin of the token, the file of the pin is preferred to the prompted one
+func (c *Config) pkcs11Pin() (string, error) {
+	if c.Pkcs11PinFile == "" {
+		return c.Pkcs11Pin, nil
+	}
+	pin, err := ioutil.ReadFile(c.Pkcs11PinFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(pin), "\r\n"), nil
+}
+
+func (c *Config) makePkcs11Config() *pkcs11store.Config {
+	return &pkcs11store.Config{
+		Module:     c.Pkcs11Module,
+		TokenLabel: c.Pkcs11TokenLabel,
+		Mechanism:  c.Pkcs11Mechanism,
 	}
 }
 
@@ -268,6 +399,13 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+func (c *Config) GraphQLEndpoint() string {
+	if c.GraphQLHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GraphQLHost, c.GraphQLPort)
+}
+
 func (c *Config) SetPrivateKey(privateKey string) {
 	c.PeerKey = privateKey
 }
@@ -16,6 +16,7 @@ var DefaultNodeConfig = Config{
 	KeyStoreDir: DefaultDataDir(),
 	HttpPort:    common.DefaultHTTPPort,
 	WSPort:      common.DefaultWSPort,
+	GraphQLPort: common.DefaultGraphQLPort,
 
 	LogLevel:      "info",
 	HTTPCors:      []string{"*"},
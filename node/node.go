@@ -21,10 +21,12 @@ import (
 	"github.com/vitelabs/go-vite/pow/remote"
 	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/rpcapi"
+	"github.com/vitelabs/go-vite/rpcapi/api"
 	"github.com/vitelabs/go-vite/rpcapi/api/filters"
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/wallet"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/pkcs11store"
 )
 
 var (
@@ -66,6 +68,9 @@ type Node struct {
 
 	wsCli *rpc.WebSocketCli
 
+	graphqlEndpoint string
+	graphqlListener net.Listener
+
 	// Channel to wait for termination notifications
 	stop            chan struct{}
 	lock            sync.RWMutex
@@ -74,14 +79,15 @@ type Node struct {
 
 func New(conf *Config) (*Node, error) {
 	return &Node{
-		config:        conf,
-		walletConfig:  conf.makeWalletConfig(),
-		viteConfig:    conf.makeViteConfig(),
-		metricsConfig: conf.makeMetricsConfig(),
-		ipcEndpoint:   conf.IPCEndpoint(),
-		httpEndpoint:  conf.HTTPEndpoint(),
-		wsEndpoint:    conf.WSEndpoint(),
-		stop:          make(chan struct{}),
+		config:          conf,
+		walletConfig:    conf.makeWalletConfig(),
+		viteConfig:      conf.makeViteConfig(),
+		metricsConfig:   conf.makeMetricsConfig(),
+		ipcEndpoint:     conf.IPCEndpoint(),
+		httpEndpoint:    conf.HTTPEndpoint(),
+		wsEndpoint:      conf.WSEndpoint(),
+		graphqlEndpoint: conf.GraphQLEndpoint(),
+		stop:            make(chan struct{}),
 	}, nil
 }
 
@@ -258,6 +264,30 @@ func (node *Node) startWallet() (err error) {
 
 	}
 
+	//unlock the pkcs11 token
+	if node.config.Pkcs11Module != "" {
+		var ks *pkcs11store.Keystore
+		ks, err = pkcs11store.New(*node.config.makePkcs11Config())
+		if err != nil {
+			log.Error(fmt.Sprintf("pkcs11store.New error: %v", err))
+			return err
+		}
+		if err = node.walletManager.AddKeystore(ks); err != nil {
+			ks.Close()
+			log.Error(fmt.Sprintf("node.walletManager.AddKeystore error: %v", err))
+			return err
+		}
+		var pin string
+		if pin, err = node.config.pkcs11Pin(); err != nil {
+			log.Error(fmt.Sprintf("read the pin of the pkcs11 token error: %v", err))
+			return err
+		}
+		if err = ks.Unlock(pin); err != nil {
+			log.Error(fmt.Sprintf("pkcs11 token Unlock error: %v", err))
+			return err
+		}
+	}
+
 	return nil
 }
 func (node *Node) startMetrics() {
@@ -306,6 +336,16 @@ func (node *Node) startVite() error {
 }
 
 func (node *Node) startRPC() (e error) {
+	// track the rolled back blocks for tx_getTransactionStatus
+	api.TxTracker = api.NewTxStatusTracker(node.Vite())
+	api.TxTracker.Start()
+	defer func() {
+		if e != nil {
+			api.TxTracker.Stop()
+			api.TxTracker = nil
+		}
+	}()
+
 	// start event system
 	if node.config.SubscribeEnabled {
 		filters.Es = filters.NewEventSystem(node.Vite())
@@ -346,8 +386,21 @@ func (node *Node) startRPC() (e error) {
 		}()
 	}
 
+	// the http, websocket and graphql endpoints share the limiter, so a client has the same quota on all of them
+	var limiter *rpc.Limiter
+	if limiterCfg := node.config.makeLimiterConfig(); limiterCfg != nil {
+		limiter = rpc.NewLimiter(limiterCfg)
+	}
+	var auth *rpc.Authenticator
+	if authCfg := node.config.makeAuthConfig(); authCfg != nil {
+		var err error
+		if auth, err = rpc.NewAuthenticator(authCfg); err != nil {
+			return err
+		}
+	}
+
 	if node.config.RPCEnabled {
-		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll); err != nil {
+		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll, limiter, auth); err != nil {
 			return err
 		}
 		defer func() {
@@ -358,7 +411,7 @@ func (node *Node) startRPC() (e error) {
 	}
 
 	if node.config.WSEnabled {
-		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll); err != nil {
+		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll, limiter, auth); err != nil {
 			return err
 		}
 		defer func() {
@@ -367,6 +420,17 @@ func (node *Node) startRPC() (e error) {
 			}
 		}()
 	}
+
+	if node.config.GraphQLEnabled {
+		if err := node.startGraphQL(node.graphqlEndpoint, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, limiter, auth); err != nil {
+			return err
+		}
+		defer func() {
+			if e != nil {
+				node.stopGraphQL()
+			}
+		}()
+	}
 	if len(node.config.DashboardTargetURL) > 0 {
 		targetUrl := node.config.DashboardTargetURL + "/ws/gvite/" + strconv.FormatUint(uint64(node.config.NetID), 10) + "@" + node.Vite().Net().Info().ID.String()
 
@@ -418,12 +482,17 @@ func (node *Node) stopVite() error {
 }
 
 func (node *Node) stopRPC() error {
+	node.stopGraphQL()
 	node.stopWS()
 	node.stopHTTP()
 	node.stopIPC()
 	if filters.Es != nil {
 		filters.Es.Stop()
 	}
+	if api.TxTracker != nil {
+		api.TxTracker.Stop()
+		api.TxTracker = nil
+	}
 	return nil
 }
 
@@ -2,8 +2,10 @@ package node
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
+	"github.com/vitelabs/go-vite/graphql"
 	"github.com/vitelabs/go-vite/rpc"
 )
 
@@ -37,12 +39,12 @@ func (node *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool) error {
+func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool, limiter *rpc.Limiter, auth *rpc.Authenticator) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll)
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll, limiter, auth)
 	if err != nil {
 		return err
 	}
@@ -70,12 +72,12 @@ func (node *Node) stopHTTP() {
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
-func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
+func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool, limiter *rpc.Limiter, auth *rpc.Authenticator) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, limiter, auth)
 	if err != nil {
 		return err
 	}
@@ -104,6 +106,41 @@ func (node *Node) stopWS() {
 	}
 }
 
+// startGraphQL initializes and starts the GraphQL endpoint, served with the cors, vhosts, limiter and auth of the HTTP RPC.
+// A query is limited and authorized as the method graphql_query.
+func (node *Node) startGraphQL(endpoint string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, limiter *rpc.Limiter, auth *rpc.Authenticator) error {
+	// Short circuit if the GraphQL endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	handler, err := graphql.NewHandler(node.Vite().Chain())
+	if err != nil {
+		return err
+	}
+	handler = rpc.NewGuardedHandler("graphql", "query", handler, limiter, auth)
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	go rpc.NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+
+	log.Info("GraphQL endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	node.graphqlEndpoint = endpoint
+	node.graphqlListener = listener
+
+	return nil
+}
+
+// stopGraphQL terminates the GraphQL endpoint.
+func (node *Node) stopGraphQL() {
+	if node.graphqlListener != nil {
+		node.graphqlListener.Close()
+		node.graphqlListener = nil
+
+		log.Info("GraphQL endpoint closed", "url", fmt.Sprintf("http://%s", node.graphqlEndpoint))
+	}
+}
+
 func (node *Node) Attach() (*rpc.Client, error) {
 	node.lock.RLock()
 	defer node.lock.RUnlock()
@@ -0,0 +1,516 @@
+package onroad
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/quota"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+const (
+	defaultAutoReceiveConcurrency = 10
+	autoReceiveScanInterval       = time.Minute
+	autoReceivePageSize           = 50
+)
+
+var (
+	errAutoReceiveQuotaNotEnough = errors.New("stake quota is not enough and PoW is disabled")
+	errAutoReceivePoWTwice       = errors.New("PoW is calculated in the current snapshot, wait for the next snapshot")
+)
+
+// autoReceivePolicy decides which onroad blocks are received
+type autoReceivePolicy struct {
+	minAmount *big.Int
+	tokens    map[types.TokenTypeId]struct{}
+}
+
+func newAutoReceivePolicy(cfg *config.AutoReceive) (*autoReceivePolicy, error) {
+	p := &autoReceivePolicy{tokens: make(map[types.TokenTypeId]struct{})}
+	if cfg.MinAmount != "" {
+		amount, ok := new(big.Int).SetString(cfg.MinAmount, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, errors.New(fmt.Sprintf("invalid MinAmount %s", cfg.MinAmount))
+		}
+		p.minAmount = amount
+	}
+	for _, tti := range cfg.TokenWhitelist {
+		tokenId, err := types.HexToTokenTypeId(tti)
+		if err != nil {
+			return nil, err
+		}
+		p.tokens[tokenId] = struct{}{}
+	}
+	return p, nil
+}
+
+func (p *autoReceivePolicy) accept(sendBlock *ledger.AccountBlock) bool {
+	if len(p.tokens) > 0 {
+		if _, ok := p.tokens[sendBlock.TokenId]; !ok {
+			return false
+		}
+	}
+	if p.minAmount != nil && (sendBlock.Amount == nil || sendBlock.Amount.Cmp(p.minAmount) < 0) {
+		return false
+	}
+	return true
+}
+
+// parseIndexRange parses a derivation index range like "0-999", a single index like "1500" is also a range
+func parseIndexRange(r string) (from, to uint32, err error) {
+	parts := strings.SplitN(strings.TrimSpace(r), "-", 2)
+	start, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New(fmt.Sprintf("invalid index range %s", r))
+	}
+	end := start
+	if len(parts) == 2 {
+		end, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+		if err != nil || end < start {
+			return 0, 0, errors.New(fmt.Sprintf("invalid index range %s", r))
+		}
+	}
+	return uint32(start), uint32(end), nil
+}
+
+// autoReceiveAccount is how an address is signed by the wallet
+type autoReceiveAccount struct {
+	keystore string
+	index    uint32
+}
+
+// AutoReceiver receives the onroad blocks of the user addresses kept by the wallet. The addresses
+// are derived from the entropy store once it's unlocked, they are received when a send to them
+// is inserted into the chain and every scan interval, at most Concurrency addresses at the same time.
+type AutoReceiver struct {
+	cfg       *config.AutoReceive
+	chain     chain.Chain
+	net       netReader
+	pool      pool
+	consensus generator.Consensus
+	wallet    *wallet.Manager
+	policy    *autoReceivePolicy
+
+	accounts map[types.Address]*autoReceiveAccount
+	mutex    sync.RWMutex
+
+	// the addresses waiting to be received, the addresses being received and
+	// the addresses waiting for the next snapshot block to calculate PoW again
+	pending      map[types.Address]struct{}
+	working      map[types.Address]struct{}
+	waitSnapshot map[types.Address]struct{}
+	taskLock     sync.Mutex
+
+	notify chan struct{}
+	sem    chan struct{}
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	log log15.Logger
+}
+
+func NewAutoReceiver(cfg *config.AutoReceive, c chain.Chain, net netReader, pool pool, consensus generator.Consensus, wallet *wallet.Manager) (*AutoReceiver, error) {
+	policy, err := newAutoReceivePolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range cfg.IndexRanges {
+		if _, _, err := parseIndexRange(r); err != nil {
+			return nil, err
+		}
+	}
+	for _, addr := range cfg.Addresses {
+		if _, err := types.HexToAddress(addr); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.IndexRanges) == 0 && len(cfg.Addresses) == 0 {
+		return nil, errors.New("auto receive has no address, IndexRanges or Addresses should be set")
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultAutoReceiveConcurrency
+	}
+	return &AutoReceiver{
+		cfg:          cfg,
+		chain:        c,
+		net:          net,
+		pool:         pool,
+		consensus:    consensus,
+		wallet:       wallet,
+		policy:       policy,
+		pending:      make(map[types.Address]struct{}),
+		working:      make(map[types.Address]struct{}),
+		waitSnapshot: make(map[types.Address]struct{}),
+		sem:          make(chan struct{}, concurrency),
+		log:          slog.New("w", "autoReceiver"),
+	}, nil
+}
+
+func (r *AutoReceiver) Start() {
+	r.notify = make(chan struct{}, 1)
+	r.stopCh = make(chan struct{})
+
+	r.wg.Add(1)
+	go r.loop()
+
+	r.chain.Register(r)
+}
+
+func (r *AutoReceiver) Stop() {
+	r.chain.UnRegister(r)
+
+	close(r.stopCh)
+	r.wg.Wait()
+}
+
+func (r *AutoReceiver) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(autoReceiveScanInterval)
+	defer ticker.Stop()
+
+	r.scan()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.scan()
+		case <-r.notify:
+			r.dispatch()
+		}
+	}
+}
+
+// scan resolves the addresses if they are not resolved yet and receives all of them
+func (r *AutoReceiver) scan() {
+	if r.net.SyncState() != net.SyncDone {
+		return
+	}
+	r.mutex.Lock()
+	if r.accounts == nil {
+		accounts, err := r.resolveAccounts()
+		if err != nil {
+			r.mutex.Unlock()
+			r.log.Warn("resolve auto receive addresses failed, error is "+err.Error(), "method", "scan")
+			return
+		}
+		r.accounts = accounts
+		r.log.Info("auto receive addresses resolved", "count", len(accounts))
+	}
+	addrList := make([]types.Address, 0, len(r.accounts))
+	for addr := range r.accounts {
+		addrList = append(addrList, addr)
+	}
+	r.mutex.Unlock()
+
+	r.addPending(addrList)
+	r.dispatch()
+}
+
+// resolveAccounts derives the addresses of the index ranges and finds the keystores of the configured addresses
+func (r *AutoReceiver) resolveAccounts() (map[types.Address]*autoReceiveAccount, error) {
+	accounts := make(map[types.Address]*autoReceiveAccount)
+	if len(r.cfg.IndexRanges) > 0 {
+		em, err := r.wallet.GetEntropyStoreManager(r.cfg.EntropyStore)
+		if err != nil {
+			return nil, err
+		}
+		if !em.IsUnlocked() {
+			return nil, errors.New(fmt.Sprintf("entropy store %s is locked", r.cfg.EntropyStore))
+		}
+		for _, ir := range r.cfg.IndexRanges {
+			from, to, _ := parseIndexRange(ir)
+			for index := uint64(from); index <= uint64(to); index++ {
+				_, key, err := em.DeriveForIndexPath(uint32(index))
+				if err != nil {
+					return nil, err
+				}
+				addr, err := key.Address()
+				if err != nil {
+					return nil, err
+				}
+				accounts[*addr] = &autoReceiveAccount{keystore: r.cfg.EntropyStore, index: uint32(index)}
+			}
+		}
+	}
+	for _, a := range r.cfg.Addresses {
+		addr, _ := types.HexToAddress(a)
+		ks, index, err := r.wallet.GlobalFindKeystore(addr)
+		if err != nil {
+			r.log.Warn("find keystore failed, the address is skipped, error is "+err.Error(), "method", "resolveAccounts", "addr", addr)
+			continue
+		}
+		accounts[addr] = &autoReceiveAccount{keystore: ks.Name(), index: index}
+	}
+	return accounts, nil
+}
+
+func (r *AutoReceiver) getAccount(addr types.Address) *autoReceiveAccount {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.accounts[addr]
+}
+
+func (r *AutoReceiver) addPending(addrList []types.Address) {
+	if len(addrList) == 0 {
+		return
+	}
+	r.taskLock.Lock()
+	for _, addr := range addrList {
+		r.pending[addr] = struct{}{}
+	}
+	r.taskLock.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// popPending returns a pending address which is not being received
+func (r *AutoReceiver) popPending() (types.Address, bool) {
+	r.taskLock.Lock()
+	defer r.taskLock.Unlock()
+	for addr := range r.pending {
+		if _, ok := r.working[addr]; ok {
+			continue
+		}
+		delete(r.pending, addr)
+		r.working[addr] = struct{}{}
+		return addr, true
+	}
+	return types.Address{}, false
+}
+
+func (r *AutoReceiver) finish(addr types.Address) {
+	r.taskLock.Lock()
+	delete(r.working, addr)
+	_, again := r.pending[addr]
+	r.taskLock.Unlock()
+
+	<-r.sem
+	if again {
+		select {
+		case r.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// dispatch receives the pending addresses, it waits if the concurrency limit is reached
+func (r *AutoReceiver) dispatch() {
+	for {
+		select {
+		case r.sem <- struct{}{}:
+		case <-r.stopCh:
+			return
+		}
+		addr, ok := r.popPending()
+		if !ok {
+			<-r.sem
+			return
+		}
+
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer r.finish(addr)
+			r.receiveAddress(addr)
+		}()
+	}
+}
+
+// receiveAddress receives the accepted onroad blocks of the address one by one. It stops at the first
+// failure, the address is received again on the next scan.
+func (r *AutoReceiver) receiveAddress(addr types.Address) {
+	account := r.getAccount(addr)
+	if account == nil || r.net.SyncState() != net.SyncDone {
+		return
+	}
+	blocks, err := r.acceptedOnRoadBlocks(addr)
+	if err != nil {
+		r.log.Error("GetOnRoadBlocksByAddr failed, error is "+err.Error(), "method", "receiveAddress", "addr", addr)
+		return
+	}
+	for _, sendBlock := range blocks {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+		if err := r.receive(addr, account, sendBlock); err != nil {
+			if err == errAutoReceivePoWTwice {
+				r.taskLock.Lock()
+				r.waitSnapshot[addr] = struct{}{}
+				r.taskLock.Unlock()
+				return
+			}
+			r.log.Warn("receive failed, error is "+err.Error(), "method", "receiveAddress", "addr", addr, "fromHash", sendBlock.Hash)
+			return
+		}
+	}
+}
+
+// acceptedOnRoadBlocks collects the accepted onroad blocks of all the pages before any of them is received,
+// a received block is removed from the onroad pages and the blocks of the later pages move forward.
+func (r *AutoReceiver) acceptedOnRoadBlocks(addr types.Address) ([]*ledger.AccountBlock, error) {
+	var accepted []*ledger.AccountBlock
+	for pageNum := 0; ; pageNum++ {
+		blocks, err := r.chain.GetOnRoadBlocksByAddr(addr, pageNum, autoReceivePageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, sendBlock := range blocks {
+			if r.policy.accept(sendBlock) {
+				accepted = append(accepted, sendBlock)
+			}
+		}
+		if len(blocks) < autoReceivePageSize {
+			return accepted, nil
+		}
+	}
+}
+
+func (r *AutoReceiver) receive(addr types.Address, account *autoReceiveAccount, sendBlock *ledger.AccountBlock) error {
+	addrState, err := generator.GetAddressStateForGenerator(r.chain, &addr)
+	if err != nil {
+		return err
+	}
+	difficulty, err := r.calcDifficulty(addr, sendBlock, addrState)
+	if err != nil {
+		return err
+	}
+	gen, err := generator.NewGenerator(r.chain, r.consensus, addr, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
+	if err != nil {
+		return err
+	}
+	result, err := gen.GenerateWithOnRoad(sendBlock, &addr, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+		return r.wallet.SignData(account.keystore, addr, account.index, data)
+	}, difficulty)
+	if err != nil {
+		return err
+	}
+	if result.Err != nil {
+		return result.Err
+	}
+	if result.VMBlock == nil {
+		return errors.New("generator gen an empty block")
+	}
+	return r.pool.AddDirectAccountBlock(addr, result.VMBlock)
+}
+
+// calcDifficulty returns nil if the stake quota is enough for the receive block, or the PoW difficulty if PoW is enabled
+func (r *AutoReceiver) calcDifficulty(addr types.Address, sendBlock *ledger.AccountBlock, addrState *generator.EnvPrepareForGenerator) (*big.Int, error) {
+	sb := r.chain.GetLatestSnapshotBlock()
+	db, err := vm_db.NewVmDb(r.chain, &addr, &sb.Hash, addrState.LatestAccountHash)
+	if err != nil {
+		return nil, err
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: addr,
+		PrevHash:       *addrState.LatestAccountHash,
+		FromBlockHash:  sendBlock.Hash,
+	}
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(sb.Height), sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	stakeAmount, err := r.chain.GetStakeBeneficialAmount(addr)
+	if err != nil {
+		return nil, err
+	}
+	q, err := quota.GetQuota(db, addr, stakeAmount, sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	if q.Current() >= quotaRequired {
+		return nil, nil
+	}
+	if !r.cfg.PoW {
+		return nil, errAutoReceiveQuotaNotEnough
+	}
+	if !quota.CanPoW(db, addr) {
+		return nil, errAutoReceivePoWTwice
+	}
+	return quota.CalcPoWDifficulty(db, quotaRequired, q, sb.Height)
+}
+
+// sendToAccounts returns the watched addresses which the blocks send to
+func (r *AutoReceiver) sendToAccounts(blocks []*vm_db.VmAccountBlock) []types.Address {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var addrList []types.Address
+	check := func(b *ledger.AccountBlock) {
+		if _, ok := r.accounts[b.ToAddress]; ok && b.IsSendBlock() {
+			addrList = append(addrList, b.ToAddress)
+		}
+	}
+	for _, b := range blocks {
+		check(b.AccountBlock)
+		for _, sendBlock := range b.AccountBlock.SendBlockList {
+			check(sendBlock)
+		}
+	}
+	return addrList
+}
+
+func (r *AutoReceiver) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (r *AutoReceiver) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	r.addPending(r.sendToAccounts(blocks))
+	return nil
+}
+
+func (r *AutoReceiver) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (r *AutoReceiver) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	r.taskLock.Lock()
+	addrList := make([]types.Address, 0, len(r.waitSnapshot))
+	for addr := range r.waitSnapshot {
+		addrList = append(addrList, addr)
+	}
+	r.waitSnapshot = make(map[types.Address]struct{})
+	r.taskLock.Unlock()
+
+	r.addPending(addrList)
+	return nil
+}
+
+func (r *AutoReceiver) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (r *AutoReceiver) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (r *AutoReceiver) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (r *AutoReceiver) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
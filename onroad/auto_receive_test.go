@@ -0,0 +1,145 @@
+package onroad
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+func TestParseIndexRange(t *testing.T) {
+	cases := []struct {
+		r        string
+		from, to uint32
+		ok       bool
+	}{
+		{"0-999", 0, 999, true},
+		{" 10 - 20 ", 10, 20, true},
+		{"1500", 1500, 1500, true},
+		{"20-10", 0, 0, false},
+		{"a-1", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, c := range cases {
+		from, to, err := parseIndexRange(c.r)
+		if (err == nil) != c.ok {
+			t.Fatalf("range %q, unexpected err %v", c.r, err)
+		}
+		if c.ok && (from != c.from || to != c.to) {
+			t.Fatalf("range %q, got %d-%d", c.r, from, to)
+		}
+	}
+}
+
+func TestAutoReceivePolicy(t *testing.T) {
+	p, err := newAutoReceivePolicy(&config.AutoReceive{
+		MinAmount:      "100",
+		TokenWhitelist: []string{ledger.ViteTokenId.String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.accept(&ledger.AccountBlock{TokenId: ledger.ViteTokenId, Amount: big.NewInt(100)}) {
+		t.Fatal("the send of min amount should be accepted")
+	}
+	if p.accept(&ledger.AccountBlock{TokenId: ledger.ViteTokenId, Amount: big.NewInt(99)}) {
+		t.Fatal("the send less than min amount should be refused")
+	}
+	if p.accept(&ledger.AccountBlock{Amount: big.NewInt(100)}) {
+		t.Fatal("the send of other token should be refused")
+	}
+
+	if _, err := newAutoReceivePolicy(&config.AutoReceive{MinAmount: "-1"}); err == nil {
+		t.Fatal("negative min amount should be refused")
+	}
+	p, err = newAutoReceivePolicy(&config.AutoReceive{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.accept(&ledger.AccountBlock{Amount: big.NewInt(0)}) {
+		t.Fatal("all sends should be accepted without policy")
+	}
+}
+
+// onRoadChain keeps the onroad blocks of an address in pages like the chain
+type onRoadChain struct {
+	chain.Chain
+	onRoads []*ledger.AccountBlock
+}
+
+func (c *onRoadChain) GetOnRoadBlocksByAddr(addr types.Address, pageNum, pageSize int) ([]*ledger.AccountBlock, error) {
+	start := pageNum * pageSize
+	if start >= len(c.onRoads) {
+		return nil, nil
+	}
+	end := start + pageSize
+	if end > len(c.onRoads) {
+		end = len(c.onRoads)
+	}
+	return c.onRoads[start:end], nil
+}
+
+func (c *onRoadChain) remove(hash types.Hash) {
+	for i, block := range c.onRoads {
+		if block.Hash == hash {
+			c.onRoads = append(c.onRoads[:i:i], c.onRoads[i+1:]...)
+			return
+		}
+	}
+}
+
+func TestAutoReceiveOnRoadPages(t *testing.T) {
+	p, err := newAutoReceivePolicy(&config.AutoReceive{MinAmount: "100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &onRoadChain{}
+	// 3 pages, the sends of odd index are refused by the policy
+	for i := 0; i < autoReceivePageSize*2+10; i++ {
+		amount := big.NewInt(100)
+		if i%2 == 1 {
+			amount = big.NewInt(1)
+		}
+		c.onRoads = append(c.onRoads, &ledger.AccountBlock{Hash: types.Hash{byte(i)}, Amount: amount})
+	}
+	r := &AutoReceiver{chain: c, policy: p}
+
+	addr := types.Address{1}
+	blocks, err := r.acceptedOnRoadBlocks(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != autoReceivePageSize+5 {
+		t.Fatalf("unexpected accepted block count %d", len(blocks))
+	}
+	// the pages move forward when the blocks are received, the collected ones are received anyway
+	for _, block := range blocks {
+		c.remove(block.Hash)
+	}
+	if len(c.onRoads) != autoReceivePageSize+5 {
+		t.Fatalf("unexpected onroad block count %d", len(c.onRoads))
+	}
+	if blocks, _ := r.acceptedOnRoadBlocks(addr); len(blocks) != 0 {
+		t.Fatalf("the refused blocks should not be received, get %d blocks", len(blocks))
+	}
+}
+
+func TestAutoReceiveResolveAccounts(t *testing.T) {
+	r := &AutoReceiver{
+		cfg:    &config.AutoReceive{Addresses: []string{types.Address{1}.String()}},
+		wallet: wallet.New(&wallet.Config{DataDir: t.TempDir()}),
+		log:    log15.New("module", "onroad_test"),
+	}
+	accounts, err := r.resolveAccounts()
+	if err != nil {
+		t.Fatalf("the address not in the wallet should be skipped, err %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Fatalf("unexpected accounts %v", accounts)
+	}
+}
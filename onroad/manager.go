@@ -15,6 +15,7 @@ import (
 	"github.com/vitelabs/go-vite/onroad/pool"
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 var (
@@ -29,6 +30,7 @@ type Manager struct {
 	net      netReader
 	producer producer
 	wallet   *wallet.Manager
+	signer   *signer.Client
 
 	pool      pool
 	chain     chain.Chain
@@ -48,12 +50,13 @@ type Manager struct {
 	log log15.Logger
 }
 
-// NewManager creates a onroad Manager.
-func NewManager(net netReader, pool pool, producer producer, consensus generator.Consensus, wallet *wallet.Manager) *Manager {
+// NewManager creates a onroad Manager, the blocks are signed by the remote signer if it's not nil.
+func NewManager(net netReader, pool pool, producer producer, consensus generator.Consensus, wallet *wallet.Manager, signer *signer.Client) *Manager {
 	m := &Manager{
 		net:             net,
 		producer:        producer,
 		wallet:          wallet,
+		signer:          signer,
 		pool:            pool,
 		consensus:       consensus,
 		contractWorkers: make(map[types.Gid]*ContractWorker),
@@ -133,7 +136,12 @@ func (manager *Manager) producerStartEventFunc(accevent producerevent.AccountEve
 		return
 	}
 
-	if !manager.wallet.GlobalCheckAddrUnlock(event.Address) {
+	if manager.signer != nil {
+		if err := manager.signer.CheckAddress(event.Address); err != nil {
+			manager.log.Error("receive chain right event but address can't be signed by the remote signer", "event", event, "err", err)
+			return
+		}
+	} else if !manager.wallet.GlobalCheckAddrUnlock(event.Address) {
 		manager.log.Error("receive chain right event but address locked", "event", event)
 		return
 	}
@@ -64,7 +64,7 @@ func TestManager_ContractWorker(t *testing.T) {
 	addr := generateUnlockAddress()
 	v.Producer().(*mockProducer).Addr = addr
 
-	manager := NewManager(v.Net(), v.Pool(), v.Producer(), nil, tWallet)
+	manager := NewManager(v.Net(), v.Pool(), v.Producer(), nil, tWallet, nil)
 	manager.Init(v.chain)
 	manager.Start()
 
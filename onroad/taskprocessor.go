@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/header"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vm/quota"
 	"strings"
@@ -105,15 +107,14 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 		blog.Error(fmt.Sprintf("NewGenerator failed, err:%v", err))
 		return true
 	}
-	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address,
-		func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-			_, key, _, err := tp.worker.manager.wallet.GlobalFindAddr(addr)
-			if err != nil {
-				return nil, nil, err
-			}
-			return key.SignData(data)
-		}, nil)
-
+	// the remote signer signs the whole block after it's generated, so that its policy can be checked
+	var signFunc header.SignFunc
+	if tp.worker.manager.signer == nil {
+		signFunc = func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+			return tp.worker.manager.wallet.GlobalSignData(addr, data)
+		}
+	}
+	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address, signFunc, nil)
 
 	// judge generator result
 	if err != nil || genResult == nil {
@@ -131,6 +132,12 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 
 	// judge vm result
 	if genResult.VMBlock != nil {
+		if signer := tp.worker.manager.signer; signer != nil {
+			if err := signer.SignAccountBlock(tp.worker.address, genResult.VMBlock.AccountBlock); err != nil {
+				blog.Error(fmt.Sprintf("remote signer SignAccountBlock failed, err:%v", err))
+				return true
+			}
+		}
 		blog.Info(fmt.Sprintf("insertBlockToPool %v, s[%v, p(%v,%v)]", genResult.VMBlock.AccountBlock.Hash, sBlock.Hash, completeBlockHeight, completeBlockHash))
 
 		if err := tp.worker.manager.insertBlockToPool(genResult.VMBlock); err != nil {
@@ -147,7 +154,7 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 	} else {
 		if genResult.IsRetry {
 			blog.Info("genResult.IsRetry true")
-			if !types.IsBuiltinContractAddrInUseWithoutQuota(task.Addr) {
+			if !ledger.IsBuiltinContractAddrInUseWithoutQuota(task.Addr, tp.worker.manager.Chain().GetLatestSnapshotBlock().Height) {
 				_, q, err := tp.worker.manager.Chain().GetStakeQuota(task.Addr)
 				if err != nil || q == nil {
 					blog.Error(fmt.Sprintf("failed to get stake quota, err:%v", err))
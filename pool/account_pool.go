@@ -235,7 +235,7 @@ func (accP *accountPool) AddDirectBlocks(received *accountPoolBlock) error {
 	tailHeight, tailHash := current.TailHH()
 	if received.Height() != tailHeight+1 ||
 		received.PrevHash() != tailHash {
-		return errors.Errorf("account head not match[%d-%s][%s]", received.Height(), received.PrevHash(), current.SprintTail())
+		return errors.Errorf("%s[%d-%s][%s]", ErrAccountHeadNotMatch, received.Height(), received.PrevHash(), current.SprintTail())
 	}
 
 	accP.checkCurrent()
@@ -243,13 +243,13 @@ func (accP *accountPool) AddDirectBlocks(received *accountPoolBlock) error {
 	result := stat.verifyResult()
 	switch result {
 	case verifier.PENDING:
-		msg := fmt.Sprintf("db for directly adding account block[%s-%s-%d].", received.block.AccountAddress, received.Hash(), received.Height())
+		msg := fmt.Sprintf("%s[%s-%s-%d].", ErrDirectBlockPending, received.block.AccountAddress, received.Hash(), received.Height())
 		return errors.New(msg)
 	case verifier.FAIL:
 		if stat.err != nil {
 			return stat.err
 		}
-		return errors.Errorf("directly adding account block[%s-%s-%d] fail.", received.block.AccountAddress, received.Hash(), received.Height())
+		return errors.Errorf("%s[%s-%s-%d] fail.", ErrDirectBlockFailed, received.block.AccountAddress, received.Hash(), received.Height())
 	case verifier.SUCCESS:
 
 		accP.log.Debug("AddDirectBlocks", "height", received.Height(), "hash", received.Hash())
@@ -279,6 +279,11 @@ var ErrBlackList = errors.New("block in blacklist")
 var ErrQuotaNotEnough = errors.New("block quota not enough")
 var ErrAllIn = errors.New("all in")
 
+// the errors of adding a block directly, they are the prefixes of the returned messages
+var ErrAccountHeadNotMatch = errors.New("account head not match")
+var ErrDirectBlockPending = errors.New("db for directly adding account block")
+var ErrDirectBlockFailed = errors.New("directly adding account block")
+
 func (accP *accountPool) makePackage(q batch.Batch, info *offsetInfo, max uint64) (uint64, error) {
 	// if current size is empty, do nothing.
 	if accP.chainpool.tree.Main().Size() <= 0 {
@@ -399,6 +404,7 @@ func (accP *accountPool) tryInsertItems(p batch.Batch, items []batch.Item, lates
 			case verifier.FAIL:
 				accP.log.Warn("add account block to blacklist.", "hash", block.Hash(), "height", block.Height(), "err", stat.err)
 				accP.hashBlacklist.AddAddTimeout(block.Hash(), time.Second*10)
+				accP.pool.notifyDropped(block.block, stat.err)
 				return errors.Wrap(stat.err, "fail verifier")
 			case verifier.PENDING:
 				accP.log.Error("snapshot db.", "hash", block.Hash(), "height", block.Height())
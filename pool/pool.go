@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -44,6 +45,10 @@ type SnapshotProducerWriter interface {
 // Reader is a reader of BlockPool
 type Reader interface {
 	GetIrreversibleBlock() *ledger.SnapshotBlock
+	// ExistAccountBlockInPool checks whether the account block is waiting in the pool to be inserted into the chain
+	ExistAccountBlockInPool(hash types.Hash) bool
+	// SetDroppedListener sets fn to be called with the account blocks refused by the pool and the reasons, fn should not block
+	SetDroppedListener(fn func(block *ledger.AccountBlock, reason string))
 }
 
 // Debug provide more detail info for BlockPool
@@ -162,6 +167,9 @@ type pool struct {
 
 	hashBlacklist Blacklist
 	cs            consensus.Consensus
+
+	droppedMu       sync.RWMutex
+	droppedListener func(block *ledger.AccountBlock, reason string)
 }
 
 func (pl *pool) Snapshot() map[string]interface{} {
@@ -265,6 +273,36 @@ func (pl *pool) AccountBlockInfo(addr types.Address, hash types.Hash) interface{
 	return nil
 }
 
+func (pl *pool) ExistAccountBlockInPool(hash types.Hash) bool {
+	exist := false
+	pl.pendingAc.Range(func(_, ac interface{}) bool {
+		exist = ac.(*accountPool).existInPool(hash)
+		return !exist
+	})
+	return exist
+}
+
+func (pl *pool) SetDroppedListener(fn func(block *ledger.AccountBlock, reason string)) {
+	pl.droppedMu.Lock()
+	defer pl.droppedMu.Unlock()
+	pl.droppedListener = fn
+}
+
+// notifyDropped notifies the listener that the block is refused by err, err is nil if the verifier gives no reason
+func (pl *pool) notifyDropped(block *ledger.AccountBlock, err error) {
+	pl.droppedMu.RLock()
+	fn := pl.droppedListener
+	pl.droppedMu.RUnlock()
+	if fn == nil {
+		return
+	}
+	reason := "verify failed"
+	if err != nil {
+		reason = err.Error()
+	}
+	fn(block, reason)
+}
+
 func (pl *pool) SnapshotBlockInfo(hash types.Hash) interface{} {
 	b, s := pl.pendingSc.blockpool.sprint(hash)
 	if b != nil {
@@ -382,12 +420,17 @@ func (pl *pool) AddDirectAccountBlock(address types.Address, block *vm_db.VmAcco
 	err := ac.v.verifyAccountData(block.AccountBlock)
 	if err != nil {
 		pl.log.Error("account err", "err", err, "height", block.AccountBlock.Height, "hash", block.AccountBlock.Hash, "addr", address)
+		pl.notifyDropped(block.AccountBlock, err)
 		return err
 	}
 
 	cBlock := newAccountPoolBlock(block.AccountBlock, block.VmDb, pl.version, types.Local)
 	err = ac.AddDirectBlocks(cBlock)
 	if err != nil {
+		// the pending block may be added again after the db is ready
+		if !strings.HasPrefix(err.Error(), ErrDirectBlockPending.Error()) {
+			pl.notifyDropped(block.AccountBlock, err)
+		}
 		return err
 	}
 	ac.f.broadcastBlock(block.AccountBlock)
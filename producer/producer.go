@@ -16,6 +16,7 @@ import (
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 // Package producer implements vite block creation
@@ -89,8 +90,9 @@ func NewProducer(rw chain.Chain,
 	cs consensus.Subscriber,
 	verifier *verifier.SnapshotVerifier,
 	wt *wallet.Manager,
+	signer *signer.Client,
 	p pool.SnapshotProducerWriter) *producer {
-	chain := newChainRw(rw, verifier, wt, p)
+	chain := newChainRw(rw, verifier, wt, signer, p)
 	miner := &producer{tools: chain, coinbase: coinbase}
 
 	miner.cs = cs
@@ -109,7 +109,7 @@ func TestSnapshot(t *testing.T) {
 	w := wallet.New(nil)
 	av := verifier.NewAccountVerifier(c, cs)
 	p1, _ := pool.NewPool(c)
-	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, p1)
+	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, nil, p1)
 
 	p1.Init(&pool.MockSyncer{}, w, sv, av)
 	p.Init()
@@ -148,7 +148,7 @@ func TestProducer_Init(t *testing.T) {
 	w := wallet.New(nil)
 	av := verifier.NewAccountVerifier(c, cs)
 	p1, _ := pool.NewPool(c)
-	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, p1)
+	p := NewProducer(c, &testSubscriber{}, coinbase, cs, sv, w, nil, p1)
 
 	c.Init()
 	c.Start()
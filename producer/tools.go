@@ -14,11 +14,13 @@ import (
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 type tools struct {
 	log       log15.Logger
 	wt        *wallet.Manager
+	signer    *signer.Client
 	pool      pool.SnapshotProducerWriter
 	chain     chain.Chain
 	sVerifier *verifier.SnapshotVerifier
@@ -57,15 +59,13 @@ func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext
 	}
 
 	block.Hash = block.ComputeHash()
-	manager, err := self.wt.GetEntropyStoreManager(coinbase.EntryPath)
-	if err != nil {
-		return nil, err
-	}
-	_, key, err := manager.DeriveForIndexPath(coinbase.Index)
-	if err != nil {
-		return nil, err
+	if self.signer != nil {
+		if err := self.signer.SignSnapshotBlock(coinbase.Address, coinbase.Index, block); err != nil {
+			return nil, err
+		}
+		return block, nil
 	}
-	signedData, pubkey, err := key.SignData(block.Hash.Bytes())
+	signedData, pubkey, err := self.wt.SignData(coinbase.EntryPath, coinbase.Address, coinbase.Index, block.Hash.Bytes())
 
 	if err != nil {
 		return nil, err
@@ -81,15 +81,18 @@ func (self *tools) insertSnapshot(block *ledger.SnapshotBlock) error {
 	return self.pool.AddDirectSnapshotBlock(block)
 }
 
-func newChainRw(ch chain.Chain, sVerifier *verifier.SnapshotVerifier, wt *wallet.Manager, p pool.SnapshotProducerWriter) *tools {
+func newChainRw(ch chain.Chain, sVerifier *verifier.SnapshotVerifier, wt *wallet.Manager, signer *signer.Client, p pool.SnapshotProducerWriter) *tools {
 	log := log15.New("module", "tools")
-	return &tools{chain: ch, log: log, sVerifier: sVerifier, wt: wt, pool: p}
+	return &tools{chain: ch, log: log, sVerifier: sVerifier, wt: wt, signer: signer, pool: p}
 }
 
 func (self *tools) checkAddressLock(address types.Address, coinbase *AddressContext) error {
 	if address != coinbase.Address {
 		return errors.Errorf("addres not equals.%s-%s", address, coinbase.Address)
 	}
+	if self.signer != nil {
+		return self.signer.CheckAddress(coinbase.Address)
+	}
 
 	return self.wt.MatchAddress(coinbase.EntryPath, coinbase.Address, coinbase.Index)
 }
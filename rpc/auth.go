@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// the min length of the jwt secret, a shorter secret is easy to brute force
+	minJWTSecretLength = 32
+
+	authTokenQuery = "token"
+)
+
+// Credential is a client which is allowed to call the namespaces or methods in Allow, it is presented as the
+// bearer token Token, or as a jwt whose subject is Name
+type Credential struct {
+	Name  string `json:"Name"`
+	Token string `json:"Token"`
+
+	// Allow are namespaces like wallet or full method names like debug_poolInfo
+	Allow []string `json:"Allow"`
+}
+
+type AuthConfig struct {
+	Credentials []*Credential `json:"Credentials"`
+
+	// JWTSecret is the HS256 key of the jwt, the jwt is disabled if it is empty
+	JWTSecret string `json:"JWTSecret"`
+}
+
+// Authenticator resolves the bearer token of a request to the permission of a credential. The requests without
+// a token can only call the methods registered as anonymous.
+type Authenticator struct {
+	credentials []*permission
+	subjects    map[string]*permission
+	jwtSecret   []byte
+}
+
+type permission struct {
+	name  string
+	token []byte
+	allow map[string]struct{}
+}
+
+func (p *permission) allows(svcname, method string) bool {
+	if _, ok := p.allow[svcname]; ok {
+		return true
+	}
+	_, ok := p.allow[svcname+serviceMethodSeparator+method]
+	return ok
+}
+
+func NewAuthenticator(cfg *AuthConfig) (*Authenticator, error) {
+	if len(cfg.JWTSecret) > 0 && len(cfg.JWTSecret) < minJWTSecretLength {
+		return nil, errors.New(fmt.Sprintf("jwt secret should be at least %d bytes", minJWTSecretLength))
+	}
+	a := &Authenticator{
+		subjects:  make(map[string]*permission),
+		jwtSecret: []byte(cfg.JWTSecret),
+	}
+	for _, c := range cfg.Credentials {
+		if c.Name == "" {
+			return nil, errors.New("credential name is empty")
+		}
+		if _, ok := a.subjects[c.Name]; ok {
+			return nil, errors.New(fmt.Sprintf("credential %s is duplicated", c.Name))
+		}
+		p := &permission{name: c.Name, token: []byte(c.Token), allow: make(map[string]struct{}, len(c.Allow))}
+		for _, allow := range c.Allow {
+			p.allow[allow] = struct{}{}
+		}
+		a.subjects[c.Name] = p
+		a.credentials = append(a.credentials, p)
+	}
+	return a, nil
+}
+
+// authenticate returns the permission of the token, it returns nil for an empty token
+func (a *Authenticator) authenticate(token string) (*permission, Error) {
+	if token == "" {
+		return nil, nil
+	}
+	if strings.Count(token, ".") == 2 {
+		return a.authenticateJWT(token, time.Now())
+	}
+	for _, p := range a.credentials {
+		if len(p.token) > 0 && subtle.ConstantTimeCompare(p.token, []byte(token)) == 1 {
+			return p, nil
+		}
+	}
+	return nil, &unauthorizedError{"invalid token"}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// authenticateJWT verifies a HS256 jwt, its subject is the name of the credential
+func (a *Authenticator) authenticateJWT(token string, now time.Time) (*permission, Error) {
+	if len(a.jwtSecret) == 0 {
+		return nil, &unauthorizedError{"jwt is disabled"}
+	}
+	parts := strings.Split(token, ".")
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, &unauthorizedError{"invalid jwt header"}
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &unauthorizedError{"invalid jwt signature"}
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, &unauthorizedError{"invalid jwt signature"}
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, &unauthorizedError{"invalid jwt claims"}
+	}
+	if claims.ExpiresAt != nil && now.Unix() >= *claims.ExpiresAt {
+		return nil, &unauthorizedError{"jwt is expired"}
+	}
+	if claims.NotBefore != nil && now.Unix() < *claims.NotBefore {
+		return nil, &unauthorizedError{"jwt is not valid yet"}
+	}
+	p, ok := a.subjects[claims.Subject]
+	if !ok {
+		return nil, &unauthorizedError{"unknown jwt subject"}
+	}
+	return p, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// authorize checks whether the token of the request is allowed to call the method
+func (s *Server) authorize(token string, req *serverRequest) Error {
+	if _, ok := s.anonymous[req.callb]; ok {
+		return nil
+	}
+	p, err := s.auth.authenticate(token)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return &unauthorizedError{"authentication required"}
+	}
+	if !p.allows(req.svcname, formatName(req.callb.method.Name)) {
+		return &unauthorizedError{fmt.Sprintf("%s is not allowed to call %s%s%s", p.name, req.svcname, serviceMethodSeparator, formatName(req.callb.method.Name))}
+	}
+	return nil
+}
+
+// permitted returns the check whether the caller of ctx is allowed to call a method, all the methods are
+// allowed if there is no authenticator
+func (s *Server) permitted(ctx context.Context) func(svcname, method string, callb *callback) bool {
+	if s.auth == nil {
+		return func(svcname, method string, callb *callback) bool { return true }
+	}
+	token, _ := ctx.Value("token").(string)
+	p, _ := s.auth.authenticate(token)
+	return func(svcname, method string, callb *callback) bool {
+		if _, ok := s.anonymous[callb]; ok {
+			return true
+		}
+		return p != nil && p.allows(svcname, method)
+	}
+}
+
+// requestAuthToken returns the bearer token from the http header, or from the query for the websocket
+// clients which can't set the headers
+func requestAuthToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.URL.Query().Get(authTokenQuery))
+}
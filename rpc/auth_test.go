@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestJWT(alg, claims string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticatorJWT(t *testing.T) {
+	a, err := NewAuthenticator(&AuthConfig{
+		Credentials: []*Credential{{Name: "ops", Allow: []string{"test"}}},
+		JWTSecret:   testJWTSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+
+	if p, err := a.authenticateJWT(newTestJWT("HS256", `{"sub":"ops","exp":2000}`), now); err != nil || p.name != "ops" {
+		t.Fatalf("valid jwt is rejected, %v", err)
+	}
+	invalid := []string{
+		newTestJWT("HS256", `{"sub":"ops","exp":1000}`),
+		newTestJWT("HS256", `{"sub":"ops","nbf":2000}`),
+		newTestJWT("HS256", `{"sub":"other"}`),
+		newTestJWT("none", `{"sub":"ops"}`),
+		newTestJWT("HS256", `{"sub":"ops"}`) + "x",
+	}
+	for i, token := range invalid {
+		if _, err := a.authenticateJWT(token, now); err == nil || err.ErrorCode() != -32006 {
+			t.Fatalf("invalid jwt %d is accepted", i)
+		}
+	}
+
+	if _, err := NewAuthenticator(&AuthConfig{JWTSecret: "short"}); err == nil {
+		t.Fatalf("short jwt secret should be rejected")
+	}
+}
+
+func TestServerAuthorize(t *testing.T) {
+	a, err := NewAuthenticator(&AuthConfig{
+		Credentials: []*Credential{
+			{Name: "all", Token: "token1", Allow: []string{"test"}},
+			{Name: "echo", Token: "token2", Allow: []string{"test_echo"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAuth(a)
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterAnonymousName("anonymous", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+
+	echo := &serverRequest{svcname: "test", callb: server.services["test"].callbacks["echo"]}
+	sleep := &serverRequest{svcname: "test", callb: server.services["test"].callbacks["sleep"]}
+	anonymous := &serverRequest{svcname: "anonymous", callb: server.services["anonymous"].callbacks["echo"]}
+
+	if err := server.authorize("", anonymous); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.authorize("", echo); err == nil {
+		t.Fatalf("the request without a token should be rejected")
+	}
+	if err := server.authorize("token3", echo); err == nil {
+		t.Fatalf("the request with an unknown token should be rejected")
+	}
+	if err := server.authorize("token1", sleep); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.authorize("token2", echo); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.authorize("token2", sleep); err == nil {
+		t.Fatalf("the method not allowed should be rejected")
+	}
+}
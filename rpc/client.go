@@ -640,13 +640,20 @@ func (c *Client) handleNotification(msg *jsonrpcMessage) {
 	var subResult struct {
 		ID     string          `json:"subscription"`
 		Result json.RawMessage `json:"result"`
+		Error  *jsonError      `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Params, &subResult); err != nil {
 		log.Debug("dropping invalid subscription message", "msg", msg)
 		return
 	}
-	if c.subs[subResult.ID] != nil {
-		c.subs[subResult.ID].deliver(subResult.Result)
+	if sub := c.subs[subResult.ID]; sub != nil {
+		// the subscription is ended by the server with the error
+		if subResult.Error != nil {
+			delete(c.subs, subResult.ID)
+			sub.quitWithError(subResult.Error, false)
+			return
+		}
+		sub.deliver(subResult.Result)
 	}
 }
 
@@ -251,6 +251,29 @@ func TestClientSubscribe(t *testing.T) {
 	}
 }
 
+func TestClientSubscribeFail(t *testing.T) {
+	server := newTestServer("eth", new(NotificationTestService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int)
+	sub, err := client.EthSubscribe(context.Background(), nc, "failSubscription", "replay failed")
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	select {
+	case v := <-nc:
+		t.Fatal("received value from the failed subscription:", v)
+	case err := <-sub.Err():
+		if err == nil || err.Error() != "replay failed" {
+			t.Fatalf("Err should return the error of the server, but get %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not failed within 5s")
+	}
+}
+
 func TestClientSubscribeCustomNamespace(t *testing.T) {
 	namespace := "custom"
 	server := newTestServer(namespace, new(NotificationTestService))
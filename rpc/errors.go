@@ -32,6 +32,20 @@ func (e *executePanicError) ErrorCode() int { return -32001 }
 
 func (e *executePanicError) Error() string { return "server execute panic" }
 
+// the request is rejected by the limiter
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
+
+// the request has no valid credential or the credential is not allowed to call the method
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32006 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
 // logic error, callback returned an error
 type callbackError struct{ message string }
 
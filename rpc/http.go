@@ -192,10 +192,9 @@ func (t *httpReadWriteNopCloser) Close() error {
 	return nil
 }
 
-// NewHTTPServer creates chain new HTTP RPC server around an API provider.
-//
-// Deprecated: Server implements http.Handler
-func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv *Server) *http.Server {
+// NewHTTPServer creates chain new HTTP RPC server around an API provider, or any other http.Handler
+// which is served with the same cors and vhosts settings, such as the GraphQL handler.
+func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv http.Handler) *http.Server {
 	// Wrap the CORS-handler within chain host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
@@ -222,6 +221,43 @@ func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv *S
 	}
 }
 
+// NewGuardedHandler wraps a http.Handler which is not served by the rpc server, such as the GraphQL handler,
+// with the limiter and the authenticator of the rpc endpoints. The requests are limited and authorized as
+// the method svcname_method, so a credential needs to allow svcname or the full method to call it.
+func NewGuardedHandler(svcname, method string, h http.Handler, limiter *Limiter, auth *Authenticator) http.Handler {
+	if limiter == nil && auth == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil {
+			p, err := auth.authenticate(requestAuthToken(r))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if p == nil {
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+				return
+			}
+			if !p.allows(svcname, method) {
+				http.Error(w, fmt.Sprintf("%s is not allowed to call %s%s%s", p.name, svcname, serviceMethodSeparator, method), http.StatusForbidden)
+				return
+			}
+		}
+		if limiter != nil {
+			ctx := context.WithValue(r.Context(), "remote", r.RemoteAddr)
+			ctx = context.WithValue(ctx, "apikey", requestApiKey(r))
+			release, err := limiter.acquireMethod(ctx, svcname, method)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			defer release()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Permit dumb empty requests for remote health-checks (AWS)
@@ -251,6 +287,8 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
+	ctx = context.WithValue(ctx, "apikey", requestApiKey(r))
+	ctx = context.WithValue(ctx, "token", requestAuthToken(r))
 
 	body := io.LimitReader(r.Body, maxRequestContentLength)
 	codec := NewJSONCodec(&httpReadWriteNopCloser{body, w})
@@ -327,7 +365,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified chain custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
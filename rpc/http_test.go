@@ -52,3 +52,38 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestGuardedHandler(t *testing.T) {
+	auth, err := NewAuthenticator(&AuthConfig{Credentials: []*Credential{
+		{Name: "reader", Token: "token1", Allow: []string{"graphql"}},
+		{Name: "other", Token: "token2", Allow: []string{"ledger"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewLimiter(&LimiterConfig{Default: RateLimit{Rate: 1, Burst: 1}})
+	h := NewGuardedHandler("graphql", "query", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), limiter, auth)
+
+	serve := func(token string) int {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(""))
+		request.RemoteAddr = "1.1.1.1:1000"
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	if code := serve(""); code != http.StatusUnauthorized {
+		t.Fatalf("a request without token should be unauthorized, got %d", code)
+	}
+	if code := serve("token2"); code != http.StatusForbidden {
+		t.Fatalf("a credential without graphql should be forbidden, got %d", code)
+	}
+	if code := serve("token1"); code != http.StatusOK {
+		t.Fatalf("the first request should be allowed, got %d", code)
+	}
+	if code := serve("token1"); code != http.StatusTooManyRequests {
+		t.Fatalf("the second request should be limited, got %d", code)
+	}
+}
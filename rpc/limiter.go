@@ -0,0 +1,227 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+const (
+	// the max count of clients whose limit states are kept, the least recently used ones are evicted
+	maxLimitedClients = 10240
+
+	defaultMethodClass = "default"
+
+	// ApiKeyHeader is the http header of the api key which identifies a client instead of its ip, the key is not
+	// accepted from the url which may be logged by the proxies
+	ApiKeyHeader = "X-Api-Key"
+)
+
+// RateLimit is the limit of a client for a class of methods, zero means no limit
+type RateLimit struct {
+	// Rate is the count of requests per second, refilled to a token bucket of Burst
+	Rate  float64 `json:"Rate"`
+	Burst int     `json:"Burst"`
+
+	// MaxConcurrent is the max count of requests in execution at the same time
+	MaxConcurrent int `json:"MaxConcurrent"`
+}
+
+// burst returns the size of the token bucket, at least 1
+func (limit *RateLimit) burst() float64 {
+	if limit.Burst < 1 {
+		return 1
+	}
+	return float64(limit.Burst)
+}
+
+// MethodClass is a group of methods sharing a limit, a method is a full name like ledger_getAccountBlocksByAddress
+// or a namespace like ledger. The methods not in any class are limited by the default limit.
+type MethodClass struct {
+	Name    string   `json:"Name"`
+	Methods []string `json:"Methods"`
+	RateLimit
+}
+
+type LimiterConfig struct {
+	Default      RateLimit      `json:"Default"`
+	Classes      []*MethodClass `json:"Classes"`
+	MaxBatchSize int            `json:"MaxBatchSize"`
+
+	// ApiKeys are the keys which identify the clients, the requests with unknown keys are limited by ip
+	ApiKeys []string `json:"ApiKeys"`
+}
+
+// Limiter limits the requests of each client by a token bucket and the count of concurrent requests
+// for each class of methods. It is shared by the http and websocket servers, so that a client has
+// the same quota on both of them.
+type Limiter struct {
+	cfg         *LimiterConfig
+	methodClass map[string]*MethodClass
+	apiKeys     map[string]struct{}
+
+	mu           sync.Mutex
+	clientLimits *lru.Cache
+	// the count of the requests in execution of each client and class, it's kept out of clientLimits
+	// so that an evicted client can't exceed its concurrent limit
+	concurrent map[string]int
+
+	allowedCounter    metrics.Counter
+	rateCounter       metrics.Counter
+	concurrentCounter metrics.Counter
+	batchCounter      metrics.Counter
+}
+
+func NewLimiter(cfg *LimiterConfig) *Limiter {
+	l := &Limiter{
+		cfg:         cfg,
+		methodClass: make(map[string]*MethodClass),
+		apiKeys:     make(map[string]struct{}),
+		concurrent:  make(map[string]int),
+
+		allowedCounter:    metrics.GetOrRegisterCounter("/rpc/limiter/allowed", nil),
+		rateCounter:       metrics.GetOrRegisterCounter("/rpc/limiter/rejected/rate", nil),
+		concurrentCounter: metrics.GetOrRegisterCounter("/rpc/limiter/rejected/concurrent", nil),
+		batchCounter:      metrics.GetOrRegisterCounter("/rpc/limiter/rejected/batch", nil),
+	}
+	l.clientLimits, _ = lru.New(maxLimitedClients)
+	for _, class := range cfg.Classes {
+		for _, method := range class.Methods {
+			l.methodClass[method] = class
+		}
+	}
+	for _, key := range cfg.ApiKeys {
+		l.apiKeys[key] = struct{}{}
+	}
+	return l
+}
+
+// clientLimit is the token bucket of a client for a class of methods, it's guarded by the mutex of the limiter
+type clientLimit struct {
+	tokens float64
+	last   time.Time
+}
+
+var (
+	errRateLimited       = &limitExceededError{"request rate limit exceeded, retry later"}
+	errTooManyConcurrent = &limitExceededError{"too many concurrent requests"}
+	errBatchTooLarge     = &limitExceededError{"batch too large"}
+)
+
+// take consumes a token, it returns an error if the bucket is empty
+func (c *clientLimit) take(limit *RateLimit, now time.Time) *limitExceededError {
+	if limit.Rate > 0 {
+		c.tokens += now.Sub(c.last).Seconds() * limit.Rate
+		if burst := limit.burst(); c.tokens > burst {
+			c.tokens = burst
+		}
+		c.last = now
+		if c.tokens < 1 {
+			return errRateLimited
+		}
+		c.tokens--
+	}
+	return nil
+}
+
+func (l *Limiter) class(svcname, method string) (string, *RateLimit) {
+	if class, ok := l.methodClass[svcname+serviceMethodSeparator+method]; ok {
+		return class.Name, &class.RateLimit
+	}
+	if class, ok := l.methodClass[svcname]; ok {
+		return class.Name, &class.RateLimit
+	}
+	return defaultMethodClass, &l.cfg.Default
+}
+
+// clientId returns the api key of the client if it is known, otherwise the ip of the client
+func (l *Limiter) clientId(ctx context.Context) string {
+	if key, ok := ctx.Value("apikey").(string); ok {
+		if _, known := l.apiKeys[key]; known {
+			return "key:" + key
+		}
+	}
+	remote, _ := ctx.Value("remote").(string)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + remote
+}
+
+// acquire checks the limit of the request, the returned function should be called after the request is executed
+func (l *Limiter) acquire(ctx context.Context, req *serverRequest) (func(), Error) {
+	if req.err != nil || req.callb == nil {
+		return func() {}, nil
+	}
+	return l.acquireMethod(ctx, req.svcname, formatName(req.callb.method.Name))
+}
+
+// acquireMethod checks the limit of a method by its namespace and name, it is used by the handlers
+// which are not served by the rpc server, such as the GraphQL handler
+func (l *Limiter) acquireMethod(ctx context.Context, svcname, method string) (func(), Error) {
+	className, limit := l.class(svcname, method)
+	if limit.Rate <= 0 && limit.MaxConcurrent <= 0 {
+		l.allowedCounter.Inc(1)
+		return func() {}, nil
+	}
+
+	now := time.Now()
+	key := l.clientId(ctx) + "/" + className
+	l.mu.Lock()
+	err := l.take(key, limit, now)
+	l.mu.Unlock()
+	if err != nil {
+		if err == errTooManyConcurrent {
+			l.concurrentCounter.Inc(1)
+		} else {
+			l.rateCounter.Inc(1)
+		}
+		metrics.GetOrRegisterCounter("/rpc/limiter/"+strings.ToLower(className)+"/rejected", nil).Inc(1)
+		return nil, err
+	}
+	l.allowedCounter.Inc(1)
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.concurrent[key]--; l.concurrent[key] <= 0 {
+			delete(l.concurrent, key)
+		}
+	}, nil
+}
+
+// take consumes a concurrent slot and a token of the client, it should be called with the mutex held
+func (l *Limiter) take(key string, limit *RateLimit, now time.Time) *limitExceededError {
+	if limit.MaxConcurrent > 0 && l.concurrent[key] >= limit.MaxConcurrent {
+		return errTooManyConcurrent
+	}
+	value, ok := l.clientLimits.Get(key)
+	if !ok {
+		value = &clientLimit{tokens: limit.burst(), last: now}
+		l.clientLimits.Add(key, value)
+	}
+	if err := value.(*clientLimit).take(limit, now); err != nil {
+		return err
+	}
+	l.concurrent[key]++
+	return nil
+}
+
+// checkBatch returns an error if the batch is too large
+func (l *Limiter) checkBatch(size int) Error {
+	if l.cfg.MaxBatchSize > 0 && size > l.cfg.MaxBatchSize {
+		l.batchCounter.Inc(1)
+		return errBatchTooLarge
+	}
+	return nil
+}
+
+// requestApiKey returns the api key from the http header
+func requestApiKey(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(ApiKeyHeader))
+}
@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+)
+
+func newLimiterTestRequest(t *testing.T, server *Server, method string) *serverRequest {
+	callb, ok := server.services["test"].callbacks[method]
+	if !ok {
+		t.Fatalf("method %s is not registered", method)
+	}
+	return &serverRequest{svcname: "test", callb: callb}
+}
+
+func TestClientLimitTake(t *testing.T) {
+	limit := &RateLimit{Rate: 2, Burst: 2}
+	now := time.Now()
+	c := &clientLimit{tokens: limit.burst(), last: now}
+
+	if err := c.take(limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.take(limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.take(limit, now); err != errRateLimited {
+		t.Fatalf("the bucket should be empty, got %v", err)
+	}
+
+	// a token is refilled after half a second
+	now = now.Add(500 * time.Millisecond)
+	if err := c.take(limit, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// the refilled tokens never exceed the burst
+	now = now.Add(time.Hour)
+	if err := c.take(limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.take(limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.take(limit, now); err != errRateLimited {
+		t.Fatalf("the bucket should be empty, got %v", err)
+	}
+}
+
+func TestLimiterConcurrentEvicted(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLimiter(&LimiterConfig{Default: RateLimit{Rate: 100, Burst: 100, MaxConcurrent: 1}})
+	// keep the state of one client only
+	l.clientLimits, _ = lru.New(1)
+	echo := newLimiterTestRequest(t, server, "echo")
+
+	ctx1 := context.WithValue(context.Background(), "remote", "1.1.1.1:1000")
+	ctx2 := context.WithValue(context.Background(), "remote", "2.2.2.2:1000")
+	release, err := l.acquire(ctx1, echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token bucket of the first client is evicted
+	if _, err := l.acquire(ctx2, echo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx1, echo); err != errTooManyConcurrent {
+		t.Fatalf("the request in execution should still be counted, got %v", err)
+	}
+	release()
+	if _, err := l.acquire(ctx1, echo); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterClassAndClient(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLimiter(&LimiterConfig{
+		Default: RateLimit{Rate: 1, Burst: 1},
+		Classes: []*MethodClass{
+			{Name: "sleep", Methods: []string{"test_sleep"}, RateLimit: RateLimit{MaxConcurrent: 1}},
+		},
+		MaxBatchSize: 2,
+		ApiKeys:      []string{"key1"},
+	})
+
+	echo := newLimiterTestRequest(t, server, "echo")
+	sleep := newLimiterTestRequest(t, server, "sleep")
+
+	ctx1 := context.WithValue(context.Background(), "remote", "1.1.1.1:1000")
+	ctx2 := context.WithValue(context.Background(), "remote", "1.1.1.1:2000")
+	if _, err := l.acquire(ctx1, echo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx2, echo); err == nil || err.ErrorCode() != -32005 {
+		t.Fatalf("the client should be limited by ip regardless of port, got %v", err)
+	}
+
+	// a known api key has its own quota, an unknown one is limited by ip
+	known := context.WithValue(ctx2, "apikey", "key1")
+	unknown := context.WithValue(ctx2, "apikey", "key2")
+	if _, err := l.acquire(known, echo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(unknown, echo); err == nil {
+		t.Fatalf("the unknown api key should be limited by ip")
+	}
+
+	// the method class has its own limit
+	release, err := l.acquire(ctx1, sleep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx1, sleep); err != errTooManyConcurrent {
+		t.Fatalf("the concurrent slot should be taken, got %v", err)
+	}
+	release()
+	if _, err := l.acquire(ctx1, sleep); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.checkBatch(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.checkBatch(3); err != errBatchTooLarge {
+		t.Fatalf("the batch should be too large, got %v", err)
+	}
+}
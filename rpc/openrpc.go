@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/vite/version"
+)
+
+const (
+	openRPCVersion = "1.2.6"
+
+	// the prefix trimmed from the package paths of the schema names
+	repoPackagePath = "github.com/vitelabs/go-vite/"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	bigIntType        = reflect.TypeOf(big.Int{})
+)
+
+// OpenRPCDocument describes the methods served by a server, see https://spec.open-rpc.org
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []*OpenRPCMethod  `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name    string                      `json:"name"`
+	Summary string                      `json:"summary,omitempty"`
+	Params  []*OpenRPCContentDescriptor `json:"params"`
+	Result  *OpenRPCContentDescriptor   `json:"result"`
+
+	// Subscription is the method which creates the subscription, the name of the subscription is its first param
+	Subscription string `json:"x-subscription,omitempty"`
+}
+
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas"`
+}
+
+// JSONSchema is the subset of json schema which describes the go types, an empty schema matches any value
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// schemaBuilder builds the schemas of the go types, the named structs are put into the components
+// and referred by name, so that the recursive types are supported
+type schemaBuilder struct {
+	schemas map[string]*JSONSchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]*JSONSchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == bigIntType {
+		return &JSONSchema{Type: "integer"}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &JSONSchema{Type: "string"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		// the format is defined by the type itself
+		return &JSONSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded in base64
+			return &JSONSchema{Type: "string"}
+		}
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Array:
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + b.structName(t)}
+	}
+	return &JSONSchema{}
+}
+
+// structName returns the name of a named struct in the components, and builds its schema at the first time.
+// The name is qualified by the package path, e.g. rpcapi.api.AccountBlock, so that the types of the same name
+// in different packages are told apart regardless of the order in which they are found.
+func (b *schemaBuilder) structName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	pkgPath := t.PkgPath()
+	if i := strings.LastIndex(pkgPath, "/vendor/"); i >= 0 {
+		pkgPath = pkgPath[i+len("/vendor/"):]
+	}
+	pkgPath = strings.TrimPrefix(pkgPath, repoPackagePath)
+	name := strings.Replace(pkgPath, "/", ".", -1) + "." + t.Name()
+	b.names[t] = name
+	// reserve the name before building the schema of the fields which may refer to it
+	b.schemas[name] = &JSONSchema{}
+	*b.schemas[name] = *b.structSchema(t)
+	return name
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	b.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of t as encoding/json encodes them, the fields of the embedded structs are promoted
+func (b *schemaBuilder) addFields(s *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.addFields(s, fieldType)
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = b.schema(field.Type)
+	}
+}
+
+// Discover returns the OpenRPC document of the methods served by the server which the caller is allowed to call
+func (s *RPCService) Discover(ctx context.Context) *OpenRPCDocument {
+	b := newSchemaBuilder()
+	doc := &OpenRPCDocument{
+		OpenRPC: openRPCVersion,
+		Info:    OpenRPCInfo{Title: "gvite", Version: version.VITE_BUILD_VERSION},
+	}
+	allowed := s.server.permitted(ctx)
+
+	svcnames := make([]string, 0, len(s.server.services))
+	for name := range s.server.services {
+		svcnames = append(svcnames, name)
+	}
+	sort.Strings(svcnames)
+	for _, svcname := range svcnames {
+		svc := s.server.services[svcname]
+		for _, name := range sortedCallbackNames(svc.callbacks) {
+			callb := svc.callbacks[name]
+			if !allowed(svc.name, name, callb) {
+				continue
+			}
+			doc.Methods = append(doc.Methods, b.method(svc.name+serviceMethodSeparator+name, callb))
+		}
+		for _, name := range sortedCallbackNames(svc.subscriptions) {
+			callb := svc.subscriptions[name]
+			if !allowed(svc.name, name, callb) {
+				continue
+			}
+			m := b.method(svc.name+serviceMethodSeparator+name, callb)
+			m.Subscription = svc.name + subscribeMethodSuffix
+			m.Summary = fmt.Sprintf("subscribed by %s with the first param %s", m.Subscription, name)
+			m.Result = &OpenRPCContentDescriptor{Name: "subscription", Required: true, Schema: &JSONSchema{Type: "string"}}
+			doc.Methods = append(doc.Methods, m)
+		}
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+	doc.Components.Schemas = b.schemas
+	return doc
+}
+
+func sortedCallbackNames(callbacks map[string]*callback) []string {
+	names := make([]string, 0, len(callbacks))
+	for name := range callbacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *schemaBuilder) method(name string, callb *callback) *OpenRPCMethod {
+	m := &OpenRPCMethod{Name: name, Params: make([]*OpenRPCContentDescriptor, 0, len(callb.argTypes))}
+
+	// the trailing pointer arguments are optional
+	optional := len(callb.argTypes)
+	for optional > 0 && callb.argTypes[optional-1].Kind() == reflect.Ptr {
+		optional--
+	}
+	for i, argType := range callb.argTypes {
+		m.Params = append(m.Params, &OpenRPCContentDescriptor{
+			Name:     fmt.Sprintf("param%d", i+1),
+			Required: i < optional,
+			Schema:   b.schema(argType),
+		})
+	}
+
+	m.Result = &OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{Type: "null"}}
+	mtype := callb.method.Type
+	for i := 0; i < mtype.NumOut(); i++ {
+		if i != callb.errPos {
+			m.Result.Schema = b.schema(mtype.Out(i))
+			break
+		}
+	}
+	return m
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRPCServiceDiscover(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	doc := (&RPCService{server}).Discover(context.Background())
+
+	methods := make(map[string]*OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	if _, ok := methods["rpc_discover"]; !ok {
+		t.Fatalf("rpc_discover should be in the document")
+	}
+
+	echo, ok := methods["test_echo"]
+	if !ok {
+		t.Fatalf("test_echo should be in the document")
+	}
+	if len(echo.Params) != 3 || echo.Params[0].Schema.Type != "string" || echo.Params[1].Schema.Type != "integer" {
+		t.Fatalf("unexpected params of test_echo")
+	}
+	if !echo.Params[1].Required || echo.Params[2].Required {
+		t.Fatalf("only the trailing pointer param should be optional")
+	}
+	if echo.Result.Schema.Ref != "#/components/schemas/rpc.Result" {
+		t.Fatalf("unexpected result of test_echo %s", echo.Result.Schema.Ref)
+	}
+	result := doc.Components.Schemas["rpc.Result"]
+	if result == nil || result.Properties["Args"].Ref != "#/components/schemas/rpc.Args" || result.Properties["Int"].Type != "integer" {
+		t.Fatalf("unexpected schema of Result")
+	}
+
+	if sub, ok := methods["test_subscription"]; !ok || sub.Subscription != "test_subscribe" {
+		t.Fatalf("test_subscription should be a subscription")
+	}
+	if rets, ok := methods["test_rets"]; !ok || rets.Result.Schema.Type != "string" {
+		t.Fatalf("the error of test_rets should not be the result")
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRPCServiceDiscoverPermission(t *testing.T) {
+	auth, err := NewAuthenticator(&AuthConfig{Credentials: []*Credential{{Name: "tester", Token: "token1", Allow: []string{"test_echo"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAuth(auth)
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+
+	discover := func(token string) map[string]bool {
+		ctx := context.WithValue(context.Background(), "token", token)
+		methods := make(map[string]bool)
+		for _, m := range (&RPCService{server}).Discover(ctx).Methods {
+			methods[m.Name] = true
+		}
+		return methods
+	}
+	if methods := discover(""); !methods["rpc_discover"] || methods["test_echo"] {
+		t.Fatalf("an anonymous caller should only see the anonymous methods: %v", methods)
+	}
+	if methods := discover("token1"); !methods["rpc_discover"] || !methods["test_echo"] || methods["test_rets"] {
+		t.Fatalf("the caller should see the allowed methods only: %v", methods)
+	}
+}
@@ -54,7 +54,7 @@ func NewServer() *Server {
 	// register chain default service which will provide meta information about the RPC service such as the services and
 	// methods it offers.
 	rpcService := &RPCService{server}
-	server.RegisterName(MetadataApi, rpcService)
+	server.RegisterAnonymousName(MetadataApi, rpcService)
 
 	return server
 }
@@ -65,7 +65,7 @@ type RPCService struct {
 	server *Server
 }
 
-// Modules returns the list of RPC services with their version number
+// Modules returns the list of RPC services with their version number, see Discover for the methods of them
 func (s *RPCService) Modules() map[string]string {
 	modules := make(map[string]string)
 	for name := range s.server.services {
@@ -74,10 +74,30 @@ func (s *RPCService) Modules() map[string]string {
 	return modules
 }
 
+// SetLimiter sets the limiter of the requests, it should be called before the server serves any request
+func (s *Server) SetLimiter(limiter *Limiter) {
+	s.limiter = limiter
+}
+
+// SetAuth sets the authenticator of the requests, only the methods registered by RegisterAnonymousName can be
+// called without a credential. It should be called before the server serves any request.
+func (s *Server) SetAuth(auth *Authenticator) {
+	s.auth = auth
+}
+
+// RegisterAnonymousName registers the service like RegisterName, and its methods can be called without a credential
+func (s *Server) RegisterAnonymousName(name string, rcvr interface{}) error {
+	return s.registerName(name, rcvr, true)
+}
+
 // RegisterName will create chain service for the given rcvr type under the given name. When no methods on the given rcvr
 // match the criteria to be either chain RPC method or chain subscription an error is returned. Otherwise chain new service is
 // created and added to the service collection this server instance serves.
 func (s *Server) RegisterName(name string, rcvr interface{}) error {
+	return s.registerName(name, rcvr, false)
+}
+
+func (s *Server) registerName(name string, rcvr interface{}, anonymous bool) error {
 	if s.services == nil {
 		s.services = make(serviceRegistry)
 	}
@@ -102,6 +122,18 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 		return fmt.Errorf("Service %T doesn't have any suitable methods/subscriptions to expose", rcvr)
 	}
 
+	if anonymous {
+		if s.anonymous == nil {
+			s.anonymous = make(map[*callback]struct{})
+		}
+		for _, m := range methods {
+			s.anonymous[m] = struct{}{}
+		}
+		for _, sub := range subscriptions {
+			s.anonymous[sub] = struct{}{}
+		}
+	}
+
 	// already chain previous service register under given name, merge methods/subscriptions
 	if regsvc, present := s.services[name]; present {
 		for _, m := range methods {
@@ -192,6 +224,20 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 			}
 			return nil
 		}
+		// reject the whole batch if it is too large
+		if batch && s.limiter != nil {
+			if err := s.limiter.checkBatch(len(reqs)); err != nil {
+				resps := make([]interface{}, len(reqs))
+				for i, r := range reqs {
+					resps[i] = codec.CreateErrorResponse(&r.id, err)
+				}
+				codec.Write(resps)
+				if singleShot {
+					return nil
+				}
+				continue
+			}
+		}
 		// If chain single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -259,6 +305,18 @@ func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *ser
 	return reply[0].Interface().(*Subscription).ID, nil
 }
 
+// handleWithLimit executes chain request if it is allowed by the limiter.
+func (s *Server) handleWithLimit(ctx context.Context, codec ServerCodec, req *serverRequest) (interface{}, func()) {
+	if s.limiter != nil {
+		release, err := s.limiter.acquire(ctx, req)
+		if err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+		defer release()
+	}
+	return s.handle(ctx, codec, req)
+}
+
 // handle executes chain request and returns the response from the callback.
 func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverRequest) (result interface{}, f func()) {
 	if req.err != nil {
@@ -282,6 +340,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	if s.auth != nil {
+		token, _ := ctx.Value("token").(string)
+		if err := s.authorize(token, req); err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -348,7 +413,7 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	if req.err != nil {
 		response = codec.CreateErrorResponse(&req.id, req.err)
 	} else {
-		response, callback = s.handle(ctx, codec, req)
+		response, callback = s.handleWithLimit(ctx, codec, req)
 	}
 
 	if err := codec.Write(response); err != nil {
@@ -372,7 +437,7 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
 			var callback func()
-			if responses[i], callback = s.handle(ctx, codec, req); callback != nil {
+			if responses[i], callback = s.handleWithLimit(ctx, codec, req); callback != nil {
 				callbacks = append(callbacks, callback)
 			}
 		}
@@ -102,6 +102,27 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// Fail ends the subscription with the error. The client receives chain notification with the error
+// instead of the result, and the subscription is removed as if the client unsubscribed it.
+func (n *Notifier) Fail(id ID, err error) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	sub, active := n.active[id]
+	if !active {
+		return ErrSubscriptionNotFound
+	}
+	close(sub.err)
+	delete(n.active, id)
+
+	notification := n.codec.CreateErrorNotification(string(id), sub.namespace, &callbackError{err.Error()})
+	if err := n.codec.Write(notification); err != nil {
+		n.codec.Close()
+		return err
+	}
+	return nil
+}
+
 // Closed returns chain channel that is closed when the RPC connection is closed.
 func (n *Notifier) Closed() <-chan interface{} {
 	return n.codec.Closed()
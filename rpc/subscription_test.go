@@ -19,6 +19,7 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -87,6 +88,23 @@ func (s *NotificationTestService) SomeSubscription(ctx context.Context, n, val i
 	return subscription, nil
 }
 
+// FailSubscription ends the subscription with an error once it is activated
+func (s *NotificationTestService) FailSubscription(ctx context.Context, msg string) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+
+	go func() {
+		// the subscription is not found until it is activated
+		for notifier.Fail(subscription.ID, errors.New(msg)) == ErrSubscriptionNotFound {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	return subscription, nil
+}
+
 // HangSubscription blocks on s.unblockHangSubscription before
 // sending anything.
 func (s *NotificationTestService) HangSubscription(ctx context.Context, val int) (*Subscription, error) {
@@ -214,7 +232,7 @@ func waitForMessages(t *testing.T, in *json.Decoder, successes chan<- jsonSucces
 				notifications <- jsonNotification{
 					Version: msg["jsonrpc"].(string),
 					Method:  msg["method"].(string),
-					Params:  jsonSubscription{params["subscription"].(string), params["result"]},
+					Params:  jsonSubscription{Subscription: params["subscription"].(string), Result: params["result"]},
 				}
 				continue
 			}
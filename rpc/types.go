@@ -69,6 +69,10 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   mapset.Set
+	limiter  *Limiter
+
+	auth      *Authenticator
+	anonymous map[*callback]struct{}
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -87,6 +91,12 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError is an Error with structured data, which is returned in the data field of the error response.
+type DataError interface {
+	Error
+	ErrorData() interface{} // returns the data
+}
+
 type ErrorWithId interface {
 	Error
 	Id() interface{}
@@ -108,6 +118,8 @@ type ServerCodec interface {
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
 	CreateNotification(id, namespace string, event interface{}) interface{}
+	// Create notification which ends the subscription with the error
+	CreateErrorNotification(id, namespace string, err Error) interface{}
 	// Write msg to client.
 	Write(msg interface{}) error
 	// Close underlying data stream
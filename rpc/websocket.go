@@ -68,7 +68,14 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			// the remote address and the api key identify the client for the limiter, the token is its credential
+			ctx := context.WithValue(context.Background(), "remote", conn.Request().RemoteAddr)
+			ctx = context.WithValue(ctx, "apikey", requestApiKey(conn.Request()))
+			ctx = context.WithValue(ctx, "token", requestAuthToken(conn.Request()))
+
+			codec := NewCodec(conn, encoder, decoder)
+			defer codec.Close()
+			srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
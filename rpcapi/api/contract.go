@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/hex"
-	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/vm/abi"
@@ -84,7 +83,7 @@ func (c *ContractApi) GetCallContractData(abiStr string, methodName string, para
 	}
 	method, ok := abiContract.Methods[methodName]
 	if !ok {
-		return nil, errors.New("method name not found")
+		return nil, ErrInvalidParam.with("method name not found")
 	}
 	arguments, err := convert(params, method.Inputs)
 	if err != nil {
@@ -101,7 +100,7 @@ func (c *ContractApi) GetCallOffChainData(abiStr string, offChainName string, pa
 	}
 	method, ok := abiContract.OffChains[offChainName]
 	if !ok {
-		return nil, errors.New("offchain name not found")
+		return nil, ErrInvalidParam.with("offchain name not found")
 	}
 	arguments, err := convert(params, method.Inputs)
 	if err != nil {
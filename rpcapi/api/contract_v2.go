@@ -163,6 +163,38 @@ func (c *ContractApi) GetContractStorage(addr types.Address, prefix string) (map
 	}
 }
 
+// GetContractStorageAtSnapshot returns the contract storage as of the snapshot height
+func (c *ContractApi) GetContractStorageAtSnapshot(addr types.Address, prefix string, snapshotHeight string) (map[string]string, error) {
+	height, err := StringToUint64(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	var prefixBytes []byte
+	if len(prefix) > 0 {
+		prefixBytes, err = hex.DecodeString(prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	iter, err := c.chain.GetStorageIteratorAtSnapshot(addr, prefixBytes, height)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Release()
+	m := make(map[string]string)
+	for {
+		if !iter.Next() {
+			if iter.Error() != nil {
+				return nil, iter.Error()
+			}
+			return m, nil
+		}
+		if len(iter.Key()) > 0 && len(iter.Value()) > 0 {
+			m[hex.EncodeToString(iter.Key())] = hex.EncodeToString(iter.Value())
+		}
+	}
+}
+
 type QuotaInfo struct {
 	CurrentQuota string  `json:"currentQuota"`
 	MaxQuota     string  `json:"maxQuota"`
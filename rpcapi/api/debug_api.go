@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/json"
-	"errors"
 	"math/big"
 	"runtime/debug"
 	"time"
@@ -11,7 +10,9 @@ import (
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/generator"
 	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm"
 )
 
 type DebugApi struct {
@@ -192,6 +193,53 @@ func errMap(err error) map[string]interface{} {
 	return m
 }
 
+type TraceAccountBlockResult struct {
+	Block      *AccountBlock   `json:"block"`
+	Consistent bool            `json:"consistent"`
+	IsRetry    bool            `json:"isRetry"`
+	Error      *string         `json:"error,omitempty"`
+	Trace      *vm.StructTrace `json:"trace"`
+}
+
+// TraceAccountBlock re-executes an inserted receive block with a struct logger attached to the vm.
+// Consistent is true if the re-executed block has the same hash as the inserted one.
+func (api DebugApi) TraceAccountBlock(hash types.Hash, cfg *vm.LogConfig) (*TraceAccountBlockResult, error) {
+	c := api.v.Chain()
+	block, err := c.GetAccountBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, ErrDataNotFound.with("account block is not exist")
+	}
+	if !block.IsReceiveBlock() {
+		return nil, ErrInvalidParam.with("only receive block can be traced")
+	}
+
+	tracer := vm.NewStructLogger(cfg)
+	genResult, err := generator.ReplayAccountBlock(c, api.v.Consensus(), block, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TraceAccountBlockResult{
+		IsRetry: genResult.IsRetry,
+		Trace:   tracer.Trace(),
+	}
+	if genResult.Err != nil {
+		errStr := genResult.Err.Error()
+		result.Error = &errStr
+	}
+	if genResult.VMBlock != nil {
+		vb := genResult.VMBlock.AccountBlock
+		if result.Block, err = ledgerToRpcBlock(c, vb); err != nil {
+			return nil, err
+		}
+		result.Consistent = vb.Hash == block.Hash
+	}
+	return result, nil
+}
+
 func (api DebugApi) MachineInfo() map[string]interface{} {
 	result := make(map[string]interface{})
 	result["now"] = time.Now().String()
@@ -232,7 +280,7 @@ func (api DebugApi) UpdateOnRoadInfo(addr types.Address, tkId types.TokenTypeId,
 	}
 	result := amount.Cmp(helper.Big0)
 	if result < 0 || (number == 0 && result > 0) {
-		return errors.New("amount invalid")
+		return ErrInvalidParam.with("amount invalid")
 	}
 	return api.v.Chain().UpdateOnRoadInfo(addr, tkId, number, *amount)
 }
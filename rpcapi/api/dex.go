@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/hex"
-	"fmt"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
@@ -237,7 +236,7 @@ func (f DexApi) GetCurrentMiningInfo() (mineInfo *apidex.NewRpcVxMineInfo, err e
 	}
 	mineInfo = new(apidex.NewRpcVxMineInfo)
 	if toMine.Sign() == 0 {
-		err = fmt.Errorf("no vx available on mine")
+		err = ErrDataNotFound.with("no vx available on mine")
 		return
 	}
 	total := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100000000))
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"fmt"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
@@ -201,7 +200,7 @@ func (f DexFundApi) GetCurrentVxMineInfo() (mineInfo *apidex.RpcVxMineInfo, err
 	}
 	mineInfo = new(apidex.RpcVxMineInfo)
 	if toMine.Sign() == 0 {
-		err = fmt.Errorf("no vx available on mine")
+		err = ErrDataNotFound.with("no vx available on mine")
 		return
 	}
 	total := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100000000))
@@ -1,16 +1,33 @@
 package api
 
 import (
+	"strings"
+
 	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
+	onroad_pool "github.com/vitelabs/go-vite/onroad/pool"
+	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
 	"github.com/vitelabs/go-vite/vm/util"
 	"github.com/vitelabs/go-vite/wallet/walleterrors"
 )
 
+// JsonRpc2Error is an error with a stable code, the code and the reason never change once they are released,
+// so that the clients can tell the errors apart without matching the messages.
 type JsonRpc2Error struct {
 	Message string
 	Code    int
+
+	// Reason is the machine-readable name of the error, like VM_INSUFFICIENT_BALANCE
+	Reason string
+	// Detail is the specific information of an occurrence, like the original message of a wrapped error
+	Detail string
+}
+
+// JsonRpc2ErrorData is returned in the data field of the json-rpc error
+type JsonRpc2ErrorData struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
 }
 
 func (e JsonRpc2Error) Error() string {
@@ -21,240 +38,623 @@ func (e JsonRpc2Error) ErrorCode() int {
 	return e.Code
 }
 
+func (e JsonRpc2Error) ErrorData() interface{} {
+	return JsonRpc2ErrorData{Reason: e.Reason, Detail: e.Detail}
+}
+
+// with returns the error with the message of a specific case
+func (e JsonRpc2Error) with(message string) JsonRpc2Error {
+	e.Message = message
+	return e
+}
+
 var (
 	// ErrNotSupport = errors.New("not support this method")
 	IllegalNodeTime = errors.New("The node time is inaccurate, quite different from the time of latest snapshot block.")
 
+	// -33001 ~ -33999 request error, the message varies with the case
+	ErrInvalidParam = JsonRpc2Error{
+		Message: "invalid param",
+		Code:    -33001,
+		Reason:  "INVALID_PARAM",
+	}
+	ErrDataNotFound = JsonRpc2Error{
+		Message: "data not found",
+		Code:    -33002,
+		Reason:  "NOT_FOUND",
+	}
+	ErrNotSupported = JsonRpc2Error{
+		Message: "not supported by the node",
+		Code:    -33003,
+		Reason:  "NOT_SUPPORTED",
+	}
+	ErrNodeNotReady = JsonRpc2Error{
+		Message: "node not ready",
+		Code:    -33004,
+		Reason:  "NODE_NOT_READY",
+	}
+	ErrGenerateBlock = JsonRpc2Error{
+		Message: "generate block failed",
+		Code:    -33005,
+		Reason:  "GENERATE_BLOCK_FAILED",
+	}
+	ErrInternal = JsonRpc2Error{
+		Message: "internal error",
+		Code:    -33006,
+		Reason:  "INTERNAL_ERROR",
+	}
+	ErrTooFrequent = JsonRpc2Error{
+		Message: "too frequent",
+		Code:    -33007,
+		Reason:  "TOO_FREQUENT",
+	}
+	ErrPermissionDenied = JsonRpc2Error{
+		Message: "permission denied",
+		Code:    -33008,
+		Reason:  "PERMISSION_DENIED",
+	}
+	ErrConvertBigInt = JsonRpc2Error{
+		Message: ErrStrToBigInt.Error(),
+		Code:    -33009,
+		Reason:  "INVALID_BIG_INT",
+	}
+	ErrInaccurateNodeTime = JsonRpc2Error{
+		Message: IllegalNodeTime.Error(),
+		Code:    -33010,
+		Reason:  "INACCURATE_NODE_TIME",
+	}
+
+	// -34001 ~ -34999 wallet
 	ErrDecryptKey = JsonRpc2Error{
 		Message: walleterrors.ErrDecryptEntropy.Error(),
 		Code:    -34001,
+		Reason:  "WALLET_DECRYPT_FAILED",
+	}
+	ErrWalletLocked = JsonRpc2Error{
+		Message: walleterrors.ErrLocked.Error(),
+		Code:    -34002,
+		Reason:  "WALLET_LOCKED",
+	}
+	ErrWalletAddressNotFound = JsonRpc2Error{
+		Message: walleterrors.ErrAddressNotFound.Error(),
+		Code:    -34003,
+		Reason:  "WALLET_ADDRESS_NOT_FOUND",
+	}
+	ErrWalletInvalidPrikey = JsonRpc2Error{
+		Message: walleterrors.ErrInvalidPrikey.Error(),
+		Code:    -34004,
+		Reason:  "WALLET_INVALID_PRIKEY",
+	}
+	ErrWalletEmptyStore = JsonRpc2Error{
+		Message: walleterrors.ErrEmptyStore.Error(),
+		Code:    -34005,
+		Reason:  "WALLET_EMPTY_STORE",
+	}
+	ErrWalletStoreNotFound = JsonRpc2Error{
+		Message: walleterrors.ErrStoreNotFound.Error(),
+		Code:    -34006,
+		Reason:  "WALLET_STORE_NOT_FOUND",
 	}
 
 	// -35001 ~ -35999 vm execution error
 	ErrBalanceNotEnough = JsonRpc2Error{
 		Message: util.ErrInsufficientBalance.Error(),
 		Code:    -35001,
+		Reason:  "VM_INSUFFICIENT_BALANCE",
 	}
 
 	ErrQuotaNotEnough = JsonRpc2Error{
 		Message: util.ErrOutOfQuota.Error(),
 		Code:    -35002,
+		Reason:  "VM_OUT_OF_QUOTA",
 	}
 
 	ErrVmIdCollision = JsonRpc2Error{
 		Message: util.ErrIDCollision.Error(),
 		Code:    -35003,
+		Reason:  "VM_ID_COLLISION",
 	}
 	ErrVmInvaildBlockData = JsonRpc2Error{
 		Message: util.ErrInvalidMethodParam.Error(),
 		Code:    -35004,
+		Reason:  "VM_INVALID_METHOD_PARAM",
 	}
 	ErrVmCalPoWTwice = JsonRpc2Error{
 		Message: util.ErrCalcPoWTwice.Error(),
 		Code:    -35005,
+		Reason:  "VM_CALC_POW_TWICE",
 	}
 
 	ErrVmMethodNotFound = JsonRpc2Error{
 		Message: util.ErrAbiMethodNotFound.Error(),
 		Code:    -35006,
+		Reason:  "VM_ABI_METHOD_NOT_FOUND",
 	}
 
 	ErrVmInvalidResponseLatency = JsonRpc2Error{
 		Message: util.ErrInvalidResponseLatency.Error(),
 		Code:    -35007,
+		Reason:  "VM_INVALID_RESPONSE_LATENCY",
 	}
 
 	ErrVmContractNotExists = JsonRpc2Error{
 		Message: util.ErrContractNotExists.Error(),
 		Code:    -35008,
+		Reason:  "VM_CONTRACT_NOT_EXISTS",
 	}
 
 	ErrVmNoReliableStatus = JsonRpc2Error{
 		Message: util.ErrNoReliableStatus.Error(),
 		Code:    -35009,
+		Reason:  "VM_NO_RELIABLE_STATUS",
 	}
 
 	ErrVmInvalidQuotaMultiplier = JsonRpc2Error{
 		Message: util.ErrInvalidQuotaMultiplier.Error(),
 		Code:    -35010,
+		Reason:  "VM_INVALID_QUOTA_MULTIPLIER",
 	}
 	ErrVmPoWNotSupported = JsonRpc2Error{
 		Message: ErrPoWNotSupportedUnderCongestion.Error(),
 		Code:    -35011,
+		Reason:  "VM_POW_NOT_SUPPORTED",
 	}
 	ErrVmQuotaLimitReached = JsonRpc2Error{
 		Message: util.ErrBlockQuotaLimitReached.Error(),
 		Code:    -35012,
+		Reason:  "VM_BLOCK_QUOTA_LIMIT_REACHED",
 	}
 	ErrVmInvalidRandomDegree = JsonRpc2Error{
 		Message: util.ErrInvalidRandomDegree.Error(),
 		Code:    -35013,
+		Reason:  "VM_INVALID_RANDOM_DEGREE",
+	}
+	ErrVmRewardIsNotDrained = JsonRpc2Error{
+		Message: util.ErrRewardIsNotDrained.Error(),
+		Code:    -35014,
+		Reason:  "VM_REWARD_IS_NOT_DRAINED",
+	}
+	ErrVmAddressNotMatch = JsonRpc2Error{
+		Message: util.ErrAddressNotMatch.Error(),
+		Code:    -35015,
+		Reason:  "VM_ADDRESS_NOT_MATCH",
+	}
+	ErrVmTransactionTypeNotSupport = JsonRpc2Error{
+		Message: util.ErrTransactionTypeNotSupport.Error(),
+		Code:    -35016,
+		Reason:  "VM_TRANSACTION_TYPE_NOT_SUPPORTED",
+	}
+	ErrVmVersionNotSupport = JsonRpc2Error{
+		Message: util.ErrVersionNotSupport.Error(),
+		Code:    -35017,
+		Reason:  "VM_VERSION_NOT_SUPPORTED",
+	}
+	ErrVmBlockTypeNotSupported = JsonRpc2Error{
+		Message: util.ErrBlockTypeNotSupported.Error(),
+		Code:    -35018,
+		Reason:  "VM_BLOCK_TYPE_NOT_SUPPORTED",
+	}
+	ErrVmDataNotExist = JsonRpc2Error{
+		Message: util.ErrDataNotExist.Error(),
+		Code:    -35019,
+		Reason:  "VM_DATA_NOT_EXIST",
+	}
+	ErrVmAddressCollision = JsonRpc2Error{
+		Message: util.ErrAddressCollision.Error(),
+		Code:    -35020,
+		Reason:  "VM_ADDRESS_COLLISION",
+	}
+	ErrVmRewardNotDue = JsonRpc2Error{
+		Message: util.ErrRewardNotDue.Error(),
+		Code:    -35021,
+		Reason:  "VM_REWARD_NOT_DUE",
+	}
+	ErrVmExecutionReverted = JsonRpc2Error{
+		Message: util.ErrExecutionReverted.Error(),
+		Code:    -35022,
+		Reason:  "VM_EXECUTION_REVERTED",
+	}
+	ErrVmDepth = JsonRpc2Error{
+		Message: util.ErrDepth.Error(),
+		Code:    -35023,
+		Reason:  "VM_MAX_CALL_DEPTH_EXCEEDED",
+	}
+	ErrVmGasUintOverflow = JsonRpc2Error{
+		Message: util.ErrGasUintOverflow.Error(),
+		Code:    -35024,
+		Reason:  "VM_GAS_UINT_OVERFLOW",
+	}
+	ErrVmStorageModifyLimitReached = JsonRpc2Error{
+		Message: util.ErrStorageModifyLimitReached.Error(),
+		Code:    -35025,
+		Reason:  "VM_STORAGE_MODIFY_LIMIT_REACHED",
+	}
+	ErrVmMemSizeOverflow = JsonRpc2Error{
+		Message: util.ErrMemSizeOverflow.Error(),
+		Code:    -35026,
+		Reason:  "VM_MEM_SIZE_OVERFLOW",
+	}
+	ErrVmReturnDataOutOfBounds = JsonRpc2Error{
+		Message: util.ErrReturnDataOutOfBounds.Error(),
+		Code:    -35027,
+		Reason:  "VM_RETURN_DATA_OUT_OF_BOUNDS",
+	}
+	ErrVmAccountQuotaLimitReached = JsonRpc2Error{
+		Message: util.ErrAccountQuotaLimitReached.Error(),
+		Code:    -35028,
+		Reason:  "VM_ACCOUNT_QUOTA_LIMIT_REACHED",
+	}
+	ErrVmInvalidCodeLength = JsonRpc2Error{
+		Message: util.ErrInvalidCodeLength.Error(),
+		Code:    -35029,
+		Reason:  "VM_INVALID_CODE_LENGTH",
+	}
+	ErrVmInvalidUnconfirmedQuota = JsonRpc2Error{
+		Message: util.ErrInvalidUnconfirmedQuota.Error(),
+		Code:    -35030,
+		Reason:  "VM_INVALID_UNCONFIRMED_QUOTA",
+	}
+	ErrVmStackLimitReached = JsonRpc2Error{
+		Message: util.ErrStackLimitReached.Error(),
+		Code:    -35031,
+		Reason:  "VM_STACK_LIMIT_REACHED",
+	}
+	ErrVmStackUnderflow = JsonRpc2Error{
+		Message: util.ErrStackUnderflow.Error(),
+		Code:    -35032,
+		Reason:  "VM_STACK_UNDERFLOW",
+	}
+	ErrVmInvalidJumpDestination = JsonRpc2Error{
+		Message: util.ErrInvalidJumpDestination.Error(),
+		Code:    -35033,
+		Reason:  "VM_INVALID_JUMP_DESTINATION",
+	}
+	ErrVmInvalidOpCode = JsonRpc2Error{
+		Message: util.ErrInvalidOpCode.Error(),
+		Code:    -35034,
+		Reason:  "VM_INVALID_OPCODE",
+	}
+	ErrVmChainForked = JsonRpc2Error{
+		Message: util.ErrChainForked.Error(),
+		Code:    -35035,
+		Reason:  "VM_CHAIN_FORKED",
+	}
+	ErrVmContractCreationFail = JsonRpc2Error{
+		Message: util.ErrContractCreationFail.Error(),
+		Code:    -35036,
+		Reason:  "VM_CONTRACT_CREATION_FAILED",
+	}
+	ErrVmExecutionCanceled = JsonRpc2Error{
+		Message: util.ErrExecutionCanceled.Error(),
+		Code:    -35037,
+		Reason:  "VM_EXECUTION_CANCELED",
 	}
 
 	// -36001 ~ -36999 verifier_account
 	ErrVerifyAccountAddr = JsonRpc2Error{
 		Message: verifier.ErrVerifyAccountNotInvalid.Error(),
 		Code:    -36001,
+		Reason:  "VERIFY_ACCOUNT_INVALID",
 	}
 	ErrVerifyHash = JsonRpc2Error{
 		Message: verifier.ErrVerifyHashFailed.Error(),
 		Code:    -36002,
+		Reason:  "VERIFY_HASH_FAILED",
 	}
 	ErrVerifySignature = JsonRpc2Error{
 		Message: verifier.ErrVerifySignatureFailed.Error(),
 		Code:    -36003,
+		Reason:  "VERIFY_SIGNATURE_FAILED",
 	}
 	ErrVerifyNonce = JsonRpc2Error{
 		Message: verifier.ErrVerifyNonceFailed.Error(),
 		Code:    -36004,
+		Reason:  "VERIFY_NONCE_FAILED",
 	}
 	ErrVerifyPrevBlock = JsonRpc2Error{
 		Message: verifier.ErrVerifyPrevBlockFailed.Error(),
 		Code:    -36005,
+		Reason:  "VERIFY_PREV_BLOCK_FAILED",
 	}
 	ErrVerifyRPCBlockIsPending = JsonRpc2Error{
 		Message: verifier.ErrVerifyRPCBlockPendingState.Error(),
 		Code:    -36006,
+		Reason:  "VERIFY_BLOCK_PENDING",
 	}
 	ErrVerifyDependentSendBlockNotExists = JsonRpc2Error{
 		Message: verifier.ErrVerifyDependentSendBlockNotExists.Error(),
 		Code:    -36007,
+		Reason:  "VERIFY_SEND_BLOCK_NOT_EXISTS",
 	}
 	ErrVerifyPowQualificationNotEnough = JsonRpc2Error{
 		Message: verifier.ErrVerifyPowNotEligible.Error(),
 		Code:    -36008,
+		Reason:  "VERIFY_POW_NOT_ELIGIBLE",
 	}
 	ErrVerifyProducerIllegal = JsonRpc2Error{
 		Message: verifier.ErrVerifyProducerIllegal.Error(),
 		Code:    -36009,
+		Reason:  "VERIFY_PRODUCER_ILLEGAL",
 	}
 	ErrVerifyBlockFieldData = JsonRpc2Error{
 		Message: verifier.ErrVerifyBlockFieldData.Error(),
 		Code:    -36010,
+		Reason:  "VERIFY_BLOCK_FIELD_ILLEGAL",
 	}
 	ErrVerifyIsAlreadyReceived = JsonRpc2Error{
 		Message: verifier.ErrVerifySendIsAlreadyReceived.Error(),
 		Code:    -36011,
+		Reason:  "VERIFY_ALREADY_RECEIVED",
 	}
 	ErrVerifyVmResultInconsistent = JsonRpc2Error{
 		Message: verifier.ErrVerifyVmResultInconsistent.Error(),
 		Code:    -36012,
+		Reason:  "VERIFY_VM_RESULT_INCONSISTENT",
+	}
+	ErrVerifyVmGeneratorFailed = JsonRpc2Error{
+		Message: verifier.ErrVerifyVmGeneratorFailed.Error(),
+		Code:    -36013,
+		Reason:  "VERIFY_GENERATOR_FAILED",
+	}
+	ErrVerifyContractMetaNotExists = JsonRpc2Error{
+		Message: verifier.ErrVerifyContractMetaNotExists.Error(),
+		Code:    -36014,
+		Reason:  "VERIFY_CONTRACT_META_NOT_EXISTS",
+	}
+	ErrVerifyConfirmedTimesNotEnough = JsonRpc2Error{
+		Message: verifier.ErrVerifyConfirmedTimesNotEnough.Error(),
+		Code:    -36015,
+		Reason:  "VERIFY_CONFIRMED_TIMES_NOT_ENOUGH",
+	}
+	ErrVerifySeedConfirmedTimesNotEnough = JsonRpc2Error{
+		Message: verifier.ErrVerifySeedConfirmedTimesNotEnough.Error(),
+		Code:    -36016,
+		Reason:  "VERIFY_SEED_CONFIRMED_TIMES_NOT_ENOUGH",
+	}
+	ErrVerifyContractReceiveSequence = JsonRpc2Error{
+		Message: verifier.ErrVerifyContractReceiveSequenceFailed.Error(),
+		Code:    -36017,
+		Reason:  "VERIFY_CONTRACT_RECEIVE_SEQUENCE_ILLEGAL",
 	}
 
 	// -37001 ~ -37999 contracts_dex
 	ErrComposeOrderIdFail = JsonRpc2Error{
 		Message: dex.ComposeOrderIdFailErr.Error(),
 		Code:    -37001,
+		Reason:  "DEX_COMPOSE_ORDER_ID_FAILED",
 	}
 	ErrDexInvalidOrderType = JsonRpc2Error{
 		Message: dex.InvalidOrderTypeErr.Error(),
 		Code:    -37002,
+		Reason:  "DEX_INVALID_ORDER_TYPE",
 	}
 	ErrDexInvalidOrderPrice = JsonRpc2Error{
 		Message: dex.InvalidOrderPriceErr.Error(),
 		Code:    -37003,
+		Reason:  "DEX_INVALID_ORDER_PRICE",
 	}
 	ErrDexInvalidOrderQuantity = JsonRpc2Error{
 		Message: dex.InvalidOrderQuantityErr.Error(),
 		Code:    -37004,
+		Reason:  "DEX_INVALID_ORDER_QUANTITY",
 	}
 	ErrDexOrderAmountTooSmall = JsonRpc2Error{
 		Message: dex.OrderAmountTooSmallErr.Error(),
 		Code:    -37005,
+		Reason:  "DEX_ORDER_AMOUNT_TOO_SMALL",
 	}
 	ErrDexTradeMarketExists = JsonRpc2Error{
 		Message: dex.TradeMarketExistsErr.Error(),
 		Code:    -37006,
+		Reason:  "DEX_TRADE_MARKET_EXISTS",
 	}
 	ErrDexTradeMarketNotExists = JsonRpc2Error{
 		Message: dex.TradeMarketNotExistsErr.Error(),
 		Code:    -37007,
+		Reason:  "DEX_TRADE_MARKET_NOT_EXISTS",
 	}
 	ErrDexTradeOrderNotExistsErr = JsonRpc2Error{
 		Message: dex.OrderNotExistsErr.Error(),
 		Code:    -37008,
+		Reason:  "DEX_ORDER_NOT_EXISTS",
 	}
 	ErrDexCancelOrderOwnerInvalid = JsonRpc2Error{
 		Message: dex.CancelOrderOwnerInvalidErr.Error(),
 		Code:    -37009,
+		Reason:  "DEX_CANCEL_ORDER_OWNER_INVALID",
 	}
 	ErrDexCancelOrderInvalidStatus = JsonRpc2Error{
 		Message: dex.CancelOrderInvalidStatusErr.Error(),
 		Code:    -37010,
+		Reason:  "DEX_CANCEL_ORDER_STATUS_INVALID",
 	}
 	ErrDexTradeMarketInvalidQuoteToken = JsonRpc2Error{
 		Message: dex.TradeMarketInvalidQuoteTokenErr.Error(),
 		Code:    -37011,
+		Reason:  "DEX_INVALID_QUOTE_TOKEN",
 	}
 	ErrDexTradeMarketInvalidTokenPair = JsonRpc2Error{
 		Message: dex.TradeMarketInvalidTokenPairErr.Error(),
 		Code:    -37012,
+		Reason:  "DEX_INVALID_TOKEN_PAIR",
 	}
 
 	ErrDexFundUserNotExists = JsonRpc2Error{
 		Message: dex.DexFundUserNotExists.Error(),
 		Code:    -37013,
+		Reason:  "DEX_FUND_USER_NOT_EXISTS",
+	}
+
+	// -38001 ~ -38999 pool
+	ErrPoolQuotaNotEnough = JsonRpc2Error{
+		Message: pool.ErrQuotaNotEnough.Error(),
+		Code:    -38001,
+		Reason:  "POOL_QUOTA_NOT_ENOUGH",
+	}
+	ErrPoolBlackList = JsonRpc2Error{
+		Message: pool.ErrBlackList.Error(),
+		Code:    -38002,
+		Reason:  "POOL_BLOCK_IN_BLACKLIST",
+	}
+	ErrPoolAccountHeadNotMatch = JsonRpc2Error{
+		Message: pool.ErrAccountHeadNotMatch.Error(),
+		Code:    -38003,
+		Reason:  "POOL_ACCOUNT_HEAD_NOT_MATCH",
+	}
+	ErrPoolDirectBlockPending = JsonRpc2Error{
+		Message: pool.ErrDirectBlockPending.Error(),
+		Code:    -38004,
+		Reason:  "POOL_BLOCK_PENDING",
+	}
+	ErrPoolDirectBlockFailed = JsonRpc2Error{
+		Message: pool.ErrDirectBlockFailed.Error(),
+		Code:    -38005,
+		Reason:  "POOL_ADD_BLOCK_FAILED",
+	}
+
+	// -39001 ~ -39999 onroad
+	ErrOnroadPoolNotAvailable = JsonRpc2Error{
+		Message: onroad_pool.ErrOnRoadPoolNotAvailable.Error(),
+		Code:    -39001,
+		Reason:  "ONROAD_POOL_NOT_AVAILABLE",
+	}
+	ErrOnroadCallerFrontCheckFailed = JsonRpc2Error{
+		Message: onroad_pool.ErrCheckIsCallerFrontOnRoadFailed.Error(),
+		Code:    -39002,
+		Reason:  "ONROAD_CALLER_FRONT_CHECK_FAILED",
 	}
 
 	concernedErrorMap map[string]JsonRpc2Error
+
+	// the errors whose messages are followed by the details of the occurrences
+	concernedErrorPrefixes = []JsonRpc2Error{
+		ErrPoolAccountHeadNotMatch,
+		ErrPoolDirectBlockPending,
+		ErrPoolDirectBlockFailed,
+	}
 )
 
 func init() {
 	concernedErrorMap = make(map[string]JsonRpc2Error)
-	concernedErrorMap[ErrDecryptKey.Error()] = ErrDecryptKey
-
-	concernedErrorMap[ErrBalanceNotEnough.Error()] = ErrBalanceNotEnough
-	concernedErrorMap[ErrQuotaNotEnough.Error()] = ErrQuotaNotEnough
-
-	concernedErrorMap[ErrVmIdCollision.Error()] = ErrVmIdCollision
-	concernedErrorMap[ErrVmInvaildBlockData.Error()] = ErrVmInvaildBlockData
-	concernedErrorMap[ErrVmCalPoWTwice.Error()] = ErrVmCalPoWTwice
-	concernedErrorMap[ErrVmMethodNotFound.Error()] = ErrVmMethodNotFound
-	concernedErrorMap[ErrVmInvalidResponseLatency.Error()] = ErrVmInvalidResponseLatency
-	concernedErrorMap[ErrVmContractNotExists.Error()] = ErrVmContractNotExists
-	concernedErrorMap[ErrVmNoReliableStatus.Error()] = ErrVmNoReliableStatus
-	concernedErrorMap[ErrVmInvalidQuotaMultiplier.Error()] = ErrVmInvalidQuotaMultiplier
-	concernedErrorMap[ErrVmPoWNotSupported.Error()] = ErrVmPoWNotSupported
-	concernedErrorMap[ErrVmQuotaLimitReached.Error()] = ErrVmQuotaLimitReached
-	concernedErrorMap[ErrVmInvalidRandomDegree.Error()] = ErrVmInvalidRandomDegree
-
-	concernedErrorMap[ErrVerifyAccountAddr.Error()] = ErrVerifyAccountAddr
-	concernedErrorMap[ErrVerifyHash.Error()] = ErrVerifyHash
-	concernedErrorMap[ErrVerifySignature.Error()] = ErrVerifySignature
-	concernedErrorMap[ErrVerifyNonce.Error()] = ErrVerifyNonce
-	concernedErrorMap[ErrVerifyPrevBlock.Error()] = ErrVerifyPrevBlock
-	concernedErrorMap[ErrVerifyRPCBlockIsPending.Error()] = ErrVerifyRPCBlockIsPending
-	concernedErrorMap[ErrVerifyDependentSendBlockNotExists.Error()] = ErrVerifyDependentSendBlockNotExists
-	concernedErrorMap[ErrVerifyPowQualificationNotEnough.Error()] = ErrVerifyPowQualificationNotEnough
-	concernedErrorMap[ErrVerifyProducerIllegal.Error()] = ErrVerifyProducerIllegal
-	concernedErrorMap[ErrVerifyBlockFieldData.Error()] = ErrVerifyBlockFieldData
-	concernedErrorMap[ErrVerifyIsAlreadyReceived.Error()] = ErrVerifyIsAlreadyReceived
-	concernedErrorMap[ErrVerifyVmResultInconsistent.Error()] = ErrVerifyVmResultInconsistent
-
-	concernedErrorMap[ErrComposeOrderIdFail.Error()] = ErrComposeOrderIdFail
-	concernedErrorMap[ErrDexInvalidOrderType.Error()] = ErrDexInvalidOrderType
-	concernedErrorMap[ErrDexInvalidOrderPrice.Error()] = ErrDexInvalidOrderPrice
-	concernedErrorMap[ErrDexInvalidOrderQuantity.Error()] = ErrDexInvalidOrderQuantity
-	concernedErrorMap[ErrDexOrderAmountTooSmall.Error()] = ErrDexOrderAmountTooSmall
-	concernedErrorMap[ErrDexTradeMarketExists.Error()] = ErrDexTradeMarketExists
-	concernedErrorMap[ErrDexTradeMarketNotExists.Error()] = ErrDexTradeMarketNotExists
-	concernedErrorMap[ErrDexTradeOrderNotExistsErr.Error()] = ErrDexTradeOrderNotExistsErr
-	concernedErrorMap[ErrDexCancelOrderOwnerInvalid.Error()] = ErrDexCancelOrderOwnerInvalid
-	concernedErrorMap[ErrDexCancelOrderInvalidStatus.Error()] = ErrDexCancelOrderInvalidStatus
-	concernedErrorMap[ErrDexTradeMarketInvalidQuoteToken.Error()] = ErrDexTradeMarketInvalidQuoteToken
-	concernedErrorMap[ErrDexTradeMarketInvalidTokenPair.Error()] = ErrDexTradeMarketInvalidTokenPair
-	concernedErrorMap[ErrDexFundUserNotExists.Error()] = ErrDexFundUserNotExists
+	for _, e := range []JsonRpc2Error{
+		ErrConvertBigInt,
+		ErrInaccurateNodeTime,
+
+		ErrDecryptKey,
+		ErrWalletLocked,
+		ErrWalletAddressNotFound,
+		ErrWalletInvalidPrikey,
+		ErrWalletEmptyStore,
+		ErrWalletStoreNotFound,
 
+		ErrBalanceNotEnough,
+		ErrQuotaNotEnough,
+		ErrVmIdCollision,
+		ErrVmInvaildBlockData,
+		ErrVmCalPoWTwice,
+		ErrVmMethodNotFound,
+		ErrVmInvalidResponseLatency,
+		ErrVmContractNotExists,
+		ErrVmNoReliableStatus,
+		ErrVmInvalidQuotaMultiplier,
+		ErrVmPoWNotSupported,
+		ErrVmQuotaLimitReached,
+		ErrVmInvalidRandomDegree,
+		ErrVmRewardIsNotDrained,
+		ErrVmAddressNotMatch,
+		ErrVmTransactionTypeNotSupport,
+		ErrVmVersionNotSupport,
+		ErrVmBlockTypeNotSupported,
+		ErrVmDataNotExist,
+		ErrVmAddressCollision,
+		ErrVmRewardNotDue,
+		ErrVmExecutionReverted,
+		ErrVmDepth,
+		ErrVmGasUintOverflow,
+		ErrVmStorageModifyLimitReached,
+		ErrVmMemSizeOverflow,
+		ErrVmReturnDataOutOfBounds,
+		ErrVmAccountQuotaLimitReached,
+		ErrVmInvalidCodeLength,
+		ErrVmInvalidUnconfirmedQuota,
+		ErrVmStackLimitReached,
+		ErrVmStackUnderflow,
+		ErrVmInvalidJumpDestination,
+		ErrVmInvalidOpCode,
+		ErrVmChainForked,
+		ErrVmContractCreationFail,
+		ErrVmExecutionCanceled,
+
+		ErrVerifyAccountAddr,
+		ErrVerifyHash,
+		ErrVerifySignature,
+		ErrVerifyNonce,
+		ErrVerifyPrevBlock,
+		ErrVerifyRPCBlockIsPending,
+		ErrVerifyDependentSendBlockNotExists,
+		ErrVerifyPowQualificationNotEnough,
+		ErrVerifyProducerIllegal,
+		ErrVerifyBlockFieldData,
+		ErrVerifyIsAlreadyReceived,
+		ErrVerifyVmResultInconsistent,
+		ErrVerifyVmGeneratorFailed,
+		ErrVerifyContractMetaNotExists,
+		ErrVerifyConfirmedTimesNotEnough,
+		ErrVerifySeedConfirmedTimesNotEnough,
+		ErrVerifyContractReceiveSequence,
+
+		ErrComposeOrderIdFail,
+		ErrDexInvalidOrderType,
+		ErrDexInvalidOrderPrice,
+		ErrDexInvalidOrderQuantity,
+		ErrDexOrderAmountTooSmall,
+		ErrDexTradeMarketExists,
+		ErrDexTradeMarketNotExists,
+		ErrDexTradeOrderNotExistsErr,
+		ErrDexCancelOrderOwnerInvalid,
+		ErrDexCancelOrderInvalidStatus,
+		ErrDexTradeMarketInvalidQuoteToken,
+		ErrDexTradeMarketInvalidTokenPair,
+		ErrDexFundUserNotExists,
+
+		ErrPoolQuotaNotEnough,
+		ErrPoolBlackList,
+
+		ErrOnroadPoolNotAvailable,
+		ErrOnroadCallerFrontCheckFailed,
+	} {
+		concernedErrorMap[e.Error()] = e
+	}
 }
 
+// detailedError is an error with the details of the occurrence, like verifier.VerifierError
+type detailedError interface {
+	Detail() string
+}
+
+// TryMakeConcernedError converts err to the JsonRpc2Error of the same message, or of the message prefix
+// followed by the details. The errors which are JsonRpc2Error already are returned as they are.
 func TryMakeConcernedError(err error) (newerr error, concerned bool) {
 	if err == nil {
 		return nil, false
 	}
+	if rerr, ok := err.(JsonRpc2Error); ok {
+		return rerr, true
+	}
 	rerr, ok := concernedErrorMap[err.Error()]
 	if ok {
+		if d, isDetailed := err.(detailedError); isDetailed {
+			rerr.Detail = d.Detail()
+		}
 		return rerr, ok
 	}
+	for _, prefixErr := range concernedErrorPrefixes {
+		if strings.HasPrefix(err.Error(), prefixErr.Message) {
+			rerr = prefixErr
+			rerr.Detail = err.Error()
+			return rerr, true
+		}
+	}
 	return err, false
 
 }
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+type testDetailedError struct {
+	err    string
+	detail string
+}
+
+func (e *testDetailedError) Error() string  { return e.err }
+func (e *testDetailedError) Detail() string { return e.detail }
+
+func TestTryMakeConcernedError(t *testing.T) {
+	e, concerned := TryMakeConcernedError(util.ErrInsufficientBalance)
+	if !concerned || e.(JsonRpc2Error).Code != -35001 || e.(JsonRpc2Error).Reason != "VM_INSUFFICIENT_BALANCE" {
+		t.Fatalf("unexpected error %v", e)
+	}
+
+	// the detail of the occurrence is kept in the data
+	e, _ = TryMakeConcernedError(&testDetailedError{util.ErrOutOfQuota.Error(), "quota 0"})
+	if data := e.(JsonRpc2Error).ErrorData().(JsonRpc2ErrorData); data.Reason != "VM_OUT_OF_QUOTA" || data.Detail != "quota 0" {
+		t.Fatalf("unexpected error data %v", data)
+	}
+
+	// the message is followed by the details
+	msg := fmt.Sprintf("%s[%d-%s][%s]", pool.ErrAccountHeadNotMatch, 2, "hash", "tail")
+	e, concerned = TryMakeConcernedError(errors.New(msg))
+	if !concerned || e.(JsonRpc2Error).Code != -38003 || e.(JsonRpc2Error).Detail != msg {
+		t.Fatalf("unexpected error %v", e)
+	}
+
+	e, concerned = TryMakeConcernedError(ErrInvalidParam.with("ToAddress is invalid"))
+	if !concerned || e.Error() != "ToAddress is invalid" || e.(JsonRpc2Error).Code != -33001 {
+		t.Fatalf("unexpected error %v", e)
+	}
+
+	if _, concerned = TryMakeConcernedError(errors.New("unknown")); concerned {
+		t.Fatalf("unknown error should not be concerned")
+	}
+}
+
+func TestConcernedErrorCodeUnique(t *testing.T) {
+	codes := make(map[int]string)
+	for _, e := range concernedErrorMap {
+		if other, ok := codes[e.Code]; ok {
+			t.Fatalf("code %d is shared by %s and %s", e.Code, other, e.Reason)
+		}
+		codes[e.Code] = e.Reason
+	}
+}
@@ -14,13 +14,15 @@ type AccountChainEvent struct {
 	Height        uint64
 	Addr          types.Address
 	ToAddr        types.Address
+	TokenId       types.TokenTypeId
 	Logs          []*ledger.VmLog
 	SendBlockList []*SendBlock
 }
 
 type SendBlock struct {
-	Hash   types.Hash
-	ToAddr types.Address
+	Hash    types.Hash
+	ToAddr  types.Address
+	TokenId types.TokenTypeId
 }
 
 func NewAccountChainEvent(block *ledger.AccountBlock, logs []*ledger.VmLog) *AccountChainEvent {
@@ -31,11 +33,12 @@ func NewAccountChainEvent(block *ledger.AccountBlock, logs []*ledger.VmLog) *Acc
 		Height:        block.Height,
 		Addr:          block.AccountAddress,
 		ToAddr:        block.ToAddress,
+		TokenId:       block.TokenId,
 		Logs:          logs}
 	if length := len(block.SendBlockList); length > 0 {
 		sendBlockList := make([]*SendBlock, length)
 		for i, s := range block.SendBlockList {
-			sendBlockList[i] = &SendBlock{Hash: s.Hash, ToAddr: s.ToAddress}
+			sendBlockList[i] = &SendBlock{Hash: s.Hash, ToAddr: s.ToAddress, TokenId: s.TokenId}
 		}
 		ace.SendBlockList = sendBlockList
 	}
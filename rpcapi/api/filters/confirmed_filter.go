@@ -0,0 +1,261 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// the max count of addresses of a confirmed filter
+const maxConfirmedFilterAddressCount = 100000
+
+type ConfirmedFilterParam struct {
+	AddressList []types.Address `json:"addressList"`
+
+	// TokenIdList filters the blocks by token, all tokens are matched if it is empty
+	TokenIdList []types.TokenTypeId `json:"tokenIdList"`
+
+	// MinConfirmations is the snapshot confirmed times which the blocks are held until
+	MinConfirmations uint64 `json:"minConfirmations"`
+}
+
+type ConfirmedAccountBlock struct {
+	Hash          types.Hash         `json:"hash"`
+	Height        string             `json:"height"`
+	Address       types.Address      `json:"address"`
+	BlockType     byte               `json:"blockType"`
+	TokenId       *types.TokenTypeId `json:"tokenId"`
+	Confirmations string             `json:"confirmations"`
+	Removed       bool               `json:"removed"`
+}
+
+type ConfirmedOnroadMsg struct {
+	Hash          types.Hash         `json:"hash"`
+	Address       types.Address      `json:"address"`
+	TokenId       *types.TokenTypeId `json:"tokenId"`
+	Received      bool               `json:"received"`
+	Confirmations string             `json:"confirmations"`
+	Removed       bool               `json:"removed"`
+}
+
+// confirmedChain is the part of the chain which the confirmed filters query
+type confirmedChain interface {
+	GetConfirmedTimes(blockHash types.Hash) (uint64, error)
+	GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+}
+
+// confirmedMsg is a message held until the block of blockHash reaches the confirmations
+type confirmedMsg struct {
+	blockHash    types.Hash
+	address      types.Address
+	accountBlock *ConfirmedAccountBlock
+	onroadMsg    *ConfirmedOnroadMsg
+}
+
+func (m *confirmedMsg) setConfirmations(confirmations uint64) {
+	if m.accountBlock != nil {
+		m.accountBlock.Confirmations = api.Uint64ToString(confirmations)
+	} else {
+		m.onroadMsg.Confirmations = api.Uint64ToString(confirmations)
+	}
+}
+
+// confirmedFilter matches the account blocks or the onroad messages of a set of addresses, and holds
+// them until the blocks are snapshot confirmed minConfirmations times. It is only accessed in the event loop.
+type confirmedFilter struct {
+	typ              FilterType
+	addrSet          map[types.Address]struct{}
+	tokenIdSet       map[types.TokenTypeId]struct{}
+	minConfirmations uint64
+	pending          []*confirmedMsg
+}
+
+func newConfirmedFilter(typ FilterType, param ConfirmedFilterParam) (*confirmedFilter, error) {
+	if len(param.AddressList) == 0 {
+		return nil, errors.New("addressList is empty")
+	}
+	f := &confirmedFilter{
+		typ:              typ,
+		addrSet:          make(map[types.Address]struct{}),
+		minConfirmations: param.MinConfirmations,
+	}
+	if err := f.addAddresses(param.AddressList); err != nil {
+		return nil, err
+	}
+	if len(param.TokenIdList) > 0 {
+		f.tokenIdSet = make(map[types.TokenTypeId]struct{}, len(param.TokenIdList))
+		for _, tokenId := range param.TokenIdList {
+			f.tokenIdSet[tokenId] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+func (f *confirmedFilter) addAddresses(addrList []types.Address) error {
+	for _, addr := range addrList {
+		f.addrSet[addr] = struct{}{}
+	}
+	if len(f.addrSet) > maxConfirmedFilterAddressCount {
+		for _, addr := range addrList {
+			delete(f.addrSet, addr)
+		}
+		return errors.New(fmt.Sprintf("address count exceeds %d", maxConfirmedFilterAddressCount))
+	}
+	return nil
+}
+
+// removeAddresses removes the addresses and drops their held messages
+func (f *confirmedFilter) removeAddresses(addrList []types.Address) {
+	for _, addr := range addrList {
+		delete(f.addrSet, addr)
+	}
+	pending := f.pending[:0]
+	for _, m := range f.pending {
+		if _, ok := f.addrSet[m.address]; ok {
+			pending = append(pending, m)
+		}
+	}
+	f.pending = pending
+}
+
+func (f *confirmedFilter) matchAddress(addr types.Address) bool {
+	_, ok := f.addrSet[addr]
+	return ok
+}
+
+func (f *confirmedFilter) matchToken(tokenId *types.TokenTypeId) bool {
+	if f.tokenIdSet == nil {
+		return true
+	}
+	if tokenId == nil {
+		return false
+	}
+	_, ok := f.tokenIdSet[*tokenId]
+	return ok
+}
+
+// receivedTokenId returns the token of the send block which a receive block receives
+func receivedTokenId(c confirmedChain, e *AccountChainEvent) *types.TokenTypeId {
+	sendBlock, err := c.GetAccountBlockByHash(e.FromBlockHash)
+	if err != nil || sendBlock == nil {
+		return nil
+	}
+	return &sendBlock.TokenId
+}
+
+func (f *confirmedFilter) newMsgs(c confirmedChain, e *AccountChainEvent, removed bool) []*confirmedMsg {
+	var msgs []*confirmedMsg
+	if f.typ == ConfirmedAccountBlocksSubscription {
+		if !f.matchAddress(e.Addr) {
+			return nil
+		}
+		var tokenId *types.TokenTypeId
+		if ledger.IsSendBlock(e.BlockType) {
+			tokenId = &e.TokenId
+		} else if f.tokenIdSet != nil {
+			tokenId = receivedTokenId(c, e)
+		}
+		if !f.matchToken(tokenId) {
+			return nil
+		}
+		block := &ConfirmedAccountBlock{Hash: e.Hash, Height: api.Uint64ToString(e.Height), Address: e.Addr, BlockType: e.BlockType, TokenId: tokenId, Removed: removed}
+		return append(msgs, &confirmedMsg{blockHash: e.Hash, address: e.Addr, accountBlock: block})
+	}
+
+	appendOnroad := func(addr types.Address, hash types.Hash, tokenId *types.TokenTypeId, received bool) {
+		if !f.matchAddress(addr) || !f.matchToken(tokenId) {
+			return
+		}
+		msg := &ConfirmedOnroadMsg{Hash: hash, Address: addr, TokenId: tokenId, Received: received, Removed: removed}
+		msgs = append(msgs, &confirmedMsg{blockHash: e.Hash, address: addr, onroadMsg: msg})
+	}
+	if ledger.IsSendBlock(e.BlockType) {
+		tokenId := e.TokenId
+		appendOnroad(e.ToAddr, e.Hash, &tokenId, false)
+		return msgs
+	}
+	if f.matchAddress(e.Addr) {
+		appendOnroad(e.Addr, e.FromBlockHash, receivedTokenId(c, e), true)
+	}
+	for _, sendBlock := range e.SendBlockList {
+		tokenId := sendBlock.TokenId
+		appendOnroad(sendBlock.ToAddr, sendBlock.Hash, &tokenId, false)
+	}
+	return msgs
+}
+
+// insert holds the messages of the inserted blocks, the messages are returned at once if no confirmation is required
+func (f *confirmedFilter) insert(c confirmedChain, events []*AccountChainEvent) []*confirmedMsg {
+	var ready []*confirmedMsg
+	for _, e := range events {
+		msgs := f.newMsgs(c, e, false)
+		if f.minConfirmations == 0 {
+			for _, m := range msgs {
+				m.setConfirmations(0)
+			}
+			ready = append(ready, msgs...)
+		} else {
+			f.pending = append(f.pending, msgs...)
+		}
+	}
+	return ready
+}
+
+// confirm returns the held messages which reach the confirmations, confirmedTimes caches the confirmed times by block hash
+func (f *confirmedFilter) confirm(c confirmedChain, confirmedTimes map[types.Hash]uint64) ([]*confirmedMsg, error) {
+	var ready []*confirmedMsg
+	pending := f.pending[:0]
+	for i, m := range f.pending {
+		times, ok := confirmedTimes[m.blockHash]
+		if !ok {
+			var err error
+			if times, err = c.GetConfirmedTimes(m.blockHash); err != nil {
+				f.pending = append(pending, f.pending[i:]...)
+				return ready, err
+			}
+			confirmedTimes[m.blockHash] = times
+		}
+		if times >= f.minConfirmations {
+			m.setConfirmations(times)
+			ready = append(ready, m)
+		} else {
+			pending = append(pending, m)
+		}
+	}
+	f.pending = pending
+	return ready, nil
+}
+
+// delete drops the held messages of the deleted blocks, and returns the removed messages which have been delivered
+func (f *confirmedFilter) delete(c confirmedChain, events []*AccountChainEvent) []*confirmedMsg {
+	deleted := make(map[types.Hash]struct{}, len(events))
+	for _, e := range events {
+		deleted[e.Hash] = struct{}{}
+	}
+	held := make(map[types.Hash]struct{})
+	pending := f.pending[:0]
+	for _, m := range f.pending {
+		if _, ok := deleted[m.blockHash]; ok {
+			held[m.blockHash] = struct{}{}
+		} else {
+			pending = append(pending, m)
+		}
+	}
+	f.pending = pending
+
+	var removed []*confirmedMsg
+	for _, e := range events {
+		if _, ok := held[e.Hash]; ok {
+			continue
+		}
+		msgs := f.newMsgs(c, e, true)
+		for _, m := range msgs {
+			m.setConfirmations(0)
+		}
+		removed = append(removed, msgs...)
+	}
+	return removed
+}
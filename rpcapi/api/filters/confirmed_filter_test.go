@@ -0,0 +1,115 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockConfirmedChain struct {
+	confirmedTimes map[types.Hash]uint64
+	blocks         map[types.Hash]*ledger.AccountBlock
+}
+
+func (c *mockConfirmedChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return c.confirmedTimes[blockHash], nil
+}
+
+func (c *mockConfirmedChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[blockHash], nil
+}
+
+func newTestAddress(t *testing.T) types.Address {
+	addr, _, err := types.CreateAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func TestConfirmedFilterAccountBlocks(t *testing.T) {
+	watched, other := newTestAddress(t), newTestAddress(t)
+	c := &mockConfirmedChain{confirmedTimes: make(map[types.Hash]uint64), blocks: make(map[types.Hash]*ledger.AccountBlock)}
+
+	f, err := newConfirmedFilter(ConfirmedAccountBlocksSubscription, ConfirmedFilterParam{
+		AddressList:      []types.Address{watched},
+		TokenIdList:      []types.TokenTypeId{ledger.ViteTokenId},
+		MinConfirmations: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendBlock := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, Hash: types.DataHash([]byte("send")), TokenId: ledger.ViteTokenId}
+	c.blocks[sendBlock.Hash] = sendBlock
+	events := []*AccountChainEvent{
+		{BlockType: ledger.BlockTypeReceive, Hash: types.DataHash([]byte("receive")), Height: 1, Addr: watched, FromBlockHash: sendBlock.Hash},
+		{BlockType: ledger.BlockTypeSendCall, Hash: types.DataHash([]byte("other token")), Height: 2, Addr: watched, TokenId: types.CreateTokenTypeId([]byte("other"))},
+		{BlockType: ledger.BlockTypeSendCall, Hash: types.DataHash([]byte("other address")), Height: 1, Addr: other, TokenId: ledger.ViteTokenId},
+	}
+	if ready := f.insert(c, events); len(ready) != 0 {
+		t.Fatalf("blocks should be held until confirmed")
+	}
+	if len(f.pending) != 1 {
+		t.Fatalf("pending count is %d, expected 1", len(f.pending))
+	}
+
+	c.confirmedTimes[events[0].Hash] = 1
+	if ready, _ := f.confirm(c, make(map[types.Hash]uint64)); len(ready) != 0 {
+		t.Fatalf("block confirmed once should be held")
+	}
+	c.confirmedTimes[events[0].Hash] = 2
+	ready, err := f.confirm(c, make(map[types.Hash]uint64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ready) != 1 || ready[0].accountBlock.Hash != events[0].Hash || ready[0].accountBlock.Confirmations != "2" ||
+		*ready[0].accountBlock.TokenId != ledger.ViteTokenId {
+		t.Fatalf("unexpected ready blocks %v", ready)
+	}
+
+	// the delivered block is removed by a rollback
+	removed := f.delete(c, events[:1])
+	if len(removed) != 1 || !removed[0].accountBlock.Removed {
+		t.Fatalf("delivered block should be removed")
+	}
+}
+
+func TestConfirmedFilterDeleteHeldAndUpdate(t *testing.T) {
+	addr1, addr2 := newTestAddress(t), newTestAddress(t)
+	c := &mockConfirmedChain{confirmedTimes: make(map[types.Hash]uint64)}
+
+	f, err := newConfirmedFilter(ConfirmedOnroadBlocksSubscription, ConfirmedFilterParam{
+		AddressList:      []types.Address{addr1},
+		MinConfirmations: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.addAddresses([]types.Address{addr2}); err != nil {
+		t.Fatal(err)
+	}
+
+	send1 := &AccountChainEvent{BlockType: ledger.BlockTypeSendCall, Hash: types.DataHash([]byte("1")), ToAddr: addr1}
+	send2 := &AccountChainEvent{BlockType: ledger.BlockTypeSendCall, Hash: types.DataHash([]byte("2")), ToAddr: addr2}
+	f.insert(c, []*AccountChainEvent{send1, send2})
+	if len(f.pending) != 2 {
+		t.Fatalf("pending count is %d, expected 2", len(f.pending))
+	}
+
+	// the held message of a rolled back block is dropped without a removed message
+	if removed := f.delete(c, []*AccountChainEvent{send1}); len(removed) != 0 {
+		t.Fatalf("held message should be dropped silently")
+	}
+
+	// the held message of a removed address is dropped
+	f.removeAddresses([]types.Address{addr2})
+	if len(f.pending) != 0 {
+		t.Fatalf("pending count is %d, expected 0", len(f.pending))
+	}
+
+	if _, err := newConfirmedFilter(ConfirmedOnroadBlocksSubscription, ConfirmedFilterParam{}); err == nil {
+		t.Fatalf("empty address list should be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
@@ -25,6 +26,9 @@ const (
 	OnroadBlocksSubscriptionV2
 	SnapshotBlocksSubscription
 	SnapshotBlocksSubscriptionV2
+	ConfirmedAccountBlocksSubscription
+	ConfirmedOnroadBlocksSubscription
+	TxStatusSubscription
 )
 
 type subscription struct {
@@ -40,6 +44,18 @@ type subscription struct {
 	accountBlockWithHeightCh chan []*AccountBlockWithHeight
 	logsCh                   chan []*Logs
 	onroadMsgCh              chan []*OnroadMsg
+	confirmed                *confirmedFilter
+	confirmedAccountBlockCh  chan []*ConfirmedAccountBlock
+	confirmedOnroadMsgCh     chan []*ConfirmedOnroadMsg
+	txStatus                 *txStatusFilter
+	txStatusCh               chan []*api.TransactionStatus
+}
+
+type addressUpdate struct {
+	sub        *subscription
+	addList    []types.Address
+	removeList []types.Address
+	err        chan error
 }
 
 type EventSystem struct {
@@ -47,6 +63,7 @@ type EventSystem struct {
 	chain     *ChainSubscribe
 	install   chan *subscription        // install filter
 	uninstall chan *subscription        // remove filter
+	update    chan *addressUpdate       // update the addresses of a confirmed filter
 	acCh      chan []*AccountChainEvent // Channel to receive new account chain event
 	acDelCh   chan []*AccountChainEvent // Channel to receive new account chain delete event when account chain fork
 	sbCh      chan []*SnapshotChainEvent
@@ -62,6 +79,7 @@ const (
 	sbDelChanSize = 10
 	installSize   = 10
 	uninstallSize = 10
+	updateSize    = 10
 )
 
 func NewEventSystem(v *vite.Vite) *EventSystem {
@@ -73,6 +91,7 @@ func NewEventSystem(v *vite.Vite) *EventSystem {
 		sbDelCh:   make(chan []*SnapshotChainEvent, sbDelChanSize),
 		install:   make(chan *subscription, installSize),
 		uninstall: make(chan *subscription, uninstallSize),
+		update:    make(chan *addressUpdate, updateSize),
 		stop:      make(chan struct{}),
 		log:       log15.New("module", "rpc_api/event_system"),
 	}
@@ -92,7 +111,7 @@ func (es *EventSystem) Stop() {
 func (es *EventSystem) eventLoop() {
 	es.log.Info("start event loop")
 	index := make(map[FilterType]map[rpc.ID]*subscription)
-	for i := LogsSubscription; i <= SnapshotBlocksSubscriptionV2; i++ {
+	for i := LogsSubscription; i <= TxStatusSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
 	}
 
@@ -110,10 +129,25 @@ func (es *EventSystem) eventLoop() {
 			es.log.Info("install ", "id", i.id)
 			index[i.typ][i.id] = i
 			close(i.installed)
+			// report the current statuses once the subscription is created
+			if i.txStatus != nil {
+				es.sendTxStatus(i, nil)
+			}
 		case u := <-es.uninstall:
 			es.log.Info("uninstall ", "id", u.id)
 			delete(index[u.typ], u.id)
 			close(u.err)
+		case u := <-es.update:
+			if _, ok := index[u.sub.typ][u.sub.id]; !ok || u.sub.confirmed == nil {
+				u.err <- errors.New("confirmed filter not found")
+				continue
+			}
+			if err := u.sub.confirmed.addAddresses(u.addList); err != nil {
+				u.err <- err
+				continue
+			}
+			u.sub.confirmed.removeAddresses(u.removeList)
+			u.err <- nil
 
 		// system stopped
 		case <-es.stop:
@@ -143,6 +177,59 @@ func (es *EventSystem) handleSbEvent(filters map[FilterType]map[rpc.ID]*subscrip
 	for _, f := range filters[SnapshotBlocksSubscriptionV2] {
 		f.snapshotBlockCh <- blocks
 	}
+	// the confirmed times of all the blocks change with the snapshot chain
+	for _, f := range filters[TxStatusSubscription] {
+		es.sendTxStatus(f, nil)
+	}
+
+	// a confirmed times decreases on rollback, the held messages are checked on the next insert
+	if removed {
+		return
+	}
+	confirmedTimes := make(map[types.Hash]uint64)
+	for _, f := range filters[ConfirmedAccountBlocksSubscription] {
+		msgs, err := f.confirmed.confirm(es.vite.Chain(), confirmedTimes)
+		if err != nil {
+			es.log.Error("get confirmed times failed, error is "+err.Error(), "method", "handleSbEvent")
+		}
+		es.sendConfirmedMsgs(f, msgs)
+	}
+	for _, f := range filters[ConfirmedOnroadBlocksSubscription] {
+		msgs, err := f.confirmed.confirm(es.vite.Chain(), confirmedTimes)
+		if err != nil {
+			es.log.Error("get confirmed times failed, error is "+err.Error(), "method", "handleSbEvent")
+		}
+		es.sendConfirmedMsgs(f, msgs)
+	}
+}
+
+func (es *EventSystem) sendConfirmedMsgs(f *subscription, msgs []*confirmedMsg) {
+	if len(msgs) == 0 {
+		return
+	}
+	if f.typ == ConfirmedAccountBlocksSubscription {
+		blocks := make([]*ConfirmedAccountBlock, len(msgs))
+		for i, m := range msgs {
+			blocks[i] = m.accountBlock
+		}
+		f.confirmedAccountBlockCh <- blocks
+	} else {
+		onroadMsgs := make([]*ConfirmedOnroadMsg, len(msgs))
+		for i, m := range msgs {
+			onroadMsgs[i] = m.onroadMsg
+		}
+		f.confirmedOnroadMsgCh <- onroadMsgs
+	}
+}
+
+func (es *EventSystem) sendTxStatus(f *subscription, relevant map[types.Hash]struct{}) {
+	statuses, err := f.txStatus.update(api.TxTracker.GetTransactionStatus, relevant)
+	if err != nil {
+		es.log.Error("get transaction status failed, error is "+err.Error(), "method", "sendTxStatus")
+	}
+	if len(statuses) > 0 {
+		f.txStatusCh <- statuses
+	}
 }
 
 func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscription, acEvent []*AccountChainEvent, removed bool) {
@@ -223,6 +310,23 @@ func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscrip
 			f.onroadMsgCh <- onroadMsgs
 		}
 	}
+	// handle confirmed account blocks and onroad blocks
+	for _, typ := range []FilterType{ConfirmedAccountBlocksSubscription, ConfirmedOnroadBlocksSubscription} {
+		for _, f := range filters[typ] {
+			if removed {
+				es.sendConfirmedMsgs(f, f.confirmed.delete(es.vite.Chain(), acEvent))
+			} else {
+				es.sendConfirmedMsgs(f, f.confirmed.insert(es.vite.Chain(), acEvent))
+			}
+		}
+	}
+	// handle transaction statuses
+	if len(filters[TxStatusSubscription]) > 0 {
+		relevant := txStatusRelevantHashes(acEvent)
+		for _, f := range filters[TxStatusSubscription] {
+			es.sendTxStatus(f, relevant)
+		}
+	}
 	// handle logs
 	for _, f := range filters[LogsSubscription] {
 		var logs []*Logs
@@ -270,7 +374,7 @@ func filterLogs(e *AccountChainEvent, filter *api.FilterParam, removed bool) []*
 	}
 	for _, l := range e.Logs {
 		if api.FilterLog(filter, l) {
-			logs = append(logs, &Logs{l, e.Hash, api.Uint64ToString(e.Height), &e.Addr, removed})
+			logs = append(logs, &Logs{Log: l, AccountBlockHash: e.Hash, AccountHeight: api.Uint64ToString(e.Height), Addr: &e.Addr, Removed: removed})
 		}
 	}
 	return logs
@@ -299,6 +403,9 @@ func (s *RpcSubscription) Unsubscribe() {
 			case <-s.sub.logsCh:
 			case <-s.sub.snapshotBlockCh:
 			case <-s.sub.onroadMsgCh:
+			case <-s.sub.confirmedAccountBlockCh:
+			case <-s.sub.confirmedOnroadMsgCh:
+			case <-s.sub.txStatusCh:
 			}
 		}
 		<-s.Err()
@@ -317,6 +424,9 @@ func (es *EventSystem) SubscribeAccountBlocks(ch chan []*AccountBlock) *RpcSubsc
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
 	}
 	return es.subscribe(sub)
 }
@@ -334,6 +444,9 @@ func (es *EventSystem) SubscribeAccountBlocksByAddr(addr types.Address, ch chan
 		accountBlockWithHeightCh: ch,
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
 	}
 	return es.subscribe(sub)
 }
@@ -351,6 +464,9 @@ func (es *EventSystem) SubscribeOnroadBlocksByAddr(addr types.Address, ch chan [
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              ch,
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
 	}
 	return es.subscribe(sub)
 }
@@ -367,6 +483,9 @@ func (es *EventSystem) SubscribeSnapshotBlocks(ch chan []*SnapshotBlock, ft Filt
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
 	}
 	return es.subscribe(sub)
 }
@@ -384,10 +503,84 @@ func (es *EventSystem) SubscribeLogs(p *api.FilterParam, ch chan []*Logs, ft Fil
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   ch,
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
+	}
+	return es.subscribe(sub)
+}
+
+func (es *EventSystem) SubscribeConfirmedAccountBlocks(f *confirmedFilter, ch chan []*ConfirmedAccountBlock) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      ConfirmedAccountBlocksSubscription,
+		confirmed:                f,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  ch,
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               make(chan []*api.TransactionStatus),
+	}
+	return es.subscribe(sub)
+}
+
+func (es *EventSystem) SubscribeConfirmedOnroadBlocks(f *confirmedFilter, ch chan []*ConfirmedOnroadMsg) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      ConfirmedOnroadBlocksSubscription,
+		confirmed:                f,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     ch,
+		txStatusCh:               make(chan []*api.TransactionStatus),
 	}
 	return es.subscribe(sub)
 }
 
+func (es *EventSystem) SubscribeTxStatus(f *txStatusFilter, ch chan []*api.TransactionStatus) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      TxStatusSubscription,
+		txStatus:                 f,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmedAccountBlockCh:  make(chan []*ConfirmedAccountBlock),
+		confirmedOnroadMsgCh:     make(chan []*ConfirmedOnroadMsg),
+		txStatusCh:               ch,
+	}
+	return es.subscribe(sub)
+}
+
+// UpdateAddresses adds and removes the addresses of a confirmed subscription
+func (s *RpcSubscription) UpdateAddresses(addList, removeList []types.Address) error {
+	u := &addressUpdate{sub: s.sub, addList: addList, removeList: removeList, err: make(chan error, 1)}
+	select {
+	case s.es.update <- u:
+	case <-s.sub.err:
+		return errors.New("subscription is closed")
+	}
+	return <-u.err
+}
+
 func (es *EventSystem) subscribe(s *subscription) *RpcSubscription {
 	es.install <- s
 	<-s.installed
@@ -0,0 +1,243 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// the count of snapshot blocks read from the chain at once during a replay
+const replayPageSize = 100
+
+// replayBatch is a snapshot block with the account blocks it confirms, snapshotBlock is nil for the unconfirmed blocks
+type replayBatch struct {
+	snapshotBlock *SnapshotChainEvent
+	blocks        []*AccountChainEvent
+}
+
+// replayer reads the historical events from fromSnapshotHeight to the head of the chain, for a subscription which
+// resumes from a snapshot height. liveHeight is recorded before the live subscription is installed, so the blocks
+// confirmed after liveHeight or unconfirmed may be delivered by both of them, they are recorded in seen to drop the
+// duplicates. The live events of the blocks confirmed at or below liveHeight may still be queued in the event system
+// when the live subscription is installed, they are dropped by dropStale* before the buffered events are delivered.
+type replayer struct {
+	chain      chain.Chain
+	from       uint64
+	liveHeight uint64
+	withLogs   bool
+	seen       map[types.Hash]struct{}
+
+	out  chan *replayBatch
+	done chan error
+	stop chan struct{}
+	log  log15.Logger
+}
+
+// newReplayer returns nil if fromSnapshotHeight is not set
+func newReplayer(c chain.Chain, fromSnapshotHeight *string, withLogs bool, log log15.Logger) (*replayer, error) {
+	if fromSnapshotHeight == nil {
+		return nil, nil
+	}
+	from, err := api.StringToUint64(*fromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		return nil, errors.New("fromSnapshotHeight should be larger than 0")
+	}
+	latest := c.GetLatestSnapshotBlock().Height
+	if from > latest+1 {
+		return nil, errors.New(fmt.Sprintf("fromSnapshotHeight %d is higher than the latest snapshot height %d", from, latest))
+	}
+	return &replayer{
+		chain:      c,
+		from:       from,
+		liveHeight: latest,
+		withLogs:   withLogs,
+		seen:       make(map[types.Hash]struct{}),
+		out:        make(chan *replayBatch),
+		done:       make(chan error, 1),
+		stop:       make(chan struct{}),
+		log:        log,
+	}, nil
+}
+
+// start replays in the background, it is called after the live subscription is installed.
+// The batches are received from out, and the result is received from done after the last batch.
+func (r *replayer) start() (<-chan *replayBatch, <-chan error) {
+	go func() {
+		r.done <- r.run()
+	}()
+	return r.out, r.done
+}
+
+// isReplayed reports whether a live event of the block is a duplicate, it is called after the replay is done
+func (r *replayer) isReplayed(hash types.Hash) bool {
+	_, ok := r.seen[hash]
+	return ok
+}
+
+// isStale reports whether a live event of the block received during the replay is confirmed at or below liveHeight
+func (r *replayer) isStale(hash types.Hash) bool {
+	sb, err := r.chain.GetConfirmSnapshotHeaderByAbHash(hash)
+	if err != nil {
+		r.log.Error("get confirm snapshot block failed, error is "+err.Error(), "method", "isStale", "hash", hash)
+		return false
+	}
+	return sb != nil && sb.Height <= r.liveHeight
+}
+
+func (r *replayer) run() error {
+	start := r.from
+	for {
+		latest := r.chain.GetLatestSnapshotBlock().Height
+		for start <= latest {
+			end := start + replayPageSize - 1
+			if end > latest {
+				end = latest
+			}
+			// the account blocks are stored before the snapshot block which confirms them,
+			// so the sub ledger is read from the previous snapshot block
+			chunks, err := r.chain.GetSubLedger(start-1, end)
+			if err != nil {
+				return err
+			}
+			for _, chunk := range chunks {
+				if chunk.SnapshotBlock == nil || chunk.SnapshotBlock.Height < start {
+					continue
+				}
+				batch := &replayBatch{
+					snapshotBlock: &SnapshotChainEvent{Hash: chunk.SnapshotBlock.Hash, Height: chunk.SnapshotBlock.Height},
+					blocks:        r.newEvents(chunk.AccountBlocks),
+				}
+				if chunk.SnapshotBlock.Height > r.liveHeight {
+					r.record(batch)
+				}
+				if !r.send(batch) {
+					return errors.New("replay is stopped")
+				}
+			}
+			start = end + 1
+		}
+
+		// the unconfirmed blocks are consistent with the latest snapshot block if no snapshot block is inserted meanwhile
+		unconfirmed := r.chain.GetAllUnconfirmedBlocks()
+		if r.chain.GetLatestSnapshotBlock().Height != latest {
+			continue
+		}
+		batch := &replayBatch{blocks: r.newEvents(unconfirmed)}
+		r.record(batch)
+		if !r.send(batch) {
+			return errors.New("replay is stopped")
+		}
+		return nil
+	}
+}
+
+func (r *replayer) newEvents(blocks []*ledger.AccountBlock) []*AccountChainEvent {
+	events := make([]*AccountChainEvent, 0, len(blocks))
+	for _, b := range blocks {
+		var logs ledger.VmLogList
+		if r.withLogs && b.LogHash != nil {
+			var err error
+			if logs, err = r.chain.GetVmLogList(b.LogHash); err != nil {
+				r.log.Error("get log list failed when replay", "addr", b.AccountAddress, "hash", b.Hash, "height", b.Height, "err", err)
+			}
+		}
+		events = append(events, NewAccountChainEvent(b, logs))
+	}
+	return events
+}
+
+func (r *replayer) record(batch *replayBatch) {
+	if batch.snapshotBlock != nil {
+		r.seen[batch.snapshotBlock.Hash] = struct{}{}
+	}
+	for _, e := range batch.blocks {
+		r.seen[e.Hash] = struct{}{}
+	}
+}
+
+func (r *replayer) send(batch *replayBatch) bool {
+	select {
+	case r.out <- batch:
+		return true
+	case <-r.stop:
+		return false
+	}
+}
+
+func (r *replayer) close() {
+	close(r.stop)
+}
+
+// filterReplayedSnapshotBlocks drops the live snapshot blocks which have been replayed
+func filterReplayedSnapshotBlocks(r *replayer, blocks []*SnapshotBlock) []*SnapshotBlock {
+	result := make([]*SnapshotBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Removed || !r.isReplayed(b.Hash) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// filterReplayedAccountBlocks drops the live account blocks which have been replayed
+func filterReplayedAccountBlocks(r *replayer, blocks []*AccountBlockWithHeight) []*AccountBlockWithHeight {
+	result := make([]*AccountBlockWithHeight, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Removed || !r.isReplayed(b.Hash) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// filterReplayedLogs drops the live vm logs whose account blocks have been replayed
+func filterReplayedLogs(r *replayer, logs []*Logs) []*Logs {
+	result := make([]*Logs, 0, len(logs))
+	for _, l := range logs {
+		if l.Removed || !r.isReplayed(l.AccountBlockHash) {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// dropStaleSnapshotBlocks drops the buffered live snapshot blocks at or below liveHeight
+func dropStaleSnapshotBlocks(r *replayer, blocks []*SnapshotBlock) []*SnapshotBlock {
+	result := make([]*SnapshotBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Removed || b.Height > r.liveHeight {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// dropStaleAccountBlocks drops the buffered live account blocks confirmed at or below liveHeight
+func dropStaleAccountBlocks(r *replayer, blocks []*AccountBlockWithHeight) []*AccountBlockWithHeight {
+	result := make([]*AccountBlockWithHeight, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Removed || !r.isStale(b.Hash) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// dropStaleLogs drops the buffered live vm logs whose account blocks are confirmed at or below liveHeight
+func dropStaleLogs(r *replayer, logs []*Logs) []*Logs {
+	result := make([]*Logs, 0, len(logs))
+	for _, l := range logs {
+		if l.Removed || !r.isStale(l.AccountBlockHash) {
+			result = append(result, l)
+		}
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+type mockReplayChain struct {
+	chain.Chain
+
+	chunks      []*ledger.SnapshotChunk
+	unconfirmed []*ledger.AccountBlock
+}
+
+func (c *mockReplayChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.chunks[len(c.chunks)-1].SnapshotBlock
+}
+
+func (c *mockReplayChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	var result []*ledger.SnapshotChunk
+	for _, chunk := range c.chunks {
+		if chunk.SnapshotBlock.Height >= startHeight && chunk.SnapshotBlock.Height <= endHeight {
+			result = append(result, chunk)
+		}
+	}
+	return result, nil
+}
+
+func (c *mockReplayChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock {
+	return c.unconfirmed
+}
+
+func (c *mockReplayChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	for _, chunk := range c.chunks {
+		for _, block := range chunk.AccountBlocks {
+			if block.Hash == abHash {
+				return chunk.SnapshotBlock, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func newReplayChunk(height uint64) *ledger.SnapshotChunk {
+	return &ledger.SnapshotChunk{
+		SnapshotBlock: &ledger.SnapshotBlock{Hash: types.DataHash([]byte{byte(height)}), Height: height},
+		AccountBlocks: []*ledger.AccountBlock{{Hash: types.DataHash([]byte{byte(height), 1}), Height: height}},
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	c := &mockReplayChain{}
+	for i := uint64(1); i <= 250; i++ {
+		c.chunks = append(c.chunks, newReplayChunk(i))
+	}
+	c.unconfirmed = []*ledger.AccountBlock{{Hash: types.DataHash([]byte("unconfirmed")), Height: 251}}
+
+	from := "10"
+	r, err := newReplayer(c, &from, false, log15.New("module", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a snapshot block is inserted after the live subscription is installed
+	r.liveHeight = 249
+	go func() {
+		r.done <- r.run()
+	}()
+	replayCh, replayDone := r.out, r.done
+
+	var heights []uint64
+	var unconfirmed []*AccountChainEvent
+	for done := false; !done; {
+		select {
+		case batch := <-replayCh:
+			if batch.snapshotBlock != nil {
+				heights = append(heights, batch.snapshotBlock.Height)
+			} else {
+				unconfirmed = batch.blocks
+			}
+		case err := <-replayDone:
+			if err != nil {
+				t.Fatal(err)
+			}
+			done = true
+		}
+	}
+
+	if len(heights) != 241 || heights[0] != 10 || heights[240] != 250 {
+		t.Fatalf("replay %d snapshot blocks from %d to %d", len(heights), heights[0], heights[len(heights)-1])
+	}
+	if len(unconfirmed) != 1 || !r.isReplayed(unconfirmed[0].Hash) {
+		t.Fatalf("unconfirmed blocks should be replayed and recorded")
+	}
+	if !r.isReplayed(c.chunks[249].SnapshotBlock.Hash) || r.isReplayed(c.chunks[248].SnapshotBlock.Hash) {
+		t.Fatalf("only the blocks after the live height should be recorded")
+	}
+
+	live := []*SnapshotBlock{
+		{Hash: c.chunks[249].SnapshotBlock.Hash, Height: 250},
+		{Hash: c.chunks[249].SnapshotBlock.Hash, Height: 250, Removed: true},
+		{Hash: types.DataHash([]byte("new")), Height: 251},
+	}
+	if result := filterReplayedSnapshotBlocks(r, live); len(result) != 2 || !result[0].Removed || result[1].Height != 251 {
+		t.Fatalf("unexpected live snapshot blocks after replay %v", result)
+	}
+
+	tooHigh := "252"
+	if _, err := newReplayer(c, &tooHigh, false, nil); err == nil {
+		t.Fatalf("fromSnapshotHeight higher than the head should be rejected")
+	}
+}
+
+func TestReplayerDropStale(t *testing.T) {
+	c := &mockReplayChain{}
+	for i := uint64(1); i <= 5; i++ {
+		c.chunks = append(c.chunks, newReplayChunk(i))
+	}
+
+	from := "3"
+	r, err := newReplayer(c, &from, true, log15.New("module", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.liveHeight != 5 {
+		t.Fatalf("live height should be recorded when the replayer is created, but get %d", r.liveHeight)
+	}
+	// a snapshot block is inserted before the live subscription is installed, it is replayed
+	c.chunks = append(c.chunks, newReplayChunk(6))
+
+	// the events of the blocks at or below the live height are queued in the event system
+	snapshotBlocks := []*SnapshotBlock{
+		{Hash: c.chunks[4].SnapshotBlock.Hash, Height: 5},
+		{Hash: c.chunks[3].SnapshotBlock.Hash, Height: 4, Removed: true},
+		{Hash: c.chunks[5].SnapshotBlock.Hash, Height: 6},
+	}
+	if result := dropStaleSnapshotBlocks(r, snapshotBlocks); len(result) != 2 || !result[0].Removed || result[1].Height != 6 {
+		t.Fatalf("unexpected buffered snapshot blocks %v", result)
+	}
+
+	unconfirmed := types.DataHash([]byte("unconfirmed"))
+	accountBlocks := []*AccountBlockWithHeight{
+		{Hash: c.chunks[4].AccountBlocks[0].Hash},
+		{Hash: c.chunks[5].AccountBlocks[0].Hash},
+		{Hash: unconfirmed},
+	}
+	if result := dropStaleAccountBlocks(r, accountBlocks); len(result) != 2 || result[0].Hash != c.chunks[5].AccountBlocks[0].Hash || result[1].Hash != unconfirmed {
+		t.Fatalf("unexpected buffered account blocks %v", result)
+	}
+
+	logs := []*Logs{
+		{AccountBlockHash: c.chunks[2].AccountBlocks[0].Hash},
+		{AccountBlockHash: c.chunks[2].AccountBlocks[0].Hash, Removed: true},
+		{AccountBlockHash: unconfirmed},
+	}
+	if result := dropStaleLogs(r, logs); len(result) != 2 || !result[0].Removed || result[1].AccountBlockHash != unconfirmed {
+		t.Fatalf("unexpected buffered logs %v", result)
+	}
+}
@@ -27,6 +27,9 @@ type filter struct {
 	logs             []*Logs
 	snapshotBlocks   []*SnapshotBlock
 	onroadMsgs       []*OnroadMsg
+
+	confirmedBlocks     []*ConfirmedAccountBlock
+	confirmedOnroadMsgs []*ConfirmedOnroadMsg
 }
 
 type SubscribeApi struct {
@@ -35,6 +38,9 @@ type SubscribeApi struct {
 	filterMap   map[rpc.ID]*filter
 	filterMapMu sync.Mutex
 	eventSystem *EventSystem
+
+	// confirmedSubMap holds the confirmed subscriptions over websocket, so that their addresses can be updated
+	confirmedSubMap map[rpc.ID]*RpcSubscription
 }
 
 func NewSubscribeApi(vite *vite.Vite) *SubscribeApi {
@@ -46,6 +52,8 @@ func NewSubscribeApi(vite *vite.Vite) *SubscribeApi {
 		log:         log15.New("module", "rpc_api/subscribe_api"),
 		filterMap:   make(map[rpc.ID]*filter),
 		eventSystem: Es,
+
+		confirmedSubMap: make(map[rpc.ID]*RpcSubscription),
 	}
 	go s.timeoutLoop()
 	return s
@@ -74,6 +82,10 @@ func (s *SubscribeApi) timeoutLoop() {
 type RpcFilterParam struct {
 	AddrRange map[string]*api.Range `json:"addrRange"`
 	Topics    [][]types.Hash        `json:"topics"`
+
+	SnapshotRange *api.Range `json:"snapshotHeightRange"`
+	Cursor        *string    `json:"cursor"`
+	Count         uint64     `json:"count"`
 }
 
 type AccountBlock struct {
@@ -123,6 +135,9 @@ type Logs struct {
 	AccountHeight    string         `json:"accountHeight"`
 	Addr             *types.Address `json:"addr"`
 	Removed          bool           `json:"removed"`
+
+	SnapshotHeight string `json:"snapshotHeight,omitempty"`
+	Cursor         string `json:"cursor,omitempty"`
 }
 type LogsV2 struct {
 	Log              *ledger.VmLog  `json:"vmlog"`
@@ -345,6 +360,101 @@ func (s *SubscribeApi) UninstallFilter(id rpc.ID) bool {
 	return found
 }
 
+// CreateConfirmedAccountBlockFilter returns the account blocks of the addresses after they are snapshot confirmed
+// minConfirmations times, the addresses can be updated by subscribe_updateConfirmedFilterAddresses
+func (s *SubscribeApi) CreateConfirmedAccountBlockFilter(param ConfirmedFilterParam) (rpc.ID, error) {
+	s.log.Info("createConfirmedAccountBlockFilter")
+	cf, err := newConfirmedFilter(ConfirmedAccountBlocksSubscription, param)
+	if err != nil {
+		return "", err
+	}
+	var (
+		acCh  = make(chan []*ConfirmedAccountBlock)
+		acSub = s.eventSystem.SubscribeConfirmedAccountBlocks(cf, acCh)
+	)
+
+	s.filterMapMu.Lock()
+	s.filterMap[acSub.ID] = &filter{typ: acSub.sub.typ, deadline: time.NewTimer(deadline), s: acSub}
+	s.filterMapMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case ac := <-acCh:
+				s.filterMapMu.Lock()
+				if f, found := s.filterMap[acSub.ID]; found {
+					f.confirmedBlocks = append(f.confirmedBlocks, ac...)
+				}
+				s.filterMapMu.Unlock()
+			case <-acSub.Err():
+				s.filterMapMu.Lock()
+				delete(s.filterMap, acSub.ID)
+				s.filterMapMu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return acSub.ID, nil
+}
+
+// CreateConfirmedUnreceivedBlockFilter returns the unreceived blocks of the addresses after the blocks which
+// send or receive them are snapshot confirmed minConfirmations times
+func (s *SubscribeApi) CreateConfirmedUnreceivedBlockFilter(param ConfirmedFilterParam) (rpc.ID, error) {
+	s.log.Info("createConfirmedUnreceivedBlockFilter")
+	cf, err := newConfirmedFilter(ConfirmedOnroadBlocksSubscription, param)
+	if err != nil {
+		return "", err
+	}
+	var (
+		acCh  = make(chan []*ConfirmedOnroadMsg)
+		acSub = s.eventSystem.SubscribeConfirmedOnroadBlocks(cf, acCh)
+	)
+
+	s.filterMapMu.Lock()
+	s.filterMap[acSub.ID] = &filter{typ: acSub.sub.typ, deadline: time.NewTimer(deadline), s: acSub}
+	s.filterMapMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case ac := <-acCh:
+				s.filterMapMu.Lock()
+				if f, found := s.filterMap[acSub.ID]; found {
+					f.confirmedOnroadMsgs = append(f.confirmedOnroadMsgs, ac...)
+				}
+				s.filterMapMu.Unlock()
+			case <-acSub.Err():
+				s.filterMapMu.Lock()
+				delete(s.filterMap, acSub.ID)
+				s.filterMapMu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return acSub.ID, nil
+}
+
+// UpdateConfirmedFilterAddresses adds and removes the addresses of a confirmed filter or subscription,
+// the held blocks of the removed addresses are dropped
+func (s *SubscribeApi) UpdateConfirmedFilterAddresses(id rpc.ID, addList []types.Address, removeList []types.Address) error {
+	s.log.Info("UpdateConfirmedFilterAddresses", "id", id)
+	s.filterMapMu.Lock()
+	var sub *RpcSubscription
+	if f, found := s.filterMap[id]; found {
+		sub = f.s
+	} else if confirmedSub, found := s.confirmedSubMap[id]; found {
+		sub = confirmedSub
+	}
+	s.filterMapMu.Unlock()
+
+	if sub == nil || sub.sub.confirmed == nil {
+		return errors.New("confirmed filter not found")
+	}
+	return sub.UpdateAddresses(addList, removeList)
+}
+
 type AccountBlocksMsg struct {
 	Blocks []*AccountBlock `json:"result"`
 	Id     rpc.ID          `json:"subscription"`
@@ -378,6 +488,16 @@ type OnroadBlocksMsgV2 struct {
 	Id     rpc.ID         `json:"subscription"`
 }
 
+type ConfirmedAccountBlocksMsg struct {
+	Blocks []*ConfirmedAccountBlock `json:"result"`
+	Id     rpc.ID                   `json:"subscription"`
+}
+
+type ConfirmedOnroadBlocksMsg struct {
+	Blocks []*ConfirmedOnroadMsg `json:"result"`
+	Id     rpc.ID                `json:"subscription"`
+}
+
 type SnapshotBlocksMsg struct {
 	Blocks []*SnapshotBlock `json:"result"`
 	Id     rpc.ID           `json:"subscription"`
@@ -458,6 +578,14 @@ func (s *SubscribeApi) getChangesByFilterId(id rpc.ID) (interface{}, error) {
 				result[i] = &SnapshotBlockV2{b.Hash, b.HeightStr, b.Removed}
 			}
 			return SnapshotBlocksMsgV2{result, id}, nil
+		case ConfirmedAccountBlocksSubscription:
+			blocks := f.confirmedBlocks
+			f.confirmedBlocks = nil
+			return ConfirmedAccountBlocksMsg{blocks, id}, nil
+		case ConfirmedOnroadBlocksSubscription:
+			onroadMsgs := f.confirmedOnroadMsgs
+			f.confirmedOnroadMsgs = nil
+			return ConfirmedOnroadBlocksMsg{onroadMsgs, id}, nil
 		}
 	}
 
@@ -466,13 +594,20 @@ func (s *SubscribeApi) getChangesByFilterId(id rpc.ID) (interface{}, error) {
 
 // Deprecated: use subscribe_createSnapshotBlockSubscription instead
 func (s *SubscribeApi) NewSnapshotBlocks(ctx context.Context) (*rpc.Subscription, error) {
-	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscription)
+	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscription, nil)
 }
-func (s *SubscribeApi) CreateSnapshotBlockSubscription(ctx context.Context) (*rpc.Subscription, error) {
-	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscriptionV2)
+
+// CreateSnapshotBlockSubscription replays the snapshot blocks from fromSnapshotHeight before the live
+// snapshot blocks if fromSnapshotHeight is set
+func (s *SubscribeApi) CreateSnapshotBlockSubscription(ctx context.Context, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createSnapshotBlockSubscription")
+	r, err := newReplayer(s.vite.Chain(), fromSnapshotHeight, false, s.log)
+	if err != nil {
+		return nil, err
+	}
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -482,18 +617,56 @@ func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft F
 	go func() {
 		snapshotBlockHashChan := make(chan []*SnapshotBlock, 128)
 		sbSub := s.eventSystem.SubscribeSnapshotBlocks(snapshotBlockHashChan, ft)
+		notify := func(h []*SnapshotBlock) {
+			if r != nil {
+				h = filterReplayedSnapshotBlocks(r, h)
+			}
+			if len(h) == 0 {
+				return
+			}
+			if ft == SnapshotBlocksSubscriptionV2 {
+				result := make([]*SnapshotBlockV2, len(h))
+				for i, b := range h {
+					result[i] = &SnapshotBlockV2{b.Hash, b.HeightStr, b.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, h)
+			}
+		}
+
+		// the live events are buffered until the replay is done
+		var replayCh <-chan *replayBatch
+		var replayDone <-chan error
+		var buffered [][]*SnapshotBlock
+		if r != nil {
+			replayCh, replayDone = r.start()
+			defer r.close()
+		}
 		for {
 			select {
 			case h := <-snapshotBlockHashChan:
-				if ft == SnapshotBlocksSubscriptionV2 {
-					result := make([]*SnapshotBlockV2, len(h))
-					for i, b := range h {
-						result[i] = &SnapshotBlockV2{b.Hash, b.HeightStr, b.Removed}
-					}
-					notifier.Notify(rpcSub.ID, result)
+				if replayDone != nil {
+					buffered = append(buffered, h)
 				} else {
-					notifier.Notify(rpcSub.ID, h)
+					notify(h)
+				}
+			case batch := <-replayCh:
+				if sb := batch.snapshotBlock; sb != nil {
+					notifier.Notify(rpcSub.ID, []*SnapshotBlockV2{{sb.Hash, api.Uint64ToString(sb.Height), false}})
+				}
+			case err := <-replayDone:
+				if err != nil {
+					s.log.Error("replay snapshot blocks failed, error is "+err.Error(), "method", "createSnapshotBlockSubscription")
+					notifier.Fail(rpcSub.ID, err)
+					sbSub.Unsubscribe()
+					return
 				}
+				replayCh, replayDone = nil, nil
+				for _, h := range buffered {
+					notify(dropStaleSnapshotBlocks(r, h))
+				}
+				buffered = nil
 			case <-rpcSub.Err():
 				sbSub.Unsubscribe()
 				return
@@ -544,13 +717,20 @@ func (s *SubscribeApi) createAccountBlockSubscription(ctx context.Context) (*rpc
 
 // Deprecated: use subscribe_createAccountBlockSubscriptionByAddress instead
 func (s *SubscribeApi) NewAccountBlocksByAddr(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
-	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscription)
+	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscription, nil)
 }
-func (s *SubscribeApi) CreateAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
-	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscriptionV2)
+
+// CreateAccountBlockSubscriptionByAddress replays the account blocks of the address confirmed from fromSnapshotHeight
+// and the unconfirmed ones before the live account blocks if fromSnapshotHeight is set
+func (s *SubscribeApi) CreateAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createAccountBlockSubscriptionByAddress")
+	r, err := newReplayer(s.vite.Chain(), fromSnapshotHeight, false, s.log)
+	if err != nil {
+		return nil, err
+	}
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -560,18 +740,62 @@ func (s *SubscribeApi) createAccountBlockSubscriptionByAddress(ctx context.Conte
 	go func() {
 		accountBlockCh := make(chan []*AccountBlockWithHeight, 128)
 		acSub := s.eventSystem.SubscribeAccountBlocksByAddr(addr, accountBlockCh, ft)
+		notify := func(h []*AccountBlockWithHeight) {
+			if r != nil {
+				h = filterReplayedAccountBlocks(r, h)
+			}
+			if len(h) == 0 {
+				return
+			}
+			if ft == AccountBlocksWithHeightSubscriptionV2 {
+				result := make([]*AccountBlockWithHeightV2, len(h))
+				for i, b := range h {
+					result[i] = &AccountBlockWithHeightV2{b.Hash, b.HeightStr, b.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, h)
+			}
+		}
+
+		// the live events are buffered until the replay is done
+		var replayCh <-chan *replayBatch
+		var replayDone <-chan error
+		var buffered [][]*AccountBlockWithHeight
+		if r != nil {
+			replayCh, replayDone = r.start()
+			defer r.close()
+		}
 		for {
 			select {
 			case h := <-accountBlockCh:
-				if ft == AccountBlocksWithHeightSubscriptionV2 {
-					result := make([]*AccountBlockWithHeightV2, len(h))
-					for i, b := range h {
-						result[i] = &AccountBlockWithHeightV2{b.Hash, b.HeightStr, b.Removed}
+				if replayDone != nil {
+					buffered = append(buffered, h)
+				} else {
+					notify(h)
+				}
+			case batch := <-replayCh:
+				var result []*AccountBlockWithHeightV2
+				for _, e := range batch.blocks {
+					if e.Addr == addr {
+						result = append(result, &AccountBlockWithHeightV2{e.Hash, api.Uint64ToString(e.Height), false})
 					}
+				}
+				if len(result) > 0 {
 					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, h)
 				}
+			case err := <-replayDone:
+				if err != nil {
+					s.log.Error("replay account blocks failed, error is "+err.Error(), "method", "createAccountBlockSubscriptionByAddress")
+					notifier.Fail(rpcSub.ID, err)
+					acSub.Unsubscribe()
+					return
+				}
+				replayCh, replayDone = nil, nil
+				for _, h := range buffered {
+					notify(dropStaleAccountBlocks(r, h))
+				}
+				buffered = nil
 			case <-rpcSub.Err():
 				acSub.Unsubscribe()
 				return
@@ -628,19 +852,143 @@ func (s *SubscribeApi) createUnreceivedBlockSubscriptionByAddress(ctx context.Co
 	return rpcSub, nil
 }
 
+func (s *SubscribeApi) CreateConfirmedAccountBlockSubscription(ctx context.Context, param ConfirmedFilterParam) (*rpc.Subscription, error) {
+	s.log.Info("createConfirmedAccountBlockSubscription")
+	cf, err := newConfirmedFilter(ConfirmedAccountBlocksSubscription, param)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	accountBlockCh := make(chan []*ConfirmedAccountBlock, 128)
+	acSub := s.eventSystem.SubscribeConfirmedAccountBlocks(cf, accountBlockCh)
+	s.filterMapMu.Lock()
+	s.confirmedSubMap[rpcSub.ID] = acSub
+	s.filterMapMu.Unlock()
+
+	go func() {
+		defer s.removeConfirmedSub(rpcSub.ID)
+		for {
+			select {
+			case h := <-accountBlockCh:
+				notifier.Notify(rpcSub.ID, h)
+			case <-rpcSub.Err():
+				acSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				acSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+func (s *SubscribeApi) CreateConfirmedUnreceivedBlockSubscription(ctx context.Context, param ConfirmedFilterParam) (*rpc.Subscription, error) {
+	s.log.Info("createConfirmedUnreceivedBlockSubscription")
+	cf, err := newConfirmedFilter(ConfirmedOnroadBlocksSubscription, param)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	onroadMsgCh := make(chan []*ConfirmedOnroadMsg, 128)
+	acSub := s.eventSystem.SubscribeConfirmedOnroadBlocks(cf, onroadMsgCh)
+	s.filterMapMu.Lock()
+	s.confirmedSubMap[rpcSub.ID] = acSub
+	s.filterMapMu.Unlock()
+
+	go func() {
+		defer s.removeConfirmedSub(rpcSub.ID)
+		for {
+			select {
+			case h := <-onroadMsgCh:
+				notifier.Notify(rpcSub.ID, h)
+			case <-rpcSub.Err():
+				acSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				acSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// CreateTransactionStatusSubscription reports the statuses of the account blocks when they are subscribed and every time they change
+func (s *SubscribeApi) CreateTransactionStatusSubscription(ctx context.Context, hashList []types.Hash) (*rpc.Subscription, error) {
+	s.log.Info("createTransactionStatusSubscription")
+	if api.TxTracker == nil {
+		return nil, errors.New("transaction status tracker is not started")
+	}
+	f, err := newTxStatusFilter(hashList)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	statusCh := make(chan []*api.TransactionStatus, 128)
+	txSub := s.eventSystem.SubscribeTxStatus(f, statusCh)
+
+	go func() {
+		for {
+			select {
+			case h := <-statusCh:
+				notifier.Notify(rpcSub.ID, h)
+			case <-rpcSub.Err():
+				txSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				txSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+func (s *SubscribeApi) removeConfirmedSub(id rpc.ID) {
+	s.filterMapMu.Lock()
+	delete(s.confirmedSubMap, id)
+	s.filterMapMu.Unlock()
+}
+
 // Deprevated: use subscribe_createVmLogSubscription instead
 func (s *SubscribeApi) NewLogs(ctx context.Context, param RpcFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscription)
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscription, nil)
 }
-func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param api.VmLogFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscriptionV2)
+
+// CreateVmlogSubscription replays the vm logs of the blocks confirmed from fromSnapshotHeight and the unconfirmed
+// blocks before the live vm logs if fromSnapshotHeight is set
+func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param api.VmLogFilterParam, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createVmLogSubscription")
 	p, err := api.ToFilterParam(rangeMap, topics)
 	if err != nil {
 		return nil, err
 	}
+	r, err := newReplayer(s.vite.Chain(), fromSnapshotHeight, true, s.log)
+	if err != nil {
+		return nil, err
+	}
 
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -651,19 +999,62 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 	go func() {
 		logsMsg := make(chan []*Logs, 128)
 		sub := s.eventSystem.SubscribeLogs(p, logsMsg, ft)
+		notify := func(msg []*Logs) {
+			if r != nil {
+				msg = filterReplayedLogs(r, msg)
+			}
+			if len(msg) == 0 {
+				return
+			}
+			if ft == LogsSubscriptionV2 {
+				result := make([]*LogsV2, len(msg))
+				for i, l := range msg {
+					result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, msg)
+			}
+		}
 
+		// the live events are buffered until the replay is done
+		var replayCh <-chan *replayBatch
+		var replayDone <-chan error
+		var buffered [][]*Logs
+		if r != nil {
+			replayCh, replayDone = r.start()
+			defer r.close()
+		}
 		for {
 			select {
 			case msg := <-logsMsg:
-				if ft == LogsSubscriptionV2 {
-					result := make([]*LogsV2, len(msg))
-					for i, l := range msg {
-						result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+				if replayDone != nil {
+					buffered = append(buffered, msg)
+				} else {
+					notify(msg)
+				}
+			case batch := <-replayCh:
+				var result []*LogsV2
+				for _, e := range batch.blocks {
+					for _, l := range filterLogs(e, p, false) {
+						result = append(result, &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed})
 					}
+				}
+				if len(result) > 0 {
 					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, msg)
 				}
+			case err := <-replayDone:
+				if err != nil {
+					s.log.Error("replay vm logs failed, error is "+err.Error(), "method", "createVmLogSubscription")
+					notifier.Fail(rpcSub.ID, err)
+					sub.Unsubscribe()
+					return
+				}
+				replayCh, replayDone = nil, nil
+				for _, msg := range buffered {
+					notify(dropStaleLogs(r, msg))
+				}
+				buffered = nil
 
 			case <-rpcSub.Err():
 				sub.Unsubscribe()
@@ -679,13 +1070,26 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 
 // Deprecated: use ledger_getVmLogsByFilter instead
 func (s *SubscribeApi) GetLogs(param RpcFilterParam) ([]*Logs, error) {
-	logs, err := api.GetLogs(s.vite.Chain(), param.AddrRange, param.Topics)
+	p := api.VmLogFilterParam{
+		AddrRange:     param.AddrRange,
+		Topics:        param.Topics,
+		SnapshotRange: param.SnapshotRange,
+		Cursor:        param.Cursor,
+		Count:         param.Count,
+	}
+	var logs []*api.Logs
+	var err error
+	if p.UseIndex() {
+		logs, err = api.GetLogsByIndex(s.vite.Chain(), p)
+	} else {
+		logs, err = api.GetLogs(s.vite.Chain(), param.AddrRange, param.Topics)
+	}
 	if err != nil {
 		return nil, err
 	}
 	resultList := make([]*Logs, len(logs))
 	for i, l := range logs {
-		resultList[i] = &Logs{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, false}
+		resultList[i] = &Logs{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, false, l.SnapshotHeight, l.Cursor}
 	}
 	return resultList, nil
 }
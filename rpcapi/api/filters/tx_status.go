@@ -0,0 +1,82 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// the max count of hashes of a transaction status subscription
+const maxTxStatusHashCount = 1000
+
+// txStatusFilter reports the statuses of a set of account blocks when they change, the confirmed times
+// are reported on every snapshot block. It is only accessed in the event loop.
+type txStatusFilter struct {
+	reported map[types.Hash]string // hash -> the key of the last reported status
+}
+
+func newTxStatusFilter(hashList []types.Hash) (*txStatusFilter, error) {
+	if len(hashList) == 0 {
+		return nil, errors.New("hashList is empty")
+	}
+	if len(hashList) > maxTxStatusHashCount {
+		return nil, errors.New(fmt.Sprintf("hash count exceeds %d", maxTxStatusHashCount))
+	}
+	f := &txStatusFilter{reported: make(map[types.Hash]string, len(hashList))}
+	for _, hash := range hashList {
+		f.reported[hash] = ""
+	}
+	return f, nil
+}
+
+func txStatusKey(status *api.TransactionStatus) string {
+	if status == nil {
+		return "unknown"
+	}
+	key := status.Status + "-" + status.ConfirmedTimes
+	if status.ReceiveBlockHash != nil {
+		key += "-" + status.ReceiveBlockHash.String()
+	}
+	return key
+}
+
+// update returns the statuses changed since the last report, only the hashes in relevant are checked if it is not nil.
+// An unknown block is not reported.
+func (f *txStatusFilter) update(getStatus func(hash types.Hash) (*api.TransactionStatus, error), relevant map[types.Hash]struct{}) ([]*api.TransactionStatus, error) {
+	var changed []*api.TransactionStatus
+	for hash, last := range f.reported {
+		if relevant != nil {
+			if _, ok := relevant[hash]; !ok {
+				continue
+			}
+		}
+		status, err := getStatus(hash)
+		if err != nil {
+			return changed, err
+		}
+		key := txStatusKey(status)
+		if key == last {
+			continue
+		}
+		f.reported[hash] = key
+		if status != nil {
+			changed = append(changed, status)
+		}
+	}
+	return changed, nil
+}
+
+// txStatusRelevantHashes returns the hashes which statuses may be changed by the account chain events
+func txStatusRelevantHashes(events []*AccountChainEvent) map[types.Hash]struct{} {
+	relevant := make(map[types.Hash]struct{}, len(events))
+	for _, e := range events {
+		relevant[e.Hash] = struct{}{}
+		relevant[e.FromBlockHash] = struct{}{}
+		for _, sendBlock := range e.SendBlockList {
+			relevant[sendBlock.Hash] = struct{}{}
+		}
+	}
+	return relevant
+}
@@ -0,0 +1,51 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+func TestTxStatusFilter(t *testing.T) {
+	known, unknown := types.DataHash([]byte("known")), types.DataHash([]byte("unknown"))
+	statuses := map[types.Hash]*api.TransactionStatus{
+		known: {Hash: known, Status: api.TxStatusPending, ConfirmedTimes: "0"},
+	}
+	getStatus := func(hash types.Hash) (*api.TransactionStatus, error) {
+		return statuses[hash], nil
+	}
+
+	if _, err := newTxStatusFilter(nil); err == nil {
+		t.Fatal("empty hash list should be refused")
+	}
+	f, err := newTxStatusFilter([]types.Hash{known, unknown})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := f.update(getStatus, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0].Hash != known {
+		t.Fatalf("only the known block should be reported, got %v", changed)
+	}
+	if changed, _ := f.update(getStatus, nil); len(changed) != 0 {
+		t.Fatalf("unchanged status should not be reported again, got %v", changed)
+	}
+
+	statuses[known] = &api.TransactionStatus{Hash: known, Status: api.TxStatusUnconfirmed, ConfirmedTimes: "0"}
+	if changed, _ := f.update(getStatus, map[types.Hash]struct{}{unknown: {}}); len(changed) != 0 {
+		t.Fatalf("irrelevant block should not be checked, got %v", changed)
+	}
+	if changed, _ := f.update(getStatus, map[types.Hash]struct{}{known: {}}); len(changed) != 1 {
+		t.Fatalf("changed status should be reported, got %v", changed)
+	}
+
+	statuses[known] = &api.TransactionStatus{Hash: known, Status: api.TxStatusConfirmed, ConfirmedTimes: "1"}
+	statuses[unknown] = &api.TransactionStatus{Hash: unknown, Status: api.TxStatusPending, ConfirmedTimes: "0"}
+	if changed, _ := f.update(getStatus, nil); len(changed) != 2 {
+		t.Fatalf("both statuses should be reported, got %v", changed)
+	}
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/vite"
 	"time"
 )
@@ -19,11 +18,11 @@ func NewHealthApi(vite *vite.Vite) *Health {
 func (h *Health) Health() error {
 	sb := h.vite.Chain().GetLatestSnapshotBlock()
 	if sb == nil {
-		return errors.New("check node height failed, sb nil")
+		return ErrNodeNotReady.with("check node height failed, sb nil")
 	}
 	nowTime := time.Now()
 	if nowTime.After(sb.Timestamp.Add(InvalidSnapshotMinutes * time.Minute)) {
-		return errors.New("check node height failed, height invalid")
+		return ErrNodeNotReady.with("check node height failed, height invalid")
 	}
 	return nil
 }
@@ -6,14 +6,13 @@ import (
 	"math/big"
 	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/vm/abi"
 )
 
 func convert(params []string, arguments abi.Arguments) ([]interface{}, error) {
 	if len(params) != len(arguments) {
-		return nil, errors.New("argument size not match")
+		return nil, ErrInvalidParam.with("argument size not match")
 	}
 	resultList := make([]interface{}, len(params))
 	for i, argument := range arguments {
@@ -49,12 +48,12 @@ func convertOne(param string, t abi.Type) (interface{}, error) {
 	} else if strings.HasPrefix(typeString, "bytes") {
 		return convertToFixedBytes(param, t.Size)
 	}
-	return nil, errors.New("unknown type " + typeString)
+	return nil, ErrInvalidParam.with("unknown type " + typeString)
 }
 
 func convertToArray(param string, t abi.Type) (interface{}, error) {
 	if t.Elem.Elem != nil {
-		return nil, errors.New(t.String() + " type not supported")
+		return nil, ErrInvalidParam.with(t.String() + " type not supported")
 	}
 	typeString := t.Elem.String()
 	if typeString == "bool" {
@@ -72,7 +71,7 @@ func convertToArray(param string, t abi.Type) (interface{}, error) {
 	} else if typeString == "string" {
 		return convertToStringArray(param)
 	}
-	return nil, errors.New(typeString + " array type not supported")
+	return nil, ErrInvalidParam.with(typeString + " array type not supported")
 }
 
 func convertToBoolArray(param string) (interface{}, error) {
@@ -191,7 +190,7 @@ func convertToBool(param string) (interface{}, error) {
 func convertToInt(param string, size int) (interface{}, error) {
 	bigInt, ok := new(big.Int).SetString(param, 0)
 	if !ok || bigInt.BitLen() > size-1 {
-		return nil, errors.New(param + " convert to int failed")
+		return nil, ErrInvalidParam.with(param + " convert to int failed")
 	}
 	if size == 8 {
 		return int8(bigInt.Int64()), nil
@@ -209,7 +208,7 @@ func convertToInt(param string, size int) (interface{}, error) {
 func convertToUint(param string, size int) (interface{}, error) {
 	bigInt, ok := new(big.Int).SetString(param, 0)
 	if !ok || bigInt.BitLen() > size {
-		return nil, errors.New(param + " convert to uint failed")
+		return nil, ErrInvalidParam.with(param + " convert to uint failed")
 	}
 	if size == 8 {
 		return uint8(bigInt.Uint64()), nil
@@ -234,7 +233,7 @@ func convertToBytes(param string, size int) (interface{}, error) {
 
 func convertToFixedBytes(param string, size int) (interface{}, error) {
 	if len(param) != size*2 {
-		return nil, errors.New(param + " is not valid bytes")
+		return nil, ErrInvalidParam.with(param + " is not valid bytes")
 	}
 	return hex.DecodeString(param)
 }
@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
@@ -311,7 +310,7 @@ func (l *LedgerApi) GetConfirmedBalances(snapshotHash types.Hash, addrList []typ
 		}
 
 		if balances == nil {
-			return nil, errors.New(fmt.Sprintf("snapshot block %s is not existed.", snapshotHash))
+			return nil, ErrDataNotFound.with(fmt.Sprintf("snapshot block %s is not existed.", snapshotHash))
 		}
 
 		for addr, balance := range balances {
@@ -2,7 +2,6 @@ package api
 
 import (
 	"github.com/vitelabs/go-vite/chain"
-	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/onroad"
 	"github.com/vitelabs/go-vite/vite"
@@ -36,7 +35,7 @@ func NewUnreceivedDebugApi(vite *vite.Vite) *UnreceivedDebugApi {
 // private: unreceived_getContractUnreceivedTransactionCount <- onroad_getContractOnRoadTotalNum
 func (ud *UnreceivedDebugApi) GetContractUnreceivedTransactionCount(addr types.Address, gid *types.Gid) (uint64, error) {
 	if !types.IsContractAddr(addr) {
-		return 0, errors.New("Address must be the type of Contract.")
+		return 0, ErrInvalidParam.with("Address must be the type of Contract.")
 	}
 
 	var g types.Gid
@@ -57,7 +56,7 @@ func (ud *UnreceivedDebugApi) GetContractUnreceivedTransactionCount(addr types.A
 // private: unreceived_getContractUnreceivedFrontBlocks <- onorad_getContractOnRoadFrontBlocks
 func (pu *UnreceivedDebugApi) GetContractUnreceivedFrontBlocks(addr types.Address, gid *types.Gid) ([]*AccountBlock, error) {
 	if !types.IsContractAddr(addr) {
-		return nil, errors.New("Address must be the type of Contract.")
+		return nil, ErrInvalidParam.with("Address must be the type of Contract.")
 	}
 	var g types.Gid
 	if gid == nil {
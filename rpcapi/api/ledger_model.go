@@ -1,7 +1,6 @@
 package api
 
 import (
-	"errors"
 	"math/big"
 	"strconv"
 
@@ -146,7 +145,7 @@ func (block *AccountBlock) RpcToLedgerBlock() (*ledger.AccountBlock, error) {
 
 	if block.Nonce != nil {
 		if block.Difficulty == nil {
-			return nil, errors.New("lack of difficulty field")
+			return nil, ErrInvalidParam.with("lack of difficulty field")
 		} else {
 			difficultyStr, ok := new(big.Int).SetString(*block.Difficulty, 10)
 			if !ok {
@@ -399,6 +398,13 @@ type AccountInfo struct {
 	BalanceInfoMap map[types.TokenTypeId]*BalanceInfo `json:"balanceInfoMap,omitempty"`
 }
 
+type SnapshotAccountInfo struct {
+	Address        types.Address                      `json:"address"`
+	SnapshotHeight string                             `json:"snapshotHeight"`
+	SnapshotHash   types.Hash                         `json:"snapshotHash"`
+	BalanceInfoMap map[types.TokenTypeId]*BalanceInfo `json:"balanceInfoMap,omitempty"`
+}
+
 type BalanceInfo struct {
 	TokenInfo        *RpcTokenInfo `json:"tokenInfo,omitempty"`
 	Balance          string        `json:"balance"`                    // big int
@@ -544,7 +550,7 @@ type NormalRequestRawTxParam struct {
 
 func (param NormalRequestRawTxParam) LedgerAccountBlock() (*ledger.AccountBlock, error) {
 	if types.IsContractAddr(param.AccountAddress) {
-		return nil, errors.New("can't send tx for the contract")
+		return nil, ErrInvalidParam.with("can't send tx for the contract")
 	}
 
 	lAb := &ledger.AccountBlock{
@@ -583,7 +589,7 @@ func (param NormalRequestRawTxParam) LedgerAccountBlock() (*ledger.AccountBlock,
 
 	if param.Nonce != nil {
 		if param.Difficulty == nil {
-			return nil, errors.New("lack of difficulty field")
+			return nil, ErrInvalidParam.with("lack of difficulty field")
 		} else {
 			difficultyStr, ok := new(big.Int).SetString(*param.Difficulty, 10)
 			if !ok {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/chain/plugins"
-	"github.com/vitelabs/go-vite/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm"
@@ -49,7 +48,7 @@ func (l *LedgerApi) GetAccountBlocks(addr types.Address, originBlockHash *types.
 		}
 		plugins := l.chain.Plugins()
 		if plugins == nil {
-			err := errors.New("config.OpenPlugins is false, api can't work")
+			err := ErrNotSupported.with("config.OpenPlugins is false, api can't work")
 			return nil, err
 		}
 
@@ -139,6 +138,45 @@ func (l *LedgerApi) GetAccountInfoByAddress(addr types.Address) (*AccountInfo, e
 	return ToAccountInfo(l.chain, info), nil
 }
 
+// GetBalanceAtSnapshot returns the balances of the address as of the snapshot height
+func (l *LedgerApi) GetBalanceAtSnapshot(addr types.Address, snapshotHeight string) (*SnapshotAccountInfo, error) {
+	height, err := StringToUint64(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	snapshotBlock, err := l.chain.GetSnapshotHeaderByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotBlock == nil {
+		return nil, ErrDataNotFound.with(fmt.Sprintf("snapshot block %d is not existed", height))
+	}
+
+	balanceMap, err := l.chain.GetBalanceMapAtSnapshot(addr, height)
+	if err != nil {
+		l.log.Error("GetBalanceMapAtSnapshot failed, error is "+err.Error(), "method", "GetBalanceAtSnapshot")
+		return nil, err
+	}
+
+	info := &SnapshotAccountInfo{
+		Address:        addr,
+		SnapshotHeight: Uint64ToString(snapshotBlock.Height),
+		SnapshotHash:   snapshotBlock.Hash,
+		BalanceInfoMap: make(map[types.TokenTypeId]*BalanceInfo, len(balanceMap)),
+	}
+	for tokenId, amount := range balanceMap {
+		token, _ := l.chain.GetTokenInfoById(tokenId)
+		if token == nil {
+			continue
+		}
+		info.BalanceInfoMap[tokenId] = &BalanceInfo{
+			TokenInfo: RawTokenInfoToRpc(token, tokenId),
+			Balance:   amount.String(),
+		}
+	}
+	return info, nil
+}
+
 func (l *LedgerApi) getAccountInfoByAddress(addr types.Address) (*ledger.AccountInfo, error) {
 	latestAccountBlock, err := l.chain.GetLatestAccountBlock(addr)
 	if err != nil {
@@ -208,7 +246,7 @@ func (l *LedgerApi) GetVmLogs(blockHash types.Hash) (ledger.VmLogList, error) {
 		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New("get block failed")
+		return nil, ErrDataNotFound.with("get block failed")
 	}
 
 	return l.chain.GetVmLogList(block.LogHash)
@@ -218,10 +256,10 @@ func (l *LedgerApi) GetVmLogs(blockHash types.Hash) (ledger.VmLogList, error) {
 func (l *LedgerApi) SendRawTransaction(block *AccountBlock) error {
 
 	if block == nil {
-		return errors.New("empty block")
+		return ErrInvalidParam.with("empty block")
 	}
 	if !checkTxToAddressAvailable(block.ToAddress) {
-		return errors.New("ToAddress is invalid")
+		return ErrInvalidParam.with("ToAddress is invalid")
 	}
 	lb, err := block.RpcToLedgerBlock()
 	if err != nil {
@@ -232,7 +270,7 @@ func (l *LedgerApi) SendRawTransaction(block *AccountBlock) error {
 	}
 	latestSb := l.chain.GetLatestSnapshotBlock()
 	if latestSb == nil {
-		return errors.New("failed to get latest snapshotBlock")
+		return ErrNodeNotReady.with("failed to get latest snapshotBlock")
 	}
 	if err := checkSnapshotValid(latestSb); err != nil {
 		return err
@@ -249,7 +287,7 @@ func (l *LedgerApi) SendRawTransaction(block *AccountBlock) error {
 	if result != nil {
 		return l.vite.Pool().AddDirectAccountBlock(result.AccountBlock.AccountAddress, result)
 	} else {
-		return errors.New("generator gen an empty block")
+		return ErrGenerateBlock.with("generator gen an empty block")
 	}
 }
 
@@ -334,7 +372,18 @@ func (l *LedgerApi) GetUnreceivedTransactionSummaryInBatch(addressList []types.A
 type VmLogFilterParam struct {
 	AddrRange map[string]*Range `json:"addressHeightRange"`
 	Topics    [][]types.Hash    `json:"topics"`
+
+	// query the vm log index plugin
+	SnapshotRange *Range  `json:"snapshotHeightRange"`
+	Cursor        *string `json:"cursor"`
+	Count         uint64  `json:"count"`
+}
+
+// UseIndex returns true if the query should be answered by the vm log index plugin
+func (p *VmLogFilterParam) UseIndex() bool {
+	return p.SnapshotRange != nil || p.Cursor != nil || len(p.AddrRange) == 0
 }
+
 type Range struct {
 	FromHeight string `json:"fromHeight"`
 	ToHeight   string `json:"toHeight"`
@@ -351,7 +400,7 @@ func (r *Range) ToHeightRange() (*HeightRange, error) {
 			return nil, err
 		}
 		if toHeight < fromHeight && toHeight != 0 {
-			return nil, errors.New("to height < from height")
+			return nil, ErrInvalidParam.with("to height < from height")
 		}
 		return &HeightRange{fromHeight, toHeight}, nil
 	}
@@ -370,7 +419,7 @@ type HeightRange struct {
 func ToFilterParam(rangeMap map[string]*Range, topics [][]types.Hash) (*FilterParam, error) {
 	var addrRange map[types.Address]HeightRange
 	if len(rangeMap) == 0 {
-		return nil, errors.New("addressHeightRange is nil")
+		return nil, ErrInvalidParam.with("addressHeightRange is nil")
 	}
 	addrRange = make(map[types.Address]HeightRange, len(rangeMap))
 	for hexAddr, r := range rangeMap {
@@ -399,11 +448,94 @@ type Logs struct {
 	AccountBlockHash types.Hash     `json:"accountBlockHash"`
 	AccountHeight    string         `json:"accountBlockHeight"`
 	Addr             *types.Address `json:"address"`
+
+	// only returned by the vm log index plugin
+	SnapshotHeight string `json:"snapshotHeight,omitempty"`
+	Cursor         string `json:"cursor,omitempty"`
 }
 
+const defaultVmLogCount = uint64(100)
+const maxVmLogCount = uint64(1000)
+
 func (l *LedgerApi) GetVmLogsByFilter(param VmLogFilterParam) ([]*Logs, error) {
+	if param.UseIndex() {
+		return GetLogsByIndex(l.chain, param)
+	}
 	return GetLogs(l.chain, param.AddrRange, param.Topics)
 }
+
+// GetLogsByIndex queries the vm logs by the vm log index plugin. The cursor of the last returned log can be
+// passed as param.Cursor to fetch the next page.
+func GetLogsByIndex(c chain.Chain, param VmLogFilterParam) ([]*Logs, error) {
+	plugins := c.Plugins()
+	if plugins == nil {
+		return nil, ErrNotSupported.with("config.OpenPlugins is false, api can't work")
+	}
+	plugin, ok := plugins.GetPlugin("vmLogIndex").(*chain_plugins.VmLogIndex)
+	if !ok || plugin == nil {
+		return nil, ErrNotSupported.with("plugins-VmLogIndex's service not provided")
+	}
+
+	filter := &chain_plugins.VmLogFilter{
+		Addresses: make(map[types.Address]chain_plugins.AccountHeightRange, len(param.AddrRange)),
+		Topics:    param.Topics,
+	}
+	for hexAddr, r := range param.AddrRange {
+		addr, err := types.HexToAddress(hexAddr)
+		if err != nil {
+			return nil, err
+		}
+		hr, err := r.ToHeightRange()
+		if err != nil {
+			return nil, err
+		}
+		if hr == nil {
+			hr = &HeightRange{0, 0}
+		}
+		filter.Addresses[addr] = chain_plugins.AccountHeightRange{FromHeight: hr.FromHeight, ToHeight: hr.ToHeight}
+	}
+	if param.SnapshotRange != nil {
+		hr, err := param.SnapshotRange.ToHeightRange()
+		if err != nil {
+			return nil, err
+		}
+		filter.FromSnapshotHeight = hr.FromHeight
+		filter.ToSnapshotHeight = hr.ToHeight
+	}
+
+	var after *chain_plugins.VmLogLocation
+	if param.Cursor != nil && len(*param.Cursor) > 0 {
+		var err error
+		if after, err = chain_plugins.ParseVmLogLocation(*param.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	count := param.Count
+	if count == 0 {
+		count = defaultVmLogCount
+	} else if count > maxVmLogCount {
+		count = maxVmLogCount
+	}
+
+	list, err := plugin.GetLogs(filter, after, count)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]*Logs, len(list))
+	for i, item := range list {
+		addr := item.Location.Address
+		logs[i] = &Logs{
+			Log:              item.Log,
+			AccountBlockHash: item.AccountBlockHash,
+			AccountHeight:    Uint64ToString(item.Location.AccountHeight),
+			Addr:             &addr,
+			SnapshotHeight:   Uint64ToString(item.Location.SnapshotHeight),
+			Cursor:           item.Location.String(),
+		}
+	}
+	return logs, nil
+}
 func GetLogs(c chain.Chain, rangeMap map[string]*Range, topics [][]types.Hash) ([]*Logs, error) {
 	filterParam, err := ToFilterParam(rangeMap, topics)
 	if err != nil {
@@ -444,7 +576,7 @@ func GetLogs(c chain.Chain, rangeMap map[string]*Range, topics [][]types.Hash) (
 					}
 					for _, l := range list {
 						if FilterLog(filterParam, l) {
-							logs = append(logs, &Logs{l, blocks[i-1].Hash, Uint64ToString(blocks[i-1].Height), &addr})
+							logs = append(logs, &Logs{Log: l, AccountBlockHash: blocks[i-1].Hash, AccountHeight: Uint64ToString(blocks[i-1].Height), Addr: &addr})
 						}
 					}
 				}
@@ -532,7 +664,7 @@ func calcPoWDifficulty(c chain.Chain, param CalcPoWDifficultyParam) (result *Cal
 	if param.ToAddr != nil {
 		block.ToAddress = *param.ToAddr
 	} else if param.BlockType == ledger.BlockTypeSendCall {
-		return nil, errors.New("toAddress is nil")
+		return nil, ErrInvalidParam.with("toAddress is nil")
 	}
 	sb := c.GetLatestSnapshotBlock()
 	db, err := vm_db.NewVmDb(c, &param.SelfAddr, &sb.Hash, &param.PrevHash)
@@ -617,7 +749,7 @@ func calcQuotaRequired(c chain.Chain, param CalcQuotaRequiredParam) (*CalcQuotaR
 	if param.ToAddr != nil {
 		block.ToAddress = *param.ToAddr
 	} else if param.BlockType == ledger.BlockTypeSendCall {
-		return nil, errors.New("toAddress is nil")
+		return nil, ErrInvalidParam.with("toAddress is nil")
 	}
 	sb := c.GetLatestSnapshotBlock()
 	db, err := vm_db.NewVmDb(c, &param.SelfAddr, &sb.Hash, &prevHash)
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+type MultisigApi struct {
+	chain chain.Chain
+	log   log15.Logger
+}
+
+func NewMultisigApi(vite *vite.Vite) *MultisigApi {
+	return &MultisigApi{
+		chain: vite.Chain(),
+		log:   log15.New("module", "rpc_api/multisig_api"),
+	}
+}
+
+func (m MultisigApi) String() string {
+	return "MultisigApi"
+}
+
+type MultisigProposeParam struct {
+	MultisigId  string            `json:"multisigId"`
+	ToAddr      types.Address     `json:"toAddr"`
+	TokenTypeId types.TokenTypeId `json:"tokenTypeId"`
+	Amount      string            `json:"amount"`
+	Data        []byte            `json:"data,omitempty"`
+}
+
+func (m *MultisigApi) GetCreateMultisigData(owners []types.Address, threshold uint8) ([]byte, error) {
+	return abi.ABIMultisig.PackMethod(abi.MethodNameCreateMultisig, owners, threshold)
+}
+
+func (m *MultisigApi) GetDepositData(multisigId string) ([]byte, error) {
+	id, err := StringToUint64(multisigId)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigDeposit, id)
+}
+
+func (m *MultisigApi) GetProposeData(param MultisigProposeParam) ([]byte, error) {
+	return getMultisigProposeData(param)
+}
+
+func (m *MultisigApi) GetApproveData(multisigId string, proposalId string) ([]byte, error) {
+	return getMultisigProposalData(abi.MethodNameMultisigApprove, multisigId, proposalId)
+}
+
+func (m *MultisigApi) GetExecuteData(multisigId string, proposalId string) ([]byte, error) {
+	return getMultisigProposalData(abi.MethodNameMultisigExecute, multisigId, proposalId)
+}
+
+func getMultisigProposeData(param MultisigProposeParam) ([]byte, error) {
+	id, err := StringToUint64(param.MultisigId)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := stringToBigInt(&param.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if param.Data == nil {
+		param.Data = []byte{}
+	}
+	return abi.ABIMultisig.PackMethod(abi.MethodNameMultisigPropose, id, param.ToAddr, param.TokenTypeId, amount, param.Data)
+}
+
+func getMultisigProposalData(methodName string, multisigId string, proposalId string) ([]byte, error) {
+	id, err := StringToUint64(multisigId)
+	if err != nil {
+		return nil, err
+	}
+	pid, err := StringToUint64(proposalId)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIMultisig.PackMethod(methodName, id, pid)
+}
+
+type MultisigInfo struct {
+	MultisigId    string                       `json:"multisigId"`
+	Threshold     uint8                        `json:"threshold"`
+	Owners        []types.Address              `json:"owners"`
+	ProposalCount string                       `json:"proposalCount"`
+	BalanceMap    map[types.TokenTypeId]string `json:"balanceMap"`
+}
+
+type MultisigProposal struct {
+	MultisigId  string            `json:"multisigId"`
+	ProposalId  string            `json:"proposalId"`
+	Proposer    types.Address     `json:"proposer"`
+	ToAddr      types.Address     `json:"toAddr"`
+	TokenTypeId types.TokenTypeId `json:"tokenTypeId"`
+	Amount      string            `json:"amount"`
+	Data        []byte            `json:"data"`
+	Approvals   []types.Address   `json:"approvals"`
+	Threshold   uint8             `json:"threshold"`
+	Executable  bool              `json:"executable"`
+}
+
+func newMultisigInfo(db abi.StorageDatabase, info *abi.MultisigInfo) (*MultisigInfo, error) {
+	balanceMap, err := abi.GetMultisigBalanceList(db, info.Id)
+	if err != nil {
+		return nil, err
+	}
+	result := &MultisigInfo{
+		MultisigId:    Uint64ToString(info.Id),
+		Threshold:     info.Threshold,
+		Owners:        info.Owners,
+		ProposalCount: Uint64ToString(info.ProposalCount),
+		BalanceMap:    make(map[types.TokenTypeId]string, len(balanceMap)),
+	}
+	for tokenId, balance := range balanceMap {
+		result.BalanceMap[tokenId] = *bigIntToString(balance)
+	}
+	return result, nil
+}
+
+// GetMultisigInfo returns the owners, the threshold and the balances of a multisig account
+func (m *MultisigApi) GetMultisigInfo(multisigId string) (*MultisigInfo, error) {
+	id, err := StringToUint64(multisigId)
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	info, err := abi.GetMultisigInfo(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, nil
+	}
+	return newMultisigInfo(db, info)
+}
+
+// GetMultisigListByOwner returns all multisig accounts of an owner
+func (m *MultisigApi) GetMultisigListByOwner(owner types.Address) ([]*MultisigInfo, error) {
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	infoList, err := abi.GetMultisigListByOwner(db, owner)
+	if err != nil {
+		return nil, err
+	}
+	resultList := make([]*MultisigInfo, 0, len(infoList))
+	for _, info := range infoList {
+		result, err := newMultisigInfo(db, info)
+		if err != nil {
+			return nil, err
+		}
+		resultList = append(resultList, result)
+	}
+	return resultList, nil
+}
+
+// GetPendingProposals returns the proposals not executed yet of a multisig account with the approval status
+func (m *MultisigApi) GetPendingProposals(multisigId string) ([]*MultisigProposal, error) {
+	id, err := StringToUint64(multisigId)
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(m.chain, types.AddressMultisig)
+	if err != nil {
+		return nil, err
+	}
+	info, err := abi.GetMultisigInfo(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrInvalidParam.with("multisig account not exists")
+	}
+	proposalList, err := abi.GetMultisigProposalList(db, id)
+	if err != nil {
+		return nil, err
+	}
+	resultList := make([]*MultisigProposal, 0, len(proposalList))
+	for _, p := range proposalList {
+		resultList = append(resultList, &MultisigProposal{
+			MultisigId:  Uint64ToString(p.MultisigId),
+			ProposalId:  Uint64ToString(p.Id),
+			Proposer:    p.Proposer,
+			ToAddr:      p.To,
+			TokenTypeId: p.TokenId,
+			Amount:      *bigIntToString(p.Amount),
+			Data:        p.Data,
+			Approvals:   p.Approvals,
+			Threshold:   info.Threshold,
+			Executable:  len(p.Approvals) >= int(info.Threshold),
+		})
+	}
+	return resultList, nil
+}
@@ -54,6 +54,11 @@ func (n *NetApi) PeerCount() int {
 	return n.net.PeerCount()
 }
 
+// PeerScores return the reputation scores of peers, from high to low
+func (n *NetApi) PeerScores() []net.PeerScore {
+	return n.net.PeerScores()
+}
+
 func (n *NetApi) NodeInfo() net.NodeInfo {
 	return n.net.Info()
 }
@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/binary"
-	"errors"
 	"math/big"
 
 	"github.com/vitelabs/go-vite/common/hexutil"
@@ -66,7 +65,7 @@ func (p Pow) GetPowNonce(difficulty string, data types.Hash) ([]byte, error) {
 	nonceStr := *work
 	nonceBig, ok := new(big.Int).SetString(nonceStr, 16)
 	if !ok {
-		return nil, errors.New("wrong nonce str")
+		return nil, ErrInvalidParam.with("wrong nonce str")
 	}
 	nonceUint64 := nonceBig.Uint64()
 	nn := make([]byte, 8)
@@ -74,11 +73,11 @@ func (p Pow) GetPowNonce(difficulty string, data types.Hash) ([]byte, error) {
 
 	bd, ok := new(big.Int).SetString(difficulty, 10)
 	if !ok {
-		return nil, errors.New("wrong nonce difficulty")
+		return nil, ErrInvalidParam.with("wrong nonce difficulty")
 	}
 
 	if !pow.CheckPowNonce(bd, nn, data.Bytes()) {
-		return nil, errors.New("check nonce failed")
+		return nil, ErrInvalidParam.with("check nonce failed")
 	}
 
 	return nn, nil
@@ -86,7 +85,7 @@ func (p Pow) GetPowNonce(difficulty string, data types.Hash) ([]byte, error) {
 
 func (p Pow) CancelPow(data types.Hash) error {
 	if err := remote.CancelWork(data.Bytes()); err != nil {
-		return errors.New("pow cancel failed")
+		return ErrInternal.with("pow cancel failed")
 	}
 	return nil
 }
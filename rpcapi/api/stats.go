@@ -1,7 +1,6 @@
 package api
 
 import (
-	"errors"
 	"time"
 
 	"github.com/vitelabs/go-vite/consensus"
@@ -99,7 +98,7 @@ type PeriodStats struct {
 
 func (c StatsApi) GetPeriodSBPStats(startIdx uint64, endIdx uint64) ([]*PeriodStats, error) {
 	if endIdx > startIdx && endIdx-startIdx > 48 {
-		return nil, errors.New("max step is 48")
+		return nil, ErrInvalidParam.with("max step is 48")
 	}
 	var result []*PeriodStats
 	reader := c.cs.SBPReader()
@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/header"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
@@ -82,7 +81,7 @@ func (t TestApi) CreateTxWithPrivKey(params CreateTxWithPrivKeyParmsTest) error
 		return err
 	}
 	if !checkTxToAddressAvailable(params.ToAddr) {
-		return errors.New("ToAddress is invalid")
+		return ErrInvalidParam.with("ToAddress is invalid")
 	}
 	if params.ToAddr == types.AddressDexFund && !dex.VerifyNewOrderPriceForRpc(params.Data) {
 		return dex.InvalidOrderPriceErr
@@ -101,7 +100,7 @@ func (t TestApi) CreateTxWithPrivKey(params CreateTxWithPrivKeyParmsTest) error
 
 	addrState, err := generator.GetAddressStateForGenerator(t.walletApi.chain, &msg.AccountAddress)
 	if err != nil || addrState == nil {
-		return errors.New(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
+		return ErrGenerateBlock.with(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
 	}
 	g, e := generator.NewGenerator(t.walletApi.chain, t.walletApi.consensus, msg.AccountAddress, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
 	if e != nil {
@@ -126,7 +125,7 @@ func (t TestApi) CreateTxWithPrivKey(params CreateTxWithPrivKeyParmsTest) error
 	if result.VMBlock != nil {
 		return t.walletApi.pool.AddDirectAccountBlock(msg.AccountAddress, result.VMBlock)
 	} else {
-		return errors.New("generator gen an empty block")
+		return ErrGenerateBlock.with("generator gen an empty block")
 	}
 }
 
@@ -142,7 +141,7 @@ func (t TestApi) ReceiveOnroadTx(params CreateReceiveTxParms) error {
 	pool := t.walletApi.pool
 
 	if types.IsContractAddr(params.SelfAddr) {
-		return errors.New("AccountTypeContract can't receiveTx without consensus's control")
+		return ErrInvalidParam.with("AccountTypeContract can't receiveTx without consensus's control")
 	}
 
 	msg := &header.IncomingMessage{
@@ -158,22 +157,22 @@ func (t TestApi) ReceiveOnroadTx(params CreateReceiveTxParms) error {
 	pubKey := privKey.PubByte()
 
 	if msg.FromBlockHash == nil {
-		return errors.New("params fromblockhash can't be nil")
+		return ErrInvalidParam.with("params fromblockhash can't be nil")
 	}
 	fromBlock, err := chain.GetAccountBlockByHash(*msg.FromBlockHash)
 	if fromBlock == nil {
 		if err != nil {
 			return err
 		}
-		return errors.New("get sendblock by hash failed")
+		return ErrDataNotFound.with("get sendblock by hash failed")
 	}
 	if fromBlock.ToAddress != msg.AccountAddress {
-		return errors.New("can't receive other address's block")
+		return ErrInvalidParam.with("can't receive other address's block")
 	}
 
 	addrState, err := generator.GetAddressStateForGenerator(t.walletApi.chain, &msg.AccountAddress)
 	if err != nil || addrState == nil {
-		return errors.New(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
+		return ErrGenerateBlock.with(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
 	}
 	g, e := generator.NewGenerator(t.walletApi.chain, t.walletApi.consensus, msg.AccountAddress, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
 	if e != nil {
@@ -192,6 +191,6 @@ func (t TestApi) ReceiveOnroadTx(params CreateReceiveTxParms) error {
 	if result.VMBlock != nil {
 		return pool.AddDirectAccountBlock(msg.AccountAddress, result.VMBlock)
 	} else {
-		return errors.New("generator gen an empty block")
+		return ErrGenerateBlock.with("generator gen an empty block")
 	}
 }
@@ -3,7 +3,6 @@ package api
 import (
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/header"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
@@ -11,6 +10,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/consensus"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
@@ -18,6 +18,7 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm_db"
 	"go.uber.org/atomic"
 )
 
@@ -38,10 +39,10 @@ func NewTxApi(vite *vite.Vite) *Tx {
 func (t Tx) SendRawTx(block *AccountBlock) error {
 	log.Info("SendRawTx")
 	if block == nil {
-		return errors.New("empty block")
+		return ErrInvalidParam.with("empty block")
 	}
 	if !checkTxToAddressAvailable(block.ToAddress) {
-		return errors.New("ToAddress is invalid")
+		return ErrInvalidParam.with("ToAddress is invalid")
 	}
 	lb, err := block.RpcToLedgerBlock()
 	if err != nil {
@@ -52,7 +53,7 @@ func (t Tx) SendRawTx(block *AccountBlock) error {
 	}
 	latestSb := t.vite.Chain().GetLatestSnapshotBlock()
 	if latestSb == nil {
-		return errors.New("failed to get latest snapshotBlock")
+		return ErrNodeNotReady.with("failed to get latest snapshotBlock")
 	}
 	if err := checkSnapshotValid(latestSb); err != nil {
 		return err
@@ -69,32 +70,32 @@ func (t Tx) SendRawTx(block *AccountBlock) error {
 	if result != nil {
 		return t.vite.Pool().AddDirectAccountBlock(result.AccountBlock.AccountAddress, result)
 	} else {
-		return errors.New("generator gen an empty block")
+		return ErrGenerateBlock.with("generator gen an empty block")
 	}
 }
 
 func (t Tx) SendTxWithPrivateKey(param SendTxWithPrivateKeyParam) (*AccountBlock, error) {
 
 	if param.Amount == nil {
-		return nil, errors.New("amount is nil")
+		return nil, ErrInvalidParam.with("amount is nil")
 	}
 
 	if param.SelfAddr == nil {
-		return nil, errors.New("selfAddr is nil")
+		return nil, ErrInvalidParam.with("selfAddr is nil")
 	}
 
 	if param.ToAddr == nil && param.BlockType != ledger.BlockTypeSendCreate {
-		return nil, errors.New("toAddr is nil")
+		return nil, ErrInvalidParam.with("toAddr is nil")
 	}
 
 	if param.ToAddr != nil && !checkTxToAddressAvailable(*param.ToAddr) {
-		return nil, errors.New("ToAddress is invalid")
+		return nil, ErrInvalidParam.with("ToAddress is invalid")
 	}
 	if param.ToAddr != nil && *param.ToAddr == types.AddressDexFund && !dex.VerifyNewOrderPriceForRpc(param.Data) {
 		return nil, dex.InvalidOrderPriceErr
 	}
 	if param.PrivateKey == nil {
-		return nil, errors.New("privateKey is nil")
+		return nil, ErrInvalidParam.with("privateKey is nil")
 	}
 
 	var d *big.Int = nil
@@ -134,7 +135,7 @@ func (t Tx) SendTxWithPrivateKey(param SendTxWithPrivateKeyParam) (*AccountBlock
 
 	addrState, err := generator.GetAddressStateForGenerator(t.vite.Chain(), &msg.AccountAddress)
 	if err != nil || addrState == nil {
-		return nil, errors.New(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
+		return nil, ErrGenerateBlock.with(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
 	}
 	g, e := generator.NewGenerator(t.vite.Chain(), t.vite.Consensus(), msg.AccountAddress, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
 	if e != nil {
@@ -162,10 +163,19 @@ func (t Tx) SendTxWithPrivateKey(param SendTxWithPrivateKeyParam) (*AccountBlock
 		}
 		return ledgerToRpcBlock(t.vite.Chain(), result.VMBlock.AccountBlock)
 	} else {
-		return nil, errors.New("generator gen an empty block")
+		return nil, ErrGenerateBlock.with("generator gen an empty block")
 	}
 }
 
+// GetTransactionStatus returns the stage of the account block: pending in the pool, unconfirmed, snapshot confirmed,
+// received by the recipient or dropped by a rollback. It returns nil if the block is unknown.
+func (t Tx) GetTransactionStatus(hash types.Hash) (*TransactionStatus, error) {
+	if TxTracker == nil {
+		return nil, ErrNodeNotReady.with("transaction status tracker is not started")
+	}
+	return TxTracker.GetTransactionStatus(hash)
+}
+
 type SendTxWithPrivateKeyParam struct {
 	SelfAddr     *types.Address    `json:"selfAddr"`
 	ToAddr       *types.Address    `json:"toAddr"`
@@ -221,6 +231,190 @@ func (t Tx) CalcQuotaRequired(param CalcQuotaRequiredParam) (*CalcQuotaRequiredR
 	return calcQuotaRequired(t.vite.Chain(), param)
 }
 
+type SimulateTxParam struct {
+	Block        *AccountBlock `json:"block"`
+	SnapshotHash *types.Hash   `json:"snapshotHash,omitempty"`
+}
+
+type StorageChange struct {
+	Key    string `json:"key"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type BalanceChange struct {
+	TokenId types.TokenTypeId `json:"tokenId"`
+	Before  string            `json:"before"`
+	After   string            `json:"after"`
+}
+
+type SimulateTxResult struct {
+	SnapshotHash   types.Hash       `json:"snapshotHash"`
+	SnapshotHeight string           `json:"snapshotHeight"`
+	Block          *AccountBlock    `json:"block"`
+	QuotaUsed      string           `json:"quotaUsed"`
+	SendBlockList  []*AccountBlock  `json:"sendBlockList"`
+	VmLogList      ledger.VmLogList `json:"vmLogList"`
+	StorageChanges []*StorageChange `json:"storageChanges"`
+	BalanceChanges []*BalanceChange `json:"balanceChanges"`
+	IsRetry        bool             `json:"isRetry"`
+	RevertReason   *string          `json:"revertReason,omitempty"`
+}
+
+// Simulate runs an unsigned send block, or the receive of an existing send block, through the vm
+// and returns the execution result. Nothing is written to the chain.
+//
+// By default the block is executed on the latest state, the same as the generator does. If the
+// snapshotHash is set, every state read by the vm is served at the height of the snapshot block,
+// the blocks confirmed later are invisible.
+func (t Tx) Simulate(param SimulateTxParam) (*SimulateTxResult, error) {
+	return simulateTx(t.vite.Chain(), t.vite.Consensus(), param)
+}
+
+func simulateTx(c chain.Chain, cs generator.Consensus, param SimulateTxParam) (*SimulateTxResult, error) {
+	msg, err := simulateParamToMessage(param)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb *ledger.SnapshotBlock
+	var stateChain vm_db.HistoryChain = c
+	if param.SnapshotHash != nil {
+		sb, err = c.GetSnapshotHeaderByHash(*param.SnapshotHash)
+		if err != nil {
+			return nil, err
+		}
+		if sb == nil {
+			return nil, ErrDataNotFound.with(fmt.Sprintf("snapshot block %s is not exist", param.SnapshotHash))
+		}
+		if stateChain, err = vm_db.NewSnapshotChain(c, sb.Height); err != nil {
+			return nil, err
+		}
+	} else {
+		sb = c.GetLatestSnapshotBlock()
+		if sb == nil {
+			return nil, ErrNodeNotReady.with("failed to get latest snapshotBlock")
+		}
+	}
+
+	addrState, err := generator.GetAddressStateForGenerator(stateChain, &msg.AccountAddress)
+	if err != nil || addrState == nil {
+		return nil, ErrGenerateBlock.with(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
+	}
+	g, err := generator.NewGenerator(stateChain, cs, msg.AccountAddress, &sb.Hash, addrState.LatestAccountHash)
+	if err != nil {
+		return nil, err
+	}
+	genResult, err := g.GenerateWithMessage(msg, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulateTxResult{
+		SnapshotHash:   sb.Hash,
+		SnapshotHeight: Uint64ToString(sb.Height),
+		QuotaUsed:      "0",
+		IsRetry:        genResult.IsRetry,
+	}
+	if genResult.Err != nil {
+		reason := genResult.Err.Error()
+		result.RevertReason = &reason
+	}
+	if genResult.VMBlock == nil {
+		return result, nil
+	}
+
+	block := genResult.VMBlock.AccountBlock
+	db := genResult.VMBlock.VmDb
+	if result.Block, err = ledgerToRpcBlock(c, block); err != nil {
+		return nil, err
+	}
+	result.QuotaUsed = Uint64ToString(block.QuotaUsed)
+	result.SendBlockList = result.Block.SendBlockList
+	result.VmLogList = db.GetLogList()
+
+	for _, kv := range db.GetUnsavedStorage() {
+		before, err := stateChain.GetValue(block.AccountAddress, kv[0])
+		if err != nil {
+			return nil, err
+		}
+		result.StorageChanges = append(result.StorageChanges, &StorageChange{
+			Key:    hex.EncodeToString(kv[0]),
+			Before: hex.EncodeToString(before),
+			After:  hex.EncodeToString(kv[1]),
+		})
+	}
+	for tokenId, after := range db.GetUnsavedBalanceMap() {
+		before, err := stateChain.GetBalance(block.AccountAddress, tokenId)
+		if err != nil {
+			return nil, err
+		}
+		if before == nil {
+			before = big.NewInt(0)
+		}
+		result.BalanceChanges = append(result.BalanceChanges, &BalanceChange{
+			TokenId: tokenId,
+			Before:  before.String(),
+			After:   after.String(),
+		})
+	}
+	return result, nil
+}
+
+func simulateParamToMessage(param SimulateTxParam) (*header.IncomingMessage, error) {
+	block := param.Block
+	if block == nil {
+		return nil, ErrInvalidParam.with("empty block")
+	}
+	msg := &header.IncomingMessage{
+		BlockType:      block.BlockType,
+		AccountAddress: block.AccountAddress,
+		Data:           block.Data,
+	}
+	if !block.Address.IsZero() {
+		msg.AccountAddress = block.Address
+	}
+
+	if ledger.IsReceiveBlock(block.BlockType) {
+		fromHash := block.FromBlockHash
+		if !block.SendBlockHash.IsZero() {
+			fromHash = block.SendBlockHash
+		}
+		msg.FromBlockHash = &fromHash
+	} else {
+		if block.BlockType != ledger.BlockTypeSendCreate {
+			if !checkTxToAddressAvailable(block.ToAddress) {
+				return nil, ErrInvalidParam.with("ToAddress is invalid")
+			}
+			msg.ToAddress = &block.ToAddress
+		}
+		msg.TokenId = &block.TokenId
+		if block.Amount != nil {
+			amount, ok := new(big.Int).SetString(*block.Amount, 10)
+			if !ok {
+				return nil, ErrStrToBigInt
+			}
+			msg.Amount = amount
+		}
+		if block.Fee != nil {
+			fee, ok := new(big.Int).SetString(*block.Fee, 10)
+			if !ok {
+				return nil, ErrStrToBigInt
+			}
+			msg.Fee = fee
+		}
+	}
+
+	if block.Difficulty != nil {
+		difficulty, ok := new(big.Int).SetString(*block.Difficulty, 10)
+		if !ok {
+			return nil, ErrStrToBigInt
+		}
+		msg.Difficulty = difficulty
+	}
+	return msg, nil
+}
+
 func (tx Tx) autoSend() {
 	if !tx.autoTx {
 		return
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	TxStatusPending     = "pending"     // waiting in the pool
+	TxStatusUnconfirmed = "unconfirmed" // inserted into the chain, not snapshot confirmed yet
+	TxStatusConfirmed   = "confirmed"   // snapshot confirmed, not received by the recipient yet
+	TxStatusReceived    = "received"    // received by the recipient
+	TxStatusDropped     = "dropped"     // rolled back from the chain, or refused by the pool
+)
+
+// the count of the dropped blocks which the reasons are kept
+const txDroppedCacheSize = 10000
+
+// TxTracker is started with the rpc, it is nil if the rpc is not started
+var TxTracker *TxStatusTracker
+
+type TransactionStatus struct {
+	Hash             types.Hash     `json:"hash"`
+	Status           string         `json:"status"`
+	Address          *types.Address `json:"address,omitempty"`
+	Height           string         `json:"height,omitempty"`
+	ConfirmedTimes   string         `json:"confirmedTimes"`
+	ReceiveBlockHash *types.Hash    `json:"receiveBlockHash,omitempty"`
+	Reason           string         `json:"reason,omitempty"`
+}
+
+// txStatusChain is the part of the chain which the statuses are computed from
+type txStatusChain interface {
+	GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+	GetConfirmedTimes(blockHash types.Hash) (uint64, error)
+	GetReceiveAbBySendAb(sendBlockHash types.Hash) (*ledger.AccountBlock, error)
+}
+
+type txStatusPool interface {
+	ExistAccountBlockInPool(hash types.Hash) bool
+}
+
+// TxStatusTracker computes the statuses of the account blocks, and listens to the chain and the pool to keep
+// the reasons of the rolled back blocks and the blocks refused by the pool
+type TxStatusTracker struct {
+	vite    *vite.Vite
+	chain   txStatusChain
+	pool    txStatusPool
+	dropped *lru.Cache // hash -> reason
+
+	log log15.Logger
+}
+
+func NewTxStatusTracker(vite *vite.Vite) *TxStatusTracker {
+	t := newTxStatusTracker(vite.Chain(), vite.Pool())
+	t.vite = vite
+	return t
+}
+
+func newTxStatusTracker(c txStatusChain, p txStatusPool) *TxStatusTracker {
+	dropped, _ := lru.New(txDroppedCacheSize)
+	return &TxStatusTracker{
+		chain:   c,
+		pool:    p,
+		dropped: dropped,
+		log:     log15.New("module", "rpc_api/tx_status"),
+	}
+}
+
+func (t *TxStatusTracker) Start() {
+	t.vite.Chain().Register(t)
+	t.vite.Pool().SetDroppedListener(t.addRefused)
+}
+
+func (t *TxStatusTracker) Stop() {
+	t.vite.Pool().SetDroppedListener(nil)
+	t.vite.Chain().UnRegister(t)
+}
+
+// GetTransactionStatus returns nil if the block is not found in the chain, the pool nor the rolled back blocks
+func (t *TxStatusTracker) GetTransactionStatus(hash types.Hash) (*TransactionStatus, error) {
+	block, err := t.chain.GetAccountBlockByHash(hash)
+	if err != nil {
+		t.log.Error("GetAccountBlockByHash failed, error is "+err.Error(), "method", "GetTransactionStatus")
+		return nil, err
+	}
+	if block == nil {
+		if t.pool.ExistAccountBlockInPool(hash) {
+			return &TransactionStatus{Hash: hash, Status: TxStatusPending, ConfirmedTimes: "0"}, nil
+		}
+		if reason, ok := t.dropped.Get(hash); ok {
+			return &TransactionStatus{Hash: hash, Status: TxStatusDropped, ConfirmedTimes: "0", Reason: reason.(string)}, nil
+		}
+		return nil, nil
+	}
+
+	confirmedTimes, err := t.chain.GetConfirmedTimes(hash)
+	if err != nil {
+		t.log.Error("GetConfirmedTimes failed, error is "+err.Error(), "method", "GetTransactionStatus")
+		return nil, err
+	}
+	status := &TransactionStatus{
+		Hash:           hash,
+		Address:        &block.AccountAddress,
+		Height:         Uint64ToString(block.Height),
+		ConfirmedTimes: Uint64ToString(confirmedTimes),
+	}
+	if block.IsSendBlock() {
+		receiveBlock, err := t.chain.GetReceiveAbBySendAb(hash)
+		if err != nil {
+			t.log.Error("GetReceiveAbBySendAb failed, error is "+err.Error(), "method", "GetTransactionStatus")
+			return nil, err
+		}
+		if receiveBlock != nil {
+			status.Status = TxStatusReceived
+			status.ReceiveBlockHash = &receiveBlock.Hash
+			return status, nil
+		}
+	}
+	if confirmedTimes == 0 {
+		status.Status = TxStatusUnconfirmed
+	} else {
+		status.Status = TxStatusConfirmed
+	}
+	return status, nil
+}
+
+func (t *TxStatusTracker) addDropped(blocks []*ledger.AccountBlock, reason string) {
+	for _, b := range blocks {
+		t.dropped.Add(b.Hash, reason)
+		for _, sendBlock := range b.SendBlockList {
+			t.dropped.Add(sendBlock.Hash, reason)
+		}
+	}
+}
+
+// addRefused keeps the reason of the block refused by the pool
+func (t *TxStatusTracker) addRefused(block *ledger.AccountBlock, reason string) {
+	t.addDropped([]*ledger.AccountBlock{block}, "refused by the pool: "+reason)
+}
+
+func (t *TxStatusTracker) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (t *TxStatusTracker) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (t *TxStatusTracker) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (t *TxStatusTracker) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (t *TxStatusTracker) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (t *TxStatusTracker) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	t.addDropped(blocks, "the account chain is rolled back")
+	return nil
+}
+
+func (t *TxStatusTracker) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (t *TxStatusTracker) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	var height uint64
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil && (height == 0 || chunk.SnapshotBlock.Height < height) {
+			height = chunk.SnapshotBlock.Height
+		}
+	}
+	reason := "the unconfirmed blocks are rolled back with the snapshot chain"
+	if height > 0 {
+		reason = fmt.Sprintf("the snapshot chain is rolled back from height %d", height)
+	}
+	for _, chunk := range chunks {
+		t.addDropped(chunk.AccountBlocks, reason)
+	}
+	return nil
+}
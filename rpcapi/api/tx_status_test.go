@@ -0,0 +1,101 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type mockTxStatusChain struct {
+	blocks         map[types.Hash]*ledger.AccountBlock
+	confirmedTimes map[types.Hash]uint64
+	received       map[types.Hash]*ledger.AccountBlock
+}
+
+func (c *mockTxStatusChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[blockHash], nil
+}
+
+func (c *mockTxStatusChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return c.confirmedTimes[blockHash], nil
+}
+
+func (c *mockTxStatusChain) GetReceiveAbBySendAb(sendBlockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.received[sendBlockHash], nil
+}
+
+type mockTxStatusPool map[types.Hash]bool
+
+func (p mockTxStatusPool) ExistAccountBlockInPool(hash types.Hash) bool {
+	return p[hash]
+}
+
+func TestTxStatusTracker_GetTransactionStatus(t *testing.T) {
+	c := &mockTxStatusChain{
+		blocks:         make(map[types.Hash]*ledger.AccountBlock),
+		confirmedTimes: make(map[types.Hash]uint64),
+		received:       make(map[types.Hash]*ledger.AccountBlock),
+	}
+	p := make(mockTxStatusPool)
+	tracker := newTxStatusTracker(c, p)
+
+	hash := types.DataHash([]byte("send"))
+	check := func(expected string) *TransactionStatus {
+		status, err := tracker.GetTransactionStatus(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected == "" {
+			if status != nil {
+				t.Fatalf("unexpected status %v", status)
+			}
+			return nil
+		}
+		if status == nil || status.Status != expected {
+			t.Fatalf("expected status %s, got %v", expected, status)
+		}
+		return status
+	}
+
+	check("")
+	p[hash] = true
+	check(TxStatusPending)
+
+	delete(p, hash)
+	send := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, Hash: hash, Height: 3}
+	c.blocks[hash] = send
+	if status := check(TxStatusUnconfirmed); status.Height != "3" || status.ConfirmedTimes != "0" {
+		t.Fatalf("unexpected status %v", status)
+	}
+
+	c.confirmedTimes[hash] = 2
+	if status := check(TxStatusConfirmed); status.ConfirmedTimes != "2" {
+		t.Fatalf("unexpected confirmed times %s", status.ConfirmedTimes)
+	}
+
+	receive := &ledger.AccountBlock{BlockType: ledger.BlockTypeReceive, Hash: types.DataHash([]byte("receive")), FromBlockHash: hash}
+	c.received[hash] = receive
+	if status := check(TxStatusReceived); status.ReceiveBlockHash == nil || *status.ReceiveBlockHash != receive.Hash {
+		t.Fatalf("unexpected receive block hash %v", status.ReceiveBlockHash)
+	}
+
+	delete(c.blocks, hash)
+	if err := tracker.DeleteAccountBlocks([]*ledger.AccountBlock{send}); err != nil {
+		t.Fatal(err)
+	}
+	if status := check(TxStatusDropped); status.Reason == "" {
+		t.Fatal("reason of the dropped block is missing")
+	}
+
+	// a block refused by the pool
+	refused := &ledger.AccountBlock{Hash: types.DataHash([]byte("refused"))}
+	tracker.addRefused(refused, "account head not match")
+	status, err := tracker.GetTransactionStatus(refused.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == nil || status.Status != TxStatusDropped || status.Reason != "refused by the pool: account head not match" {
+		t.Fatalf("unexpected status of the refused block %v", status)
+	}
+}
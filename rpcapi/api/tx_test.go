@@ -2,13 +2,24 @@ package api
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	//_ "net/http/pprof"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/header"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/quota"
 
 	"github.com/vitelabs/go-vite/pow"
 
@@ -169,3 +180,207 @@ func Benchmark_Rand(b *testing.B) {
 		crypto.GetEntropyCSPRNG(8)
 	}
 }
+
+func TestSimulateParamToMessage(t *testing.T) {
+	amount := "100"
+	difficulty := "67108863"
+	msg, err := simulateParamToMessage(SimulateTxParam{Block: &AccountBlock{
+		BlockType:  ledger.BlockTypeSendCall,
+		Address:    types.AddressQuota,
+		ToAddress:  types.AddressGovernance,
+		TokenId:    ledger.ViteTokenId,
+		Amount:     &amount,
+		Difficulty: &difficulty,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.AccountAddress != types.AddressQuota || *msg.ToAddress != types.AddressGovernance ||
+		msg.Amount.String() != amount || msg.Difficulty.String() != difficulty || msg.FromBlockHash != nil {
+		t.Fatalf("unexpected send message %+v", msg)
+	}
+
+	sendHash := types.DataHash([]byte("send"))
+	msg, err = simulateParamToMessage(SimulateTxParam{Block: &AccountBlock{
+		BlockType:     ledger.BlockTypeReceive,
+		Address:       types.AddressGovernance,
+		SendBlockHash: sendHash,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *msg.FromBlockHash != sendHash || msg.ToAddress != nil || msg.Amount != nil {
+		t.Fatalf("unexpected receive message %+v", msg)
+	}
+
+	invalid := "1.5"
+	if _, err := simulateParamToMessage(SimulateTxParam{Block: &AccountBlock{BlockType: ledger.BlockTypeSendCall, Amount: &invalid}}); err == nil {
+		t.Fatal("expected error for invalid amount")
+	}
+	if _, err := simulateParamToMessage(SimulateTxParam{}); err == nil {
+		t.Fatal("expected error for empty block")
+	}
+}
+
+const simulateGenesisJson = `{
+  "GenesisAccountAddress": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+  "GovernanceInfo": {
+    "ConsensusGroupInfoMap": {
+      "00000000000000000001": {
+        "NodeCount": 1, "Interval": 1, "PerCount": 3, "RandCount": 2, "RandRank": 100, "Repeat": 1, "CheckLevel": 0,
+        "CountingTokenId": "tti_5649544520544f4b454e6e40", "RegisterConditionId": 1,
+        "RegisterConditionParam": {"StakeAmount": 100000000000000000000000, "StakeHeight": 1, "StakeToken": "tti_5649544520544f4b454e6e40"},
+        "VoteConditionId": 1, "VoteConditionParam": {},
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a", "StakeAmount": 0, "ExpirationHeight": 1
+      }
+    }
+  },
+  "AssetInfo": {
+    "TokenInfoMap": {
+      "tti_5649544520544f4b454e6e40": {
+        "TokenName": "Vite Token", "TokenSymbol": "VITE", "TotalSupply": 1000000000000000000000000000, "Decimals": 18,
+        "Owner": "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a",
+        "MaxSupply": 115792089237316195423570985008687907853269984665640564039457584007913129639935,
+        "IsOwnerBurnOnly": false, "IsReIssuable": true
+      }
+    }
+  },
+  "QuotaInfo": {
+    "StakeInfoMap": {
+      "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a": [
+        {"Amount": 10000000000000000000000000, "ExpirationHeight": 259200, "Beneficiary": "%s"}
+      ]
+    },
+    "StakeBeneficialMap": {
+      "%s": 10000000000000000000000000
+    }
+  },
+  "AccountBalanceMap": {
+    "%s": {
+      "tti_5649544520544f4b454e6e40": 100000000000000000000000000
+    }
+  }
+}`
+
+func TestSimulateTx_History(t *testing.T) {
+	// no fork is active in the chain
+	point := &config.ForkPoint{Height: 10000000, Version: 1}
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork: point, DexFork: point, DexFeeFork: point, StemFork: point,
+		LeafFork: point, EarthFork: point, DexMiningFork: point, DexRobotFork: point, MultisigFork: point,
+	})
+	quota.InitQuotaConfig(false, true)
+	vm.InitVMConfig(false, false, false, false, "")
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := types.PubkeyToAddress(pub)
+	signFunc := func(addr types.Address, data []byte) ([]byte, []byte, error) {
+		return ed25519.Sign(key, data), pub, nil
+	}
+	toAddr, _, _ := types.CreateAddress()
+
+	dir, err := ioutil.TempDir("", "simulate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	genesisConfig := &config.Genesis{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(simulateGenesisJson, addr, addr, addr)), genesisConfig); err != nil {
+		t.Fatal(err)
+	}
+	c := chain.NewChain(dir, &config.Chain{}, genesisConfig)
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	cs := &test_tools.MockConsensus{}
+	amount := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+	// every send is confirmed by a new snapshot block, the genesis block of the account is on height 1
+	var sends []*ledger.AccountBlock
+	for i := 0; i < 2; i++ {
+		latestSb := c.GetLatestSnapshotBlock()
+		addrState, err := generator.GetAddressStateForGenerator(c, &addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := generator.NewGenerator(c, cs, addr, &latestSb.Hash, addrState.LatestAccountHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := g.GenerateWithMessage(&header.IncomingMessage{
+			BlockType:      ledger.BlockTypeSendCall,
+			AccountAddress: addr,
+			ToAddress:      &toAddr,
+			TokenId:        &ledger.ViteTokenId,
+			Amount:         amount,
+		}, &addr, signFunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.VMBlock == nil {
+			t.Fatalf("generate block failed: %v", result.Err)
+		}
+		if err := c.InsertAccountBlock(result.VMBlock); err != nil {
+			t.Fatal(err)
+		}
+		sends = append(sends, result.VMBlock.AccountBlock)
+
+		now := latestSb.Timestamp.Add(time.Second)
+		sb := &ledger.SnapshotBlock{
+			PrevHash:        latestSb.Hash,
+			Height:          latestSb.Height + 1,
+			Timestamp:       &now,
+			SnapshotContent: c.GetContentNeedSnapshot(),
+		}
+		sb.Hash = sb.ComputeHash()
+		if _, err := c.InsertSnapshotBlock(sb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sbAfterSend1, err := c.GetSnapshotBlockByHeight(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := new(big.Int).Mul(big.NewInt(1e8), big.NewInt(1e18))
+	amountStr := "1"
+	for _, tt := range []struct {
+		snapshotHash *types.Hash
+		height       string
+		prevHash     types.Hash
+		before       *big.Int
+	}{
+		{&sbAfterSend1.Hash, "3", sends[0].Hash, new(big.Int).Sub(total, amount)},
+		{nil, "4", sends[1].Hash, new(big.Int).Sub(total, new(big.Int).Mul(amount, big.NewInt(2)))},
+	} {
+		result, err := simulateTx(c, cs, SimulateTxParam{
+			Block: &AccountBlock{
+				BlockType: ledger.BlockTypeSendCall,
+				Address:   addr,
+				ToAddress: toAddr,
+				TokenId:   ledger.ViteTokenId,
+				Amount:    &amountStr,
+			},
+			SnapshotHash: tt.snapshotHash,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.RevertReason != nil {
+			t.Fatalf("simulate failed: %s", *result.RevertReason)
+		}
+		if result.Block.Height != tt.height || result.Block.PrevHash != tt.prevHash {
+			t.Fatalf("block should be on height %s after %s, but get %s after %s", tt.height, tt.prevHash, result.Block.Height, result.Block.PrevHash)
+		}
+		if len(result.BalanceChanges) != 1 || result.BalanceChanges[0].Before != tt.before.String() {
+			t.Fatalf("balance before should be %s, but get %+v", tt.before, result.BalanceChanges)
+		}
+	}
+}
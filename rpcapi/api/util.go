@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -51,7 +50,7 @@ func (p UtilApi) GetPoWNonce(difficulty string, data types.Hash) ([]byte, error)
 	nonceStr := *work
 	nonceBig, ok := new(big.Int).SetString(nonceStr, 16)
 	if !ok {
-		return nil, errors.New("wrong nonce str")
+		return nil, ErrInvalidParam.with("wrong nonce str")
 	}
 	nonceUint64 := nonceBig.Uint64()
 	nn := make([]byte, 8)
@@ -59,11 +58,11 @@ func (p UtilApi) GetPoWNonce(difficulty string, data types.Hash) ([]byte, error)
 
 	bd, ok := new(big.Int).SetString(difficulty, 10)
 	if !ok {
-		return nil, errors.New("wrong nonce difficulty")
+		return nil, ErrInvalidParam.with("wrong nonce difficulty")
 	}
 
 	if !pow.CheckPowNonce(bd, nn, data.Bytes()) {
-		return nil, errors.New("check nonce failed")
+		return nil, ErrInvalidParam.with("check nonce failed")
 	}
 
 	return nn, nil
@@ -85,7 +84,7 @@ func (p Pow) GetPowNoncePrivate(address types.Address, height uint64, difficulty
 		return nil, err
 	}
 	if !flag {
-		return nil, errors.New("auth fail")
+		return nil, ErrPermissionDenied.with("auth fail")
 	}
 	realDifficulty, ok := new(big.Int).SetString(difficulty, 10)
 	if !ok {
@@ -3,7 +3,6 @@ package api
 import (
 	"context"
 	"github.com/hashicorp/golang-lru"
-	"github.com/pkg/errors"
 	"github.com/robfig/cron"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common"
@@ -22,7 +21,7 @@ var (
 	log                              = log15.New("module", "rpc/api")
 	testapi_hexPrivKey               = ""
 	testapi_tti                      = ""
-	convertError                     = errors.New("convert error")
+	convertError                     = ErrInvalidParam.with("convert error")
 	testapi_testtokenlru  *lru.Cache = nil
 	testtokenlruCron      *cron.Cron = nil
 	testtokenlruLimitSize            = 20
@@ -79,7 +78,7 @@ func CheckGetTestTokenIpFrequency(cache *lru.Cache, ctx context.Context) error {
 			if count, ok := cache.Get(ip); ok {
 				c := count.(int)
 				if c >= testtokenlruLimitSize {
-					return errors.New("too frequent")
+					return ErrTooFrequent.with("too frequent")
 				} else {
 					c++
 					cache.Add(ip, c)
@@ -194,7 +193,7 @@ func checkTokenIdValid(chain chain.Chain, tokenId *types.TokenTypeId) error {
 			return err
 		}
 		if tkInfo == nil {
-			return errors.New("tokenId doesn’t exist")
+			return ErrDataNotFound.with("tokenId doesn’t exist")
 		}
 	}
 	return nil
@@ -10,7 +10,6 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
@@ -365,7 +364,7 @@ func compile(fileName string) ([]compileResult, error) {
 	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, errors.New(strings.Trim(string(out), "\n"))
+		return nil, ErrInternal.with(strings.Trim(string(out), "\n"))
 	}
 	list := strings.Split(string(out), "\n")
 	codeList := make([]compileResult, 0)
@@ -373,7 +372,7 @@ func compile(fileName string) ([]compileResult, error) {
 		if runtime.GOOS == "windows" {
 			if strings.HasPrefix(list[i], "=======") && i < len(list)-6 && strings.HasPrefix(list[i+1], "Binary: ") && strings.HasPrefix(list[i+5], "Contract JSON ABI") {
 				if len(list[i+2]) == 0 || len(list[i+6]) == 0 {
-					return nil, errors.New("code len is 0")
+					return nil, ErrInvalidParam.with("code len is 0")
 				}
 				name := list[i][9+len(fileName) : len(list[i])-9]
 				codeList = append(codeList, compileResult{name, list[i+2][:len(list[i+2])-1], list[i+6][:len(list[i+6])-1]})
@@ -382,7 +381,7 @@ func compile(fileName string) ([]compileResult, error) {
 		} else {
 			if strings.HasPrefix(list[i], "=======") && i < len(list)-4 && list[i+1] == "Binary: " && list[i+5] == "Contract JSON ABI " {
 				if len(list[i+2]) == 0 || len(list[i+6]) == 0 {
-					return nil, errors.New("code len is 0")
+					return nil, ErrInvalidParam.with("code len is 0")
 				}
 				name := list[i][9+len(fileName) : len(list[i])-8]
 				codeList = append(codeList, compileResult{name, list[i+2], list[i+6]})
@@ -391,7 +390,7 @@ func compile(fileName string) ([]compileResult, error) {
 		}
 	}
 	if len(codeList) == 0 {
-		return nil, errors.New("contract len is 0")
+		return nil, ErrInvalidParam.with("contract len is 0")
 	}
 	return codeList, nil
 }
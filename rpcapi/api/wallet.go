@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/header"
 	"math/big"
@@ -13,6 +12,7 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
 	"github.com/vitelabs/go-vite/wallet"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
@@ -87,7 +87,7 @@ func (m WalletApi) ListEntropyFilesInStandardDir() ([]string, error) {
 
 func (m WalletApi) ListEntropyStoreAddresses(entropyStore string, from, to uint32) ([]types.Address, error) {
 	if from > to {
-		return nil, errors.New("from value > to")
+		return nil, ErrInvalidParam.with("from value > to")
 	}
 
 	manager, e := m.wallet.GetEntropyStoreManager(entropyStore)
@@ -180,11 +180,12 @@ func (m WalletApi) GlobalCheckAddrUnlocked(addr types.Address) bool {
 }
 
 func (m WalletApi) IsAddrUnlocked(entropyStore string, addr types.Address) bool {
-	manager, e := m.wallet.GetEntropyStoreManager(entropyStore)
+	ks, e := m.wallet.GetKeystore(entropyStore)
 	if e != nil {
 		return false
 	}
-	return manager.IsAddrUnlocked(addr)
+	_, e = ks.FindAddr(addr)
+	return e == nil
 }
 
 func (m WalletApi) RefreshCache() {
@@ -209,24 +210,24 @@ func (m WalletApi) FindAddrWithPassphrase(entropyStore string, passphrase string
 }
 
 func (m WalletApi) FindAddr(entropyStore string, addr types.Address) (findResult *FindAddrResult, e error) {
-	manager, e := m.wallet.GetEntropyStoreManager(entropyStore)
+	ks, e := m.wallet.GetKeystore(entropyStore)
 	if e != nil {
 		return nil, e
 	}
-	_, index, e := manager.FindAddr(addr)
+	index, e := ks.FindAddr(addr)
 	return &FindAddrResult{
-		EntropyStoreFile: manager.GetEntropyStoreFile(),
+		EntropyStoreFile: ks.Name(),
 		Index:            index,
 	}, nil
 }
 
 func (m WalletApi) GlobalFindAddr(addr types.Address) (findResult *FindAddrResult, e error) {
-	path, _, index, e := m.wallet.GlobalFindAddr(addr)
+	ks, index, e := m.wallet.GlobalFindKeystore(addr)
 	if e != nil {
 		return nil, e
 	}
 	return &FindAddrResult{
-		EntropyStoreFile: path,
+		EntropyStoreFile: ks.Name(),
 		Index:            index,
 	}, nil
 }
@@ -252,12 +253,7 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 	if err != nil {
 		return nil, err
 	}
-	_, key, _, e := m.wallet.GlobalFindAddr(addr)
-	if e != nil {
-		return nil, e
-	}
-
-	signedData, pubkey, err := key.SignData(msgbytes)
+	signedData, pubkey, err := m.wallet.GlobalSignData(addr, msgbytes)
 	if err != nil {
 		return nil, err
 	}
@@ -273,7 +269,7 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 
 func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.Hash, error) {
 	if !checkTxToAddressAvailable(params.ToAddr) {
-		return nil, errors.New("ToAddress is invalid")
+		return nil, ErrInvalidParam.with("ToAddress is invalid")
 	}
 	if params.ToAddr == types.AddressDexFund && !dex.VerifyNewOrderPriceForRpc(params.Data) {
 		return nil, dex.InvalidOrderPriceErr
@@ -306,7 +302,7 @@ func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.
 
 	addrState, err := generator.GetAddressStateForGenerator(m.chain, &msg.AccountAddress)
 	if err != nil || addrState == nil {
-		return nil, errors.New(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
+		return nil, ErrGenerateBlock.with(fmt.Sprintf("failed to get addr state for generator, err:%v", err))
 	}
 	g, e := generator.NewGenerator(m.chain, m.consensus, msg.AccountAddress, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
 	if e != nil {
@@ -337,11 +333,69 @@ func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.
 	if result.VMBlock != nil {
 		return &result.VMBlock.AccountBlock.Hash, m.pool.AddDirectAccountBlock(params.SelfAddr, result.VMBlock)
 	} else {
-		return nil, errors.New("generator gen an empty block")
+		return nil, ErrGenerateBlock.with("generator gen an empty block")
 	}
 
 }
 
+type CreateMultisigProposalTxParams struct {
+	EntropystoreFile *string       `json:"entropystoreFile,omitempty"`
+	SelfAddr         types.Address `json:"selfAddr"`
+	Passphrase       string        `json:"passphrase"`
+	Difficulty       *string       `json:"difficulty,omitempty"`
+	MultisigProposeParam
+}
+
+type MultisigProposalTxParams struct {
+	EntropystoreFile *string       `json:"entropystoreFile,omitempty"`
+	SelfAddr         types.Address `json:"selfAddr"`
+	Passphrase       string        `json:"passphrase"`
+	Difficulty       *string       `json:"difficulty,omitempty"`
+	MultisigId       string        `json:"multisigId"`
+	ProposalId       string        `json:"proposalId"`
+}
+
+// ProposeMultisigTxWithPassphrase sends a proposal of a transfer from a multisig account, which is approved
+// by the proposer at the same time
+func (m WalletApi) ProposeMultisigTxWithPassphrase(params CreateMultisigProposalTxParams) (*types.Hash, error) {
+	data, err := getMultisigProposeData(params.MultisigProposeParam)
+	if err != nil {
+		return nil, err
+	}
+	return m.createMultisigTxWithPassphrase(params.EntropystoreFile, params.SelfAddr, params.Passphrase, params.Difficulty, data)
+}
+
+// ApproveMultisigTxWithPassphrase sends an approval of a pending proposal
+func (m WalletApi) ApproveMultisigTxWithPassphrase(params MultisigProposalTxParams) (*types.Hash, error) {
+	data, err := getMultisigProposalData(abi.MethodNameMultisigApprove, params.MultisigId, params.ProposalId)
+	if err != nil {
+		return nil, err
+	}
+	return m.createMultisigTxWithPassphrase(params.EntropystoreFile, params.SelfAddr, params.Passphrase, params.Difficulty, data)
+}
+
+// ExecuteMultisigTxWithPassphrase executes a proposal approved by enough owners
+func (m WalletApi) ExecuteMultisigTxWithPassphrase(params MultisigProposalTxParams) (*types.Hash, error) {
+	data, err := getMultisigProposalData(abi.MethodNameMultisigExecute, params.MultisigId, params.ProposalId)
+	if err != nil {
+		return nil, err
+	}
+	return m.createMultisigTxWithPassphrase(params.EntropystoreFile, params.SelfAddr, params.Passphrase, params.Difficulty, data)
+}
+
+func (m WalletApi) createMultisigTxWithPassphrase(entropystoreFile *string, selfAddr types.Address, passphrase string, difficulty *string, data []byte) (*types.Hash, error) {
+	return m.CreateTxWithPassphrase(CreateTransferTxParms{
+		EntropystoreFile: entropystoreFile,
+		SelfAddr:         selfAddr,
+		ToAddr:           types.AddressMultisig,
+		TokenTypeId:      ledger.ViteTokenId,
+		Passphrase:       passphrase,
+		Amount:           "0",
+		Data:             data,
+		Difficulty:       difficulty,
+	})
+}
+
 func (m WalletApi) SignDataWithPassphrase(addr types.Address, hexMsg string, passphrase string) (*HexSignedTuple, error) {
 
 	msgbytes, err := hex.DecodeString(hexMsg)
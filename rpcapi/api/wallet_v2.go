@@ -1,8 +1,6 @@
 package api
 
 import (
-	"errors"
-
 	"github.com/vitelabs/go-vite/common/types"
 )
 
@@ -19,29 +17,16 @@ func (m WalletApi) ExportMnemonic(entropyFile string, passphrase string) (string
 }
 
 func (m WalletApi) Unlock(entropyFile string, passphrase string) error {
-	manager, e := m.wallet.GetEntropyStoreManager(entropyFile)
-	if e != nil {
-		return e
-	}
-	err := manager.Unlock(passphrase)
-	if err != nil {
-		return err
-	}
-	return nil
+	return m.wallet.Unlock(entropyFile, passphrase)
 }
 
 func (m WalletApi) Lock(entropyFile string) error {
-	manager, e := m.wallet.GetEntropyStoreManager(entropyFile)
-	if e != nil {
-		return e
-	}
-	manager.Lock()
-	return nil
+	return m.wallet.Lock(entropyFile)
 }
 
 func (m WalletApi) DeriveAddressesByIndexRange(entropyFile string, startIndex, endIndex uint32) ([]types.Address, error) {
 	if startIndex > endIndex {
-		return nil, errors.New("from value > to")
+		return nil, ErrInvalidParam.with("from value > to")
 	}
 
 	manager, e := m.wallet.GetEntropyStoreManager(entropyFile)
@@ -146,24 +131,24 @@ type FindAddrResponse struct {
 }
 
 func (m WalletApi) FindAddressInEntropyFile(entropyFile string, address types.Address) (findResult *FindAddrResponse, e error) {
-	manager, e := m.wallet.GetEntropyStoreManager(entropyFile)
+	ks, e := m.wallet.GetKeystore(entropyFile)
 	if e != nil {
 		return nil, e
 	}
-	_, index, e := manager.FindAddr(address)
+	index, e := ks.FindAddr(address)
 	return &FindAddrResponse{
-		EntropyFile: manager.GetEntropyStoreFile(),
+		EntropyFile: ks.Name(),
 		Index:       index,
 	}, nil
 }
 
 func (m WalletApi) FindAddress(address types.Address) (findResult *FindAddrResponse, e error) {
-	path, _, index, e := m.wallet.GlobalFindAddr(address)
+	ks, index, e := m.wallet.GlobalFindKeystore(address)
 	if e != nil {
 		return nil, e
 	}
 	return &FindAddrResponse{
-		EntropyFile: path,
+		EntropyFile: ks.Name(),
 		Index:       index,
 	}, nil
 }
@@ -104,6 +104,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewQuotaApi(vite),
 			Public:    true,
 		}
+	case "multisig":
+		return rpc.API{
+			Namespace: "multisig",
+			Version:   "1.0",
+			Service:   api.NewMultisigApi(vite),
+			Public:    true,
+		}
 	case "dexfund":
 		return rpc.API{
 			Namespace: "dexfund",
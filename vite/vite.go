@@ -13,6 +13,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/eventsink"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/onroad"
@@ -21,6 +22,7 @@ import (
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vm"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 var (
@@ -38,10 +40,14 @@ type Vite struct {
 	pool          pool.BlockPool
 	consensus     consensus.Consensus
 	onRoad        *onroad.Manager
+	eventSink     *eventsink.EventSink
+	autoReceiver  *onroad.AutoReceiver
+	signer        *signer.Client
 }
 
 func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err error) {
 	var addressContext *producer.AddressContext
+	var signerClient *signer.Client
 	if cfg.Producer.Producer && cfg.Producer.Coinbase != "" {
 		var coinbase *types.Address
 		var index uint32
@@ -50,23 +56,45 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 			log.Error(fmt.Sprintf("coinBase parse fail. %v", cfg.Producer.Coinbase), "err", err)
 			return nil, err
 		}
-		err = walletManager.MatchAddress(cfg.EntropyStorePath, *coinbase, index)
 
-		if err != nil {
-			log.Error(fmt.Sprintf("coinBase is not child of entropyStore, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
-			return nil, err
-		}
-
-		var key *derivation.Key
-		_, key, _, err = walletManager.GlobalFindAddr(*coinbase)
-		if err != nil {
-			return
-		}
-
-		cfg.Net.MineKey, err = key.PrivateKey()
-		if err != nil {
-			return
+		if cfg.Producer.RemoteSigner != "" {
+			// the key is kept by the remote signer, which signs only the blocks checked by its policy,
+			// so the node can't prove to its peers that it's the producer of the coinbase
+			signerClient = signer.NewClient(cfg.Producer.RemoteSigner)
+			err = signerClient.CheckAddress(*coinbase)
+			if err != nil {
+				log.Error(fmt.Sprintf("coinBase can't be signed by the remote signer, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
+				return nil, err
+			}
+			log.Warn("the net handshakes are not signed by the coinBase, its key is kept by the remote signer", "coinBase", coinbase)
+		} else {
+			err = walletManager.MatchAddress(cfg.EntropyStorePath, *coinbase, index)
+
+			if err != nil {
+				log.Error(fmt.Sprintf("coinBase is not child of entropyStore, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
+				return nil, err
+			}
+
+			// the key of a hardware keystore can't be exported, the net signs by the keystore
+			if _, e := walletManager.GetEntropyStoreManager(cfg.EntropyStorePath); e == nil {
+				var key *derivation.Key
+				_, key, _, err = walletManager.GlobalFindAddr(*coinbase)
+				if err != nil {
+					return
+				}
+
+				cfg.Net.MineKey, err = key.PrivateKey()
+				if err != nil {
+					return
+				}
+			} else {
+				entryPath, addr := cfg.EntropyStorePath, *coinbase
+				cfg.Net.MineSigner = func(data []byte) (signedData, pubkey []byte, err error) {
+					return walletManager.SignData(entryPath, addr, index, data)
+				}
+			}
 		}
+		cfg.Net.MineAddress = coinbase
 
 		addressContext = &producer.AddressContext{
 			EntryPath: cfg.EntropyStorePath,
@@ -110,17 +138,36 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 		pool:          pl,
 		consensus:     cs,
 		verifier:      verifier,
+		signer:        signerClient,
 	}
 
 	if addressContext != nil {
-		vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, signerClient, pl)
 	}
 
 	// onroad
-	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, walletManager)
+	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, walletManager, signerClient)
 
 	// set onroad
 	vite.onRoad = or
+
+	// event sink
+	if cfg.EventSink != nil && cfg.EventSink.Enabled {
+		vite.eventSink, err = eventsink.NewEventSink(chain, cfg.EventSink, cfg.DataDir)
+		if err != nil {
+			log.Error("new event sink failed, error is "+err.Error(), "method", "vite.New")
+			return nil, err
+		}
+	}
+
+	// auto receive
+	if cfg.AutoReceive != nil && cfg.AutoReceive.Enabled {
+		vite.autoReceiver, err = onroad.NewAutoReceiver(cfg.AutoReceive, chain, net, pl, cs, walletManager)
+		if err != nil {
+			log.Error("new auto receiver failed, error is "+err.Error(), "method", "vite.New")
+			return nil, err
+		}
+	}
 	return
 }
 
@@ -147,6 +194,10 @@ func (v *Vite) Start() (err error) {
 
 	v.chain.Start()
 
+	if v.eventSink != nil {
+		v.eventSink.Start()
+	}
+
 	err = v.consensus.Init()
 	if err != nil {
 		return err
@@ -162,6 +213,9 @@ func (v *Vite) Start() (err error) {
 	}
 
 	v.pool.Start()
+	if v.autoReceiver != nil {
+		v.autoReceiver.Start()
+	}
 	if v.producer != nil {
 
 		if err := v.producer.Start(); err != nil {
@@ -173,6 +227,9 @@ func (v *Vite) Start() (err error) {
 }
 
 func (v *Vite) Stop() (err error) {
+	if v.autoReceiver != nil {
+		v.autoReceiver.Stop()
+	}
 
 	v.net.Stop()
 	v.pool.Stop()
@@ -184,8 +241,14 @@ func (v *Vite) Stop() (err error) {
 		}
 	}
 	v.consensus.Stop()
+	if v.eventSink != nil {
+		v.eventSink.Stop()
+	}
 	v.chain.Stop()
 	v.onRoad.Stop()
+	if v.signer != nil {
+		v.signer.Close()
+	}
 	return nil
 }
 
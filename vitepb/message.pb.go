@@ -58,6 +58,7 @@ type Handshake struct {
 	Key                  []byte   `protobuf:"bytes,10,opt,name=Key,proto3" json:"Key,omitempty"`
 	Token                []byte   `protobuf:"bytes,11,opt,name=Token,proto3" json:"Token,omitempty"`
 	PublicAddress        []byte   `protobuf:"bytes,12,opt,name=PublicAddress,proto3" json:"PublicAddress,omitempty"`
+	VersionToken         []byte   `protobuf:"bytes,13,opt,name=VersionToken,proto3" json:"VersionToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -172,6 +173,13 @@ func (m *Handshake) GetPublicAddress() []byte {
 	return nil
 }
 
+func (m *Handshake) GetVersionToken() []byte {
+	if m != nil {
+		return m.VersionToken
+	}
+	return nil
+}
+
 type SyncConnHandshake struct {
 	ID                   []byte   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
 	Timestamp            int64    `protobuf:"varint,2,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
@@ -1092,55 +1100,55 @@ func init() {
 func init() { proto.RegisterFile("vitepb/message.proto", fileDescriptor_2a6a8486deb9ab39) }
 
 var fileDescriptor_2a6a8486deb9ab39 = []byte{
-	// 788 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0x4b, 0x6f, 0x1a, 0x57,
-	0x14, 0xee, 0xbc, 0x30, 0x1c, 0x03, 0xc5, 0x57, 0xb4, 0x1d, 0xd1, 0x2e, 0xd0, 0xa8, 0xaa, 0x50,
-	0x5b, 0xe3, 0xca, 0xdd, 0x74, 0xd3, 0x56, 0xd8, 0x8e, 0x8d, 0x15, 0x8b, 0x90, 0x0b, 0xca, 0xd6,
-	0x1a, 0x86, 0x23, 0x33, 0xc2, 0xcc, 0x90, 0xb9, 0x17, 0x5b, 0x8e, 0x94, 0x5d, 0x56, 0xf9, 0x3b,
-	0xf9, 0x83, 0xd1, 0x7d, 0xcc, 0x0b, 0x43, 0x94, 0x4d, 0x76, 0xe7, 0x35, 0xe7, 0x3b, 0xdf, 0xb9,
-	0x1f, 0x07, 0x68, 0x3f, 0x84, 0x1c, 0xd7, 0xb3, 0x93, 0x15, 0x32, 0xe6, 0xdf, 0x61, 0x7f, 0x9d,
-	0xc4, 0x3c, 0x26, 0x15, 0x15, 0xed, 0x74, 0x74, 0xd6, 0x0f, 0x82, 0x78, 0x13, 0xf1, 0xdb, 0xd9,
-	0x7d, 0x1c, 0x2c, 0x55, 0x4d, 0xe7, 0x67, 0x9d, 0x63, 0x91, 0xbf, 0x66, 0x8b, 0xb8, 0x94, 0xf4,
-	0x3e, 0x99, 0x50, 0x1b, 0xfa, 0xd1, 0x9c, 0x2d, 0xfc, 0x25, 0x12, 0x17, 0x0e, 0xde, 0x60, 0xc2,
-	0xc2, 0x38, 0x72, 0x8d, 0xae, 0xd1, 0xb3, 0x68, 0xea, 0x92, 0x36, 0x38, 0x23, 0xe4, 0xd7, 0x73,
-	0xd7, 0x94, 0x71, 0xe5, 0x10, 0x02, 0xf6, 0xc8, 0x5f, 0xa1, 0x6b, 0x75, 0x8d, 0x5e, 0x8d, 0x4a,
-	0x9b, 0x34, 0xc1, 0xbc, 0xbe, 0x70, 0xed, 0xae, 0xd1, 0xab, 0x53, 0xf3, 0xfa, 0x82, 0xfc, 0x02,
-	0xb5, 0x69, 0xb8, 0x42, 0xc6, 0xfd, 0xd5, 0xda, 0x75, 0xe4, 0xd7, 0x79, 0x40, 0x20, 0x5e, 0x61,
-	0x84, 0x2c, 0x64, 0x6e, 0x45, 0x7e, 0x92, 0xba, 0xe4, 0x47, 0xa8, 0x0c, 0x31, 0xbc, 0x5b, 0x70,
-	0xf7, 0xa0, 0x6b, 0xf4, 0x6c, 0xaa, 0x3d, 0x81, 0x39, 0x44, 0x7f, 0xee, 0x56, 0x65, 0xb9, 0xb4,
-	0x49, 0x17, 0x0e, 0x2f, 0xc3, 0x7b, 0x1c, 0xcc, 0xe7, 0x09, 0x32, 0xe6, 0xd6, 0x64, 0xaa, 0x18,
-	0x22, 0x2d, 0xb0, 0x5e, 0xe2, 0x93, 0x0b, 0x32, 0x23, 0x4c, 0xc1, 0x68, 0x1a, 0x2f, 0x31, 0x72,
-	0x0f, 0x65, 0x4c, 0x39, 0xe4, 0x57, 0x68, 0x8c, 0x37, 0xb3, 0xfb, 0x30, 0x48, 0x7b, 0xd5, 0x65,
-	0xb6, 0x1c, 0xf4, 0x42, 0x38, 0x9a, 0x3c, 0x45, 0xc1, 0x79, 0x1c, 0x45, 0xf9, 0xf2, 0x14, 0x71,
-	0x63, 0x37, 0x71, 0x73, 0x9b, 0xb8, 0x1e, 0xc8, 0xda, 0x31, 0x90, 0x5d, 0x18, 0xc8, 0x5b, 0x40,
-	0xfd, 0x7c, 0xb1, 0x89, 0x96, 0x14, 0xdf, 0x6e, 0x90, 0x49, 0xfa, 0x97, 0x49, 0xbc, 0x92, 0x38,
-	0x36, 0x95, 0xb6, 0x40, 0x9e, 0xc6, 0x12, 0xc2, 0xa6, 0xe6, 0x34, 0x26, 0x1d, 0xa8, 0x8e, 0x13,
-	0x7c, 0x18, 0xfa, 0x6c, 0xa1, 0x01, 0x32, 0x5f, 0x2c, 0xfc, 0x45, 0x34, 0x97, 0x29, 0x85, 0x93,
-	0xba, 0xde, 0x7b, 0x68, 0x68, 0x24, 0xb6, 0x8e, 0x23, 0x86, 0xdf, 0x0e, 0x4a, 0x74, 0x9e, 0x84,
-	0xef, 0x50, 0xca, 0xc1, 0xa6, 0xd2, 0xf6, 0x3e, 0x9a, 0xe0, 0x4c, 0xb8, 0xcf, 0x91, 0xf4, 0xc0,
-	0x19, 0x23, 0x26, 0xcc, 0x35, 0xba, 0x56, 0xef, 0xf0, 0x94, 0xf4, 0x95, 0x80, 0xfb, 0x32, 0xdb,
-	0x17, 0x29, 0xaa, 0x0a, 0xc4, 0xca, 0xc6, 0x3e, 0x0f, 0x16, 0x72, 0xa0, 0x2a, 0x55, 0x4e, 0xa6,
-	0x10, 0xab, 0xa0, 0x90, 0x5c, 0x4d, 0x76, 0x49, 0x4d, 0xa5, 0x47, 0x82, 0xad, 0x47, 0xea, 0x0c,
-	0xc1, 0x16, 0x40, 0xcf, 0x9e, 0xf6, 0x2f, 0xa8, 0x88, 0x61, 0x36, 0x4c, 0x62, 0x34, 0x4f, 0xdd,
-	0xe7, 0x23, 0xaa, 0x3c, 0xd5, 0x75, 0xde, 0x31, 0x40, 0x1e, 0x25, 0x0d, 0xa8, 0x09, 0xed, 0x60,
-	0xc0, 0x71, 0xde, 0xfa, 0x8e, 0xb4, 0xa0, 0x7e, 0x11, 0xb2, 0x20, 0x8b, 0x18, 0xde, 0x3f, 0x00,
-	0x62, 0x51, 0x05, 0xc9, 0x8b, 0x2d, 0x1a, 0x9a, 0x90, 0x58, 0x61, 0x4e, 0xc8, 0x2c, 0x12, 0xf2,
-	0x5e, 0xc1, 0xf7, 0xf9, 0x97, 0xe3, 0x38, 0x8c, 0xb8, 0xdc, 0xa7, 0x30, 0xe4, 0xf7, 0x85, 0x7d,
-	0xe6, 0x75, 0x54, 0x15, 0x64, 0xef, 0x62, 0x16, 0xde, 0x65, 0x00, 0xcd, 0xbc, 0xf0, 0x26, 0x64,
-	0x9c, 0x9c, 0x40, 0x45, 0x96, 0xa7, 0x0f, 0xf4, 0xd3, 0xf3, 0x86, 0x32, 0x4f, 0x75, 0x99, 0x77,
-	0x0b, 0x47, 0x57, 0xc8, 0xb7, 0xba, 0xfc, 0x96, 0xa9, 0xcb, 0xda, 0x33, 0x94, 0x52, 0x9c, 0x98,
-	0x89, 0xe3, 0x3a, 0x9b, 0x89, 0xe3, 0x5a, 0xab, 0xd0, 0x4a, 0x55, 0xe8, 0x2d, 0x25, 0xc0, 0x44,
-	0x1f, 0xb8, 0x33, 0x71, 0xdf, 0x58, 0x01, 0xc0, 0xf8, 0x22, 0x40, 0x1b, 0x9c, 0x73, 0x71, 0x34,
-	0x35, 0x82, 0x72, 0x84, 0x78, 0x2f, 0xe3, 0xe4, 0xd1, 0x4f, 0x94, 0x8e, 0xaa, 0x34, 0x75, 0xbd,
-	0xff, 0xa1, 0xb9, 0x85, 0x74, 0x0c, 0x15, 0x65, 0x69, 0x32, 0x3f, 0x64, 0x72, 0x28, 0xd6, 0x51,
-	0x5d, 0xe4, 0x7d, 0x30, 0xa0, 0x75, 0x85, 0x7c, 0xa0, 0x6e, 0xb5, 0xee, 0xe1, 0xc2, 0x41, 0x7a,
-	0x72, 0xd4, 0x33, 0xa7, 0x6e, 0xc6, 0xc3, 0xfc, 0x5a, 0x1e, 0xd6, 0x1e, 0x1e, 0x76, 0x99, 0xc7,
-	0xbf, 0xd0, 0x28, 0x8f, 0xf0, 0xe7, 0x16, 0x8d, 0x76, 0x0a, 0x55, 0x2c, 0xcb, 0x58, 0xbc, 0x86,
-	0xd6, 0x08, 0x1f, 0x4b, 0x0c, 0xc9, 0x1f, 0xe0, 0x48, 0x43, 0xef, 0x7c, 0xcf, 0x1e, 0x54, 0x8d,
-	0xb8, 0x80, 0xd3, 0xe9, 0x8d, 0xa4, 0xe5, 0x50, 0x61, 0x0a, 0xed, 0x8e, 0xf0, 0xb1, 0x88, 0x46,
-	0x7e, 0x2f, 0x77, 0xdc, 0x3d, 0xd2, 0xde, 0x86, 0xff, 0x41, 0x7b, 0xab, 0xe1, 0xd9, 0x13, 0x47,
-	0x79, 0x37, 0xf2, 0xae, 0xf5, 0xfd, 0xdf, 0x0f, 0xc0, 0x99, 0x26, 0x7e, 0x80, 0x3b, 0x7f, 0x81,
-	0x04, 0xec, 0xb1, 0xcf, 0xc5, 0xed, 0xb1, 0x44, 0x4c, 0xd8, 0x69, 0x0b, 0xf1, 0x02, 0x0d, 0xd9,
-	0x62, 0x56, 0x91, 0xff, 0xb3, 0x7f, 0x7f, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x61, 0x22, 0x50, 0xa0,
-	0xc0, 0x07, 0x00, 0x00,
+	// 787 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb5, 0x55, 0x4b, 0x6f, 0xd3, 0x40,
+	0x10, 0x26, 0xb6, 0x93, 0x26, 0xd3, 0x24, 0xa4, 0xab, 0x00, 0x56, 0xe0, 0x50, 0x59, 0x08, 0x55,
+	0x40, 0x53, 0x54, 0x2e, 0x5c, 0x00, 0xa5, 0x2d, 0x6d, 0x2a, 0xaa, 0x12, 0x36, 0x51, 0xaf, 0x95,
+	0x63, 0xaf, 0x1a, 0x2b, 0x8d, 0x1d, 0x6c, 0xa7, 0x55, 0x91, 0xb8, 0x71, 0xe2, 0x0f, 0xf2, 0x77,
+	0xd8, 0x9d, 0x5d, 0xbf, 0xd2, 0x04, 0x71, 0xe1, 0x36, 0xaf, 0x9d, 0x6f, 0x1e, 0x9f, 0xc7, 0xd0,
+	0xbe, 0xf1, 0x62, 0x36, 0x1f, 0xef, 0xcd, 0x58, 0x14, 0xd9, 0x57, 0xac, 0x3b, 0x0f, 0x83, 0x38,
+	0x20, 0x15, 0x69, 0xed, 0x74, 0x94, 0xd7, 0x76, 0x9c, 0x60, 0xe1, 0xc7, 0x97, 0xe3, 0xeb, 0xc0,
+	0x99, 0xca, 0x98, 0xce, 0x53, 0xe5, 0x8b, 0x7c, 0x7b, 0x1e, 0x4d, 0x82, 0x82, 0xd3, 0xfa, 0xad,
+	0x41, 0xad, 0x6f, 0xfb, 0x6e, 0x34, 0xb1, 0xa7, 0x8c, 0x98, 0xb0, 0x71, 0xc1, 0xc2, 0xc8, 0x0b,
+	0x7c, 0xb3, 0xb4, 0x5d, 0xda, 0xd1, 0x69, 0xa2, 0x92, 0x36, 0x94, 0xcf, 0x59, 0x7c, 0xea, 0x9a,
+	0x1a, 0xda, 0xa5, 0x42, 0x08, 0x18, 0xe7, 0xf6, 0x8c, 0x99, 0x3a, 0x37, 0xd6, 0x28, 0xca, 0xa4,
+	0x09, 0xda, 0xe9, 0x91, 0x69, 0x70, 0x4b, 0x9d, 0x72, 0x89, 0x3c, 0x83, 0xda, 0xc8, 0xe3, 0x55,
+	0xc7, 0xf6, 0x6c, 0x6e, 0x96, 0xf1, 0x75, 0x66, 0x10, 0x88, 0x27, 0xcc, 0x67, 0x91, 0x17, 0x99,
+	0x15, 0x7c, 0x92, 0xa8, 0xe4, 0x31, 0x54, 0xfa, 0xcc, 0xbb, 0x9a, 0xc4, 0xe6, 0x06, 0x77, 0x18,
+	0x54, 0x69, 0x02, 0xb3, 0xcf, 0x6c, 0xd7, 0xac, 0x62, 0x38, 0xca, 0x64, 0x1b, 0x36, 0x8f, 0xbd,
+	0x6b, 0xd6, 0x73, 0xdd, 0x90, 0x8f, 0xc7, 0xac, 0xa1, 0x2b, 0x6f, 0x22, 0x2d, 0xd0, 0x3f, 0xb3,
+	0x3b, 0x13, 0xd0, 0x23, 0x44, 0xd1, 0xd1, 0x28, 0x98, 0x32, 0xdf, 0xdc, 0x44, 0x9b, 0x54, 0xc8,
+	0x73, 0x68, 0x0c, 0x16, 0xe3, 0x6b, 0xcf, 0x49, 0x72, 0xd5, 0xd1, 0x5b, 0x34, 0x12, 0x0b, 0xea,
+	0x6a, 0x30, 0x32, 0x45, 0x03, 0x83, 0x0a, 0x36, 0xcb, 0x83, 0xad, 0xe1, 0x9d, 0xef, 0x1c, 0x06,
+	0xbe, 0x9f, 0x0d, 0x58, 0x0e, 0xa7, 0xb4, 0x7a, 0x38, 0xda, 0xf2, 0x70, 0x54, 0xd1, 0xfa, 0x8a,
+	0xa2, 0x8d, 0x5c, 0xd1, 0xd6, 0x04, 0xea, 0x87, 0x93, 0x85, 0x3f, 0xa5, 0xec, 0xdb, 0x82, 0x3f,
+	0x15, 0x23, 0x3a, 0x0e, 0x83, 0x19, 0xe2, 0x18, 0x14, 0x65, 0x81, 0x3c, 0x0a, 0x10, 0xc2, 0xa0,
+	0x5c, 0x22, 0x1d, 0xa8, 0x0e, 0x42, 0x76, 0xd3, 0xb7, 0xa3, 0x89, 0x02, 0x48, 0x75, 0xb1, 0x94,
+	0x4f, 0xbe, 0x8b, 0x2e, 0x89, 0x93, 0xa8, 0xd6, 0x0f, 0x68, 0x28, 0xa4, 0x68, 0x1e, 0xf8, 0x11,
+	0xfb, 0x7f, 0x50, 0x22, 0xf3, 0xd0, 0xfb, 0xce, 0x90, 0x32, 0x3c, 0xb3, 0x90, 0xad, 0x5f, 0x1a,
+	0x94, 0x87, 0xb1, 0x1d, 0x33, 0xb2, 0x03, 0xe5, 0x01, 0xe3, 0xe3, 0xe6, 0xc0, 0xfa, 0xce, 0xe6,
+	0x3e, 0xe9, 0x4a, 0x92, 0x77, 0xd1, 0xdb, 0x15, 0x2e, 0x2a, 0x03, 0xc4, 0xc8, 0x06, 0x76, 0xec,
+	0x4c, 0xb0, 0xa0, 0x2a, 0x95, 0x4a, 0xca, 0x22, 0x3d, 0xc7, 0xa2, 0x8c, 0x71, 0x46, 0x81, 0x71,
+	0x85, 0x25, 0xc1, 0xd2, 0x92, 0x3a, 0x7d, 0x30, 0x04, 0xd0, 0xbd, 0xd5, 0xbe, 0x81, 0x8a, 0x28,
+	0x66, 0x11, 0x21, 0x46, 0x73, 0xdf, 0xbc, 0x5f, 0xa2, 0xf4, 0x53, 0x15, 0x67, 0xed, 0x02, 0x64,
+	0x56, 0xd2, 0x80, 0x9a, 0xe0, 0x0e, 0x73, 0x62, 0xe6, 0xb6, 0x1e, 0x70, 0x2e, 0xd4, 0x8f, 0xbc,
+	0xc8, 0x49, 0x2d, 0x25, 0xeb, 0x1d, 0x80, 0x18, 0x54, 0xee, 0xb3, 0x10, 0x53, 0x2c, 0xa9, 0x86,
+	0xc4, 0x08, 0xb3, 0x86, 0xb4, 0x7c, 0x43, 0xd6, 0x17, 0x78, 0x98, 0xbd, 0x1c, 0x04, 0x9e, 0x1f,
+	0xe3, 0x3c, 0x85, 0x80, 0xef, 0x73, 0xf3, 0xcc, 0xe2, 0xa8, 0x0c, 0x48, 0xf7, 0xa2, 0xe5, 0xf6,
+	0xd2, 0x83, 0x66, 0x16, 0x78, 0xe6, 0x71, 0x0a, 0xee, 0x41, 0x05, 0xc3, 0x93, 0x05, 0x3d, 0xb9,
+	0x9f, 0x10, 0xfd, 0x54, 0x85, 0x59, 0x97, 0xb0, 0x75, 0xc2, 0xe2, 0xa5, 0x2c, 0x2f, 0x52, 0x76,
+	0xe9, 0x6b, 0x8a, 0x92, 0x8c, 0x13, 0x35, 0x71, 0x4f, 0x5a, 0x13, 0x97, 0x15, 0x0b, 0xf5, 0x84,
+	0x85, 0xd6, 0x14, 0x01, 0x86, 0xea, 0x08, 0x1e, 0x88, 0x1b, 0x18, 0xe5, 0x00, 0x4a, 0x7f, 0x05,
+	0xe0, 0x24, 0x3a, 0x14, 0x87, 0x55, 0x21, 0x48, 0x45, 0x90, 0xf7, 0x38, 0x08, 0x6f, 0xed, 0x50,
+	0xf2, 0xa8, 0x4a, 0x13, 0xd5, 0xfa, 0x08, 0xcd, 0x25, 0xa4, 0x5d, 0xa8, 0x48, 0x49, 0x35, 0xf3,
+	0x28, 0xa5, 0x43, 0x3e, 0x8e, 0xaa, 0x20, 0xeb, 0x67, 0x09, 0x5a, 0xbc, 0xdc, 0x9e, 0xbc, 0xe7,
+	0x2a, 0x07, 0xc7, 0x4b, 0xce, 0x92, 0x5c, 0x73, 0xa2, 0xa6, 0x7d, 0x68, 0xff, 0xda, 0x87, 0xbe,
+	0xa6, 0x0f, 0xa3, 0xd8, 0xc7, 0x7b, 0x68, 0x14, 0x4b, 0x78, 0xbd, 0xd4, 0x46, 0x3b, 0x81, 0xca,
+	0x87, 0xa5, 0x5d, 0x7c, 0x85, 0xd6, 0x39, 0xbb, 0x2d, 0x74, 0x48, 0x5e, 0x41, 0x19, 0x05, 0x35,
+	0xf3, 0x35, 0x73, 0x90, 0x31, 0xe2, 0x02, 0x8e, 0x46, 0x67, 0xd8, 0x56, 0x99, 0x0a, 0x51, 0x70,
+	0x97, 0xa7, 0xcc, 0xa3, 0x91, 0x97, 0xc5, 0x8c, 0xab, 0x4b, 0x5a, 0x9b, 0xf0, 0x03, 0xb4, 0x97,
+	0x12, 0x1e, 0xdc, 0xc5, 0x0c, 0xef, 0x46, 0x96, 0xb5, 0xbe, 0xfe, 0x7d, 0x8f, 0x9f, 0xe4, 0xd0,
+	0x76, 0xd8, 0xca, 0x2f, 0x90, 0xdb, 0xf8, 0xbd, 0x11, 0xb7, 0x47, 0x17, 0x36, 0x21, 0x27, 0x29,
+	0xc4, 0x06, 0x1a, 0x98, 0x62, 0x5c, 0xc1, 0x7f, 0xf1, 0xdb, 0x3f, 0x99, 0xcd, 0xd4, 0x8f, 0xe4,
+	0x07, 0x00, 0x00,
 }
@@ -61,5 +61,19 @@ func (c *contract) run(vm *VM) (ret []byte, err error) {
 		c.intPool = nil
 	}()
 
+	if vm.tracer != nil {
+		quota := c.quotaLeft
+		vm.tracer.CaptureEnter(c.block.AccountAddress, c.codeAddr, c.data, quota, vm.depth)
+		vm.depth++
+		defer func() {
+			vm.depth--
+			quotaUsed := uint64(0)
+			if quota > c.quotaLeft {
+				quotaUsed = quota - c.quotaLeft
+			}
+			vm.tracer.CaptureExit(ret, quotaUsed, vm.depth, err)
+		}()
+	}
+
 	return vm.i.runLoop(vm, c)
 }
@@ -0,0 +1,338 @@
+package abi
+
+import (
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+	"math/big"
+	"strings"
+)
+
+const (
+	jsonMultisig = `
+	[
+		{"type":"function","name":"CreateMultisig","inputs":[{"name":"owners","type":"address[]"},{"name":"threshold","type":"uint8"}]},
+		{"type":"function","name":"Deposit","inputs":[{"name":"id","type":"uint64"}]},
+		{"type":"function","name":"Propose","inputs":[{"name":"id","type":"uint64"},{"name":"to","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}]},
+		{"type":"function","name":"Approve","inputs":[{"name":"id","type":"uint64"},{"name":"proposalId","type":"uint64"}]},
+		{"type":"function","name":"Execute","inputs":[{"name":"id","type":"uint64"},{"name":"proposalId","type":"uint64"}]},
+
+		{"type":"variable","name":"multisigInfo","inputs":[{"name":"threshold","type":"uint8"},{"name":"proposalCount","type":"uint64"},{"name":"owners","type":"address[]"}]},
+		{"type":"variable","name":"multisigBalance","inputs":[{"name":"amount","type":"uint256"}]},
+		{"type":"variable","name":"multisigProposal","inputs":[{"name":"proposer","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"},{"name":"approvals","type":"address[]"}]},
+
+		{"type":"event","name":"createMultisig","inputs":[{"name":"id","type":"uint64","indexed":true},{"name":"creator","type":"address"}]},
+		{"type":"event","name":"deposit","inputs":[{"name":"id","type":"uint64","indexed":true},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"}]},
+		{"type":"event","name":"propose","inputs":[{"name":"id","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64","indexed":true},{"name":"proposer","type":"address"}]},
+		{"type":"event","name":"approve","inputs":[{"name":"id","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64","indexed":true},{"name":"approver","type":"address"}]},
+		{"type":"event","name":"execute","inputs":[{"name":"id","type":"uint64","indexed":true},{"name":"proposalId","type":"uint64","indexed":true},{"name":"executor","type":"address"}]}
+	]`
+
+	MethodNameCreateMultisig     = "CreateMultisig"
+	MethodNameMultisigDeposit    = "Deposit"
+	MethodNameMultisigPropose    = "Propose"
+	MethodNameMultisigApprove    = "Approve"
+	MethodNameMultisigExecute    = "Execute"
+	VariableNameMultisigInfo     = "multisigInfo"
+	VariableNameMultisigBalance  = "multisigBalance"
+	VariableNameMultisigProposal = "multisigProposal"
+
+	multisigIdSize          = 8
+	multisigInfoKeySize     = 1 + multisigIdSize                         // 9byte, 1 + 8byte multisig id
+	multisigBalanceKeySize  = 1 + multisigIdSize + types.TokenTypeIdSize // 19byte, 1 + 8byte multisig id + 10byte token id
+	multisigProposalKeySize = 1 + multisigIdSize + multisigIdSize        // 17byte, 1 + 8byte multisig id + 8byte proposal id
+)
+
+var (
+	// ABIMultisig is abi definition of multisig contract
+	ABIMultisig, _ = abi.JSONToABIContract(strings.NewReader(jsonMultisig))
+
+	multisigCountKey          = []byte{0}
+	multisigInfoKeyPrefix     = []byte{1}
+	multisigBalanceKeyPrefix  = []byte{2}
+	multisigProposalKeyPrefix = []byte{3}
+)
+
+// MultisigInfo defines an M-of-N owner set in multisig contract
+type MultisigInfo struct {
+	Id            uint64
+	Threshold     uint8
+	ProposalCount uint64
+	Owners        []types.Address
+}
+
+// IsOwner checks whether an address is one of the owners
+func (m *MultisigInfo) IsOwner(addr types.Address) bool {
+	for _, owner := range m.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// MultisigProposal defines a pending transfer of a multisig account
+type MultisigProposal struct {
+	MultisigId uint64
+	Id         uint64
+	Proposer   types.Address
+	To         types.Address
+	TokenId    types.TokenTypeId
+	Amount     *big.Int
+	Data       []byte
+	Approvals  []types.Address
+}
+
+// IsApprovedBy checks whether an owner has approved the proposal
+func (p *MultisigProposal) IsApprovedBy(addr types.Address) bool {
+	for _, approver := range p.Approvals {
+		if approver == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// VariableMultisigBalance defines variable of balance of a multisig account
+type VariableMultisigBalance struct {
+	Amount *big.Int
+}
+
+// ParamCreateMultisig defines parameters of create multisig method in multisig contract
+type ParamCreateMultisig struct {
+	Owners    []types.Address
+	Threshold uint8
+}
+
+// ParamMultisigPropose defines parameters of propose method in multisig contract
+type ParamMultisigPropose struct {
+	Id      uint64
+	To      types.Address
+	TokenId types.TokenTypeId
+	Amount  *big.Int
+	Data    []byte
+}
+
+// ParamMultisigProposal defines parameters of approve and execute method in multisig contract
+type ParamMultisigProposal struct {
+	Id         uint64
+	ProposalId uint64
+}
+
+func multisigIdToBytes(id uint64) []byte {
+	return helper.LeftPadBytes(new(big.Int).SetUint64(id).Bytes(), multisigIdSize)
+}
+
+func bytesToMultisigId(b []byte) uint64 {
+	return new(big.Int).SetBytes(b).Uint64()
+}
+
+// GetMultisigCountKey generate db key for count of multisig accounts, which is the id of the latest one
+func GetMultisigCountKey() []byte {
+	return multisigCountKey
+}
+
+// PackMultisigCount generate db value for count of multisig accounts
+func PackMultisigCount(count uint64) []byte {
+	return multisigIdToBytes(count)
+}
+
+// GetMultisigCount query count of multisig accounts
+func GetMultisigCount(db StorageDatabase) (uint64, error) {
+	if *db.Address() != types.AddressMultisig {
+		return 0, util.ErrAddressNotMatch
+	}
+	value, err := db.GetValue(multisigCountKey)
+	if err != nil {
+		return 0, err
+	}
+	return bytesToMultisigId(value), nil
+}
+
+// GetMultisigInfoKey generate db key for multisig info
+func GetMultisigInfoKey(id uint64) []byte {
+	return helper.JoinBytes(multisigInfoKeyPrefix, multisigIdToBytes(id))
+}
+
+func isMultisigInfoKey(key []byte) bool {
+	return len(key) == multisigInfoKeySize && key[0] == multisigInfoKeyPrefix[0]
+}
+
+// GetMultisigBalanceKey generate db key for balance of a multisig account
+func GetMultisigBalanceKey(id uint64, tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes(multisigBalanceKeyPrefix, multisigIdToBytes(id), tokenId.Bytes())
+}
+
+func isMultisigBalanceKey(key []byte) bool {
+	return len(key) == multisigBalanceKeySize && key[0] == multisigBalanceKeyPrefix[0]
+}
+
+// GetMultisigProposalKey generate db key for proposal of a multisig account
+func GetMultisigProposalKey(id uint64, proposalId uint64) []byte {
+	return helper.JoinBytes(multisigProposalKeyPrefix, multisigIdToBytes(id), multisigIdToBytes(proposalId))
+}
+
+func isMultisigProposalKey(key []byte) bool {
+	return len(key) == multisigProposalKeySize && key[0] == multisigProposalKeyPrefix[0]
+}
+
+// GetMultisigInfo query multisig info by id, returns nil if not exists
+func GetMultisigInfo(db StorageDatabase, id uint64) (*MultisigInfo, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	value, err := db.GetValue(GetMultisigInfoKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return UnpackMultisigInfo(id, value)
+}
+
+// UnpackMultisigInfo decode multisig info from db value
+func UnpackMultisigInfo(id uint64, value []byte) (*MultisigInfo, error) {
+	info := new(MultisigInfo)
+	if err := ABIMultisig.UnpackVariable(info, VariableNameMultisigInfo, value); err != nil {
+		return nil, err
+	}
+	info.Id = id
+	return info, nil
+}
+
+// GetMultisigListByOwner query all multisig accounts owned by an address
+func GetMultisigListByOwner(db StorageDatabase, owner types.Address) ([]*MultisigInfo, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator(multisigInfoKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	infoList := make([]*MultisigInfo, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), isMultisigInfoKey) {
+			continue
+		}
+		info, err := UnpackMultisigInfo(bytesToMultisigId(iterator.Key()[1:]), iterator.Value())
+		if err == nil && info.IsOwner(owner) {
+			infoList = append(infoList, info)
+		}
+	}
+	return infoList, nil
+}
+
+// GetMultisigBalance query balance of a token of a multisig account
+func GetMultisigBalance(db StorageDatabase, id uint64, tokenId types.TokenTypeId) (*big.Int, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	value, err := db.GetValue(GetMultisigBalanceKey(id, tokenId))
+	if err != nil {
+		return nil, err
+	}
+	return unpackMultisigBalance(value), nil
+}
+
+func unpackMultisigBalance(value []byte) *big.Int {
+	if len(value) == 0 {
+		return big.NewInt(0)
+	}
+	balance := new(VariableMultisigBalance)
+	ABIMultisig.UnpackVariable(balance, VariableNameMultisigBalance, value)
+	return balance.Amount
+}
+
+// GetMultisigBalanceList query balances of all tokens of a multisig account
+func GetMultisigBalanceList(db StorageDatabase, id uint64) (map[types.TokenTypeId]*big.Int, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator(helper.JoinBytes(multisigBalanceKeyPrefix, multisigIdToBytes(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	balanceMap := make(map[types.TokenTypeId]*big.Int)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), isMultisigBalanceKey) {
+			continue
+		}
+		tokenId, err := types.BytesToTokenTypeId(iterator.Key()[1+multisigIdSize:])
+		if err != nil {
+			continue
+		}
+		balanceMap[tokenId] = unpackMultisigBalance(iterator.Value())
+	}
+	return balanceMap, nil
+}
+
+// GetMultisigProposal query a pending proposal of a multisig account, returns nil if not exists
+func GetMultisigProposal(db StorageDatabase, id uint64, proposalId uint64) (*MultisigProposal, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	value, err := db.GetValue(GetMultisigProposalKey(id, proposalId))
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return UnpackMultisigProposal(id, proposalId, value)
+}
+
+// UnpackMultisigProposal decode proposal from db value
+func UnpackMultisigProposal(id uint64, proposalId uint64, value []byte) (*MultisigProposal, error) {
+	proposal := new(MultisigProposal)
+	if err := ABIMultisig.UnpackVariable(proposal, VariableNameMultisigProposal, value); err != nil {
+		return nil, err
+	}
+	proposal.MultisigId = id
+	proposal.Id = proposalId
+	return proposal, nil
+}
+
+// GetMultisigProposalList query all pending proposals of a multisig account, ordered by proposal id
+func GetMultisigProposalList(db StorageDatabase, id uint64) ([]*MultisigProposal, error) {
+	if *db.Address() != types.AddressMultisig {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator(helper.JoinBytes(multisigProposalKeyPrefix, multisigIdToBytes(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	proposalList := make([]*MultisigProposal, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), isMultisigProposalKey) {
+			continue
+		}
+		proposal, err := UnpackMultisigProposal(id, bytesToMultisigId(iterator.Key()[1+multisigIdSize:]), iterator.Value())
+		if err == nil {
+			proposalList = append(proposalList, proposal)
+		}
+	}
+	return proposalList, nil
+}
@@ -59,6 +59,7 @@ var (
 	leafContracts     = newLeafContracts()
 	earthContracts    = newEarthContracts()
 	dexRobotContracts = newDexRobotContracts()
+	multisigContracts = newMultisigContracts()
 )
 
 func newSimpleContracts() map[types.Address]*builtinContract {
@@ -226,10 +227,27 @@ func newDexRobotContracts() map[types.Address]*builtinContract {
 
 }
 
+func newMultisigContracts() map[types.Address]*builtinContract {
+	contracts := newDexRobotContracts()
+	contracts[types.AddressMultisig] = &builtinContract{
+		map[string]BuiltinContractMethod{
+			cabi.MethodNameCreateMultisig:  &MethodCreateMultisig{cabi.MethodNameCreateMultisig},
+			cabi.MethodNameMultisigDeposit: &MethodMultisigDeposit{cabi.MethodNameMultisigDeposit},
+			cabi.MethodNameMultisigPropose: &MethodMultisigPropose{cabi.MethodNameMultisigPropose},
+			cabi.MethodNameMultisigApprove: &MethodMultisigApprove{cabi.MethodNameMultisigApprove},
+			cabi.MethodNameMultisigExecute: &MethodMultisigExecute{cabi.MethodNameMultisigExecute},
+		},
+		cabi.ABIMultisig,
+	}
+	return contracts
+}
+
 // GetBuiltinContractMethod finds method instance of built-in contract method by address and method id
 func GetBuiltinContractMethod(addr types.Address, methodSelector []byte, sbHeight uint64) (BuiltinContractMethod, bool, error) {
 	var contractsMap map[types.Address]*builtinContract
-	if fork.IsDexRobotFork(sbHeight) {
+	if fork.IsMultisigFork(sbHeight) {
+		contractsMap = multisigContracts
+	} else if fork.IsDexRobotFork(sbHeight) {
 		contractsMap = dexRobotContracts
 	} else if fork.IsEarthFork(sbHeight) {
 		contractsMap = earthContracts
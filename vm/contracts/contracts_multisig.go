@@ -0,0 +1,305 @@
+package contracts
+
+import (
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+	"math/big"
+)
+
+// Multisig contract keeps balances of M-of-N multisig accounts. An owner proposes a
+// transfer from the balance of the account, the transfer is executed by any owner
+// after it's approved by at least threshold owners.
+
+type MethodCreateMultisig struct {
+	MethodName string
+}
+
+func (p *MethodCreateMultisig) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodCreateMultisig) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodCreateMultisig) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigCreateQuota, nil
+}
+
+func (p *MethodCreateMultisig) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodCreateMultisig) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamCreateMultisig)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if param.Threshold == 0 || int(param.Threshold) > len(param.Owners) || len(param.Owners) > multisigOwnerCountMax {
+		return util.ErrInvalidMethodParam
+	}
+	owners := make(map[types.Address]bool, len(param.Owners))
+	for _, owner := range param.Owners {
+		if owners[owner] {
+			return util.ErrInvalidMethodParam
+		}
+		owners[owner] = true
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.Owners, param.Threshold)
+	return nil
+}
+
+func (p *MethodCreateMultisig) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamCreateMultisig)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	count, err := abi.GetMultisigCount(db)
+	util.DealWithErr(err)
+	id := count + 1
+	util.SetValue(db, abi.GetMultisigCountKey(), abi.PackMultisigCount(id))
+	info, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigInfo, param.Threshold, uint64(0), param.Owners)
+	util.SetValue(db, abi.GetMultisigInfoKey(id), info)
+	db.AddLog(NewLog(abi.ABIMultisig, util.FirstToLower(p.MethodName), id, sendBlock.AccountAddress))
+	return nil, nil
+}
+
+type MethodMultisigDeposit struct {
+	MethodName string
+}
+
+func (p *MethodMultisigDeposit) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigDeposit) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigDeposit) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigDepositQuota, nil
+}
+
+func (p *MethodMultisigDeposit) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigDeposit) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() <= 0 {
+		return util.ErrInvalidMethodParam
+	}
+	id := new(uint64)
+	if err := abi.ABIMultisig.UnpackMethod(id, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, *id)
+	return nil
+}
+
+func (p *MethodMultisigDeposit) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	id := new(uint64)
+	abi.ABIMultisig.UnpackMethod(id, p.MethodName, sendBlock.Data)
+	info, err := abi.GetMultisigInfo(db, *id)
+	util.DealWithErr(err)
+	if info == nil {
+		return nil, util.ErrInvalidMethodParam
+	}
+	balance, err := abi.GetMultisigBalance(db, *id, sendBlock.TokenId)
+	util.DealWithErr(err)
+	balance.Add(balance, sendBlock.Amount)
+	setMultisigBalance(db, *id, sendBlock.TokenId, balance)
+	db.AddLog(NewLog(abi.ABIMultisig, util.FirstToLower(p.MethodName), *id, sendBlock.TokenId, sendBlock.Amount))
+	return nil, nil
+}
+
+func setMultisigBalance(db vm_db.VmDb, id uint64, tokenId types.TokenTypeId, balance *big.Int) {
+	if balance.Sign() == 0 {
+		util.SetValue(db, abi.GetMultisigBalanceKey(id, tokenId), nil)
+		return
+	}
+	balanceData, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigBalance, balance)
+	util.SetValue(db, abi.GetMultisigBalanceKey(id, tokenId), balanceData)
+}
+
+type MethodMultisigPropose struct {
+	MethodName string
+}
+
+func (p *MethodMultisigPropose) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigPropose) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigPropose) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	dataCost, err := util.DataQuotaCost(data, gasTable)
+	if err != nil {
+		return 0, err
+	}
+	quota, overflow := helper.SafeAdd(gasTable.MultisigProposeQuota, dataCost)
+	if overflow {
+		return 0, util.ErrGasUintOverflow
+	}
+	return quota, nil
+}
+
+func (p *MethodMultisigPropose) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigPropose) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamMultisigPropose)
+	if err := abi.ABIMultisig.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	// transfers to contracts are refused, the contracts may send tokens back without calling deposit,
+	// and the refund of a failed receive can not be credited to the multisig account
+	if types.IsContractAddr(param.To) || (param.Amount.Sign() == 0 && len(param.Data) == 0) {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(p.MethodName, param.Id, param.To, param.TokenId, param.Amount, param.Data)
+	return nil
+}
+
+func (p *MethodMultisigPropose) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigPropose)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	info, err := abi.GetMultisigInfo(db, param.Id)
+	util.DealWithErr(err)
+	if info == nil || !info.IsOwner(sendBlock.AccountAddress) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	proposalId := info.ProposalCount + 1
+	infoData, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigInfo, info.Threshold, proposalId, info.Owners)
+	util.SetValue(db, abi.GetMultisigInfoKey(param.Id), infoData)
+	proposal, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigProposal,
+		sendBlock.AccountAddress, param.To, param.TokenId, param.Amount, param.Data, []types.Address{sendBlock.AccountAddress})
+	util.SetValue(db, abi.GetMultisigProposalKey(param.Id, proposalId), proposal)
+	db.AddLog(NewLog(abi.ABIMultisig, util.FirstToLower(p.MethodName), param.Id, proposalId, sendBlock.AccountAddress))
+	return nil, nil
+}
+
+type MethodMultisigApprove struct {
+	MethodName string
+}
+
+func (p *MethodMultisigApprove) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigApprove) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigApprove) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigApproveQuota, nil
+}
+
+func (p *MethodMultisigApprove) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigApprove) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	return doSendMultisigProposal(p.MethodName, block)
+}
+
+func (p *MethodMultisigApprove) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigProposal)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	_, proposal := getMultisigProposal(db, param, sendBlock.AccountAddress)
+	if proposal == nil || proposal.IsApprovedBy(sendBlock.AccountAddress) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	proposalData, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigProposal,
+		proposal.Proposer, proposal.To, proposal.TokenId, proposal.Amount, proposal.Data, append(proposal.Approvals, sendBlock.AccountAddress))
+	util.SetValue(db, abi.GetMultisigProposalKey(param.Id, param.ProposalId), proposalData)
+	db.AddLog(NewLog(abi.ABIMultisig, util.FirstToLower(p.MethodName), param.Id, param.ProposalId, sendBlock.AccountAddress))
+	return nil, nil
+}
+
+type MethodMultisigExecute struct {
+	MethodName string
+}
+
+func (p *MethodMultisigExecute) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodMultisigExecute) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodMultisigExecute) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.MultisigExecuteQuota, nil
+}
+
+func (p *MethodMultisigExecute) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodMultisigExecute) DoSend(db vm_db.VmDb, block *ledger.AccountBlock) error {
+	return doSendMultisigProposal(p.MethodName, block)
+}
+
+func (p *MethodMultisigExecute) DoReceive(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamMultisigProposal)
+	abi.ABIMultisig.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	info, proposal := getMultisigProposal(db, param, sendBlock.AccountAddress)
+	if proposal == nil || len(proposal.Approvals) < int(info.Threshold) || types.IsContractAddr(proposal.To) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	balance, err := abi.GetMultisigBalance(db, param.Id, proposal.TokenId)
+	util.DealWithErr(err)
+	if balance.Cmp(proposal.Amount) < 0 {
+		return nil, util.ErrInsufficientBalance
+	}
+	balance.Sub(balance, proposal.Amount)
+	setMultisigBalance(db, param.Id, proposal.TokenId, balance)
+	util.SetValue(db, abi.GetMultisigProposalKey(param.Id, param.ProposalId), nil)
+	db.AddLog(NewLog(abi.ABIMultisig, util.FirstToLower(p.MethodName), param.Id, param.ProposalId, sendBlock.AccountAddress))
+	return []*ledger.AccountBlock{
+		{
+			AccountAddress: block.AccountAddress,
+			ToAddress:      proposal.To,
+			BlockType:      ledger.BlockTypeSendCall,
+			Amount:         proposal.Amount,
+			TokenId:        proposal.TokenId,
+			Data:           proposal.Data,
+		},
+	}, nil
+}
+
+func doSendMultisigProposal(methodName string, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamMultisigProposal)
+	if err := abi.ABIMultisig.UnpackMethod(param, methodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIMultisig.PackMethod(methodName, param.Id, param.ProposalId)
+	return nil
+}
+
+// getMultisigProposal returns the multisig info and the pending proposal, the proposal is nil if
+// either of them is not exist or the sender is not an owner.
+func getMultisigProposal(db vm_db.VmDb, param *abi.ParamMultisigProposal, sender types.Address) (*abi.MultisigInfo, *abi.MultisigProposal) {
+	info, err := abi.GetMultisigInfo(db, param.Id)
+	util.DealWithErr(err)
+	if info == nil || !info.IsOwner(sender) {
+		return nil, nil
+	}
+	proposal, err := abi.GetMultisigProposal(db, param.Id, param.ProposalId)
+	util.DealWithErr(err)
+	return info, proposal
+}
@@ -0,0 +1,167 @@
+package contracts
+
+import (
+	"github.com/vitelabs/go-vite/common/db/xleveldb/comparer"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/memdb"
+	dbutil "github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_db"
+	"math/big"
+	"testing"
+)
+
+// multisigTestDb implements the storage methods of vm_db.VmDb used by the multisig contract
+type multisigTestDb struct {
+	vm_db.VmDb
+	storage *memdb.DB
+	logs    []*ledger.VmLog
+}
+
+func newMultisigTestDb() *multisigTestDb {
+	return &multisigTestDb{storage: memdb.New(comparer.DefaultComparer, 0)}
+}
+
+func (db *multisigTestDb) Address() *types.Address {
+	return &types.AddressMultisig
+}
+
+func (db *multisigTestDb) GetValue(key []byte) ([]byte, error) {
+	value, err := db.storage.Get(key)
+	if err != nil {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (db *multisigTestDb) SetValue(key []byte, value []byte) error {
+	if len(value) == 0 {
+		return db.storage.Delete(key)
+	}
+	return db.storage.Put(key, value)
+}
+
+func (db *multisigTestDb) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return db.storage.NewIterator(dbutil.BytesPrefix(prefix)), nil
+}
+
+func (db *multisigTestDb) AddLog(log *ledger.VmLog) {
+	db.logs = append(db.logs, log)
+}
+
+func TestContractsMultisig(t *testing.T) {
+	owner1 := types.Address{1}
+	owner2 := types.Address{2}
+	owner3 := types.Address{3}
+	stranger := types.Address{4}
+	db := newMultisigTestDb()
+
+	send := func(from types.Address, amount *big.Int, method BuiltinContractMethod, name string, args ...interface{}) *ledger.AccountBlock {
+		data, err := abi.ABIMultisig.PackMethod(name, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := &ledger.AccountBlock{AccountAddress: from, ToAddress: types.AddressMultisig, Amount: amount, TokenId: ledger.ViteTokenId, Data: data}
+		if err := method.DoSend(db, block); err != nil {
+			return nil
+		}
+		return block
+	}
+	receive := func(sendBlock *ledger.AccountBlock, method BuiltinContractMethod) ([]*ledger.AccountBlock, error) {
+		return method.DoReceive(db, &ledger.AccountBlock{AccountAddress: types.AddressMultisig}, sendBlock, nil)
+	}
+
+	create := &MethodCreateMultisig{abi.MethodNameCreateMultisig}
+	if send(owner1, big.NewInt(0), create, create.MethodName, []types.Address{owner1, owner2}, uint8(0)) != nil {
+		t.Fatalf("threshold 0 should be refused")
+	}
+	if send(owner1, big.NewInt(0), create, create.MethodName, []types.Address{owner1, owner2}, uint8(3)) != nil {
+		t.Fatalf("threshold over owner count should be refused")
+	}
+	if send(owner1, big.NewInt(0), create, create.MethodName, []types.Address{owner1, owner1}, uint8(1)) != nil {
+		t.Fatalf("duplicated owners should be refused")
+	}
+	if _, err := receive(send(owner1, big.NewInt(0), create, create.MethodName, []types.Address{owner1, owner2, owner3}, uint8(2)), create); err != nil {
+		t.Fatal(err)
+	}
+	id := uint64(1)
+	if list, _ := abi.GetMultisigListByOwner(db, owner2); len(list) != 1 || list[0].Id != id || list[0].Threshold != 2 {
+		t.Fatalf("unexpected multisig list of owner %v", list)
+	}
+
+	deposit := &MethodMultisigDeposit{abi.MethodNameMultisigDeposit}
+	if _, err := receive(send(stranger, big.NewInt(100), deposit, deposit.MethodName, id), deposit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := receive(send(stranger, big.NewInt(100), deposit, deposit.MethodName, uint64(2)), deposit); err == nil {
+		t.Fatalf("deposit to a multisig account not existed should be refused")
+	}
+
+	propose := &MethodMultisigPropose{abi.MethodNameMultisigPropose}
+	if send(owner1, big.NewInt(0), propose, propose.MethodName, id, types.AddressQuota, ledger.ViteTokenId, big.NewInt(60), []byte{}) != nil {
+		t.Fatalf("transfer to built-in contract should be refused")
+	}
+	userContract := types.CreateContractAddress([]byte("multisig test"))
+	if send(owner1, big.NewInt(0), propose, propose.MethodName, id, userContract, ledger.ViteTokenId, big.NewInt(60), []byte{}) != nil {
+		t.Fatalf("transfer to user contract should be refused")
+	}
+	if _, err := receive(send(stranger, big.NewInt(0), propose, propose.MethodName, id, stranger, ledger.ViteTokenId, big.NewInt(60), []byte{}), propose); err == nil {
+		t.Fatalf("proposal of a stranger should be refused")
+	}
+	if _, err := receive(send(owner1, big.NewInt(0), propose, propose.MethodName, id, stranger, ledger.ViteTokenId, big.NewInt(60), []byte{}), propose); err != nil {
+		t.Fatal(err)
+	}
+	proposalId := uint64(1)
+
+	approve := &MethodMultisigApprove{abi.MethodNameMultisigApprove}
+	execute := &MethodMultisigExecute{abi.MethodNameMultisigExecute}
+	if _, err := receive(send(owner1, big.NewInt(0), execute, execute.MethodName, id, proposalId), execute); err == nil {
+		t.Fatalf("proposal under threshold should not be executed")
+	}
+	if _, err := receive(send(owner1, big.NewInt(0), approve, approve.MethodName, id, proposalId), approve); err == nil {
+		t.Fatalf("proposal should not be approved twice by the same owner")
+	}
+	if _, err := receive(send(stranger, big.NewInt(0), approve, approve.MethodName, id, proposalId), approve); err == nil {
+		t.Fatalf("proposal should not be approved by a stranger")
+	}
+	if _, err := receive(send(owner2, big.NewInt(0), approve, approve.MethodName, id, proposalId), approve); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := abi.GetMultisigProposalList(db, id); len(list) != 1 || len(list[0].Approvals) != 2 || list[0].Amount.Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("unexpected pending proposal list %v", list)
+	}
+
+	blocks, err := receive(send(owner3, big.NewInt(0), execute, execute.MethodName, id, proposalId), execute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].ToAddress != stranger || blocks[0].Amount.Cmp(big.NewInt(60)) != 0 {
+		t.Fatalf("unexpected transfer %v", blocks)
+	}
+	if balance, _ := abi.GetMultisigBalance(db, id, ledger.ViteTokenId); balance.Cmp(big.NewInt(40)) != 0 {
+		t.Fatalf("unexpected balance %v", balance)
+	}
+	if list, _ := abi.GetMultisigProposalList(db, id); len(list) != 0 {
+		t.Fatalf("executed proposal should be removed")
+	}
+	if _, err := receive(send(owner3, big.NewInt(0), execute, execute.MethodName, id, proposalId), execute); err == nil {
+		t.Fatalf("proposal should not be executed twice")
+	}
+
+	// the receive of a contract may fail and the refund is not credited to the multisig account,
+	// so an approved proposal to a contract is not executed either
+	contractProposal, _ := abi.ABIMultisig.PackVariable(abi.VariableNameMultisigProposal,
+		owner1, userContract, ledger.ViteTokenId, big.NewInt(10), []byte{}, []types.Address{owner1, owner2})
+	db.SetValue(abi.GetMultisigProposalKey(id, proposalId+1), contractProposal)
+	if _, err := receive(send(owner3, big.NewInt(0), execute, execute.MethodName, id, proposalId+1), execute); err == nil {
+		t.Fatalf("transfer to user contract should not be executed")
+	}
+	if balance, _ := abi.GetMultisigBalance(db, id, ledger.ViteTokenId); balance.Cmp(big.NewInt(40)) != 0 {
+		t.Fatalf("unexpected balance after the refused transfer %v", balance)
+	}
+	if len(db.logs) != 5 {
+		t.Fatalf("unexpected log count %v", len(db.logs))
+	}
+}
@@ -2,6 +2,8 @@ package vm
 
 import (
 	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/util"
 	"testing"
 )
@@ -27,3 +29,31 @@ func TestMemoryGasCost(t *testing.T) {
 		t.Errorf("Expected error")
 	}
 }
+
+func TestGasUserSendCall_multisig(t *testing.T) {
+	block := &ledger.AccountBlock{
+		BlockType: ledger.BlockTypeSendCall,
+		ToAddress: types.AddressMultisig,
+	}
+	gasTable := util.QuotaTableByHeight(1)
+	expected, err := gasSendCall(block, gasTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// before the multisig fork, the send to the multisig address is a normal send
+	if ledger.IsBuiltinContractAddrInUse(types.AddressMultisig, 799) {
+		t.Fatal("multisig contract should not be in use before the fork")
+	}
+	if cost, err := gasRequiredForSendBlock(block, gasTable, 799); err != nil || cost != expected {
+		t.Fatalf("send before the fork should cost %d, got %d %v", expected, cost, err)
+	}
+
+	// after the multisig fork, the data must be a method of the multisig contract
+	if !ledger.IsBuiltinContractAddrInUse(types.AddressMultisig, 800) {
+		t.Fatal("multisig contract should be in use after the fork")
+	}
+	if _, err := gasRequiredForSendBlock(block, util.QuotaTableByHeight(800), 800); err != util.ErrAbiMethodNotFound {
+		t.Fatalf("send without method after the fork should be refused, got %v", err)
+	}
+}
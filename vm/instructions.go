@@ -794,14 +794,17 @@ func opCall(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte,
 	toAddress, _ := types.BigToAddress(toAddrBig)
 	tokenID, _ := types.BigToTokenTypeId(tokenIDBig)
 	data := mem.get(inOffset.Int64(), inSize.Int64())
-	vm.AppendBlock(
-		util.MakeRequestBlock(
-			c.block.AccountAddress,
-			toAddress,
-			ledger.BlockTypeSendCall,
-			amount,
-			tokenID,
-			data))
+	sendBlock := util.MakeRequestBlock(
+		c.block.AccountAddress,
+		toAddress,
+		ledger.BlockTypeSendCall,
+		amount,
+		tokenID,
+		data)
+	vm.AppendBlock(sendBlock)
+	if vm.tracer != nil {
+		vm.tracer.CaptureSend(sendBlock, vm.depth)
+	}
 	return nil, nil
 }
 
@@ -43,16 +43,25 @@ func newInterpreter(blockHeight uint64, offChain bool) *interpreter {
 func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 	c.returnData = nil
 	var (
-		op   opCode
-		mem  = newMemory()
-		st   = newStack()
-		pc   = uint64(0)
-		cost uint64
-		flag bool
+		op        opCode
+		mem       = newMemory()
+		st        = newStack()
+		pc        = uint64(0)
+		currentPc = uint64(0)
+		cost      uint64
+		flag      bool
 	)
 
+	if vm.tracer != nil {
+		defer func() {
+			if err != nil && err != util.ErrExecutionReverted {
+				vm.tracer.CaptureFault(currentPc, op.String(), c.quotaLeft, vm.depth, err)
+			}
+		}()
+	}
+
 	for atomic.LoadInt32(&vm.abort) == 0 {
-		currentPc := pc
+		currentPc = pc
 		op = c.getOp(pc)
 		operation := i.instructionSet[op]
 
@@ -89,6 +98,10 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 			mem.resize(memorySize)
 		}
 
+		if vm.tracer != nil {
+			vm.tracer.CaptureState(currentPc, op.String(), c.quotaLeft, cost, vm.depth, st.data, mem.store)
+		}
+
 		res, err := operation.execute(&pc, vm, c, mem, st)
 
 		if nodeConfig.IsDebug {
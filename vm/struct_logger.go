@@ -0,0 +1,172 @@
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// LogConfig is the configuration of StructLogger.
+type LogConfig struct {
+	DisableStack  bool `json:"disableStack"`
+	DisableMemory bool `json:"disableMemory"`
+	// Limit is the maximum number of steps to record, zero means no limit
+	Limit int `json:"limit"`
+}
+
+// StructLog is a single step of the interpreter.
+type StructLog struct {
+	Pc        uint64   `json:"pc"`
+	Op        string   `json:"op"`
+	QuotaLeft uint64   `json:"quotaLeft"`
+	Cost      uint64   `json:"cost"`
+	Depth     int      `json:"depth"`
+	Stack     []string `json:"stack,omitempty"`
+	Memory    string   `json:"memory,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// StructCall is a contract code run or a call sent by contract code.
+type StructCall struct {
+	Type      string             `json:"type"`
+	Depth     int                `json:"depth"`
+	Address   types.Address      `json:"address"`
+	CodeAddr  *types.Address     `json:"codeAddress,omitempty"`
+	ToAddress *types.Address     `json:"toAddress,omitempty"`
+	TokenId   *types.TokenTypeId `json:"tokenId,omitempty"`
+	Amount    string             `json:"amount,omitempty"`
+	Input     string             `json:"input,omitempty"`
+	Output    string             `json:"output,omitempty"`
+	Quota     uint64             `json:"quota"`
+	Error     string             `json:"error,omitempty"`
+	// index of the first step recorded after the call
+	StepIndex int `json:"stepIndex"`
+}
+
+// StructTrace is the JSON result of StructLogger.
+type StructTrace struct {
+	QuotaUsed uint64        `json:"quotaUsed"`
+	Output    string        `json:"output"`
+	Error     string        `json:"error,omitempty"`
+	Steps     []*StructLog  `json:"steps"`
+	Calls     []*StructCall `json:"calls"`
+	Truncated bool          `json:"truncated"`
+}
+
+// StructLogger is a Tracer which records each step and each call of the
+// interpreter in a JSON serializable form.
+type StructLogger struct {
+	cfg   LogConfig
+	trace StructTrace
+}
+
+// NewStructLogger returns a new StructLogger, cfg may be nil.
+func NewStructLogger(cfg *LogConfig) *StructLogger {
+	logger := &StructLogger{
+		trace: StructTrace{Steps: make([]*StructLog, 0), Calls: make([]*StructCall, 0)},
+	}
+	if cfg != nil {
+		logger.cfg = *cfg
+	}
+	return logger
+}
+
+// CaptureEnter implements Tracer.
+func (l *StructLogger) CaptureEnter(addr types.Address, codeAddr types.Address, input []byte, quota uint64, depth int) {
+	call := &StructCall{
+		Type:      "enter",
+		Depth:     depth,
+		Address:   addr,
+		Input:     hex.EncodeToString(input),
+		Quota:     quota,
+		StepIndex: len(l.trace.Steps),
+	}
+	if codeAddr != addr {
+		call.CodeAddr = &codeAddr
+	}
+	l.trace.Calls = append(l.trace.Calls, call)
+}
+
+// CaptureExit implements Tracer.
+func (l *StructLogger) CaptureExit(ret []byte, quotaUsed uint64, depth int, err error) {
+	call := &StructCall{
+		Type:      "exit",
+		Depth:     depth,
+		Output:    hex.EncodeToString(ret),
+		Quota:     quotaUsed,
+		StepIndex: len(l.trace.Steps),
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	l.trace.Calls = append(l.trace.Calls, call)
+	if depth == 0 {
+		l.trace.QuotaUsed = quotaUsed
+		l.trace.Output = call.Output
+		l.trace.Error = call.Error
+	}
+}
+
+// CaptureState implements Tracer.
+func (l *StructLogger) CaptureState(pc uint64, op string, quotaLeft, cost uint64, depth int, stack []*big.Int, memory []byte) {
+	if l.cfg.Limit > 0 && len(l.trace.Steps) >= l.cfg.Limit {
+		l.trace.Truncated = true
+		return
+	}
+	step := &StructLog{
+		Pc:        pc,
+		Op:        op,
+		QuotaLeft: quotaLeft,
+		Cost:      cost,
+		Depth:     depth,
+	}
+	if !l.cfg.DisableStack {
+		step.Stack = make([]string, len(stack))
+		for i, item := range stack {
+			step.Stack[i] = item.Text(16)
+		}
+	}
+	if !l.cfg.DisableMemory {
+		step.Memory = hex.EncodeToString(memory)
+	}
+	l.trace.Steps = append(l.trace.Steps, step)
+}
+
+// CaptureFault implements Tracer.
+func (l *StructLogger) CaptureFault(pc uint64, op string, quotaLeft uint64, depth int, err error) {
+	if l.cfg.Limit > 0 && len(l.trace.Steps) >= l.cfg.Limit {
+		l.trace.Truncated = true
+		return
+	}
+	l.trace.Steps = append(l.trace.Steps, &StructLog{
+		Pc:        pc,
+		Op:        op,
+		QuotaLeft: quotaLeft,
+		Depth:     depth,
+		Error:     err.Error(),
+	})
+}
+
+// CaptureSend implements Tracer.
+func (l *StructLogger) CaptureSend(block *ledger.AccountBlock, depth int) {
+	call := &StructCall{
+		Type:      "send",
+		Depth:     depth,
+		Address:   block.AccountAddress,
+		ToAddress: &block.ToAddress,
+		TokenId:   &block.TokenId,
+		Input:     hex.EncodeToString(block.Data),
+		StepIndex: len(l.trace.Steps),
+	}
+	if block.Amount != nil {
+		call.Amount = block.Amount.String()
+	}
+	l.trace.Calls = append(l.trace.Calls, call)
+}
+
+// Trace returns the recorded trace.
+func (l *StructLogger) Trace() *StructTrace {
+	return &l.trace
+}
@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+func TestStructLogger(t *testing.T) {
+	tests := []struct {
+		input   []byte
+		cfg     *LogConfig
+		steps   int
+		lastOp  string
+		err     string
+		summary string
+	}{
+		{[]byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD), byte(PUSH1), 32, byte(DUP1), byte(SWAP2), byte(SWAP1), byte(MSTORE), byte(PUSH1), 32, byte(SWAP1), byte(RETURN)}, nil, 11, "RETURN", "", "return 1+2"},
+		{[]byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD), byte(PUSH1), 32, byte(DUP1), byte(SWAP2), byte(SWAP1), byte(MSTORE), byte(PUSH1), 32, byte(SWAP1), byte(RETURN)}, &LogConfig{Limit: 3}, 3, "ADD", "", "limit"},
+		{[]byte{byte(PUSH1), 32, byte(JUMP)}, nil, 3, "JUMP", util.ErrInvalidJumpDestination.Error(), "execution error"},
+	}
+	for _, test := range tests {
+		vm := NewVM(nil)
+		vm.i = newInterpreter(1, false)
+		vm.gasTable = util.QuotaTableByHeight(1)
+		logger := NewStructLogger(test.cfg)
+		vm.SetTracer(logger)
+		sendCallBlock := ledger.AccountBlock{
+			BlockType: ledger.BlockTypeSendCall,
+			Data:      test.input,
+			Amount:    big.NewInt(10),
+			Fee:       big.NewInt(0),
+			TokenId:   ledger.ViteTokenId,
+		}
+		receiveCallBlock := &ledger.AccountBlock{
+			BlockType: ledger.BlockTypeReceive,
+		}
+		c := newContract(receiveCallBlock, newNoDatabase(), &sendCallBlock, sendCallBlock.Data, 1000000)
+		c.setCallCode(types.Address{}, test.input)
+		c.run(vm)
+
+		trace := logger.Trace()
+		if len(trace.Steps) != test.steps || trace.Steps[len(trace.Steps)-1].Op != test.lastOp {
+			t.Fatalf("unexpected steps, summary: %v", test.summary)
+		}
+		if trace.Error != test.err || trace.Truncated != (test.cfg != nil) {
+			t.Fatalf("unexpected trace result, summary: %v", test.summary)
+		}
+		if len(trace.Calls) != 2 || trace.Calls[0].Type != "enter" || trace.Calls[1].Type != "exit" || vm.depth != 0 {
+			t.Fatalf("unexpected calls, summary: %v", test.summary)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// Tracer is used to collect execution traces of the interpreter.
+// Stack and memory passed to the tracer are owned by the interpreter,
+// tracers must copy them if they need to keep them after the call returns.
+type Tracer interface {
+	// CaptureEnter is called before contract code is run, either for a
+	// receive block or for a delegate call.
+	CaptureEnter(addr types.Address, codeAddr types.Address, input []byte, quota uint64, depth int)
+	// CaptureExit is called after contract code returns.
+	CaptureExit(ret []byte, quotaUsed uint64, depth int, err error)
+	// CaptureState is called on each step after the quota cost of the step is
+	// charged and before the operation is executed.
+	CaptureState(pc uint64, op string, quotaLeft, cost uint64, depth int, stack []*big.Int, memory []byte)
+	// CaptureFault is called when a step fails.
+	CaptureFault(pc uint64, op string, quotaLeft uint64, depth int, err error)
+	// CaptureSend is called when contract code sends a call to another contract.
+	CaptureSend(block *ledger.AccountBlock, depth int)
+}
+
+// SetTracer attaches a tracer to the vm, the tracer is called by the
+// interpreter during every following execution of the vm.
+func (vm *VM) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
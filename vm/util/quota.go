@@ -239,11 +239,18 @@ type QuotaTable struct {
 	DexFundDelegateStakeCallbackV2Quota       uint64
 	DexFundDelegateCancelStakeCallbackV2Quota uint64
 	DexFundCancelOrderBySendHashQuota         uint64
+	MultisigCreateQuota                       uint64
+	MultisigDepositQuota                      uint64
+	MultisigProposeQuota                      uint64
+	MultisigApproveQuota                      uint64
+	MultisigExecuteQuota                      uint64
 }
 
 // QuotaTableByHeight returns different quota table by hard fork version
 func QuotaTableByHeight(sbHeight uint64) *QuotaTable {
-	if fork.IsDexRobotFork(sbHeight) {
+	if fork.IsMultisigFork(sbHeight) {
+		return &multisigQuotaTable
+	} else if fork.IsDexRobotFork(sbHeight) {
 		return &dexRobotQuotaTable
 	} else if fork.IsEarthFork(sbHeight) {
 		return &earthQuotaTable
@@ -356,7 +363,8 @@ var (
 	viteQuotaTable     = newViteQuotaTable()
 	dexAgentQuotaTable = newDexAgentQuotaTable()
 	earthQuotaTable    = newEarthQuotaTable()
-	dexRobotQuotaTable    = newDexRobotQuotaTable()
+	dexRobotQuotaTable = newDexRobotQuotaTable()
+	multisigQuotaTable = newMultisigQuotaTable()
 )
 
 func newViteQuotaTable() QuotaTable {
@@ -505,3 +513,13 @@ func newDexRobotQuotaTable() QuotaTable {
 	gt.DexFundCancelOrderBySendHashQuota = 15200
 	return gt
 }
+
+func newMultisigQuotaTable() QuotaTable {
+	gt := newDexRobotQuotaTable()
+	gt.MultisigCreateQuota = 126000
+	gt.MultisigDepositQuota = 52500
+	gt.MultisigProposeQuota = 105000
+	gt.MultisigApproveQuota = 52500
+	gt.MultisigExecuteQuota = 73500
+	return gt
+}
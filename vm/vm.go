@@ -159,6 +159,10 @@ type VM struct {
 	// latest snapshot block height, used for fork check
 	latestSnapshotHeight uint64
 	gasTable             *util.QuotaTable
+	// tracer collects execution traces of the interpreter, used for debug
+	tracer Tracer
+	// depth of the running contract code, only maintained when tracer is set
+	depth int
 }
 
 // NewVM is a constructor of VM. This method is called before running an
@@ -774,7 +778,7 @@ func (vm *VM) receiveReward(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock
 	quotaLeft := uint64(0)
 	quotaAddition := uint64(0)
 	var err error
-	if !types.IsBuiltinContractAddrInUse(block.AccountAddress) {
+	if !ledger.IsBuiltinContractAddrInUse(block.AccountAddress, vm.latestSnapshotHeight) {
 		quotaTotal, quotaAddition, err = quota.GetQuotaForBlock(
 			db,
 			block.AccountAddress,
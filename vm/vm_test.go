@@ -33,7 +33,9 @@ func initFork() {
 		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
 		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
 		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
-		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7}})
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+		DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+		MultisigFork:  &config.ForkPoint{Height: 800, Version: 9}})
 	fork.SetActiveChecker(mockActiveChecker{})
 }
 
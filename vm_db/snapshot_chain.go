@@ -0,0 +1,480 @@
+package vm_db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// HistoryChain is a Chain which can also read the account state at a snapshot height.
+type HistoryChain interface {
+	Chain
+
+	GetBalanceMapAtSnapshot(addr types.Address, snapshotHeight uint64) (map[types.TokenTypeId]*big.Int, error)
+
+	GetValueAtSnapshot(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error)
+
+	GetStorageIteratorAtSnapshot(address types.Address, prefix []byte, snapshotHeight uint64) (interfaces.StorageIterator, error)
+
+	GetStakeBeneficialAmountAtSnapshot(addr types.Address, snapshotHeight uint64) (*big.Int, error)
+
+	GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error)
+
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+}
+
+// quotaAccumulateHeight is the count of snapshot blocks the quota used is accumulated in,
+// the same as the quota list of chain cache
+const quotaAccumulateHeight = 75
+
+// SnapshotChain serves the account state at a snapshot height, with the state changes
+// of the applied account blocks on top of it. It is used to re-execute historical
+// account blocks, nothing is written to the chain.
+//
+// Every read which depends on the latest snapshot block is served at the snapshot height:
+// balance, storage, stake amount, quota used, contract meta, latest account blocks,
+// confirmed times and snapshot blocks. Account blocks, vm logs and call depth are read
+// by hash and never change after the block is inserted, so they are read from the chain.
+type SnapshotChain struct {
+	HistoryChain
+
+	snapshotHeight uint64
+
+	unsavedMap map[types.Address]*Unsaved
+	latestMap  map[types.Address]*ledger.AccountBlock
+	blocksMap  map[types.Address][]*ledger.AccountBlock
+	metaMap    map[types.Address]*ledger.ContractMeta
+	appliedMap map[types.Hash]struct{}
+
+	// quota of the confirmed blocks in every snapshot block of the accumulating range
+	quotaList []map[types.Address]*types.QuotaInfo
+}
+
+func NewSnapshotChain(chain HistoryChain, snapshotHeight uint64) (*SnapshotChain, error) {
+	sc := &SnapshotChain{
+		HistoryChain:   chain,
+		snapshotHeight: snapshotHeight,
+		unsavedMap:     make(map[types.Address]*Unsaved),
+		latestMap:      make(map[types.Address]*ledger.AccountBlock),
+		blocksMap:      make(map[types.Address][]*ledger.AccountBlock),
+		metaMap:        make(map[types.Address]*ledger.ContractMeta),
+		appliedMap:     make(map[types.Hash]struct{}),
+	}
+
+	if err := sc.loadQuotaList(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// loadQuotaList reads the confirmed blocks of the latest quotaAccumulateHeight - 1 snapshot blocks
+// at the snapshot height, the applied blocks are accumulated as the unconfirmed ones
+func (sc *SnapshotChain) loadQuotaList() error {
+	startHeight := uint64(1)
+	if sc.snapshotHeight >= quotaAccumulateHeight {
+		startHeight = sc.snapshotHeight + 1 - quotaAccumulateHeight
+	}
+
+	chunks, err := sc.HistoryChain.GetSubLedger(startHeight, sc.snapshotHeight)
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		// the blocks confirmed by the start snapshot block are out of range
+		if chunk.SnapshotBlock == nil || chunk.SnapshotBlock.Height <= startHeight {
+			continue
+		}
+		if chunk.SnapshotBlock.Height > sc.snapshotHeight {
+			break
+		}
+
+		item := make(map[types.Address]*types.QuotaInfo)
+		for _, block := range chunk.AccountBlocks {
+			addQuotaInfo(item, block)
+		}
+		sc.quotaList = append(sc.quotaList, item)
+	}
+	return nil
+}
+
+func addQuotaInfo(item map[types.Address]*types.QuotaInfo, block *ledger.AccountBlock) {
+	qi, ok := item[block.AccountAddress]
+	if !ok {
+		qi = &types.QuotaInfo{}
+		item[block.AccountAddress] = qi
+	}
+	qi.BlockCount += 1
+	qi.QuotaTotal += block.Quota
+	qi.QuotaUsedTotal += block.QuotaUsed
+}
+
+func (sc *SnapshotChain) SnapshotHeight() uint64 {
+	return sc.snapshotHeight
+}
+
+// SetLatestAccountBlock sets the latest account block of the address at the snapshot height
+func (sc *SnapshotChain) SetLatestAccountBlock(block *ledger.AccountBlock) {
+	sc.latestMap[block.AccountAddress] = block
+}
+
+// Apply applies the state changes of an executed account block
+func (sc *SnapshotChain) Apply(vmBlock *VmAccountBlock) {
+	block := vmBlock.AccountBlock
+	unsaved, ok := sc.unsavedMap[block.AccountAddress]
+	if !ok {
+		unsaved = NewUnsaved()
+		sc.unsavedMap[block.AccountAddress] = unsaved
+	}
+
+	for _, kv := range vmBlock.VmDb.GetUnsavedStorage() {
+		unsaved.SetValue(kv[0], kv[1])
+	}
+	for tokenId, balance := range vmBlock.VmDb.GetUnsavedBalanceMap() {
+		tokenTypeId := tokenId
+		unsaved.SetBalance(&tokenTypeId, new(big.Int).Set(balance))
+	}
+	for addr, meta := range vmBlock.VmDb.GetUnsavedContractMeta() {
+		sc.metaMap[addr] = meta
+	}
+
+	sc.latestMap[block.AccountAddress] = block
+	sc.blocksMap[block.AccountAddress] = append(sc.blocksMap[block.AccountAddress], block)
+	sc.appliedMap[block.Hash] = struct{}{}
+	for _, sendBlock := range block.SendBlockList {
+		sc.appliedMap[sendBlock.Hash] = struct{}{}
+	}
+}
+
+func (sc *SnapshotChain) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	if unsaved, ok := sc.unsavedMap[addr]; ok {
+		if balance, ok := unsaved.GetBalance(&tokenId); ok {
+			return new(big.Int).Set(balance), nil
+		}
+	}
+
+	balanceMap, err := sc.HistoryChain.GetBalanceMapAtSnapshot(addr, sc.snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if balance, ok := balanceMap[tokenId]; ok {
+		return balance, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (sc *SnapshotChain) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	if unsaved, ok := sc.unsavedMap[addr]; ok {
+		if value, ok := unsaved.GetValue(key); ok {
+			return value, nil
+		}
+	}
+	return sc.HistoryChain.GetValueAtSnapshot(addr, key, sc.snapshotHeight)
+}
+
+func (sc *SnapshotChain) GetStorageIterator(addr types.Address, prefix []byte) (interfaces.StorageIterator, error) {
+	iter, err := sc.HistoryChain.GetStorageIteratorAtSnapshot(addr, prefix, sc.snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	unsaved, ok := sc.unsavedMap[addr]
+	if !ok {
+		return iter, nil
+	}
+	return db.NewMergedIterator([]interfaces.StorageIterator{
+		unsaved.NewStorageIterator(prefix),
+		iter,
+	}, unsaved.IsDelete), nil
+}
+
+// GetLatestAccountBlock returns the latest account block of the address confirmed at the snapshot
+// height, or the latest applied one
+func (sc *SnapshotChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	if block, ok := sc.latestMap[addr]; ok {
+		return block, nil
+	}
+
+	latestBlock, err := sc.HistoryChain.GetLatestAccountBlock(addr)
+	if err != nil || latestBlock == nil {
+		return nil, err
+	}
+	confirmed, err := sc.isConfirmed(latestBlock.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if confirmed {
+		sc.latestMap[addr] = latestBlock
+		return latestBlock, nil
+	}
+
+	// the confirmed blocks are always before the unconfirmed ones of an account chain,
+	// so search the highest block confirmed at the snapshot height
+	var block *ledger.AccountBlock
+	low, high := uint64(1), latestBlock.Height-1
+	for low <= high {
+		mid := low + (high-low)/2
+		midBlock, err := sc.HistoryChain.GetAccountBlockByHeight(addr, mid)
+		if err != nil {
+			return nil, err
+		}
+		if midBlock == nil {
+			return nil, errors.New(fmt.Sprintf("account block %d of %s is not exist", mid, addr))
+		}
+		if confirmed, err = sc.isConfirmed(midBlock.Hash); err != nil {
+			return nil, err
+		}
+		if confirmed {
+			block = midBlock
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+
+	if block != nil {
+		sc.latestMap[addr] = block
+	}
+	return block, nil
+}
+
+// GetUnconfirmedBlocks returns the applied account blocks of the address, which are
+// not confirmed at the snapshot height
+func (sc *SnapshotChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return sc.blocksMap[addr]
+}
+
+func (sc *SnapshotChain) isConfirmed(blockHash types.Hash) (bool, error) {
+	sb, err := sc.GetConfirmSnapshotHeaderByAbHash(blockHash)
+	if err != nil {
+		return false, err
+	}
+	return sb != nil, nil
+}
+
+// GetConfirmSnapshotHeaderByAbHash returns nil if the block is not confirmed at the snapshot height
+func (sc *SnapshotChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	if _, ok := sc.appliedMap[abHash]; ok {
+		return nil, nil
+	}
+
+	sb, err := sc.HistoryChain.GetConfirmSnapshotHeaderByAbHash(abHash)
+	if err != nil || sb == nil || sb.Height > sc.snapshotHeight {
+		return nil, err
+	}
+	return sb, nil
+}
+
+func (sc *SnapshotChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	sb, err := sc.GetConfirmSnapshotHeaderByAbHash(blockHash)
+	if err != nil || sb == nil {
+		return 0, err
+	}
+	return sc.snapshotHeight + 1 - sb.Height, nil
+}
+
+func (sc *SnapshotChain) GetSnapshotHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	sb, err := sc.HistoryChain.GetSnapshotHeaderByHash(hash)
+	if err != nil || sb == nil || sb.Height > sc.snapshotHeight {
+		return nil, err
+	}
+	return sb, nil
+}
+
+func (sc *SnapshotChain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height > sc.snapshotHeight {
+		return nil, nil
+	}
+	return sc.HistoryChain.GetSnapshotBlockByHeight(height)
+}
+
+func (sc *SnapshotChain) IsContractAccount(addr types.Address) (bool, error) {
+	if ledger.IsBuiltinContractAddrInUse(addr, sc.snapshotHeight) {
+		return true, nil
+	}
+
+	meta, err := sc.GetContractMeta(addr)
+	if err != nil {
+		return false, err
+	}
+	return meta != nil, nil
+}
+
+// GetContractMeta returns the meta of the contract which is created before the replayed blocks.
+// The replayed blocks are confirmed by the next snapshot block, so is the contract creation,
+// unless the replayed blocks are not confirmed yet.
+func (sc *SnapshotChain) GetContractMeta(addr types.Address) (*ledger.ContractMeta, error) {
+	if meta, ok := sc.metaMap[addr]; ok {
+		return meta, nil
+	}
+
+	if sc.snapshotHeight >= sc.HistoryChain.GetLatestSnapshotBlock().Height {
+		return sc.HistoryChain.GetContractMeta(addr)
+	}
+	return sc.HistoryChain.GetContractMetaInSnapshot(addr, sc.snapshotHeight+1)
+}
+
+// GetContractCode returns nil if the contract is not created, the code never changes after creation
+func (sc *SnapshotChain) GetContractCode(addr types.Address) ([]byte, error) {
+	meta, err := sc.GetContractMeta(addr)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return sc.HistoryChain.GetContractCode(addr)
+}
+
+func (sc *SnapshotChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	if _, ok := sc.unsavedMap[types.AddressQuota]; ok {
+		return nil, errors.New(fmt.Sprintf("stake amount of %s is changed by the applied blocks", addr))
+	}
+	return sc.HistoryChain.GetStakeBeneficialAmountAtSnapshot(addr, sc.snapshotHeight)
+}
+
+// GetQuotaUsedList returns the quota used in the accumulating range of the snapshot height,
+// the last item is the quota used by the applied blocks
+func (sc *SnapshotChain) GetQuotaUsedList(addr types.Address) []types.QuotaInfo {
+	usedList := make([]types.QuotaInfo, 0, len(sc.quotaList)+1)
+	for _, item := range sc.quotaList {
+		if qi, ok := item[addr]; ok {
+			usedList = append(usedList, *qi)
+		} else {
+			usedList = append(usedList, types.QuotaInfo{})
+		}
+	}
+
+	unconfirmed := make(map[types.Address]*types.QuotaInfo)
+	for _, block := range sc.blocksMap[addr] {
+		addQuotaInfo(unconfirmed, block)
+	}
+	if qi, ok := unconfirmed[addr]; ok {
+		usedList = append(usedList, *qi)
+	} else {
+		usedList = append(usedList, types.QuotaInfo{})
+	}
+	return usedList
+}
+
+// GetGlobalQuota returns the quota used by the confirmed blocks in the accumulating range of the snapshot height
+func (sc *SnapshotChain) GetGlobalQuota() types.QuotaInfo {
+	var globalQuota types.QuotaInfo
+	for _, item := range sc.quotaList {
+		for _, qi := range item {
+			globalQuota.BlockCount += qi.BlockCount
+			globalQuota.QuotaTotal += qi.QuotaTotal
+			globalQuota.QuotaUsedTotal += qi.QuotaUsedTotal
+		}
+	}
+	return globalQuota
+}
+
+func (sc *SnapshotChain) GetSnapshotBlockByContractMeta(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	meta, err := sc.GetContractMeta(addr)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil || meta.SendConfirmedTimes == 0 {
+		return nil, nil
+	}
+	firstConfirmedSb, err := sc.GetConfirmSnapshotHeaderByAbHash(fromHash)
+	if err != nil {
+		return nil, err
+	}
+	if firstConfirmedSb == nil {
+		return nil, errors.New("failed to find referred sendBlock' confirmSnapshotBlock")
+	}
+	limitSb, err := sc.GetSnapshotBlockByHeight(firstConfirmedSb.Height + uint64(meta.SendConfirmedTimes) - 1)
+	if err != nil {
+		return nil, err
+	}
+	if limitSb == nil {
+		return nil, errors.New("fromBlock confirmed times not enough")
+	}
+	return limitSb, nil
+}
+
+func (sc *SnapshotChain) GetSeedConfirmedSnapshotBlock(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	meta, err := sc.GetContractMeta(addr)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil || meta.SeedConfirmedTimes == 0 {
+		return nil, nil
+	}
+	firstConfirmedSb, err := sc.GetConfirmSnapshotHeaderByAbHash(fromHash)
+	if err != nil {
+		return nil, err
+	}
+	if firstConfirmedSb == nil {
+		return nil, errors.New("failed to find referred sendBlock' confirmSnapshotBlock")
+	}
+
+	seedCount := uint8(0)
+	for h := firstConfirmedSb.Height; h <= sc.snapshotHeight; h++ {
+		sb, err := sc.HistoryChain.GetSnapshotBlockByHeight(h)
+		if err != nil {
+			return nil, err
+		}
+		if sb == nil {
+			break
+		}
+		if sb.Seed > 0 {
+			seedCount++
+		}
+		if seedCount == meta.SeedConfirmedTimes {
+			return sb, nil
+		}
+	}
+	return nil, errors.New("fromBlock confirmed times not enough")
+}
+
+// GetSeed only reads the snapshot blocks before limitSb
+func (sc *SnapshotChain) GetSeed(limitSb *ledger.SnapshotBlock, fromHash types.Hash) (uint64, error) {
+	if limitSb.Height > sc.snapshotHeight {
+		return 0, errors.New(fmt.Sprintf("snapshot block %d is higher than snapshot height %d", limitSb.Height, sc.snapshotHeight))
+	}
+	return sc.HistoryChain.GetSeed(limitSb, fromHash)
+}
+
+// VerifyState compares the state changes applied to the address with the account state
+// at the snapshot height, and returns an error describing the first difference
+func (sc *SnapshotChain) VerifyState(addr types.Address, snapshotHeight uint64) error {
+	unsaved, ok := sc.unsavedMap[addr]
+	if !ok {
+		return nil
+	}
+
+	for _, kv := range unsaved.GetStorage() {
+		value, err := sc.HistoryChain.GetValueAtSnapshot(addr, kv[0], snapshotHeight)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(value, kv[1]) {
+			return errors.New(fmt.Sprintf("storage of %s is different at snapshot height %d, key is %x, value is %x, expected %x",
+				addr, snapshotHeight, kv[0], kv[1], value))
+		}
+	}
+
+	balanceMap, err := sc.HistoryChain.GetBalanceMapAtSnapshot(addr, snapshotHeight)
+	if err != nil {
+		return err
+	}
+	for tokenId, balance := range unsaved.GetBalanceMap() {
+		expected, ok := balanceMap[tokenId]
+		if !ok {
+			expected = big.NewInt(0)
+		}
+		if balance.Cmp(expected) != 0 {
+			return errors.New(fmt.Sprintf("balance of %s is different at snapshot height %d, token is %s, balance is %s, expected %s",
+				addr, snapshotHeight, tokenId, balance, expected))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+)
+
+// Keystore is a backend keeping private keys, the manager only asks it for signatures
+// so that the keys of a hardware backend never leave the device.
+type Keystore interface {
+	// Name identifies the keystore in the manager, it's the abs path of an entropy store file or the uri of a pkcs11 token
+	Name() string
+
+	Unlock(passphrase string) error
+	Lock()
+	IsUnlocked() bool
+
+	// FindAddr returns the index of the address in the keystore, or walleterrors.ErrAddressNotFound
+	FindAddr(addr types.Address) (index uint32, err error)
+	SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error)
+}
+
+// entropyKeystore adapts an entropy store to Keystore
+type entropyKeystore struct {
+	*entropystore.Manager
+}
+
+func (ks entropyKeystore) Name() string {
+	return ks.GetEntropyStoreFile()
+}
+
+func (ks entropyKeystore) FindAddr(addr types.Address) (uint32, error) {
+	_, index, err := ks.Manager.FindAddr(addr)
+	return index, err
+}
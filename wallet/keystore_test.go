@@ -0,0 +1,100 @@
+package wallet_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+// memKeystore keeps a single key in memory, like a token with one key
+type memKeystore struct {
+	priv     ed25519.PrivateKey
+	unlocked bool
+}
+
+func (ks *memKeystore) Name() string { return "mem" }
+
+func (ks *memKeystore) Unlock(passphrase string) error {
+	ks.unlocked = true
+	return nil
+}
+
+func (ks *memKeystore) Lock() { ks.unlocked = false }
+
+func (ks *memKeystore) IsUnlocked() bool { return ks.unlocked }
+
+func (ks *memKeystore) FindAddr(addr types.Address) (uint32, error) {
+	if !ks.unlocked {
+		return 0, walleterrors.ErrLocked
+	}
+	if addr != types.PrikeyToAddress(ks.priv) {
+		return 0, walleterrors.ErrAddressNotFound
+	}
+	return 0, nil
+}
+
+func (ks *memKeystore) SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	if _, err := ks.FindAddr(addr); err != nil {
+		return nil, nil, err
+	}
+	return ed25519.Sign(ks.priv, data), ks.priv.PubByte(), nil
+}
+
+func TestManager_Keystore(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := types.PrikeyToAddress(priv)
+	ks := &memKeystore{priv: priv}
+
+	manager := wallet.New(&wallet.Config{DataDir: t.TempDir()})
+	if err := manager.AddKeystore(ks); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.AddKeystore(ks); err == nil {
+		t.Fatal("the keystore of the same name should be refused")
+	}
+
+	if _, _, err := manager.GlobalFindKeystore(addr); err != walleterrors.ErrAddressNotFound {
+		t.Fatalf("locked keystore should be skipped, err %v", err)
+	}
+	if err := manager.Unlock(ks.Name(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if found, _, err := manager.GlobalFindKeystore(addr); err != nil || found.Name() != ks.Name() {
+		t.Fatalf("unexpected keystore %v, err %v", found, err)
+	}
+	if !manager.GlobalCheckAddrUnlock(addr) {
+		t.Fatal("address should be unlocked")
+	}
+	if err := manager.MatchAddress(ks.Name(), addr, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.MatchAddress(ks.Name(), addr, 1); err == nil {
+		t.Fatal("the coinbase of another index should be refused")
+	}
+
+	data := []byte("vite")
+	signedData, pubkey, err := manager.GlobalSignData(addr, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pubkey, data, signedData) {
+		t.Fatal("invalid signature")
+	}
+	if _, _, err := manager.SignData(ks.Name(), addr, 0, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Lock(ks.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if manager.IsUnlocked(ks.Name()) {
+		t.Fatal("keystore should be locked")
+	}
+}
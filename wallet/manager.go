@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -20,6 +21,7 @@ type Manager struct {
 	config              *Config
 	unlockChangedIndex  int
 	entropyStoreManager map[string]*entropystore.Manager // key is the entropyStore`s abs path
+	keystores           map[string]Keystore              // the backends other than entropyStore, key is the name
 	unlockChangedLis    map[int]func(event entropystore.UnlockEvent)
 	mutex               sync.Mutex
 
@@ -39,6 +41,7 @@ func New(config *Config) *Manager {
 		config:              config,
 		unlockChangedLis:    make(map[int]func(event entropystore.UnlockEvent)),
 		entropyStoreManager: make(map[string]*entropystore.Manager),
+		keystores:           make(map[string]Keystore),
 
 		log: log15.New("module", "wallet"),
 	}
@@ -52,34 +55,34 @@ func (m Manager) ListAllEntropyFiles() []string {
 	return files
 }
 
-func (m *Manager) Unlock(entropyStore, passphrase string) error {
-	manager, e := m.GetEntropyStoreManager(entropyStore)
+func (m *Manager) Unlock(keystore, passphrase string) error {
+	ks, e := m.GetKeystore(keystore)
 	if e != nil {
 		return e
 	}
 
-	return manager.Unlock(passphrase)
+	return ks.Unlock(passphrase)
 }
 
-func (m *Manager) IsUnlocked(entropyStore string) bool {
-	manager, e := m.GetEntropyStoreManager(entropyStore)
+func (m *Manager) IsUnlocked(keystore string) bool {
+	ks, e := m.GetKeystore(keystore)
 	if e != nil {
 		return false
 	}
-	return manager.IsUnlocked()
+	return ks.IsUnlocked()
 }
 
-func (m *Manager) Lock(entropyStore string) error {
-	manager, e := m.GetEntropyStoreManager(entropyStore)
+func (m *Manager) Lock(keystore string) error {
+	ks, e := m.GetKeystore(keystore)
 	if e != nil {
 		return e
 	}
-	manager.Lock()
+	ks.Lock()
 	return nil
 }
 
 func (m Manager) GlobalCheckAddrUnlock(targetAdr types.Address) bool {
-	_, _, _, err := m.GlobalFindAddr(targetAdr)
+	_, _, err := m.GlobalFindKeystore(targetAdr)
 	return err == nil
 }
 
@@ -113,6 +116,33 @@ func (m Manager) GlobalFindAddr(targetAdr types.Address) (path string, key *deri
 	return "", nil, 0, walleterrors.ErrAddressNotFound
 }
 
+// GlobalFindKeystore returns the unlocked keystore which keeps the key of the address
+func (m *Manager) GlobalFindKeystore(targetAdr types.Address) (ks Keystore, index uint32, err error) {
+	for _, ks := range m.ListKeystores() {
+		if !ks.IsUnlocked() {
+			continue
+		}
+		index, err = ks.FindAddr(targetAdr)
+		if err == walleterrors.ErrAddressNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return ks, index, nil
+	}
+	return nil, 0, walleterrors.ErrAddressNotFound
+}
+
+// GlobalSignData signs the data by the unlocked keystore which keeps the key of the address
+func (m *Manager) GlobalSignData(targetAdr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	ks, _, err := m.GlobalFindKeystore(targetAdr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ks.SignData(targetAdr, data)
+}
+
 func (m Manager) GlobalFindAddrWithPassphrase(targetAdr types.Address, pass string) (path string, key *derivation.Key, index uint32, err error) {
 	for path, em := range m.entropyStoreManager {
 		key, index, err = em.FindAddrWithPassphrase(pass, targetAdr)
@@ -173,6 +203,39 @@ func (m *Manager) GetEntropyStoreManager(entropyStore string) (*entropystore.Man
 	return nil, walleterrors.ErrStoreNotFound
 }
 
+// GetKeystore returns the keystore of the name, it's an entropyStore if the name is a file path
+func (m *Manager) GetKeystore(name string) (Keystore, error) {
+	if ks, ok := m.keystores[name]; ok {
+		return ks, nil
+	}
+	manager, err := m.GetEntropyStoreManager(name)
+	if err != nil {
+		return nil, err
+	}
+	return entropyKeystore{manager}, nil
+}
+
+// ListKeystores returns the entropyStores and the other backends
+func (m *Manager) ListKeystores() []Keystore {
+	result := make([]Keystore, 0, len(m.entropyStoreManager)+len(m.keystores))
+	for _, em := range m.entropyStoreManager {
+		result = append(result, entropyKeystore{em})
+	}
+	for _, ks := range m.keystores {
+		result = append(result, ks)
+	}
+	return result
+}
+
+// AddKeystore adds a backend other than the entropyStore files, e.g. a pkcs11 token
+func (m *Manager) AddKeystore(ks Keystore) error {
+	if _, err := m.GetKeystore(ks.Name()); err == nil {
+		return errors.Errorf("keystore %s already exists", ks.Name())
+	}
+	m.keystores[ks.Name()] = ks
+	return nil
+}
+
 // if your entropyStore file is not in the standard dir you can add it so we can index it
 func (m *Manager) AddEntropyStore(entropyStore string) error {
 	absPath := entropyStore
@@ -273,6 +336,15 @@ func (m *Manager) Stop() {
 		em.RemoveUnlockChangeChannel()
 	}
 	m.entropyStoreManager = nil
+	for name, ks := range m.keystores {
+		ks.Lock()
+		if closer, ok := ks.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				m.log.Error("wallet stop keystore", "keystore", name, "err", err)
+			}
+		}
+	}
+	m.keystores = make(map[string]Keystore)
 }
 
 func (m Manager) AddLockEventListener(lis func(event entropystore.UnlockEvent)) int {
@@ -289,7 +361,19 @@ func (m Manager) RemoveUnlockChangeChannel(id int) {
 	defer m.mutex.Unlock()
 	delete(m.unlockChangedLis, id)
 }
+
+// MatchAddress checks the coinbase is kept by the keystore at the index
 func (m *Manager) MatchAddress(EntryPath string, coinbase types.Address, index uint32) error {
+	if ks, ok := m.keystores[EntryPath]; ok {
+		keyIndex, err := ks.FindAddr(coinbase)
+		if err != nil {
+			return err
+		}
+		if keyIndex != index {
+			return errors.New("address do not match.")
+		}
+		return nil
+	}
 	manager, err := m.GetEntropyStoreManager(EntryPath)
 	if err != nil {
 		return err
@@ -307,3 +391,19 @@ func (m *Manager) MatchAddress(EntryPath string, coinbase types.Address, index u
 	}
 	return nil
 }
+
+// SignData signs the data with the coinbase key of the keystore, the key of an entropyStore is derived by the index directly
+func (m *Manager) SignData(EntryPath string, coinbase types.Address, index uint32, data []byte) (signedData, pubkey []byte, err error) {
+	if ks, ok := m.keystores[EntryPath]; ok {
+		return ks.SignData(coinbase, data)
+	}
+	manager, err := m.GetEntropyStoreManager(EntryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, key, err := manager.DeriveForIndexPath(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.SignData(data)
+}
@@ -0,0 +1,34 @@
+package pkcs11store
+
+import "github.com/pkg/errors"
+
+// the constants of pkcs11 v3.0, the headers of the library are v2.40
+const (
+	CKK_EC_EDWARDS              = 0x40
+	CKM_EC_EDWARDS_KEY_PAIR_GEN = 0x1055
+	CKM_EDDSA                   = 0x1057
+)
+
+var (
+	ErrTokenNotFound   = errors.New("pkcs11 token not found")
+	ErrSignatureScheme = errors.New("the signature of the token can't be verified, vite signs with the blake2b variant of ed25519 and the token must support it by the configured mechanism")
+	// ErrNotSupported is returned by New if gvite is built without cgo, which the pkcs11 library is loaded by
+	ErrNotSupported = errors.New("pkcs11 not supported in this build")
+)
+
+// Config of the token. Vite signs with the blake2b variant of ed25519 instead of the sha512 one of RFC 8032,
+// so the standard CKM_EDDSA of most tokens (softhsm2 included) doesn't work, the token must implement the
+// blake2b variant, usually by a vendor defined mechanism. A token not supporting the mechanism is refused
+// by New, and a token signing with another scheme is refused by Unlock.
+type Config struct {
+	// Module is the path of the pkcs11 library of the HSM, e.g. /usr/lib/softhsm/libsofthsm2.so
+	Module     string
+	TokenLabel string
+	// Mechanism is the signing mechanism of the ed25519 keys in the token, CKM_EDDSA by default
+	Mechanism uint
+}
+
+// Name returns the name of the keystore of the token in the wallet manager
+func Name(tokenLabel string) string {
+	return "pkcs11:token=" + tokenLabel
+}
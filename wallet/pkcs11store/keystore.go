@@ -0,0 +1,370 @@
+// +build cgo
+
+package pkcs11store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+// signatureProbe is signed by the keys when the token is unlocked to check the signature scheme
+var signatureProbe = []byte("vite pkcs11 signature probe")
+
+// module is the part of the pkcs11 api used by the keystore, it's implemented by *pkcs11.Ctx
+type module interface {
+	Initialize(opts ...pkcs11.InitializeOption) error
+	Finalize() error
+	Destroy()
+	GetSlotList(tokenPresent bool) ([]uint, error)
+	GetTokenInfo(slotID uint) (pkcs11.TokenInfo, error)
+	GetMechanismList(slotID uint) ([]*pkcs11.Mechanism, error)
+	OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error)
+	CloseSession(sh pkcs11.SessionHandle) error
+	Login(sh pkcs11.SessionHandle, userType uint, pin string) error
+	Logout(sh pkcs11.SessionHandle) error
+	GetAttributeValue(sh pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error)
+	FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error
+	FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh pkcs11.SessionHandle) error
+	SignInit(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error
+	Sign(sh pkcs11.SessionHandle, message []byte) ([]byte, error)
+}
+
+type tokenKey struct {
+	index  uint32
+	handle pkcs11.ObjectHandle
+	pubKey ed25519.PublicKey
+}
+
+// Keystore keeps the ed25519 keys in a pkcs11 token, the private keys never leave the token.
+// A key is found by its public key object with the same CKA_ID, and the CKA_ID is the index
+// of the key in big-endian of at most 4 bytes, e.g. 0x01 for the key of index 1.
+type Keystore struct {
+	cfg  Config
+	ctx  module
+	slot uint
+
+	session  pkcs11.SessionHandle
+	unlocked bool
+	keys     map[types.Address]*tokenKey
+	mutex    sync.Mutex
+
+	log log15.Logger
+}
+
+// New loads the module and finds the slot of the token, the token needs to be unlocked by the pin later
+func New(cfg Config) (*Keystore, error) {
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, errors.New(fmt.Sprintf("load pkcs11 module %s fail", cfg.Module))
+	}
+	return newKeystore(cfg, ctx)
+}
+
+func newKeystore(cfg Config, ctx module) (*Keystore, error) {
+	if cfg.Mechanism == 0 {
+		cfg.Mechanism = CKM_EDDSA
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+	ks := &Keystore{
+		cfg: cfg,
+		ctx: ctx,
+		log: log15.New("module", "wallet/pkcs11store"),
+	}
+	slot, err := ks.findSlot()
+	if err != nil {
+		ks.Close()
+		return nil, err
+	}
+	ks.slot = slot
+	if err := ks.checkMechanism(); err != nil {
+		ks.Close()
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *Keystore) findSlot() (uint, error) {
+	slots, err := ks.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := ks.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(info.Label) == ks.cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, ErrTokenNotFound
+}
+
+func (ks *Keystore) checkMechanism() error {
+	mechanisms, err := ks.ctx.GetMechanismList(ks.slot)
+	if err != nil {
+		return err
+	}
+	for _, m := range mechanisms {
+		if m.Mechanism == ks.cfg.Mechanism {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("the token %s doesn't support the mechanism 0x%x", ks.cfg.TokenLabel, ks.cfg.Mechanism))
+}
+
+func (ks *Keystore) Name() string {
+	return Name(ks.cfg.TokenLabel)
+}
+
+// Unlock logins the token with the pin and loads the ed25519 keys in it
+func (ks *Keystore) Unlock(pin string) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if ks.unlocked {
+		return nil
+	}
+	session, err := ks.ctx.OpenSession(ks.slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return err
+	}
+	if err := ks.ctx.Login(session, pkcs11.CKU_USER, pin); err != nil {
+		ks.ctx.CloseSession(session)
+		return err
+	}
+	ks.session = session
+	ks.unlocked = true
+	if err := ks.loadKeys(); err != nil {
+		ks.lock()
+		return err
+	}
+	if err := ks.checkSignatureScheme(); err != nil {
+		ks.lock()
+		return err
+	}
+	ks.log.Info("pkcs11 token unlocked", "token", ks.cfg.TokenLabel, "keys", len(ks.keys))
+	return nil
+}
+
+func (ks *Keystore) Lock() {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.lock()
+}
+
+func (ks *Keystore) lock() {
+	if !ks.unlocked {
+		return
+	}
+	if err := ks.ctx.Logout(ks.session); err != nil {
+		ks.log.Error("pkcs11 logout fail", "err", err)
+	}
+	if err := ks.ctx.CloseSession(ks.session); err != nil {
+		ks.log.Error("pkcs11 close session fail", "err", err)
+	}
+	ks.unlocked = false
+	ks.keys = nil
+}
+
+func (ks *Keystore) IsUnlocked() bool {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	return ks.unlocked
+}
+
+// Close locks the token and unloads the module
+func (ks *Keystore) Close() error {
+	ks.Lock()
+	err := ks.ctx.Finalize()
+	ks.ctx.Destroy()
+	return err
+}
+
+// ListAddress returns the addresses of the keys in the token ordered by index
+func (ks *Keystore) ListAddress() ([]types.Address, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if !ks.unlocked {
+		return nil, walleterrors.ErrLocked
+	}
+	addrs := make([]types.Address, 0, len(ks.keys))
+	for addr := range ks.keys {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return ks.keys[addrs[i]].index < ks.keys[addrs[j]].index
+	})
+	return addrs, nil
+}
+
+func (ks *Keystore) FindAddr(addr types.Address) (uint32, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	key, err := ks.findKey(addr)
+	if err != nil {
+		return 0, err
+	}
+	return key.index, nil
+}
+
+func (ks *Keystore) findKey(addr types.Address) (*tokenKey, error) {
+	if !ks.unlocked {
+		return nil, walleterrors.ErrLocked
+	}
+	key, ok := ks.keys[addr]
+	if !ok {
+		return nil, walleterrors.ErrAddressNotFound
+	}
+	return key, nil
+}
+
+// SignData signs the data in the token, the signature is verified before it's returned
+// because a token signing with the standard ed25519 makes signatures invalid in vite.
+func (ks *Keystore) SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	key, err := ks.findKey(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ks.sign(key, data)
+}
+
+func (ks *Keystore) sign(key *tokenKey, data []byte) (signedData, pubkey []byte, err error) {
+	if err := ks.ctx.SignInit(ks.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(ks.cfg.Mechanism, nil)}, key.handle); err != nil {
+		return nil, nil, err
+	}
+	signedData, err = ks.ctx.Sign(ks.session, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ed25519.Verify(key.pubKey, data, signedData) {
+		return nil, nil, ErrSignatureScheme
+	}
+	return signedData, key.pubKey, nil
+}
+
+// checkSignatureScheme signs the probe by all the keys, so that a token signing with the
+// standard ed25519 is refused when it's unlocked rather than when a block is produced
+func (ks *Keystore) checkSignatureScheme() error {
+	for _, key := range ks.keys {
+		if _, _, err := ks.sign(key, signatureProbe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyIndex returns the index of the CKA_ID, it's false if the CKA_ID is not an index
+func keyIndex(id []byte) (uint32, bool) {
+	if len(id) == 0 || len(id) > 4 {
+		return 0, false
+	}
+	var index uint32
+	for _, b := range id {
+		index = index<<8 | uint32(b)
+	}
+	return index, true
+}
+
+// loadKeys finds the ed25519 private keys and takes the public keys of the same CKA_ID for the addresses,
+// the index of a key is kept by its CKA_ID so that it doesn't change when the other keys are added or removed
+func (ks *Keystore) loadKeys() error {
+	handles, err := ks.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, CKK_EC_EDWARDS),
+	})
+	if err != nil {
+		return err
+	}
+	keys := make(map[types.Address]*tokenKey, len(handles))
+	indexes := make(map[uint32]bool, len(handles))
+	for _, handle := range handles {
+		attrs, err := ks.ctx.GetAttributeValue(ks.session, handle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, nil)})
+		if err != nil {
+			return err
+		}
+		id := attrs[0].Value
+		index, ok := keyIndex(id)
+		if !ok {
+			ks.log.Warn("the id of the private key is not an index", "id", fmt.Sprintf("%x", id))
+			continue
+		}
+		pubHandles, err := ks.findObjects([]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, CKK_EC_EDWARDS),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		})
+		if err != nil {
+			return err
+		}
+		if len(pubHandles) == 0 {
+			ks.log.Warn("public key of the private key not found", "id", fmt.Sprintf("%x", id))
+			continue
+		}
+		attrs, err = ks.ctx.GetAttributeValue(ks.session, pubHandles[0], []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+		if err != nil {
+			return err
+		}
+		pubKey, err := parseEcPoint(attrs[0].Value)
+		if err != nil {
+			return err
+		}
+		addr := types.PubkeyToAddress(pubKey)
+		if _, ok := keys[addr]; ok {
+			continue
+		}
+		if indexes[index] {
+			ks.log.Warn("the index of the private key is duplicated", "id", fmt.Sprintf("%x", id), "addr", addr)
+			continue
+		}
+		indexes[index] = true
+		keys[addr] = &tokenKey{index: index, handle: handle, pubKey: pubKey}
+	}
+	ks.keys = keys
+	return nil
+}
+
+func (ks *Keystore) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := ks.ctx.FindObjectsInit(ks.session, template); err != nil {
+		return nil, err
+	}
+	defer ks.ctx.FindObjectsFinal(ks.session)
+
+	var result []pkcs11.ObjectHandle
+	for {
+		handles, _, err := ks.ctx.FindObjects(ks.session, 16)
+		if err != nil {
+			return nil, err
+		}
+		if len(handles) == 0 {
+			return result, nil
+		}
+		result = append(result, handles...)
+	}
+}
+
+// parseEcPoint returns the public key of CKA_EC_POINT, which is a DER octet string
+// by the standard while some tokens return the raw 32 bytes.
+func parseEcPoint(point []byte) (ed25519.PublicKey, error) {
+	if len(point) == ed25519.PublicKeySize+2 && point[0] == 0x04 && point[1] == ed25519.PublicKeySize {
+		point = point[2:]
+	}
+	if len(point) != ed25519.PublicKeySize {
+		return nil, errors.New(fmt.Sprintf("invalid ed25519 ec point %x", point))
+	}
+	return ed25519.PublicKey(point), nil
+}
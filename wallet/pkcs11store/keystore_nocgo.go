@@ -0,0 +1,42 @@
+// +build !cgo
+
+package pkcs11store
+
+import "github.com/vitelabs/go-vite/common/types"
+
+// Keystore is not supported without cgo, New always returns ErrNotSupported
+type Keystore struct{}
+
+func New(cfg Config) (*Keystore, error) {
+	return nil, ErrNotSupported
+}
+
+func (ks *Keystore) Name() string {
+	return ""
+}
+
+func (ks *Keystore) Unlock(pin string) error {
+	return ErrNotSupported
+}
+
+func (ks *Keystore) Lock() {}
+
+func (ks *Keystore) IsUnlocked() bool {
+	return false
+}
+
+func (ks *Keystore) Close() error {
+	return nil
+}
+
+func (ks *Keystore) ListAddress() ([]types.Address, error) {
+	return nil, ErrNotSupported
+}
+
+func (ks *Keystore) FindAddr(addr types.Address) (uint32, error) {
+	return 0, ErrNotSupported
+}
+
+func (ks *Keystore) SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	return nil, nil, ErrNotSupported
+}
@@ -0,0 +1,272 @@
+// +build cgo
+
+package pkcs11store
+
+import (
+	"bytes"
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/wallet/walleterrors"
+)
+
+func TestParseEcPoint(t *testing.T) {
+	pub := bytes.Repeat([]byte{1}, ed25519.PublicKeySize)
+	if key, err := parseEcPoint(append([]byte{0x04, ed25519.PublicKeySize}, pub...)); err != nil || !bytes.Equal(key, pub) {
+		t.Fatalf("unexpected key %x of der octet string, err %v", key, err)
+	}
+	if key, err := parseEcPoint(pub); err != nil || !bytes.Equal(key, pub) {
+		t.Fatalf("unexpected key %x of raw point, err %v", key, err)
+	}
+	if _, err := parseEcPoint(pub[1:]); err == nil {
+		t.Fatal("short point should be refused")
+	}
+}
+
+// memToken is a token in memory, sign is the signature scheme of its mechanism
+type memToken struct {
+	label   string
+	pin     string
+	sign    func(priv ed25519.PrivateKey, data []byte) []byte
+	objects []memObject // the handle of an object is its position + 1
+	found   []pkcs11.ObjectHandle
+	signKey pkcs11.ObjectHandle
+}
+
+type memObject struct {
+	class uint
+	id    []byte
+	priv  ed25519.PrivateKey
+	pub   ed25519.PublicKey
+}
+
+// addKey adds a key pair of the id and returns the address of it
+func (tk *memToken) addKey(t *testing.T, id []byte) types.Address {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk.objects = append(tk.objects,
+		memObject{class: pkcs11.CKO_PRIVATE_KEY, id: id, priv: priv},
+		memObject{class: pkcs11.CKO_PUBLIC_KEY, id: id, pub: pub})
+	return types.PubkeyToAddress(pub)
+}
+
+func (tk *memToken) Initialize(opts ...pkcs11.InitializeOption) error { return nil }
+func (tk *memToken) Finalize() error                                  { return nil }
+func (tk *memToken) Destroy()                                         {}
+func (tk *memToken) GetSlotList(tokenPresent bool) ([]uint, error)    { return []uint{0}, nil }
+func (tk *memToken) GetTokenInfo(slotID uint) (pkcs11.TokenInfo, error) {
+	return pkcs11.TokenInfo{Label: tk.label}, nil
+}
+func (tk *memToken) GetMechanismList(slotID uint) ([]*pkcs11.Mechanism, error) {
+	return []*pkcs11.Mechanism{pkcs11.NewMechanism(CKM_EDDSA, nil)}, nil
+}
+func (tk *memToken) OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error) {
+	return 1, nil
+}
+func (tk *memToken) CloseSession(sh pkcs11.SessionHandle) error { return nil }
+func (tk *memToken) Login(sh pkcs11.SessionHandle, userType uint, pin string) error {
+	if pin != tk.pin {
+		return pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)
+	}
+	return nil
+}
+func (tk *memToken) Logout(sh pkcs11.SessionHandle) error { return nil }
+
+func (tk *memToken) GetAttributeValue(sh pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+	object := tk.objects[o-1]
+	result := make([]*pkcs11.Attribute, 0, len(a))
+	for _, attr := range a {
+		switch attr.Type {
+		case pkcs11.CKA_ID:
+			result = append(result, pkcs11.NewAttribute(attr.Type, object.id))
+		case pkcs11.CKA_EC_POINT:
+			result = append(result, pkcs11.NewAttribute(attr.Type, append([]byte{0x04, ed25519.PublicKeySize}, object.pub...)))
+		default:
+			return nil, pkcs11.Error(pkcs11.CKR_ATTRIBUTE_TYPE_INVALID)
+		}
+	}
+	return result, nil
+}
+
+func (tk *memToken) FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error {
+	tk.found = nil
+	for i, object := range tk.objects {
+		matched := true
+		for _, attr := range temp {
+			var value []byte
+			switch attr.Type {
+			case pkcs11.CKA_CLASS:
+				value = pkcs11.NewAttribute(attr.Type, object.class).Value
+			case pkcs11.CKA_KEY_TYPE:
+				value = pkcs11.NewAttribute(attr.Type, CKK_EC_EDWARDS).Value
+			case pkcs11.CKA_ID:
+				value = object.id
+			}
+			matched = matched && bytes.Equal(value, attr.Value)
+		}
+		if matched {
+			tk.found = append(tk.found, pkcs11.ObjectHandle(i+1))
+		}
+	}
+	return nil
+}
+
+func (tk *memToken) FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error) {
+	found := tk.found
+	tk.found = nil
+	return found, false, nil
+}
+
+func (tk *memToken) FindObjectsFinal(sh pkcs11.SessionHandle) error { return nil }
+
+func (tk *memToken) SignInit(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error {
+	tk.signKey = o
+	return nil
+}
+
+func (tk *memToken) Sign(sh pkcs11.SessionHandle, message []byte) ([]byte, error) {
+	return tk.sign(tk.objects[tk.signKey-1].priv, message), nil
+}
+
+func TestKeystoreSign(t *testing.T) {
+	token := &memToken{label: "vite", pin: "1234", sign: ed25519.Sign}
+	addr2 := token.addKey(t, []byte{0x02})
+	addr1 := token.addKey(t, []byte{0x00, 0x00, 0x00, 0x01})
+	token.addKey(t, []byte{0x01})
+	token.addKey(t, bytes.Repeat([]byte{0x01}, 8))
+
+	if _, err := newKeystore(Config{TokenLabel: "vite", Mechanism: 0x80000001}, token); err == nil {
+		t.Fatal("the mechanism not supported by the token should be refused")
+	}
+	ks, err := newKeystore(Config{TokenLabel: "vite"}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ks.Close()
+	if err := ks.Unlock("4321"); err == nil || ks.IsUnlocked() {
+		t.Fatal("the wrong pin should be refused")
+	}
+	if err := ks.Unlock("1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys are indexed by CKA_ID, the duplicated index and the id longer than 4 bytes are skipped
+	addrs, err := ks.ListAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 || addrs[0] != addr1 || addrs[1] != addr2 {
+		t.Fatalf("unexpected addresses %v", addrs)
+	}
+	if index, err := ks.FindAddr(addr2); err != nil || index != 2 {
+		t.Fatalf("unexpected index %v of the key, err %v", index, err)
+	}
+
+	data := []byte("vite")
+	signedData, pubkey, err := ks.SignData(addr1, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types.PubkeyToAddress(pubkey) != addr1 || !ed25519.Verify(pubkey, data, signedData) {
+		t.Fatal("invalid signature returned")
+	}
+
+	// a token signing with the standard ed25519 is refused when it's unlocked
+	std := &memToken{label: "std", sign: func(priv ed25519.PrivateKey, data []byte) []byte {
+		return stded25519.Sign(stded25519.NewKeyFromSeed(priv[:stded25519.SeedSize]), data)
+	}}
+	std.addKey(t, []byte{0x01})
+	stdKs, err := newKeystore(Config{TokenLabel: "std"}, std)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdKs.Close()
+	if err := stdKs.Unlock(""); err != ErrSignatureScheme || stdKs.IsUnlocked() {
+		t.Fatalf("the token of the standard ed25519 should be refused, err %v", err)
+	}
+}
+
+// TestKeystore runs against a token initialized by softhsm2-util, e.g.
+// softhsm2-util --init-token --free --label vite --pin 1234 --so-pin 1234
+// VITE_PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so VITE_PKCS11_TOKEN=vite VITE_PKCS11_PIN=1234 go test
+func TestKeystore(t *testing.T) {
+	module := os.Getenv("VITE_PKCS11_MODULE")
+	if module == "" {
+		t.Skip("VITE_PKCS11_MODULE not set")
+	}
+	ks, err := New(Config{Module: module, TokenLabel: os.Getenv("VITE_PKCS11_TOKEN")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ks.Close()
+
+	if _, err := ks.FindAddr(types.Address{}); err != walleterrors.ErrLocked {
+		t.Fatalf("locked token should be refused, err %v", err)
+	}
+	if err := ks.Unlock(os.Getenv("VITE_PKCS11_PIN")); err != nil {
+		t.Fatal(err)
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	ctx := ks.ctx.(*pkcs11.Ctx)
+	pubHandle, privHandle, err := ctx.GenerateKeyPair(ks.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(CKM_EC_EDWARDS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			// the der of the curve oid 1.3.101.112
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, []byte{0x06, 0x03, 0x2b, 0x65, 0x70}),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.DestroyObject(ks.session, pubHandle)
+	defer ctx.DestroyObject(ks.session, privHandle)
+
+	if err := ks.loadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	var addr *types.Address
+	for a, key := range ks.keys {
+		if key.handle == privHandle {
+			found := a
+			addr = &found
+		}
+	}
+	if addr == nil {
+		t.Fatal("generated key not found")
+	}
+	if _, err := ks.FindAddr(*addr); err != nil {
+		t.Fatal(err)
+	}
+
+	// a token signing with the standard ed25519 like softhsm gets ErrSignatureScheme
+	data := []byte("vite")
+	signedData, pubkey, err := ks.SignData(*addr, data)
+	if err == nil && !ed25519.Verify(pubkey, data, signedData) {
+		t.Fatal("invalid signature returned")
+	}
+	if err != nil && err != ErrSignatureScheme {
+		t.Fatal(err)
+	}
+
+	ks.Lock()
+	if ks.IsUnlocked() {
+		t.Fatal("token should be locked")
+	}
+}
@@ -0,0 +1,148 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const callTimeout = 10 * time.Second
+
+var errCallTimeout = errors.New("remote signer call timeout")
+
+// Client requests signatures from the signer listening on a unix socket. It dials lazily and redials
+// after the connection is broken, so the signer can be restarted without restarting the node.
+type Client struct {
+	endpoint string
+
+	lock   sync.Mutex
+	client *rpc.Client
+
+	log log15.Logger
+}
+
+func NewClient(endpoint string) *Client {
+	return &Client{
+		endpoint: endpoint,
+		log:      log15.New("module", "wallet/signer", "endpoint", endpoint),
+	}
+}
+
+func (c *Client) conn() (*rpc.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.client != nil {
+		return c.client, nil
+	}
+	conn, err := net.DialTimeout("unix", c.endpoint, callTimeout)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("dial remote signer fail, %v", err))
+	}
+	c.client = jsonrpc.NewClient(conn)
+	return c.client, nil
+}
+
+// drop closes the broken connection, the next call redials.
+func (c *Client) drop(client *rpc.Client) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.client == client {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+func (c *Client) call(method string, args interface{}, reply interface{}) error {
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+	call := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-time.After(callTimeout):
+		err = errCallTimeout
+	}
+	if err != nil {
+		if _, refused := err.(rpc.ServerError); !refused {
+			c.log.Error("remote signer call fail", "method", method, "err", err)
+			c.drop(client)
+		}
+	}
+	return err
+}
+
+// CheckAddress returns nil if the signer is able and allowed to sign with the address.
+func (c *Client) CheckAddress(addr types.Address) error {
+	var ok bool
+	return c.call(methodCheckAddress, &CheckAddressArgs{Address: addr}, &ok)
+}
+
+// SignAccountBlock signs the account block with addr, the hash of the block must be computed.
+func (c *Client) SignAccountBlock(addr types.Address, block *ledger.AccountBlock) error {
+	data, err := block.Serialize()
+	if err != nil {
+		return err
+	}
+	result := &SignResult{}
+	if err := c.call(methodSignAccountBlock, &SignArgs{Address: addr, Block: data}, result); err != nil {
+		return err
+	}
+	if err := verify(addr, block.Hash, result); err != nil {
+		return err
+	}
+	block.Signature = result.Signature
+	block.PublicKey = result.PublicKey
+	return nil
+}
+
+// SignSnapshotBlock signs the snapshot block with addr at the hd index, the hash of the block must be computed.
+func (c *Client) SignSnapshotBlock(addr types.Address, index uint32, block *ledger.SnapshotBlock) error {
+	data, err := block.Serialize()
+	if err != nil {
+		return err
+	}
+	result := &SignResult{}
+	if err := c.call(methodSignSnapshotBlock, &SignArgs{Address: addr, Index: &index, Block: data}, result); err != nil {
+		return err
+	}
+	if err := verify(addr, block.Hash, result); err != nil {
+		return err
+	}
+	block.Signature = result.Signature
+	block.PublicKey = result.PublicKey
+	return nil
+}
+
+func (c *Client) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+// verify checks the signature returned by the signer before it's put into the block.
+func verify(addr types.Address, hash types.Hash, result *SignResult) error {
+	if types.PubkeyToAddress(result.PublicKey) != addr {
+		return errors.New(fmt.Sprintf("public key returned by the remote signer is not of %s", addr))
+	}
+	ok, err := crypto.VerifySig(result.PublicKey, hash.Bytes(), result.Signature)
+	if err != nil || !ok {
+		return errors.New(fmt.Sprintf("invalid signature returned by the remote signer, %v", err))
+	}
+	return nil
+}
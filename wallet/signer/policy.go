@@ -0,0 +1,142 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const methodSelectorSize = 4
+
+// PolicyConfig is the policy of the signer, it's loaded from a json file.
+type PolicyConfig struct {
+	// Addresses are the addresses the signer is allowed to sign with
+	Addresses []types.Address `json:"Addresses"`
+
+	// MaxAmounts maps the token id to the maximum amount of a send block in decimal, the tokens not listed
+	// are not limited
+	MaxAmounts map[string]string `json:"MaxAmounts"`
+
+	// Contracts are the contracts a send block is allowed to call
+	Contracts []*ContractRule `json:"Contracts"`
+}
+
+// ContractRule allows the calls to a contract. Methods are the hex method selectors allowed, all the methods
+// of the contract are allowed if it's empty.
+type ContractRule struct {
+	Address types.Address `json:"Address"`
+	Methods []string      `json:"Methods"`
+}
+
+// Policy decides which blocks the signer signs.
+type Policy struct {
+	addresses  map[types.Address]struct{}
+	maxAmounts map[types.TokenTypeId]*big.Int
+	contracts  map[types.Address][][]byte
+}
+
+// LoadPolicyConfig reads the policy config from a json file.
+func LoadPolicyConfig(file string) (*PolicyConfig, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &PolicyConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid policy file %s, %v", file, err))
+	}
+	return cfg, nil
+}
+
+func NewPolicy(cfg *PolicyConfig) (*Policy, error) {
+	if len(cfg.Addresses) == 0 {
+		return nil, errors.New("no address is allowed by the policy")
+	}
+	p := &Policy{
+		addresses:  make(map[types.Address]struct{}, len(cfg.Addresses)),
+		maxAmounts: make(map[types.TokenTypeId]*big.Int, len(cfg.MaxAmounts)),
+		contracts:  make(map[types.Address][][]byte, len(cfg.Contracts)),
+	}
+	for _, addr := range cfg.Addresses {
+		p.addresses[addr] = struct{}{}
+	}
+	for tokenId, amount := range cfg.MaxAmounts {
+		tti, err := types.HexToTokenTypeId(tokenId)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid token id %s in the policy, %v", tokenId, err))
+		}
+		max, ok := new(big.Int).SetString(amount, 10)
+		if !ok || max.Sign() < 0 {
+			return nil, errors.New(fmt.Sprintf("invalid max amount %s of token %s in the policy", amount, tokenId))
+		}
+		p.maxAmounts[tti] = max
+	}
+	for _, rule := range cfg.Contracts {
+		if !types.IsContractAddr(rule.Address) {
+			return nil, errors.New(fmt.Sprintf("%s in the policy is not a contract", rule.Address))
+		}
+		selectors := make([][]byte, 0, len(rule.Methods))
+		for _, method := range rule.Methods {
+			selector, err := hex.DecodeString(strings.TrimPrefix(method, "0x"))
+			if err != nil || len(selector) != methodSelectorSize {
+				return nil, errors.New(fmt.Sprintf("invalid method selector %s of contract %s in the policy", method, rule.Address))
+			}
+			selectors = append(selectors, selector)
+		}
+		p.contracts[rule.Address] = selectors
+	}
+	return p, nil
+}
+
+func (p *Policy) checkAddress(addr types.Address) error {
+	if _, ok := p.addresses[addr]; !ok {
+		return errors.New(fmt.Sprintf("address %s is not allowed by the policy", addr))
+	}
+	return nil
+}
+
+// checkAccountBlock checks the account block signed by addr. The contract receive blocks are signed by
+// the producer, the other blocks must be signed by their own account.
+func (p *Policy) checkAccountBlock(addr types.Address, block *ledger.AccountBlock) error {
+	if err := p.checkAddress(addr); err != nil {
+		return err
+	}
+	if !(block.IsReceiveBlock() && types.IsContractAddr(block.AccountAddress)) && block.AccountAddress != addr {
+		return errors.New(fmt.Sprintf("block of %s can't be signed by %s", block.AccountAddress, addr))
+	}
+	if !block.IsSendBlock() {
+		return nil
+	}
+
+	if max, ok := p.maxAmounts[block.TokenId]; ok && block.Amount != nil && block.Amount.Cmp(max) > 0 {
+		return errors.New(fmt.Sprintf("amount %s of token %s exceeds the max amount %s", block.Amount, block.TokenId, max))
+	}
+
+	if block.BlockType == ledger.BlockTypeSendCreate {
+		return errors.New("creating contracts is not allowed by the policy")
+	}
+	if !types.IsContractAddr(block.ToAddress) {
+		return nil
+	}
+	selectors, ok := p.contracts[block.ToAddress]
+	if !ok {
+		return errors.New(fmt.Sprintf("contract %s is not allowed by the policy", block.ToAddress))
+	}
+	if len(selectors) == 0 {
+		return nil
+	}
+	for _, selector := range selectors {
+		if len(block.Data) >= methodSelectorSize && bytes.Equal(block.Data[:methodSelectorSize], selector) {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("the method called on contract %s is not allowed by the policy", block.ToAddress))
+}
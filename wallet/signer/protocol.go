@@ -0,0 +1,40 @@
+// Package signer implements the protocol by which the producer and the onroad workers request signatures
+// from a separate signer process over a local socket, and a reference signer built on entropystore.
+//
+// The protocol is JSON-RPC 1.0 of net/rpc/jsonrpc over a unix socket. The whole serialized block is sent
+// instead of its hash, so that the signer can recompute the hash and enforce its policy on the content.
+package signer
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+const serviceName = "Signer"
+
+const (
+	methodCheckAddress      = serviceName + ".CheckAddress"
+	methodSignAccountBlock  = serviceName + ".SignAccountBlock"
+	methodSignSnapshotBlock = serviceName + ".SignSnapshotBlock"
+)
+
+// CheckAddressArgs asks whether the signer is able and allowed to sign with the address.
+type CheckAddressArgs struct {
+	Address types.Address `json:"address"`
+}
+
+// SignArgs asks the signer to sign a block with the address.
+type SignArgs struct {
+	Address types.Address `json:"address"`
+
+	// Index is the hd index of the address in the entropy store, the signer searches for it if it's nil
+	Index *uint32 `json:"index,omitempty"`
+
+	// Block is the serialized account block or snapshot block
+	Block []byte `json:"block"`
+}
+
+// SignResult is the signature of the block hash and the public key of the address.
+type SignResult struct {
+	Signature []byte `json:"signature"`
+	PublicKey []byte `json:"publicKey"`
+}
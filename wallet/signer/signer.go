@@ -0,0 +1,190 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+// Signer is the reference signer, it signs the blocks allowed by the policy with the keys of an unlocked
+// entropy store.
+type Signer struct {
+	manager *entropystore.Manager
+	policy  *Policy
+
+	lock     sync.Mutex
+	listener net.Listener
+	closed   bool
+
+	log log15.Logger
+}
+
+func NewSigner(manager *entropystore.Manager, policy *Policy) *Signer {
+	return &Signer{
+		manager: manager,
+		policy:  policy,
+		log:     log15.New("module", "wallet/signer"),
+	}
+}
+
+// ListenAndServe listens on the unix socket and serves the requests until Close is called.
+func (s *Signer) ListenAndServe(endpoint string) error {
+	// remove the socket left by the last run
+	if err := os.Remove(endpoint); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", endpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(endpoint, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves the requests from the listener until Close is called, it returns nil after Close.
+func (s *Signer) Serve(listener net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &service{s}); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return listener.Close()
+	}
+	s.listener = listener
+	s.lock.Unlock()
+	s.log.Info("signer started", "endpoint", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+func (s *Signer) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// key returns the key of the address, the index is only a hint to skip the search.
+func (s *Signer) key(addr types.Address, index *uint32) (*derivation.Key, error) {
+	if err := s.policy.checkAddress(addr); err != nil {
+		return nil, err
+	}
+	if index == nil {
+		key, _, err := s.manager.FindAddr(addr)
+		return key, err
+	}
+	_, key, err := s.manager.DeriveForIndexPath(*index)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := key.Address()
+	if err != nil {
+		return nil, err
+	}
+	if *derived != addr {
+		return nil, errors.New(fmt.Sprintf("address of index %d is %s, not %s", *index, derived, addr))
+	}
+	return key, nil
+}
+
+func (s *Signer) signAccountBlock(args *SignArgs) (*SignResult, error) {
+	block := &ledger.AccountBlock{}
+	if err := block.Deserialize(args.Block); err != nil {
+		return nil, err
+	}
+	if hash := block.ComputeHash(); hash != block.Hash {
+		return nil, errors.New(fmt.Sprintf("block hash %s is not the hash %s of the content", block.Hash, hash))
+	}
+	if err := s.policy.checkAccountBlock(args.Address, block); err != nil {
+		return nil, err
+	}
+	return s.sign(args.Address, args.Index, block.Hash)
+}
+
+func (s *Signer) signSnapshotBlock(args *SignArgs) (*SignResult, error) {
+	block := &ledger.SnapshotBlock{}
+	if err := block.Deserialize(args.Block); err != nil {
+		return nil, err
+	}
+	if hash := block.ComputeHash(); hash != block.Hash {
+		return nil, errors.New(fmt.Sprintf("block hash %s is not the hash %s of the content", block.Hash, hash))
+	}
+	return s.sign(args.Address, args.Index, block.Hash)
+}
+
+func (s *Signer) sign(addr types.Address, index *uint32, hash types.Hash) (*SignResult, error) {
+	key, err := s.key(addr, index)
+	if err != nil {
+		return nil, err
+	}
+	signature, publicKey, err := key.SignData(hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("block signed", "addr", addr, "hash", hash)
+	return &SignResult{Signature: signature, PublicKey: publicKey}, nil
+}
+
+// service is the receiver registered to the rpc server, only the methods of the protocol are exported.
+type service struct {
+	s *Signer
+}
+
+func (svc *service) CheckAddress(args *CheckAddressArgs, reply *bool) error {
+	if _, err := svc.s.key(args.Address, nil); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+func (svc *service) SignAccountBlock(args *SignArgs, reply *SignResult) error {
+	result, err := svc.s.signAccountBlock(args)
+	if err != nil {
+		svc.s.log.Warn("refuse to sign account block", "addr", args.Address, "err", err)
+		return err
+	}
+	*reply = *result
+	return nil
+}
+
+func (svc *service) SignSnapshotBlock(args *SignArgs, reply *SignResult) error {
+	result, err := svc.s.signSnapshotBlock(args)
+	if err != nil {
+		svc.s.log.Warn("refuse to sign snapshot block", "addr", args.Address, "err", err)
+		return err
+	}
+	*reply = *result
+	return nil
+}
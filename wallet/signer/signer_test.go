@@ -0,0 +1,152 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+)
+
+const (
+	testMnemonic = "stone clock kid clean huge loud receive wrong pulse reform october spirit sphere moment run fly situate during whale aim slogan kick decade alpha"
+	testAddr0    = "vite_80d446de0b3267b03cba7d9b49afa5e71341c7cd0693651ad1"
+	testAddr1    = "vite_c5947d16a449ee17e14eb0dc37e702c43b9e8d8b2553b8a801"
+)
+
+func newTestPolicy(t *testing.T) *Policy {
+	p, err := NewPolicy(&PolicyConfig{
+		Addresses:  []types.Address{types.HexToAddressPanic(testAddr0)},
+		MaxAmounts: map[string]string{ledger.ViteTokenId.String(): "100"},
+		Contracts:  []*ContractRule{{Address: types.AddressQuota, Methods: []string{"0x12345678"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func newTestSendBlock(to types.Address, amount int64, data []byte) *ledger.AccountBlock {
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		Height:         1,
+		AccountAddress: types.HexToAddressPanic(testAddr0),
+		ToAddress:      to,
+		Amount:         big.NewInt(amount),
+		TokenId:        ledger.ViteTokenId,
+		Fee:            big.NewInt(0),
+		Data:           data,
+	}
+	block.Hash = block.ComputeHash()
+	return block
+}
+
+func TestPolicyCheckAccountBlock(t *testing.T) {
+	p := newTestPolicy(t)
+	addr0 := types.HexToAddressPanic(testAddr0)
+	addr1 := types.HexToAddressPanic(testAddr1)
+
+	allowed := []*ledger.AccountBlock{
+		newTestSendBlock(addr1, 100, nil),
+		newTestSendBlock(types.AddressQuota, 0, []byte{0x12, 0x34, 0x56, 0x78, 0}),
+		{BlockType: ledger.BlockTypeReceive, AccountAddress: types.AddressGovernance},
+	}
+	for i, block := range allowed {
+		if err := p.checkAccountBlock(addr0, block); err != nil {
+			t.Fatalf("block %d should be allowed, %v", i, err)
+		}
+	}
+
+	refused := []*ledger.AccountBlock{
+		newTestSendBlock(addr1, 101, nil),
+		newTestSendBlock(types.AddressQuota, 0, []byte{0x12, 0x34, 0x56, 0x79}),
+		newTestSendBlock(types.AddressGovernance, 0, nil),
+		{BlockType: ledger.BlockTypeReceive, AccountAddress: addr1},
+	}
+	for i, block := range refused {
+		if err := p.checkAccountBlock(addr0, block); err == nil {
+			t.Fatalf("block %d should be refused", i)
+		}
+	}
+	if err := p.checkAccountBlock(addr1, &ledger.AccountBlock{BlockType: ledger.BlockTypeReceive, AccountAddress: addr1}); err == nil {
+		t.Fatalf("address not allowed should be refused")
+	}
+}
+
+func TestSignerClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manager, err := entropystore.StoreNewEntropy(dir, testMnemonic, "123456", entropystore.DefaultMaxIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Unlock("123456"); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSigner(manager, newTestPolicy(t))
+	endpoint := filepath.Join(dir, "signer.ipc")
+	go s.ListenAndServe(endpoint)
+	defer s.Close()
+
+	client := NewClient(endpoint)
+	defer client.Close()
+	addr0 := types.HexToAddressPanic(testAddr0)
+	addr1 := types.HexToAddressPanic(testAddr1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err = client.CheckAddress(addr0); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CheckAddress(addr1); err == nil {
+		t.Fatalf("address not allowed should be refused")
+	}
+
+	block := newTestSendBlock(addr1, 10, nil)
+	if err := client.SignAccountBlock(addr0, block); err != nil {
+		t.Fatal(err)
+	}
+	if !block.VerifySignature() || block.Producer() != addr0 {
+		t.Fatalf("invalid signature of the account block")
+	}
+
+	tampered := newTestSendBlock(addr1, 10, nil)
+	tampered.Amount = big.NewInt(1000)
+	if err := client.SignAccountBlock(addr0, tampered); err == nil {
+		t.Fatalf("block with a wrong hash should be refused")
+	}
+
+	point := &config.ForkPoint{Height: 100, Version: 1}
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork: point, DexFork: point, DexFeeFork: point, StemFork: point,
+		LeafFork: point, EarthFork: point, DexMiningFork: point, DexRobotFork: point, MultisigFork: point,
+	})
+	now := time.Unix(1000, 0)
+	sb := &ledger.SnapshotBlock{Height: 2, Timestamp: &now}
+	sb.Hash = sb.ComputeHash()
+	if err := client.SignSnapshotBlock(addr0, 1, sb); err == nil {
+		t.Fatalf("wrong index should be refused")
+	}
+	if err := client.SignSnapshotBlock(addr0, 0, sb); err != nil {
+		t.Fatal(err)
+	}
+	if !sb.VerifySignature() || sb.Producer() != addr0 {
+		t.Fatalf("invalid signature of the snapshot block")
+	}
+}